@@ -0,0 +1,18 @@
+-- Background jobs for importing notes from other apps (Markdown, Obsidian, ...)
+CREATE TABLE import_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    format VARCHAR(20) NOT NULL,                   -- markdown, ...
+    filename TEXT NOT NULL,                        -- original name of the uploaded archive
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, processing, completed, failed
+    total_items INTEGER NOT NULL DEFAULT 0,
+    processed_items INTEGER NOT NULL DEFAULT 0,
+    imported_notes INTEGER NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',            -- per item problems: [{"path": ..., "message": ...}]
+    error_message TEXT,                            -- set when the whole import failed
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_import_jobs_user_id ON import_jobs(user_id);
+CREATE INDEX idx_import_jobs_status_created ON import_jobs(status, created_at);
@@ -0,0 +1,35 @@
+-- Meal plans: recipes planned for the meals of a range of days
+CREATE TABLE meal_plans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_meal_plans_user_id ON meal_plans(user_id);
+
+CREATE TABLE meal_plan_meals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    meal_plan_id UUID NOT NULL REFERENCES meal_plans(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    slot VARCHAR(20) NOT NULL CHECK (slot IN ('breakfast', 'lunch', 'dinner')),
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    servings INTEGER,                  -- overrides the servings of the recipe
+    position INTEGER NOT NULL DEFAULT 0, -- order of recipes within a slot
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_meal_plan_meals_meal_plan_id ON meal_plan_meals(meal_plan_id);
+CREATE INDEX idx_meal_plan_meals_recipe_id ON meal_plan_meals(recipe_id);
+
+-- Items the user always has at home, left out of generated shopping lists
+CREATE TABLE stocked_items (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_name TEXT NOT NULL, -- normalized like shopping list items: "salt"
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_id, item_name)
+);
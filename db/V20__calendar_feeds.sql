@@ -0,0 +1,11 @@
+-- Calendar clients follow planned meals by id, SEQUENCE tells them an event changed
+ALTER TABLE meal_plan_meals
+    ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN updated_at TIMESTAMPTZ DEFAULT NOW();
+
+-- Secret feed URLs for subscribing to planned meals from calendar apps, one per user
+CREATE TABLE calendar_feeds (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- SHA-256 hash is 64 hex characters
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
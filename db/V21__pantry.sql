@@ -0,0 +1,16 @@
+-- Food the user has at home
+CREATE TABLE pantry_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_name TEXT NOT NULL,    -- normalized like shopping list items: "milk"
+    display_name TEXT NOT NULL, -- as written: "Milk"
+    quantity DOUBLE PRECISION,
+    unit VARCHAR(50),
+    location VARCHAR(100),      -- fridge, freezer, pantry, ...
+    best_before DATE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_pantry_items_user_id ON pantry_items(user_id);
+CREATE INDEX idx_pantry_items_user_item_name ON pantry_items(user_id, item_name);
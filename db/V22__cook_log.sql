@@ -0,0 +1,16 @@
+-- Times a user cooked a recipe. Recipes are shared between users, so ratings
+-- and notes are kept per user here.
+CREATE TABLE recipe_cook_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    cooked_on DATE NOT NULL,
+    rating INTEGER CHECK (rating BETWEEN 1 AND 5),
+    servings INTEGER,              -- servings made
+    notes TEXT,                    -- "too salty, use less soy sauce"
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_recipe_cook_log_user_recipe ON recipe_cook_log(user_id, recipe_id);
+CREATE INDEX idx_recipe_cook_log_recipe_id ON recipe_cook_log(recipe_id);
@@ -0,0 +1,5 @@
+-- Recipe jobs can import pasted text and uploaded files instead of a URL
+ALTER TABLE recipe_jobs ADD COLUMN source_type VARCHAR(20) NOT NULL DEFAULT 'url'; -- url, text, html
+ALTER TABLE recipe_jobs ADD COLUMN source_content TEXT;   -- cleared once the job completes
+ALTER TABLE recipe_jobs ADD COLUMN source_filename TEXT;
+ALTER TABLE recipe_jobs ALTER COLUMN url DROP NOT NULL;
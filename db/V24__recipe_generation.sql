@@ -0,0 +1,3 @@
+-- Recipe jobs can generate recipes with AI (source_type 'generate') and
+-- refine an existing recipe into a new revision (source_type 'refine')
+ALTER TABLE recipe_jobs ADD COLUMN source_recipe_id UUID REFERENCES recipes(id) ON DELETE SET NULL; -- the recipe being refined
@@ -0,0 +1,25 @@
+-- Structured recipe metadata for search filters. The time columns keep the
+-- text shown to the user, the minutes columns are parsed from it.
+ALTER TABLE recipes ADD COLUMN cook_time VARCHAR;
+ALTER TABLE recipes ADD COLUMN total_time VARCHAR;
+ALTER TABLE recipes ADD COLUMN prep_minutes INTEGER;
+ALTER TABLE recipes ADD COLUMN cook_minutes INTEGER;
+ALTER TABLE recipes ADD COLUMN total_minutes INTEGER;  -- prep + cook when no total is given
+ALTER TABLE recipes ADD COLUMN cuisine TEXT;           -- "Italian", "Thai"
+ALTER TABLE recipes ADD COLUMN course TEXT;            -- "dinner", "dessert"
+ALTER TABLE recipes ADD COLUMN dietary JSONB NOT NULL DEFAULT '[]';   -- vegetarian, vegan, gluten-free, dairy-free
+ALTER TABLE recipes ADD COLUMN dietary_manual BOOLEAN NOT NULL DEFAULT FALSE; -- labels set by the user instead of inferred
+ALTER TABLE recipes ADD COLUMN equipment JSONB NOT NULL DEFAULT '[]'; -- "oven", "stand mixer"
+
+CREATE INDEX idx_recipes_total_minutes ON recipes(total_minutes);
+CREATE INDEX idx_recipes_dietary ON recipes USING GIN (dietary);
+
+-- Minutes of existing recipes from the common ways of writing times, like
+-- "1 hour 30 minutes" or "45 min". Prep time used to hold the whole time of
+-- a recipe, so it's the total as well. Dietary labels of existing recipes are
+-- inferred the next time they're saved.
+UPDATE recipes SET prep_minutes = NULLIF(
+    COALESCE((regexp_match(prep_time, '(\d+)\s*(?:hours?|hrs?|h|timer?|t)\M', 'i'))[1]::int * 60, 0) +
+    COALESCE((regexp_match(prep_time, '(\d+)\s*(?:minutes?|mins?|minutter?|m)\M', 'i'))[1]::int, 0), 0)
+WHERE prep_time IS NOT NULL;
+UPDATE recipes SET total_minutes = prep_minutes;
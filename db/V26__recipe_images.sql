@@ -0,0 +1,7 @@
+-- Recipe images are files in the file store. Anyone with access to a recipe
+-- can view its images, even when another user imported them.
+ALTER TABLE recipes ADD COLUMN image_id UUID REFERENCES files(id) ON DELETE SET NULL;
+ALTER TABLE recipes ADD COLUMN step_images JSONB NOT NULL DEFAULT '[]'; -- [{"step": 0, "fileId": "..."}]
+
+CREATE INDEX idx_recipes_image_id ON recipes(image_id);
+CREATE INDEX idx_recipes_step_images ON recipes USING GIN (step_images);
@@ -0,0 +1,3 @@
+-- Headings like "For the sauce" that group a recipe's steps. Timers and the
+-- ingredients of each step are worked out from the step text when needed.
+ALTER TABLE recipes ADD COLUMN step_sections JSONB NOT NULL DEFAULT '[]'; -- [{"step": 0, "name": "For the sauce"}]
@@ -0,0 +1,39 @@
+-- Recipes imported from a URL keep the recipe as it was read from the page,
+-- so changes at the source can be told apart from the user's own edits
+ALTER TABLE recipes ADD COLUMN source_snapshot JSONB;
+ALTER TABLE recipes ADD COLUMN source_checked_at TIMESTAMPTZ; -- last read of the source, for scheduled re-checks
+
+CREATE INDEX idx_recipes_source_checked_at ON recipes(COALESCE(source_checked_at, created_at))
+    WHERE source_url IS NOT NULL;
+
+-- Changes found at a recipe's source, kept per user until applied or dismissed
+CREATE TABLE recipe_updates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    source JSONB NOT NULL,         -- the recipe as read from the source
+    diff JSONB NOT NULL,           -- changes since the recipe was last read
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, applied, dismissed
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_recipe_updates_user_recipe ON recipe_updates(user_id, recipe_id, created_at DESC);
+
+-- Recipes never saved since their import are as they were read. Edited
+-- recipes have no snapshot, all changes at their source are conflicts.
+UPDATE recipes SET source_snapshot = jsonb_build_object(
+    'name', name,
+    'summary', summary,
+    'servings', servings,
+    'prepTime', prep_time,
+    'cookTime', cook_time,
+    'totalTime', total_time,
+    'cuisine', cuisine,
+    'course', course,
+    'equipment', equipment,
+    'ingredients', ingredients,
+    'steps', steps,
+    'stepSections', step_sections
+)
+WHERE source_url IS NOT NULL AND updated_at = created_at;
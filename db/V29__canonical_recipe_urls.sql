@@ -0,0 +1,85 @@
+-- The recipe URL cache is keyed by the SHA-256 of the canonical URL instead of
+-- the URL as entered, so https://site.com/r?utm_source=x, http://www.site.com/r/
+-- and the AMP page share an entry. Existing rows are re-keyed with the rules of
+-- utils.CanonicalURL.
+CREATE FUNCTION pg_temp.canonical_recipe_url(raw_url TEXT) RETURNS TEXT AS $$
+DECLARE
+    parts TEXT[];
+    host TEXT;
+    port TEXT;
+    path TEXT;
+    path_bytes BYTEA := ''::BYTEA;
+    i INT := 1;
+    query TEXT;
+BEGIN
+    -- scheme, host with optional port, path, query
+    parts := regexp_match(btrim(raw_url), '^https?://(?:[^/?#@]*@)?([^/?#:]+)(?::(\d+))?([^?#]*)(?:\?([^#]*))?', 'i');
+    IF parts IS NULL THEN
+        RETURN btrim(raw_url);
+    END IF;
+
+    host := regexp_replace(lower(parts[1]), '\.$', '');
+    -- Only while a dotted host remains, amp.dev is a site of its own
+    host := regexp_replace(host, '^www\.(?=.*\.)', '');
+    host := regexp_replace(host, '^amp\.(?=.*\.)', '');
+    port := parts[2];
+    IF port IS NOT NULL AND port NOT IN ('80', '443') THEN
+        host := host || ':' || port;
+    END IF;
+
+    -- Percent-escapes are decoded, so escaped and unescaped paths agree. Paths
+    -- with broken escapes or that aren't UTF-8 once decoded are kept as they are.
+    BEGIN
+        WHILE i <= length(parts[3]) LOOP
+            IF substr(parts[3], i, 1) = '%' THEN
+                path_bytes := path_bytes || decode(substr(parts[3], i + 1, 2), 'hex');
+                i := i + 3;
+            ELSE
+                path_bytes := path_bytes || convert_to(substr(parts[3], i, 1), 'UTF8');
+                i := i + 1;
+            END IF;
+        END LOOP;
+        path := convert_from(path_bytes, 'UTF8');
+    EXCEPTION WHEN OTHERS THEN
+        RETURN btrim(raw_url);
+    END;
+
+    path := regexp_replace(path, '^/amp(/|$)', '/');
+    path := regexp_replace(path, '/amp/?$', '');
+    path := regexp_replace(path, '/+$', '');
+    IF path = '' THEN
+        path := '/';
+    END IF;
+
+    -- Tracking parameters are dropped, the rest sorted bytewise
+    SELECT string_agg(param, '&' ORDER BY param COLLATE "C") INTO query
+    FROM unnest(string_to_array(parts[4], '&')) AS param
+    WHERE param <> ''
+      AND lower(split_part(param, '=', 1)) NOT LIKE 'utm\_%'
+      AND lower(split_part(param, '=', 1)) NOT IN (
+          'fbclid', 'gclid', 'dclid', 'msclkid', 'yclid', 'igshid',
+          'mc_cid', 'mc_eid', '_ga', '_gl', 'ref', 'ref_src', 'amp'
+      );
+
+    RETURN 'https://' || host || path || COALESCE('?' || query, '');
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- URLs that now share a key keep the entry accessed last
+CREATE TEMP TABLE recipe_url_cache_rekeyed AS
+SELECT DISTINCT ON (url_hash) url_hash, original_url, recipe_id, created_at, last_accessed
+FROM (
+    SELECT encode(sha256(convert_to(pg_temp.canonical_recipe_url(original_url), 'UTF8')), 'hex') AS url_hash,
+           original_url, recipe_id, created_at, last_accessed
+    FROM recipe_url_cache
+) AS rekeyed
+ORDER BY url_hash, last_accessed DESC NULLS LAST;
+
+DELETE FROM recipe_url_cache;
+
+INSERT INTO recipe_url_cache (url_hash, original_url, recipe_id, created_at, last_accessed)
+SELECT url_hash, original_url, recipe_id, created_at, last_accessed
+FROM recipe_url_cache_rekeyed;
+
+DROP TABLE recipe_url_cache_rekeyed;
+DROP FUNCTION pg_temp.canonical_recipe_url(TEXT);
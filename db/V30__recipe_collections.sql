@@ -0,0 +1,22 @@
+-- Named groups of a user's recipes, like "Weeknight" or "Christmas". Recipes
+-- are shared between users, so collections are kept per user and a recipe can
+-- be in any number of them.
+CREATE TABLE recipe_collections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,            -- shown below the title of the cookbook
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_recipe_collections_user_name ON recipe_collections(user_id, LOWER(name));
+
+CREATE TABLE recipe_collection_recipes (
+    collection_id UUID NOT NULL REFERENCES recipe_collections(id) ON DELETE CASCADE,
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    added_at TIMESTAMPTZ DEFAULT NOW(), -- the recipe added last is the cover
+    PRIMARY KEY (collection_id, recipe_id)
+);
+
+CREATE INDEX idx_recipe_collection_recipes_recipe_id ON recipe_collection_recipes(recipe_id);
@@ -2,7 +2,10 @@ export { userClient } from "./users"
 export { noteClient } from "./notes"
 export { notebooks } from "./notebooks"
 export { recipeClient } from "./recipes"
+export { recipeCollectionClient } from "./recipe-collections"
 export { shoppingListClient } from "./shopping-lists"
+export { mealPlanClient } from "./meal-plans"
+export { pantryClient } from "./pantry"
 export { sections } from "./sections"
 export { fileClient } from "./files"
 export { treeClient } from "./tree"
@@ -0,0 +1,112 @@
+import { client } from "./client"
+import {
+  CalendarFeed,
+  GeneratedShoppingListResponse,
+  MealPlan,
+  MealPlanRequest,
+  StockedItems,
+  fromCalendarFeedJson,
+  fromGeneratedShoppingListResponseJson,
+  fromMealPlanJson,
+} from "./model/meal-plan"
+import { commonHeaders } from "./utils"
+
+export const mealPlanClient = {
+  list: () =>
+    client
+      .get("meal-plans", {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<MealPlan[]>()
+      .then((plans: MealPlan[]) => plans.map(fromMealPlanJson)),
+
+  get: (id: string) =>
+    client
+      .get(`meal-plans/${id}`, {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<MealPlan>()
+      .then(fromMealPlanJson),
+
+  create: (plan: MealPlanRequest) =>
+    client
+      .post("meal-plans", {
+        json: plan,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<MealPlan>()
+      .then(fromMealPlanJson),
+
+  update: (id: string, plan: MealPlanRequest) =>
+    client
+      .put(`meal-plans/${id}`, {
+        json: plan,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<MealPlan>()
+      .then(fromMealPlanJson),
+
+  delete: (id: string) =>
+    client.delete(`meal-plans/${id}`, {
+      headers: commonHeaders(),
+      credentials: "include",
+    }),
+
+  // Create a shopping list with the ingredients of every planned meal
+  generateShoppingList: (id: string) =>
+    client
+      .post(`meal-plans/${id}/shopping-list`, {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<GeneratedShoppingListResponse>()
+      .then(fromGeneratedShoppingListResponseJson),
+
+  // Items always in stock are left out of generated shopping lists
+  getStockedItems: () =>
+    client
+      .get("meal-plans/stocked-items", {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<StockedItems>(),
+
+  updateStockedItems: (items: string[]) =>
+    client
+      .put("meal-plans/stocked-items", {
+        json: { items } as StockedItems,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<StockedItems>(),
+
+  // Secret URL for subscribing to planned meals from calendar apps
+  getCalendarFeed: () =>
+    client
+      .get("meal-plans/calendar-feed", {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CalendarFeed>()
+      .then(fromCalendarFeedJson),
+
+  // Creating a feed revokes the previous feed URL
+  createCalendarFeed: () =>
+    client
+      .post("meal-plans/calendar-feed", {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CalendarFeed>()
+      .then(fromCalendarFeedJson),
+
+  deleteCalendarFeed: () =>
+    client.delete("meal-plans/calendar-feed", {
+      headers: commonHeaders(),
+      credentials: "include",
+    }),
+}
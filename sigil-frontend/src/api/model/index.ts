@@ -9,5 +9,40 @@ export type {
   VocabularyItem,
   ToggleItemRequest,
   MergeRecipeRequest,
+  MergeRecipeResponse,
 } from "./shopping-list"
-export type { Recipe, Ingredient, Quantity } from "./recipe"
+export type {
+  MealPlan,
+  MealSlot,
+  PlannedMeal,
+  MealPlanRequest,
+  PlannedMealRequest,
+  StockedItems,
+  CalendarFeed,
+  GeneratedShoppingListResponse,
+} from "./meal-plan"
+export type {
+  PantryItem,
+  PantryItemRequest,
+  RecipeSuggestion,
+} from "./pantry"
+export type {
+  Recipe,
+  Ingredient,
+  Quantity,
+  RecipeStats,
+  Nutrients,
+  IngredientNutrition,
+  RecipeNutrition,
+  RecipeSort,
+  DietaryLabel,
+  ListRecipesParams,
+  CookLogEntry,
+  CookLogEntryRequest,
+  RecipeExportFormat,
+  ImportRecipesResponse,
+} from "./recipe"
+export type {
+  RecipeCollection,
+  RecipeCollectionRequest,
+} from "./recipe-collection"
@@ -0,0 +1,85 @@
+// eslint-disable-next-line no-restricted-imports
+import dayjs, { Dayjs } from "dayjs"
+import { ShoppingList, fromShoppingListJson } from "./shopping-list"
+
+export type MealSlot = "breakfast" | "lunch" | "dinner"
+
+// Dates are formatted as YYYY-MM-DD
+export interface MealPlan {
+  id: string
+  userId: string
+  title: string
+  startDate: string
+  endDate: string
+  meals: PlannedMeal[]
+  createdAt: Dayjs
+  updatedAt: Dayjs
+}
+
+export interface PlannedMeal {
+  id: string
+  mealPlanId: string
+  date: string
+  slot: MealSlot
+  recipeId: string
+  recipeName: string
+  servings?: number
+  position: number
+  sequence: number
+  updatedAt: string
+}
+
+export interface MealPlanRequest {
+  title?: string
+  startDate: string
+  endDate: string
+  meals: PlannedMealRequest[]
+}
+
+export interface PlannedMealRequest {
+  id?: string
+  date: string
+  slot: MealSlot
+  recipeId: string
+  servings?: number
+}
+
+export interface StockedItems {
+  items: string[]
+}
+
+// The feed URLs are only returned when the feed is created
+export interface CalendarFeed {
+  url?: string
+  timedUrl?: string
+  createdAt: Dayjs
+}
+
+export interface GeneratedShoppingListResponse {
+  shoppingList: ShoppingList
+  skipped: string[]
+}
+
+export function fromMealPlanJson(plan: MealPlan): MealPlan {
+  return {
+    ...plan,
+    createdAt: dayjs(plan.createdAt),
+    updatedAt: dayjs(plan.updatedAt),
+  }
+}
+
+export function fromGeneratedShoppingListResponseJson(
+  response: GeneratedShoppingListResponse
+): GeneratedShoppingListResponse {
+  return {
+    ...response,
+    shoppingList: fromShoppingListJson(response.shoppingList),
+  }
+}
+
+export function fromCalendarFeedJson(feed: CalendarFeed): CalendarFeed {
+  return {
+    ...feed,
+    createdAt: dayjs(feed.createdAt),
+  }
+}
@@ -0,0 +1,40 @@
+// eslint-disable-next-line no-restricted-imports
+import dayjs, { Dayjs } from "dayjs"
+import { Recipe } from "./recipe"
+
+// Best before dates are formatted as YYYY-MM-DD
+export interface PantryItem {
+  id: string
+  userId: string
+  itemName: string
+  displayName: string
+  quantity?: number
+  unit: string
+  location?: string
+  bestBefore?: string
+  createdAt: Dayjs
+  updatedAt: Dayjs
+}
+
+// The quantity can also be given in the name, e.g. "2 L milk"
+export interface PantryItemRequest {
+  name: string
+  quantity?: number
+  unit?: string
+  location?: string
+  bestBefore?: string
+}
+
+export interface RecipeSuggestion {
+  recipe: Recipe
+  available: string[]
+  missing: string[]
+}
+
+export function fromPantryItemJson(item: PantryItem): PantryItem {
+  return {
+    ...item,
+    createdAt: dayjs(item.createdAt),
+    updatedAt: dayjs(item.updatedAt),
+  }
+}
@@ -0,0 +1,30 @@
+// eslint-disable-next-line no-restricted-imports
+import dayjs, { Dayjs } from "dayjs"
+
+// A named group of recipes, like "Weeknight" or "Christmas"
+export interface RecipeCollection {
+  id: string
+  userId: string
+  name: string
+  description?: string
+  recipeCount: number
+  // Image of the recipe added last that has one
+  coverImageId?: string
+  createdAt: Dayjs
+  updatedAt: Dayjs
+}
+
+export interface RecipeCollectionRequest {
+  name: string
+  description?: string
+}
+
+export function fromRecipeCollectionJson(
+  collection: RecipeCollection
+): RecipeCollection {
+  return {
+    ...collection,
+    createdAt: dayjs(collection.createdAt),
+    updatedAt: dayjs(collection.updatedAt),
+  }
+}
@@ -7,11 +7,167 @@ export interface Recipe {
   summary?: string
   servings?: number
   prepTime?: string
+  cookTime?: string
+  totalTime?: string
+  // Parsed from the times, the total falls back to prep + cook time
+  prepMinutes?: number
+  cookMinutes?: number
+  totalMinutes?: number
+  cuisine?: string
+  course?: string
+  dietary: DietaryLabel[]
+  // Labels set by the user instead of inferred from the ingredients
+  dietaryManual: boolean
+  equipment: string[]
   sourceUrl?: string
+  // Served from /files/<id>
+  imageId?: string
+  stepImages: StepImage[]
   ingredients: Ingredient[]
   steps: string[]
+  stepSections: StepSection[]
   createdAt: Dayjs
   updatedAt: Dayjs
+  // The user's cook log of the recipe
+  stats?: RecipeStats
+  // Estimated from the ingredients
+  nutrition?: RecipeNutrition
+}
+
+export interface StepImage {
+  // Index into steps
+  step: number
+  fileId: string
+}
+
+// A heading like "For the sauce" over the steps from step on
+export interface StepSection {
+  // Index into steps
+  step: number
+  name: string
+}
+
+// A step with the timers and ingredients found in its text
+export interface RecipeStep {
+  // Starts at 1
+  number: number
+  section?: string
+  text: string
+  timers: StepTimer[]
+  // Indexes into ingredients
+  ingredients: number[]
+  imageId?: string
+}
+
+// Durations like "10-15 minutes" have a longer max
+export interface StepTimer {
+  text: string
+  seconds: number
+  maxSeconds: number
+}
+
+export interface CookStepParams {
+  // Starts at 1
+  step?: number
+  servings?: number
+  factor?: number
+  units?: "original" | "metric" | "us"
+}
+
+export interface CookStepResponse {
+  recipeId: string
+  name: string
+  servings?: number
+  step: RecipeStep
+  totalSteps: number
+  // The scaled ingredients the step uses
+  ingredients: Ingredient[]
+  factor: number
+  units: string
+}
+
+export type DietaryLabel = "vegetarian" | "vegan" | "gluten-free" | "dairy-free"
+
+export interface RecipeStats {
+  timesCooked: number
+  averageRating?: number
+  lastCooked?: string
+}
+
+// Calories in kcal, the rest in grams
+export interface Nutrients {
+  calories: number
+  protein: number
+  fat: number
+  carbs: number
+}
+
+export type NutritionStatus =
+  | "estimated"
+  | "unknownFood"
+  | "unknownAmount"
+  | "optional"
+
+export interface IngredientNutrition {
+  name: string
+  status: NutritionStatus
+  food?: string
+  grams?: number
+  nutrients?: Nutrients
+}
+
+// Confidence is the share of ingredients that were estimated, 0-1
+export interface RecipeNutrition {
+  total: Nutrients
+  perServing?: Nutrients
+  confidence: number
+  ingredients: IngredientNutrition[]
+}
+
+export type RecipeSort =
+  | "created"
+  | "name"
+  | "timesCooked"
+  | "rating"
+  | "lastCooked"
+
+export interface ListRecipesParams {
+  name?: string
+  ingredient?: string
+  // Maximum total time in minutes
+  maxTime?: number
+  cuisine?: string
+  course?: string
+  // Recipes with all of the labels
+  dietary?: DietaryLabel[]
+  equipment?: string
+  // Recipes in one of the user's collections
+  collection?: string
+  sort?: RecipeSort
+  order?: "asc" | "desc"
+  limit?: number
+  offset?: number
+}
+
+// Dates are formatted as YYYY-MM-DD
+export interface CookLogEntry {
+  id: string
+  userId: string
+  recipeId: string
+  cookedOn: string
+  rating?: number
+  servings?: number
+  notes?: string
+  createdAt: Dayjs
+  updatedAt: Dayjs
+}
+
+// Entries without a date are for today
+export interface CookLogEntryRequest {
+  cookedOn?: string
+  rating?: number
+  servings?: number
+  notes?: string
 }
 
 export interface Ingredient {
@@ -30,7 +186,12 @@ export interface Quantity {
 export interface RecipeJob {
   id: string
   userId: string
+  // Empty for pasted text and uploaded files
   url: string
+  sourceType: "url" | "text" | "html" | "generate" | "refine" | "refresh"
+  sourceFilename?: string
+  // The recipe a refinement was made from, or the recipe being refreshed
+  sourceRecipeId?: string
   status: "pending" | "processing" | "completed" | "failed"
   errorMessage?: string
   recipeId?: string
@@ -39,14 +200,108 @@ export interface RecipeJob {
   completedAt?: Dayjs
 }
 
+// The recipe as read from its source URL
+export interface RecipeSnapshot {
+  name: string
+  summary?: string
+  servings?: number
+  prepTime?: string
+  cookTime?: string
+  totalTime?: string
+  cuisine?: string
+  course?: string
+  equipment: string[]
+  ingredients: Ingredient[]
+  steps: string[]
+  stepSections: StepSection[]
+}
+
+export type RecipeChange = "added" | "removed" | "changed"
+
+// Values are written as text
+export interface FieldChange {
+  field: string
+  old: string
+  new: string
+  conflict: boolean
+}
+
+export interface IngredientChange {
+  change: RecipeChange
+  old?: Ingredient
+  new?: Ingredient
+  conflict: boolean
+}
+
+// step indexes the old steps when removed, otherwise the new ones
+export interface StepChange {
+  change: RecipeChange
+  step: number
+  old: string
+  new: string
+  conflict: boolean
+}
+
+// Changes the user also edited are conflicts, merging keeps the user's version
+export interface RecipeDiff {
+  fields: FieldChange[]
+  ingredients: IngredientChange[]
+  steps: StepChange[]
+}
+
+// Changes found at a recipe's source URL
+export interface RecipeUpdate {
+  id: string
+  userId: string
+  recipeId: string
+  source: RecipeSnapshot
+  diff: RecipeDiff
+  status: "pending" | "applied" | "dismissed"
+  createdAt: Dayjs
+  resolvedAt?: Dayjs
+}
+
+// merge applies the changes to the recipe, version creates a new recipe
+export interface ApplyRecipeUpdateParams {
+  mode?: "merge" | "version"
+  regenerateNote?: boolean
+}
+
 export interface CreateRecipeRequest {
   url: string
 }
 
+export interface CreateRecipeFromTextRequest {
+  text: string
+}
+
+export interface GenerateRecipeRequest {
+  description: string
+  servings?: number
+  dietary?: string[]
+  // Ingredients the recipe should use
+  ingredients?: string[]
+}
+
+export interface RefineRecipeRequest {
+  instruction: string
+}
+
 export interface CreateRecipeResponse {
   jobId: string
 }
 
+export type RecipeExportFormat = "jsonld" | "paprika" | "cooklang"
+
+// Imported recipes along with the notes created for them
+export type RecipeResponse = Recipe & { noteIds: string[] }
+
+export interface ImportRecipesResponse {
+  recipes: (Recipe & { noteIds: string[] })[]
+  // Names of the recipes in the file that couldn't be saved
+  failed: string[]
+}
+
 export interface RecipeJobResponse {
   job: RecipeJob
   recipe?: Recipe
@@ -65,6 +320,14 @@ export function fromRecipeJson(recipe: Recipe): Recipe {
   }
 }
 
+export function fromRecipeUpdateJson(update: RecipeUpdate): RecipeUpdate {
+  return {
+    ...update,
+    createdAt: dayjs(update.createdAt),
+    resolvedAt: update.resolvedAt ? dayjs(update.resolvedAt) : undefined,
+  }
+}
+
 export function fromJobJson(job: RecipeJob): RecipeJob {
   return {
     ...job,
@@ -82,3 +345,11 @@ export function fromJobResponseJson(
     recipe: response.recipe ? fromRecipeJson(response.recipe) : undefined,
   }
 }
+
+export function fromCookLogEntryJson(entry: CookLogEntry): CookLogEntry {
+  return {
+    ...entry,
+    createdAt: dayjs(entry.createdAt),
+    updatedAt: dayjs(entry.updatedAt),
+  }
+}
@@ -36,12 +36,20 @@ export interface VocabularyItem {
 
 export interface ToggleItemRequest {
   checked: boolean
+  // Add the checked off item to the pantry
+  addToPantry?: boolean
 }
 
 export interface MergeRecipeRequest {
   recipeId: string
 }
 
+export interface MergeRecipeResponse {
+  shoppingList: ShoppingList
+  merged: string[]
+  added: string[]
+}
+
 export function fromShoppingListJson(list: ShoppingList): ShoppingList {
   return {
     ...list,
@@ -66,3 +74,12 @@ export function fromVocabularyItemJson(item: VocabularyItem): VocabularyItem {
     lastUsed: dayjs(item.lastUsed),
   }
 }
+
+export function fromMergeRecipeResponseJson(
+  response: MergeRecipeResponse
+): MergeRecipeResponse {
+  return {
+    ...response,
+    shoppingList: fromShoppingListJson(response.shoppingList),
+  }
+}
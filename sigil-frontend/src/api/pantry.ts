@@ -0,0 +1,55 @@
+import { client } from "./client"
+import {
+  PantryItem,
+  PantryItemRequest,
+  RecipeSuggestion,
+  fromPantryItemJson,
+} from "./model/pantry"
+import { commonHeaders } from "./utils"
+
+export const pantryClient = {
+  list: () =>
+    client
+      .get("pantry", {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<PantryItem[]>()
+      .then((items: PantryItem[]) => items.map(fromPantryItemJson)),
+
+  create: (item: PantryItemRequest) =>
+    client
+      .post("pantry", {
+        json: item,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<PantryItem>()
+      .then(fromPantryItemJson),
+
+  update: (id: string, item: PantryItemRequest) =>
+    client
+      .put(`pantry/${id}`, {
+        json: item,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<PantryItem>()
+      .then(fromPantryItemJson),
+
+  delete: (id: string) =>
+    client.delete(`pantry/${id}`, {
+      headers: commonHeaders(),
+      credentials: "include",
+    }),
+
+  // Recipes that can be made with what is in the pantry, best matches first
+  suggestRecipes: (limit?: number) =>
+    client
+      .get("pantry/recipe-suggestions", {
+        searchParams: limit ? { limit: limit.toString() } : {},
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeSuggestion[]>(),
+}
@@ -0,0 +1,85 @@
+import { client } from "./client"
+import {
+  RecipeCollection,
+  RecipeCollectionRequest,
+  fromRecipeCollectionJson,
+} from "./model/recipe-collection"
+import { commonHeaders } from "./utils"
+
+export const recipeCollectionClient = {
+  // All collections by name, or only the ones a recipe is in
+  list: (recipeId?: string) =>
+    client
+      .get("recipe-collections", {
+        searchParams: recipeId ? { recipeId } : {},
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeCollection[]>()
+      .then((collections: RecipeCollection[]) =>
+        collections.map(fromRecipeCollectionJson)
+      ),
+
+  get: (id: string) =>
+    client
+      .get(`recipe-collections/${id}`, {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeCollection>()
+      .then(fromRecipeCollectionJson),
+
+  create: (collection: RecipeCollectionRequest) =>
+    client
+      .post("recipe-collections", {
+        json: collection,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeCollection>()
+      .then(fromRecipeCollectionJson),
+
+  update: (id: string, collection: RecipeCollectionRequest) =>
+    client
+      .put(`recipe-collections/${id}`, {
+        json: collection,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeCollection>()
+      .then(fromRecipeCollectionJson),
+
+  // The recipes are kept
+  delete: (id: string) =>
+    client.delete(`recipe-collections/${id}`, {
+      headers: commonHeaders(),
+      credentials: "include",
+    }),
+
+  addRecipe: (id: string, recipeId: string) =>
+    client
+      .put(`recipe-collections/${id}/recipes/${recipeId}`, {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeCollection>()
+      .then(fromRecipeCollectionJson),
+
+  removeRecipe: (id: string, recipeId: string) =>
+    client
+      .delete(`recipe-collections/${id}/recipes/${recipeId}`, {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeCollection>()
+      .then(fromRecipeCollectionJson),
+
+  // A printable HTML cookbook with a table of contents
+  cookbook: (id: string) =>
+    client
+      .get(`recipe-collections/${id}/cookbook`, {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .blob(),
+}
@@ -1,9 +1,26 @@
 import { client } from "./client"
 import {
+  ApplyRecipeUpdateParams,
+  CookLogEntry,
+  CookLogEntryRequest,
+  CookStepParams,
+  CookStepResponse,
+  CreateRecipeFromTextRequest,
   CreateRecipeRequest,
   CreateRecipeResponse,
+  GenerateRecipeRequest,
+  ImportRecipesResponse,
+  ListRecipesParams,
+  Recipe,
+  RecipeExportFormat,
   RecipeJobResponse,
+  RecipeResponse,
+  RecipeUpdate,
+  RefineRecipeRequest,
+  fromCookLogEntryJson,
   fromJobResponseJson,
+  fromRecipeJson,
+  fromRecipeUpdateJson,
 } from "./model/recipe"
 import { commonHeaders } from "./utils"
 
@@ -17,6 +34,93 @@ export const recipeClient = {
       })
       .json<CreateRecipeResponse>(),
 
+  // Recipes pasted as text, e.g. from an email
+  createFromText: (text: string) =>
+    client
+      .post("recipes/text", {
+        json: { text } as CreateRecipeFromTextRequest,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CreateRecipeResponse>(),
+
+  // HTML or plain text files
+  createFromFile: (file: File) => {
+    const form = new FormData()
+    form.append("file", file)
+
+    return client
+      .post("recipes/upload", {
+        body: form,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CreateRecipeResponse>()
+  },
+
+  // Have the AI create a recipe from a dish name or description
+  generate: (request: GenerateRecipeRequest) =>
+    client
+      .post("recipes/generate", {
+        json: request,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CreateRecipeResponse>(),
+
+  // Have the AI revise a recipe, the revision is created as a new recipe
+  refine: (recipeId: string, instruction: string) =>
+    client
+      .post(`recipes/${recipeId}/refine`, {
+        json: { instruction } as RefineRecipeRequest,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CreateRecipeResponse>(),
+
+  // Reads the recipe from its source URL again, changes are kept as an update
+  refresh: (recipeId: string) =>
+    client
+      .post(`recipes/${recipeId}/refresh`, {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CreateRecipeResponse>(),
+
+  // Pending updates, newest first
+  listUpdates: (recipeId?: string) =>
+    client
+      .get("recipes/updates", {
+        searchParams: recipeId ? { recipeId } : {},
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeUpdate[]>()
+      .then((updates: RecipeUpdate[]) => updates.map(fromRecipeUpdateJson)),
+
+  applyUpdate: (id: string, params: ApplyRecipeUpdateParams = {}) =>
+    client
+      .post(`recipes/updates/${id}/apply`, {
+        searchParams: Object.fromEntries(
+          Object.entries(params)
+            .filter(([, value]) => value !== undefined)
+            .map(([key, value]) => [key, value.toString()])
+        ),
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeResponse>()
+      .then((response: RecipeResponse) => ({
+        ...fromRecipeJson(response),
+        noteIds: response.noteIds,
+      })),
+
+  dismissUpdate: (id: string) =>
+    client.post(`recipes/updates/${id}/dismiss`, {
+      headers: commonHeaders(),
+      credentials: "include",
+    }),
+
   getJobStatus: (jobId: string) =>
     client
       .get(`recipes/jobs/${jobId}`, {
@@ -25,4 +129,132 @@ export const recipeClient = {
       })
       .json<RecipeJobResponse>()
       .then(fromJobResponseJson),
+
+  list: (params: ListRecipesParams = {}) =>
+    client
+      .get("recipes", {
+        searchParams: Object.fromEntries(
+          Object.entries(params)
+            .filter(([, value]) => value !== undefined && value !== "")
+            .map(([key, value]) => [key, value.toString()])
+        ),
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<Recipe[]>()
+      .then((recipes: Recipe[]) => recipes.map(fromRecipeJson)),
+
+  // One step of the recipe in cook mode, with its ingredients scaled to the
+  // servings or factor
+  cookStep: (recipeId: string, params: CookStepParams = {}) =>
+    client
+      .get(`recipes/${recipeId}/cook`, {
+        searchParams: Object.fromEntries(
+          Object.entries(params)
+            .filter(([, value]) => value !== undefined)
+            .map(([key, value]) => [key, value.toString()])
+        ),
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CookStepResponse>(),
+
+  // A single recipe as a file download
+  exportRecipe: (recipeId: string, format: RecipeExportFormat = "jsonld") =>
+    client
+      .get(`recipes/${recipeId}/export`, {
+        searchParams: { format },
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .blob(),
+
+  // All of the user's recipes, Cooklang recipes are zipped
+  exportAll: (format: RecipeExportFormat = "jsonld") =>
+    client
+      .get("recipes/export", {
+        searchParams: { format },
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .blob(),
+
+  // Paprika archives, Cooklang files or zip archives of Cooklang files
+  importFile: (file: File, format?: "paprika" | "cooklang") => {
+    const form = new FormData()
+    form.append("file", file)
+    if (format) {
+      form.append("format", format)
+    }
+
+    return client
+      .post("recipes/import", {
+        body: form,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<ImportRecipesResponse>()
+      .then((response: ImportRecipesResponse) => ({
+        recipes: response.recipes.map((recipe) => ({
+          ...fromRecipeJson(recipe),
+          noteIds: recipe.noteIds,
+        })),
+        failed: response.failed,
+      }))
+  },
+
+  // Replaces the recipe's image, its notes show the new one in place of the
+  // previous image. regenerateNote rewrites the notes from the recipe instead
+  replaceImage: (recipeId: string, file: File, regenerateNote = false) => {
+    const form = new FormData()
+    form.append("file", file)
+
+    return client
+      .put(`recipes/${recipeId}/image`, {
+        body: form,
+        searchParams: { regenerateNote: regenerateNote.toString() },
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<RecipeResponse>()
+      .then((response: RecipeResponse) => ({
+        ...fromRecipeJson(response),
+        noteIds: response.noteIds,
+      }))
+  },
+
+  getCookLog: (recipeId: string) =>
+    client
+      .get(`recipes/${recipeId}/cook-log`, {
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CookLogEntry[]>()
+      .then((entries: CookLogEntry[]) => entries.map(fromCookLogEntryJson)),
+
+  addCookLogEntry: (recipeId: string, entry: CookLogEntryRequest) =>
+    client
+      .post(`recipes/${recipeId}/cook-log`, {
+        json: entry,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CookLogEntry>()
+      .then(fromCookLogEntryJson),
+
+  updateCookLogEntry: (id: string, entry: CookLogEntryRequest) =>
+    client
+      .put(`recipes/cook-log/${id}`, {
+        json: entry,
+        headers: commonHeaders(),
+        credentials: "include",
+      })
+      .json<CookLogEntry>()
+      .then(fromCookLogEntryJson),
+
+  deleteCookLogEntry: (id: string) =>
+    client.delete(`recipes/cook-log/${id}`, {
+      headers: commonHeaders(),
+      credentials: "include",
+    }),
 }
@@ -4,6 +4,8 @@ import {
   VocabularyItem,
   ToggleItemRequest,
   MergeRecipeRequest,
+  MergeRecipeResponse,
+  fromMergeRecipeResponseJson,
   fromShoppingListJson,
   fromVocabularyItemJson,
 } from "./model/shopping-list"
@@ -99,6 +101,6 @@ export const shoppingListClient = {
         headers: commonHeaders(),
         credentials: "include",
       })
-      .json<ShoppingList>()
-      .then(fromShoppingListJson),
+      .json<MergeRecipeResponse>()
+      .then(fromMergeRecipeResponseJson),
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"tofoss/sigil-go/pkg/config"
 	"tofoss/sigil-go/pkg/genai"
 	"tofoss/sigil-go/pkg/parser"
 )
@@ -38,9 +39,13 @@ func main() {
 	}
 	systemContent := string(systemContentBytes)
 
-	client := genai.NewDeepseekClient()
+	client, err := genai.NewProvider(config.LoadAI())
+	if err != nil {
+		fmt.Printf("Error creating AI provider: %s\n", err)
+		os.Exit(1)
+	}
 
-	msg, err := client.Chat(systemContent, userContent, true, ctx)
+	msg, err := client.Chat(ctx, systemContent, userContent, true)
 
 	if err != nil {
 		fmt.Printf("%v\n", err)
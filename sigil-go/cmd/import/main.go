@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"tofoss/sigil-go/pkg/config"
+	"tofoss/sigil-go/pkg/db"
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/importer"
+	"tofoss/sigil-go/pkg/services"
+)
+
+func main() {
+	username := flag.String("user", "", "username to import the notes for")
+	format := flag.String("format", importer.FormatMarkdown, "format of the export: markdown, enex, joplin or notion")
+	flag.Usage = func() {
+		fmt.Println("Usage: go run cmd/import -user <username> [-format markdown|enex|joplin|notion] <zip, folder or file>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *username == "" || flag.NArg() != 1 {
+		fmt.Println("Error: missing arguments")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if !importer.IsSupportedFormat(*format) {
+		fmt.Printf("Error: unsupported format %q\n", *format)
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error loading configuration: %s\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	pool := db.NewPool()
+	defer pool.Close()
+
+	user, err := repositories.NewUserRepository(pool).FetchUser(ctx, *username)
+	if err != nil {
+		fmt.Printf("Error fetching user: %s\n", err)
+		os.Exit(1)
+	}
+	if user == nil {
+		fmt.Printf("Error: user %q not found\n", *username)
+		os.Exit(1)
+	}
+
+	fileService := services.NewFileService(repositories.NewFileRepository(pool), services.FileConfig{
+		StorageRoot:        cfg.UploadPath,
+		MaxFilesize:        int(cfg.MaxFileSize),
+		SupportedFiletypes: services.SupportedImageTypes,
+	})
+
+	pipeline := importer.NewPipeline(
+		repositories.NewNoteRepository(pool),
+		repositories.NewNotebookRepository(pool),
+		repositories.NewSectionRepository(pool),
+		repositories.NewTagRepository(pool),
+		fileService,
+	)
+
+	progress := func(processed, total int) {
+		fmt.Printf("\rImported %d/%d", processed, total)
+	}
+
+	report, err := pipeline.Import(ctx, user.ID, *format, flag.Arg(0), progress)
+	fmt.Println()
+	if err != nil {
+		fmt.Printf("Error importing: %s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Imported %d notes\n", report.Imported)
+	if len(report.Errors) > 0 {
+		fmt.Printf("%d problems:\n", len(report.Errors))
+		for _, importErr := range report.Errors {
+			fmt.Printf("  %s: %s\n", importErr.Path, importErr.Message)
+		}
+		os.Exit(2)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"path"
 	"strconv"
 	"time"
+
+	"tofoss/sigil-go/pkg/genai"
 )
 
 // Config holds all application configuration
@@ -40,12 +42,29 @@ type Config struct {
 	JobMaxRetries   int
 	JobTimeout      time.Duration
 
+	// Import settings
+	MaxImportSize    int64
+	ImportJobTimeout time.Duration
+
 	// AI/Processing timeouts
 	ContentFetchTimeout time.Duration
 	AIProcessingTimeout time.Duration
 
+	// Download step images along with a recipe's main image
+	RecipeStepImages bool
+
+	// How often recipes imported from URLs are checked for changes at their
+	// source, zero turns the checks off
+	RecipeRefreshInterval time.Duration
+
+	// AI provider
+	AI genai.Config
+
 	// CORS
 	CORSMaxAge int
+
+	// Frontend address, for links to the app from outside it
+	AppURL string
 }
 
 // Load reads configuration from environment variables with sensible defaults
@@ -95,16 +114,45 @@ func Load() (*Config, error) {
 	cfg.JobMaxRetries = getInt("JOB_MAX_RETRIES", 3)
 	cfg.JobTimeout = getDuration("JOB_TIMEOUT", 5*time.Minute)
 
+	// Import settings
+	cfg.MaxImportSize = getInt64("MAX_IMPORT_SIZE", 500*1024*1024) // 500MB
+	cfg.ImportJobTimeout = getDuration("IMPORT_JOB_TIMEOUT", 30*time.Minute)
+
 	// AI/Processing timeouts
 	cfg.ContentFetchTimeout = getDuration("CONTENT_FETCH_TIMEOUT", 30*time.Second)
 	cfg.AIProcessingTimeout = getDuration("AI_PROCESSING_TIMEOUT", 180*time.Second)
+	cfg.RecipeStepImages = getBool("RECIPE_STEP_IMAGES", false)
+	cfg.RecipeRefreshInterval = getDuration("RECIPE_REFRESH_INTERVAL", 0)
+
+	// AI provider
+	cfg.AI = LoadAI()
 
 	// CORS
 	cfg.CORSMaxAge = getInt("CORS_MAX_AGE", 3600)
 
+	// Frontend
+	cfg.AppURL = getEnv("APP_URL", "http://localhost:5173")
+
 	return cfg, nil
 }
 
+// LoadAI reads the AI provider settings. It is separate from Load so tools
+// that only talk to the model don't need the server's secrets.
+func LoadAI() genai.Config {
+	ai := genai.Config{
+		Provider: getEnv("AI_PROVIDER", genai.ProviderDeepseek),
+		BaseURL:  os.Getenv("AI_BASE_URL"),
+		Model:    os.Getenv("AI_MODEL"),
+		APIKey:   os.Getenv("AI_API_KEY"),
+	}
+	if value := os.Getenv("AI_TEMPERATURE"); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			ai.Temperature = &f
+		}
+	}
+	return ai
+}
+
 // Helper functions for parsing environment variables
 
 func getEnv(key, defaultValue string) string {
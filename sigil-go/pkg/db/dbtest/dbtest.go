@@ -0,0 +1,95 @@
+// Package dbtest runs tests against a PostgreSQL database migrated from the
+// files in db/, for code whose behavior lives in its queries
+package dbtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DatabaseURLEnv names the database tests run against. Tests using NewPool
+// are skipped when it isn't set.
+const DatabaseURLEnv = "SIGIL_TEST_DATABASE_URL"
+
+// NewPool returns a pool for a schema of the test's own, with every migration
+// applied. The schema is dropped when the test ends.
+func NewPool(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+
+	databaseURL := os.Getenv(DatabaseURLEnv)
+	if databaseURL == "" {
+		t.Skipf("%s is not set", DatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		conn, err := pgx.Connect(ctx, databaseURL)
+		if err != nil {
+			t.Logf("failed to drop schema %s: %v", schema, err)
+			return
+		}
+		defer conn.Close(ctx)
+		if _, err := conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Logf("failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", DatabaseURLEnv, err)
+	}
+	// Extensions installed before stay reachable from public
+	config.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrate(t, pool)
+	return pool
+}
+
+// migrate applies the migrations in db/ in order, like migrate.sh
+func migrate(t testing.TB, pool *pgxpool.Pool) {
+	t.Helper()
+
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "db")
+
+	migrations, err := filepath.Glob(filepath.Join(dir, "V*.sql"))
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("no migrations found in %s", dir)
+	}
+
+	for _, migration := range migrations {
+		sql, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatalf("failed to read migration: %v", err)
+		}
+		// Without arguments the whole file is sent as one simple query
+		if _, err := pool.Exec(context.Background(), string(sql)); err != nil {
+			t.Fatalf("failed to apply %s: %v", filepath.Base(migration), err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CalendarFeedRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCalendarFeedRepository(pool *pgxpool.Pool) *CalendarFeedRepository {
+	return &CalendarFeedRepository{pool: pool}
+}
+
+// Upsert stores the user's feed, replacing the token of an existing one
+func (r *CalendarFeedRepository) Upsert(
+	ctx context.Context,
+	feed models.CalendarFeed,
+) (models.CalendarFeed, error) {
+	query := `
+		INSERT INTO calendar_feeds (user_id, token_hash, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = EXCLUDED.created_at
+		RETURNING user_id, token_hash, created_at`
+
+	rows, err := r.pool.Query(ctx, query, feed.UserID, feed.TokenHash, feed.CreatedAt)
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.CalendarFeed])
+}
+
+// FetchByUserID returns the user's feed, pgx.ErrNoRows if they have none
+func (r *CalendarFeedRepository) FetchByUserID(
+	ctx context.Context,
+	userID uuid.UUID,
+) (models.CalendarFeed, error) {
+	query := `SELECT user_id, token_hash, created_at FROM calendar_feeds WHERE user_id = $1`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.CalendarFeed])
+}
+
+// FetchByTokenHash returns the feed of a token, pgx.ErrNoRows if it isn't valid
+func (r *CalendarFeedRepository) FetchByTokenHash(
+	ctx context.Context,
+	tokenHash string,
+) (models.CalendarFeed, error) {
+	query := `SELECT user_id, token_hash, created_at FROM calendar_feeds WHERE token_hash = $1`
+
+	rows, err := r.pool.Query(ctx, query, tokenHash)
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.CalendarFeed])
+}
+
+// Delete revokes the user's feed
+func (r *CalendarFeedRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, "DELETE FROM calendar_feeds WHERE user_id = $1", userID)
+	return err
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const cookLogEntryColumns = `id, user_id, recipe_id, cooked_on::text AS cooked_on, rating, servings, notes, created_at, updated_at`
+
+type CookLogRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCookLogRepository(pool *pgxpool.Pool) *CookLogRepository {
+	return &CookLogRepository{pool: pool}
+}
+
+func (r *CookLogRepository) Create(
+	ctx context.Context,
+	entry models.CookLogEntry,
+) (models.CookLogEntry, error) {
+	query := `
+		INSERT INTO recipe_cook_log (id, user_id, recipe_id, cooked_on, rating, servings, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4::date, $5, $6, $7, $8, $9)
+		RETURNING ` + cookLogEntryColumns
+
+	rows, err := r.pool.Query(ctx, query,
+		entry.ID,
+		entry.UserID,
+		entry.RecipeID,
+		entry.CookedOn,
+		entry.Rating,
+		entry.Servings,
+		entry.Notes,
+		entry.CreatedAt,
+		entry.UpdatedAt,
+	)
+	if err != nil {
+		return models.CookLogEntry{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.CookLogEntry])
+}
+
+func (r *CookLogRepository) Update(
+	ctx context.Context,
+	entry models.CookLogEntry,
+) (models.CookLogEntry, error) {
+	query := `
+		UPDATE recipe_cook_log
+		SET cooked_on = $2::date, rating = $3, servings = $4, notes = $5, updated_at = $6
+		WHERE id = $1
+		RETURNING ` + cookLogEntryColumns
+
+	rows, err := r.pool.Query(ctx, query,
+		entry.ID,
+		entry.CookedOn,
+		entry.Rating,
+		entry.Servings,
+		entry.Notes,
+		entry.UpdatedAt,
+	)
+	if err != nil {
+		return models.CookLogEntry{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.CookLogEntry])
+}
+
+// FetchByID returns a cook log entry, pgx.ErrNoRows if it doesn't exist
+func (r *CookLogRepository) FetchByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (models.CookLogEntry, error) {
+	query := `SELECT ` + cookLogEntryColumns + ` FROM recipe_cook_log WHERE id = $1`
+
+	rows, err := r.pool.Query(ctx, query, id)
+	if err != nil {
+		return models.CookLogEntry{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.CookLogEntry])
+}
+
+// ListByRecipe returns the user's cook log of a recipe, most recent first
+func (r *CookLogRepository) ListByRecipe(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipeID uuid.UUID,
+) ([]models.CookLogEntry, error) {
+	query := `
+		SELECT ` + cookLogEntryColumns + `
+		FROM recipe_cook_log
+		WHERE user_id = $1 AND recipe_id = $2
+		ORDER BY cooked_on DESC, created_at DESC`
+
+	rows, err := r.pool.Query(ctx, query, userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.CookLogEntry])
+}
+
+// FetchStats sums up the user's cook log of a recipe
+func (r *CookLogRepository) FetchStats(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipeID uuid.UUID,
+) (models.RecipeStats, error) {
+	query := `
+		SELECT COUNT(*) AS times_cooked,
+			AVG(rating)::float8 AS average_rating,
+			MAX(cooked_on)::text AS last_cooked
+		FROM recipe_cook_log
+		WHERE user_id = $1 AND recipe_id = $2`
+
+	rows, err := r.pool.Query(ctx, query, userID, recipeID)
+	if err != nil {
+		return models.RecipeStats{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RecipeStats])
+}
+
+func (r *CookLogRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, "DELETE FROM recipe_cook_log WHERE id = $1", id)
+	return err
+}
@@ -15,19 +15,27 @@ type FileRepository struct {
 	pool *pgxpool.Pool
 }
 
-// FetchFile implements FileRepositoryInterface.
+// FetchFile implements FileRepositoryInterface. Users have access to their
+// own files and to the images of recipes linked to their notes.
 func (r *FileRepository) FetchFileForUser(ctx context.Context, id, userID uuid.UUID) (models.FileMetadata, error) {
 	fmt.Printf("getting files where id = %v and user_id = %v", id, userID)
 	query := `
 	SELECT
-		id,
-		user_id,
-		note_id,
-		filetype,
-		filesize,
-		extension
-	FROM files 
-	WHERE id = $1 and user_id = $2
+		f.id,
+		f.user_id,
+		f.note_id,
+		f.filetype,
+		f.filesize,
+		f.extension
+	FROM files f
+	WHERE f.id = $1 AND (f.user_id = $2 OR EXISTS (
+		SELECT 1
+		FROM recipes r
+		JOIN note_recipes nr ON nr.recipe_id = r.id
+		JOIN notes n ON n.id = nr.note_id
+		WHERE n.user_id = $2
+		  AND (r.image_id = f.id OR r.step_images @> jsonb_build_array(jsonb_build_object('fileId', f.id)))
+	))
 	`
 	rows, err := r.pool.Query(ctx, query, id, userID)
 	if err != nil {
@@ -103,6 +111,34 @@ func (r *FileRepository) FetchFilesForNote(ctx context.Context, noteID uuid.UUID
 	return pgx.CollectRows(rows, pgx.RowToStructByName[models.FileMetadata])
 }
 
+// FetchUnusedRecipeImages implements FileRepositoryInterface. It returns the
+// files among fileIDs that no recipe uses as an image anymore.
+func (r *FileRepository) FetchUnusedRecipeImages(ctx context.Context, fileIDs []uuid.UUID) ([]models.FileMetadata, error) {
+	query := `
+	SELECT
+		f.id,
+		f.user_id,
+		f.note_id,
+		f.filetype,
+		f.filesize,
+		f.extension
+	FROM files f
+	WHERE f.id = ANY($1)
+	  AND f.note_id IS NULL
+	  AND NOT EXISTS (
+		SELECT 1 FROM recipes r
+		WHERE r.image_id = f.id OR r.step_images @> jsonb_build_array(jsonb_build_object('fileId', f.id))
+	  )
+	`
+	rows, err := r.pool.Query(ctx, query, fileIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.FileMetadata])
+}
+
 // Delete implements FileRepositoryInterface.
 func (r *FileRepository) Delete(ctx context.Context, fileID uuid.UUID) error {
 	query := `DELETE FROM files WHERE id = $1`
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const importJobColumns = `id, user_id, format, filename, status, total_items, processed_items,
+		imported_notes, errors, error_message, created_at, completed_at`
+
+type ImportJobRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewImportJobRepository(pool *pgxpool.Pool) *ImportJobRepository {
+	return &ImportJobRepository{pool: pool}
+}
+
+func (r *ImportJobRepository) Create(
+	ctx context.Context,
+	job models.ImportJob,
+) (models.ImportJob, error) {
+	query := `
+		INSERT INTO import_jobs (id, user_id, format, filename, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + importJobColumns
+
+	rows, err := r.pool.Query(ctx, query,
+		job.ID,
+		job.UserID,
+		job.Format,
+		job.Filename,
+		job.Status,
+		job.CreatedAt,
+	)
+	if err != nil {
+		return models.ImportJob{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.ImportJob{}, pgx.ErrNoRows
+	}
+	return r.scanJob(rows)
+}
+
+func (r *ImportJobRepository) FetchByID(
+	ctx context.Context,
+	jobID uuid.UUID,
+) (models.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1`
+
+	rows, err := r.pool.Query(ctx, query, jobID)
+	if err != nil {
+		return models.ImportJob{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.ImportJob{}, pgx.ErrNoRows
+	}
+	return r.scanJob(rows)
+}
+
+// FetchPendingJobs returns the oldest jobs waiting to be processed
+func (r *ImportJobRepository) FetchPendingJobs(
+	ctx context.Context,
+	limit int,
+) ([]models.ImportJob, error) {
+	query := `
+		SELECT ` + importJobColumns + `
+		FROM import_jobs
+		WHERE status = 'pending'
+		ORDER BY created_at ASC
+		LIMIT $1`
+
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.ImportJob
+	for rows.Next() {
+		job, err := r.scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// MarkProcessing claims a pending job, returning false if another worker got to it first
+func (r *ImportJobRepository) MarkProcessing(
+	ctx context.Context,
+	jobID uuid.UUID,
+) (bool, error) {
+	query := `
+		UPDATE import_jobs
+		SET status = 'processing'
+		WHERE id = $1 AND status = 'pending'`
+
+	tag, err := r.pool.Exec(ctx, query, jobID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *ImportJobRepository) UpdateProgress(
+	ctx context.Context,
+	jobID uuid.UUID,
+	processed int,
+	total int,
+) error {
+	query := `
+		UPDATE import_jobs
+		SET processed_items = $2, total_items = $3
+		WHERE id = $1`
+
+	_, err := r.pool.Exec(ctx, query, jobID, processed, total)
+	return err
+}
+
+func (r *ImportJobRepository) Complete(
+	ctx context.Context,
+	jobID uuid.UUID,
+	importedNotes int,
+	importErrors []models.ImportError,
+) error {
+	if importErrors == nil {
+		importErrors = []models.ImportError{}
+	}
+	errorsJSON, err := json.Marshal(importErrors)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE import_jobs
+		SET status = 'completed', imported_notes = $2, errors = $3, completed_at = $4
+		WHERE id = $1`
+
+	_, err = r.pool.Exec(ctx, query, jobID, importedNotes, errorsJSON, time.Now())
+	return err
+}
+
+func (r *ImportJobRepository) Fail(
+	ctx context.Context,
+	jobID uuid.UUID,
+	errorMessage string,
+) error {
+	query := `
+		UPDATE import_jobs
+		SET status = 'failed', error_message = $2, completed_at = $3
+		WHERE id = $1`
+
+	_, err := r.pool.Exec(ctx, query, jobID, errorMessage, time.Now())
+	return err
+}
+
+func (r *ImportJobRepository) scanJob(rows pgx.Rows) (models.ImportJob, error) {
+	var job models.ImportJob
+	var errorsJSON []byte
+
+	err := rows.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Format,
+		&job.Filename,
+		&job.Status,
+		&job.TotalItems,
+		&job.ProcessedItems,
+		&job.ImportedNotes,
+		&errorsJSON,
+		&job.ErrorMessage,
+		&job.CreatedAt,
+		&job.CompletedAt,
+	)
+	if err != nil {
+		return models.ImportJob{}, err
+	}
+
+	if err := json.Unmarshal(errorsJSON, &job.Errors); err != nil {
+		return models.ImportJob{}, err
+	}
+
+	return job, nil
+}
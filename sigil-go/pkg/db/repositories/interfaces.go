@@ -80,6 +80,7 @@ type FileRepositoryInterface interface {
 	Insert(ctx context.Context, file models.FileMetadata) (models.FileMetadata, error)
 	FetchFileForUser(ctx context.Context, id, userID uuid.UUID) (models.FileMetadata, error)
 	FetchFilesForNote(ctx context.Context, noteID uuid.UUID) ([]models.FileMetadata, error)
+	FetchUnusedRecipeImages(ctx context.Context, fileIDs []uuid.UUID) ([]models.FileMetadata, error)
 	Delete(ctx context.Context, fileID uuid.UUID) error
 }
 
@@ -122,9 +123,78 @@ type ShoppingListRepositoryInterface interface {
 	Update(ctx context.Context, list models.ShoppingList) (*models.ShoppingList, error)
 	Delete(ctx context.Context, noteID uuid.UUID) error
 	UpdateItemCheckStatus(ctx context.Context, itemID uuid.UUID, checked bool) error
+	GetItemForUser(ctx context.Context, itemID uuid.UUID, userID uuid.UUID) (*models.ShoppingListEntry, error)
 	GetUserVocabulary(ctx context.Context, userID uuid.UUID, prefix string, limit int) ([]models.VocabularyItem, error)
 	AddToVocabulary(ctx context.Context, userID uuid.UUID, itemName string) error
 }
 
 // Ensure ShoppingListRepository implements the interface
 var _ ShoppingListRepositoryInterface = (*ShoppingListRepository)(nil)
+
+// MealPlanRepositoryInterface defines the contract for meal plan data access
+type MealPlanRepositoryInterface interface {
+	Create(ctx context.Context, plan models.MealPlan) (models.MealPlan, error)
+	Update(ctx context.Context, plan models.MealPlan) (models.MealPlan, error)
+	FetchByID(ctx context.Context, id uuid.UUID) (models.MealPlan, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID, limit int, offset int) ([]models.MealPlan, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	FetchCalendarMeals(ctx context.Context, userID uuid.UUID, since string) ([]models.CalendarMeal, error)
+	FetchStockedItems(ctx context.Context, userID uuid.UUID) ([]string, error)
+	ReplaceStockedItems(ctx context.Context, userID uuid.UUID, itemNames []string) error
+}
+
+// Ensure MealPlanRepository implements the interface
+var _ MealPlanRepositoryInterface = (*MealPlanRepository)(nil)
+
+// CalendarFeedRepositoryInterface defines the contract for calendar feed data access
+type CalendarFeedRepositoryInterface interface {
+	Upsert(ctx context.Context, feed models.CalendarFeed) (models.CalendarFeed, error)
+	FetchByUserID(ctx context.Context, userID uuid.UUID) (models.CalendarFeed, error)
+	FetchByTokenHash(ctx context.Context, tokenHash string) (models.CalendarFeed, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+// Ensure CalendarFeedRepository implements the interface
+var _ CalendarFeedRepositoryInterface = (*CalendarFeedRepository)(nil)
+
+// PantryRepositoryInterface defines the contract for pantry data access
+type PantryRepositoryInterface interface {
+	Create(ctx context.Context, item models.PantryItem) (models.PantryItem, error)
+	Update(ctx context.Context, item models.PantryItem) (models.PantryItem, error)
+	FetchByID(ctx context.Context, id uuid.UUID) (models.PantryItem, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]models.PantryItem, error)
+	FetchByItemName(ctx context.Context, userID uuid.UUID, itemName string) ([]models.PantryItem, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// Ensure PantryRepository implements the interface
+var _ PantryRepositoryInterface = (*PantryRepository)(nil)
+
+// CookLogRepositoryInterface defines the contract for cook log data access
+type CookLogRepositoryInterface interface {
+	Create(ctx context.Context, entry models.CookLogEntry) (models.CookLogEntry, error)
+	Update(ctx context.Context, entry models.CookLogEntry) (models.CookLogEntry, error)
+	FetchByID(ctx context.Context, id uuid.UUID) (models.CookLogEntry, error)
+	ListByRecipe(ctx context.Context, userID uuid.UUID, recipeID uuid.UUID) ([]models.CookLogEntry, error)
+	FetchStats(ctx context.Context, userID uuid.UUID, recipeID uuid.UUID) (models.RecipeStats, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// Ensure CookLogRepository implements the interface
+var _ CookLogRepositoryInterface = (*CookLogRepository)(nil)
+
+// RecipeCollectionRepositoryInterface defines the contract for recipe
+// collection data access
+type RecipeCollectionRepositoryInterface interface {
+	Create(ctx context.Context, collection models.RecipeCollection) (models.RecipeCollection, error)
+	Update(ctx context.Context, collection models.RecipeCollection) (models.RecipeCollection, error)
+	FetchByID(ctx context.Context, id uuid.UUID) (models.RecipeCollection, error)
+	FetchByName(ctx context.Context, userID uuid.UUID, name string) (models.RecipeCollection, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID, recipeID *uuid.UUID) ([]models.RecipeCollection, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	AddRecipe(ctx context.Context, collectionID uuid.UUID, recipeID uuid.UUID, addedAt time.Time) error
+	RemoveRecipe(ctx context.Context, collectionID uuid.UUID, recipeID uuid.UUID) error
+}
+
+// Ensure RecipeCollectionRepository implements the interface
+var _ RecipeCollectionRepositoryInterface = (*RecipeCollectionRepository)(nil)
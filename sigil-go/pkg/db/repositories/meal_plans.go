@@ -0,0 +1,374 @@
+package repositories
+
+import (
+	"context"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const mealPlanColumns = `id, user_id, title, start_date::text, end_date::text, created_at, updated_at`
+
+type MealPlanRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMealPlanRepository(pool *pgxpool.Pool) *MealPlanRepository {
+	return &MealPlanRepository{pool: pool}
+}
+
+// Create stores a meal plan along with its meals
+func (r *MealPlanRepository) Create(
+	ctx context.Context,
+	plan models.MealPlan,
+) (models.MealPlan, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return models.MealPlan{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO meal_plans (id, user_id, title, start_date, end_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4::date, $5::date, $6, $7)`
+
+	_, err = tx.Exec(ctx, query,
+		plan.ID,
+		plan.UserID,
+		plan.Title,
+		plan.StartDate,
+		plan.EndDate,
+		plan.CreatedAt,
+		plan.UpdatedAt,
+	)
+	if err != nil {
+		return models.MealPlan{}, err
+	}
+
+	if err = r.insertMeals(ctx, tx, plan.ID, plan.Meals); err != nil {
+		return models.MealPlan{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.MealPlan{}, err
+	}
+
+	return r.FetchByID(ctx, plan.ID)
+}
+
+// Update changes the title and dates of a meal plan and replaces its meals
+func (r *MealPlanRepository) Update(
+	ctx context.Context,
+	plan models.MealPlan,
+) (models.MealPlan, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return models.MealPlan{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE meal_plans
+		SET title = $2, start_date = $3::date, end_date = $4::date, updated_at = $5
+		WHERE id = $1`
+
+	_, err = tx.Exec(ctx, query,
+		plan.ID,
+		plan.Title,
+		plan.StartDate,
+		plan.EndDate,
+		plan.UpdatedAt,
+	)
+	if err != nil {
+		return models.MealPlan{}, err
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM meal_plan_meals WHERE meal_plan_id = $1", plan.ID)
+	if err != nil {
+		return models.MealPlan{}, err
+	}
+
+	if err = r.insertMeals(ctx, tx, plan.ID, plan.Meals); err != nil {
+		return models.MealPlan{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.MealPlan{}, err
+	}
+
+	return r.FetchByID(ctx, plan.ID)
+}
+
+func (r *MealPlanRepository) insertMeals(
+	ctx context.Context,
+	tx pgx.Tx,
+	planID uuid.UUID,
+	meals []models.PlannedMeal,
+) error {
+	query := `
+		INSERT INTO meal_plan_meals (id, meal_plan_id, date, slot, recipe_id, servings, position, sequence, updated_at)
+		VALUES ($1, $2, $3::date, $4, $5, $6, $7, $8, $9)`
+
+	for _, meal := range meals {
+		_, err := tx.Exec(ctx, query,
+			meal.ID,
+			planID,
+			meal.Date,
+			meal.Slot,
+			meal.RecipeID,
+			meal.Servings,
+			meal.Position,
+			meal.Sequence,
+			meal.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FetchByID returns a meal plan with its meals, pgx.ErrNoRows if it doesn't exist
+func (r *MealPlanRepository) FetchByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (models.MealPlan, error) {
+	query := `SELECT ` + mealPlanColumns + ` FROM meal_plans WHERE id = $1`
+
+	rows, err := r.pool.Query(ctx, query, id)
+	if err != nil {
+		return models.MealPlan{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.MealPlan{}, pgx.ErrNoRows
+	}
+	plan, err := r.scanMealPlan(rows)
+	if err != nil {
+		return models.MealPlan{}, err
+	}
+	rows.Close()
+
+	plan.Meals, err = r.fetchMeals(ctx, plan.ID)
+	if err != nil {
+		return models.MealPlan{}, err
+	}
+	return plan, nil
+}
+
+// ListByUserID returns the user's meal plans, the latest first
+func (r *MealPlanRepository) ListByUserID(
+	ctx context.Context,
+	userID uuid.UUID,
+	limit int,
+	offset int,
+) ([]models.MealPlan, error) {
+	query := `
+		SELECT ` + mealPlanColumns + `
+		FROM meal_plans
+		WHERE user_id = $1
+		ORDER BY start_date DESC, created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.pool.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []models.MealPlan{}
+	for rows.Next() {
+		plan, err := r.scanMealPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range plans {
+		plans[i].Meals, err = r.fetchMeals(ctx, plans[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return plans, nil
+}
+
+// Delete deletes a meal plan, its meals are deleted with it
+func (r *MealPlanRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, "DELETE FROM meal_plans WHERE id = $1", id)
+	return err
+}
+
+// fetchMeals returns the meals of a plan by day, meal and position
+func (r *MealPlanRepository) fetchMeals(
+	ctx context.Context,
+	planID uuid.UUID,
+) ([]models.PlannedMeal, error) {
+	query := `
+		SELECT m.id, m.meal_plan_id, m.date::text, m.slot, m.recipe_id, r.name, m.servings, m.position,
+			m.sequence, m.updated_at
+		FROM meal_plan_meals m
+		JOIN recipes r ON m.recipe_id = r.id
+		WHERE m.meal_plan_id = $1
+		ORDER BY m.date,
+			CASE m.slot WHEN 'breakfast' THEN 0 WHEN 'lunch' THEN 1 ELSE 2 END,
+			m.position`
+
+	rows, err := r.pool.Query(ctx, query, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	meals := []models.PlannedMeal{}
+	for rows.Next() {
+		var meal models.PlannedMeal
+		err := rows.Scan(
+			&meal.ID,
+			&meal.MealPlanID,
+			&meal.Date,
+			&meal.Slot,
+			&meal.RecipeID,
+			&meal.RecipeName,
+			&meal.Servings,
+			&meal.Position,
+			&meal.Sequence,
+			&meal.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		meals = append(meals, meal)
+	}
+
+	return meals, rows.Err()
+}
+
+// FetchCalendarMeals returns the user's meals planned on or after a date,
+// across all their meal plans
+func (r *MealPlanRepository) FetchCalendarMeals(
+	ctx context.Context,
+	userID uuid.UUID,
+	since string,
+) ([]models.CalendarMeal, error) {
+	query := `
+		SELECT m.id, m.meal_plan_id, m.date::text, m.slot, m.recipe_id, r.name, m.servings, m.position,
+			m.sequence, m.updated_at, r.summary, r.prep_time, r.updated_at,
+			(
+				SELECT nr.note_id
+				FROM note_recipes nr
+				JOIN notes n ON nr.note_id = n.id
+				WHERE nr.recipe_id = r.id AND n.user_id = p.user_id
+				ORDER BY nr.created_at
+				LIMIT 1
+			)
+		FROM meal_plan_meals m
+		JOIN meal_plans p ON m.meal_plan_id = p.id
+		JOIN recipes r ON m.recipe_id = r.id
+		WHERE p.user_id = $1 AND m.date >= $2::date
+		ORDER BY m.date,
+			CASE m.slot WHEN 'breakfast' THEN 0 WHEN 'lunch' THEN 1 ELSE 2 END,
+			m.position`
+
+	rows, err := r.pool.Query(ctx, query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	meals := []models.CalendarMeal{}
+	for rows.Next() {
+		var meal models.CalendarMeal
+		err := rows.Scan(
+			&meal.ID,
+			&meal.MealPlanID,
+			&meal.Date,
+			&meal.Slot,
+			&meal.RecipeID,
+			&meal.RecipeName,
+			&meal.Servings,
+			&meal.Position,
+			&meal.Sequence,
+			&meal.UpdatedAt,
+			&meal.RecipeSummary,
+			&meal.RecipePrepTime,
+			&meal.RecipeUpdatedAt,
+			&meal.NoteID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		meals = append(meals, meal)
+	}
+
+	return meals, rows.Err()
+}
+
+func (r *MealPlanRepository) scanMealPlan(rows pgx.Rows) (models.MealPlan, error) {
+	var plan models.MealPlan
+	err := rows.Scan(
+		&plan.ID,
+		&plan.UserID,
+		&plan.Title,
+		&plan.StartDate,
+		&plan.EndDate,
+		&plan.CreatedAt,
+		&plan.UpdatedAt,
+	)
+	return plan, err
+}
+
+// FetchStockedItems returns the normalized names of the items the user always
+// has at home
+func (r *MealPlanRepository) FetchStockedItems(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]string, error) {
+	query := `SELECT item_name FROM stocked_items WHERE user_id = $1 ORDER BY item_name`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// ReplaceStockedItems replaces the items the user always has at home
+func (r *MealPlanRepository) ReplaceStockedItems(
+	ctx context.Context,
+	userID uuid.UUID,
+	itemNames []string,
+) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM stocked_items WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range itemNames {
+		_, err = tx.Exec(ctx,
+			"INSERT INTO stocked_items (user_id, item_name) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			userID, name,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
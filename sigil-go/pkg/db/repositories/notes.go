@@ -2,7 +2,6 @@ package repositories
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"tofoss/sigil-go/pkg/models"
@@ -24,6 +23,10 @@ func (r *NoteRepository) Upsert(
 	ctx context.Context,
 	note models.Note,
 ) (models.Note, error) {
+	return upsertNote(ctx, r.pool, note)
+}
+
+func upsertNote(ctx context.Context, q querier, note models.Note) (models.Note, error) {
 	query := `
 		INSERT INTO notes (id, user_id, title, content, created_at, updated_at, published_at, published, tsv)
 		VALUES ($1, $2, $3::varchar, $4, $5, $6, $7, $8,
@@ -46,7 +49,7 @@ func (r *NoteRepository) Upsert(
 			tsv = EXCLUDED.tsv
         RETURNING id, user_id, title, content, created_at, updated_at, published_at, published`
 
-	rows, err := r.pool.Query(ctx, query,
+	rows, err := q.Query(ctx, query,
 		note.ID,
 		note.UserID,
 		note.Title,
@@ -453,7 +456,7 @@ func (r *NoteRepository) GetRecipesForNote(
 	noteID uuid.UUID,
 ) ([]models.Recipe, error) {
 	query := `
-		SELECT r.id, r.name, r.summary, r.servings, r.prep_time, r.source_url, r.ingredients, r.steps, r.created_at, r.updated_at
+		SELECT ` + recipeColumns + `
 		FROM recipes r 
 		JOIN note_recipes nr ON r.id = nr.recipe_id 
 		WHERE nr.note_id = $1
@@ -479,7 +482,7 @@ func (r *NoteRepository) GetRecipesForNotes(
 	}
 
 	query := `
-		SELECT nr.note_id, r.id, r.name, r.summary, r.servings, r.prep_time, r.source_url, r.ingredients, r.steps, r.created_at, r.updated_at
+		SELECT nr.note_id, ` + recipeColumns + `
 		FROM recipes r 
 		JOIN note_recipes nr ON r.id = nr.recipe_id 
 		WHERE nr.note_id = ANY($1)
@@ -503,29 +506,15 @@ func (r *NoteRepository) GetRecipesForNotes(
 	// Process query results
 	for rows.Next() {
 		var noteID uuid.UUID
-		var recipe models.Recipe
-		var ingredientsJSON []byte
-		var stepsJSON []byte
-		
-		err := rows.Scan(
-			&noteID,
-			&recipe.ID,
-			&recipe.Name,
-			&recipe.Summary,
-			&recipe.Servings,
-			&recipe.PrepTime,
-			&recipe.SourceURL,
-			&ingredientsJSON,
-			&stepsJSON,
-			&recipe.CreatedAt,
-			&recipe.UpdatedAt,
-		)
+		row := recipeRow{}
+
+		err := rows.Scan(append([]any{&noteID}, row.targets()...)...)
 		if err != nil {
 			return nil, err
 		}
 
-		// Unmarshal JSON fields
-		if err := r.unmarshalRecipeJSON(&recipe, ingredientsJSON, stepsJSON); err != nil {
+		recipe, err := row.decode()
+		if err != nil {
 			return nil, err
 		}
 		
@@ -602,53 +591,7 @@ func (r *NoteRepository) FetchNoteWithRecipes(
 
 // Helper methods for recipe JSON handling
 func (r *NoteRepository) scanRecipes(rows pgx.Rows) ([]models.Recipe, error) {
-	var recipes []models.Recipe
-
-	for rows.Next() {
-		var recipe models.Recipe
-		var ingredientsJSON []byte
-		var stepsJSON []byte
-
-		err := rows.Scan(
-			&recipe.ID,
-			&recipe.Name,
-			&recipe.Summary,
-			&recipe.Servings,
-			&recipe.PrepTime,
-			&recipe.SourceURL,
-			&ingredientsJSON,
-			&stepsJSON,
-			&recipe.CreatedAt,
-			&recipe.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		if err := r.unmarshalRecipeJSON(&recipe, ingredientsJSON, stepsJSON); err != nil {
-			return nil, err
-		}
-
-		recipes = append(recipes, recipe)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return recipes, nil
-}
-
-func (r *NoteRepository) unmarshalRecipeJSON(recipe *models.Recipe, ingredientsJSON, stepsJSON []byte) error {
-	if err := json.Unmarshal(ingredientsJSON, &recipe.Ingredients); err != nil {
-		return err
-	}
-
-	if err := json.Unmarshal(stepsJSON, &recipe.Steps); err != nil {
-		return err
-	}
-
-	return nil
+	return scanRecipeRows(rows)
 }
 
 // DeleteNote deletes a note by ID
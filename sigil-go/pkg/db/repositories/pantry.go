@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const pantryItemColumns = `id, user_id, item_name, display_name, quantity, COALESCE(unit, '') AS unit,
+		location, best_before::text AS best_before, created_at, updated_at`
+
+type PantryRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPantryRepository(pool *pgxpool.Pool) *PantryRepository {
+	return &PantryRepository{pool: pool}
+}
+
+func (r *PantryRepository) Create(
+	ctx context.Context,
+	item models.PantryItem,
+) (models.PantryItem, error) {
+	query := `
+		INSERT INTO pantry_items (id, user_id, item_name, display_name, quantity, unit, location, best_before, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8::date, $9, $10)
+		RETURNING ` + pantryItemColumns
+
+	rows, err := r.pool.Query(ctx, query,
+		item.ID,
+		item.UserID,
+		item.ItemName,
+		item.DisplayName,
+		item.Quantity,
+		item.Unit,
+		item.Location,
+		item.BestBefore,
+		item.CreatedAt,
+		item.UpdatedAt,
+	)
+	if err != nil {
+		return models.PantryItem{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.PantryItem])
+}
+
+func (r *PantryRepository) Update(
+	ctx context.Context,
+	item models.PantryItem,
+) (models.PantryItem, error) {
+	query := `
+		UPDATE pantry_items
+		SET item_name = $2, display_name = $3, quantity = $4, unit = NULLIF($5, ''),
+			location = $6, best_before = $7::date, updated_at = $8
+		WHERE id = $1
+		RETURNING ` + pantryItemColumns
+
+	rows, err := r.pool.Query(ctx, query,
+		item.ID,
+		item.ItemName,
+		item.DisplayName,
+		item.Quantity,
+		item.Unit,
+		item.Location,
+		item.BestBefore,
+		item.UpdatedAt,
+	)
+	if err != nil {
+		return models.PantryItem{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.PantryItem])
+}
+
+// FetchByID returns a pantry item, pgx.ErrNoRows if it doesn't exist
+func (r *PantryRepository) FetchByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (models.PantryItem, error) {
+	query := `SELECT ` + pantryItemColumns + ` FROM pantry_items WHERE id = $1`
+
+	rows, err := r.pool.Query(ctx, query, id)
+	if err != nil {
+		return models.PantryItem{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.PantryItem])
+}
+
+// ListByUserID returns the user's pantry by name, the ones going off first
+func (r *PantryRepository) ListByUserID(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]models.PantryItem, error) {
+	query := `
+		SELECT ` + pantryItemColumns + `
+		FROM pantry_items
+		WHERE user_id = $1
+		ORDER BY item_name, best_before NULLS LAST, created_at`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.PantryItem])
+}
+
+// FetchByItemName returns the user's pantry items with a normalized name,
+// oldest first
+func (r *PantryRepository) FetchByItemName(
+	ctx context.Context,
+	userID uuid.UUID,
+	itemName string,
+) ([]models.PantryItem, error) {
+	query := `
+		SELECT ` + pantryItemColumns + `
+		FROM pantry_items
+		WHERE user_id = $1 AND item_name = $2
+		ORDER BY created_at`
+
+	rows, err := r.pool.Query(ctx, query, userID, itemName)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.PantryItem])
+}
+
+func (r *PantryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, "DELETE FROM pantry_items WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// collectionRecipeInNotes limits a collection's recipes, joined as cr, to the
+// ones still in the notes of the collection's owner
+const collectionRecipeInNotes = `EXISTS (
+			SELECT 1 FROM note_recipes nr
+			JOIN notes n ON nr.note_id = n.id
+			WHERE nr.recipe_id = cr.recipe_id AND n.user_id = c.user_id
+		)`
+
+const recipeCollectionColumns = `c.id, c.user_id, c.name, c.description,
+		(
+			SELECT COUNT(*)::int FROM recipe_collection_recipes cr
+			WHERE cr.collection_id = c.id AND ` + collectionRecipeInNotes + `
+		) AS recipe_count,
+		(
+			SELECT r.image_id FROM recipe_collection_recipes cr
+			JOIN recipes r ON r.id = cr.recipe_id
+			WHERE cr.collection_id = c.id AND r.image_id IS NOT NULL AND ` + collectionRecipeInNotes + `
+			ORDER BY cr.added_at DESC
+			LIMIT 1
+		) AS cover_image_id,
+		c.created_at, c.updated_at`
+
+type RecipeCollectionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRecipeCollectionRepository(pool *pgxpool.Pool) *RecipeCollectionRepository {
+	return &RecipeCollectionRepository{pool: pool}
+}
+
+func (r *RecipeCollectionRepository) Create(
+	ctx context.Context,
+	collection models.RecipeCollection,
+) (models.RecipeCollection, error) {
+	query := `
+		INSERT INTO recipe_collections AS c (id, user_id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + recipeCollectionColumns
+
+	rows, err := r.pool.Query(ctx, query,
+		collection.ID,
+		collection.UserID,
+		collection.Name,
+		collection.Description,
+		collection.CreatedAt,
+		collection.UpdatedAt,
+	)
+	if err != nil {
+		return models.RecipeCollection{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RecipeCollection])
+}
+
+func (r *RecipeCollectionRepository) Update(
+	ctx context.Context,
+	collection models.RecipeCollection,
+) (models.RecipeCollection, error) {
+	query := `
+		UPDATE recipe_collections AS c
+		SET name = $2, description = $3, updated_at = $4
+		WHERE c.id = $1
+		RETURNING ` + recipeCollectionColumns
+
+	rows, err := r.pool.Query(ctx, query,
+		collection.ID,
+		collection.Name,
+		collection.Description,
+		collection.UpdatedAt,
+	)
+	if err != nil {
+		return models.RecipeCollection{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RecipeCollection])
+}
+
+// FetchByID returns a recipe collection, pgx.ErrNoRows if it doesn't exist
+func (r *RecipeCollectionRepository) FetchByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (models.RecipeCollection, error) {
+	query := `SELECT ` + recipeCollectionColumns + ` FROM recipe_collections c WHERE c.id = $1`
+
+	rows, err := r.pool.Query(ctx, query, id)
+	if err != nil {
+		return models.RecipeCollection{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RecipeCollection])
+}
+
+// FetchByName returns the user's collection with a name, compared
+// case-insensitively, pgx.ErrNoRows if there is none
+func (r *RecipeCollectionRepository) FetchByName(
+	ctx context.Context,
+	userID uuid.UUID,
+	name string,
+) (models.RecipeCollection, error) {
+	query := `
+		SELECT ` + recipeCollectionColumns + `
+		FROM recipe_collections c
+		WHERE c.user_id = $1 AND LOWER(c.name) = LOWER($2)`
+
+	rows, err := r.pool.Query(ctx, query, userID, name)
+	if err != nil {
+		return models.RecipeCollection{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RecipeCollection])
+}
+
+// ListByUserID returns the user's collections by name. With a recipe, only
+// the collections the recipe is in are returned.
+func (r *RecipeCollectionRepository) ListByUserID(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipeID *uuid.UUID,
+) ([]models.RecipeCollection, error) {
+	query := `
+		SELECT ` + recipeCollectionColumns + `
+		FROM recipe_collections c
+		WHERE c.user_id = $1
+		  AND ($2::uuid IS NULL OR EXISTS (
+			SELECT 1 FROM recipe_collection_recipes cr
+			WHERE cr.collection_id = c.id AND cr.recipe_id = $2
+		  ))
+		ORDER BY LOWER(c.name), c.created_at`
+
+	rows, err := r.pool.Query(ctx, query, userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.RecipeCollection])
+}
+
+func (r *RecipeCollectionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, "DELETE FROM recipe_collections WHERE id = $1", id)
+	return err
+}
+
+// AddRecipe adds a recipe to a collection. Adding it again keeps the time it
+// was first added.
+func (r *RecipeCollectionRepository) AddRecipe(
+	ctx context.Context,
+	collectionID uuid.UUID,
+	recipeID uuid.UUID,
+	addedAt time.Time,
+) error {
+	query := `
+		INSERT INTO recipe_collection_recipes (collection_id, recipe_id, added_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+
+	_, err := r.pool.Exec(ctx, query, collectionID, recipeID, addedAt)
+	return err
+}
+
+func (r *RecipeCollectionRepository) RemoveRecipe(
+	ctx context.Context,
+	collectionID uuid.UUID,
+	recipeID uuid.UUID,
+) error {
+	_, err := r.pool.Exec(ctx,
+		"DELETE FROM recipe_collection_recipes WHERE collection_id = $1 AND recipe_id = $2",
+		collectionID, recipeID,
+	)
+	return err
+}
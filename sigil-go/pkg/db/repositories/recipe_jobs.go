@@ -10,6 +10,9 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const recipeJobColumns = `id, user_id, COALESCE(url, ''), source_type, source_content, source_filename,
+		source_recipe_id, status, error_message, recipe_id, note_id, created_at, completed_at`
+
 type RecipeJobRepository struct {
 	pool *pgxpool.Pool
 }
@@ -23,14 +26,18 @@ func (r *RecipeJobRepository) Create(
 	job models.RecipeJob,
 ) (models.RecipeJob, error) {
 	query := `
-		INSERT INTO recipe_jobs (id, user_id, url, status, created_at) 
-		VALUES ($1, $2, $3, $4, $5) 
-		RETURNING id, user_id, url, status, error_message, recipe_id, note_id, created_at, completed_at`
+		INSERT INTO recipe_jobs (id, user_id, url, source_type, source_content, source_filename, source_recipe_id, status, created_at) 
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9) 
+		RETURNING ` + recipeJobColumns
 
 	rows, err := r.pool.Query(ctx, query,
 		job.ID,
 		job.UserID,
 		job.URL,
+		job.SourceType,
+		job.SourceContent,
+		job.SourceFilename,
+		job.SourceRecipeID,
 		job.Status,
 		job.CreatedAt,
 	)
@@ -60,6 +67,8 @@ func (r *RecipeJobRepository) UpdateStatus(
 	return err
 }
 
+// Complete links the job to its recipe and note. Pasted text and uploaded
+// files are no longer needed once the recipe is created.
 func (r *RecipeJobRepository) Complete(
 	ctx context.Context,
 	jobID uuid.UUID,
@@ -70,7 +79,7 @@ func (r *RecipeJobRepository) Complete(
 	
 	query := `
 		UPDATE recipe_jobs 
-		SET status = 'completed', recipe_id = $2, note_id = $3, completed_at = $4
+		SET status = 'completed', recipe_id = $2, note_id = $3, completed_at = $4, source_content = NULL
 		WHERE id = $1`
 
 	_, err := r.pool.Exec(ctx, query, jobID, recipeID, noteID, now)
@@ -82,7 +91,7 @@ func (r *RecipeJobRepository) FetchByID(
 	jobID uuid.UUID,
 ) (models.RecipeJob, error) {
 	query := `
-		SELECT id, user_id, url, status, error_message, recipe_id, note_id, created_at, completed_at 
+		SELECT ` + recipeJobColumns + `
 		FROM recipe_jobs 
 		WHERE id = $1`
 
@@ -100,7 +109,7 @@ func (r *RecipeJobRepository) FetchPendingJobs(
 	limit int,
 ) ([]models.RecipeJob, error) {
 	query := `
-		SELECT id, user_id, url, status, error_message, recipe_id, note_id, created_at, completed_at 
+		SELECT ` + recipeJobColumns + `
 		FROM recipe_jobs 
 		WHERE status = 'pending' 
 		ORDER BY created_at ASC 
@@ -125,6 +134,10 @@ func (r *RecipeJobRepository) scanJob(rows pgx.Rows) (models.RecipeJob, error) {
 		&job.ID,
 		&job.UserID,
 		&job.URL,
+		&job.SourceType,
+		&job.SourceContent,
+		&job.SourceFilename,
+		&job.SourceRecipeID,
 		&job.Status,
 		&job.ErrorMessage,
 		&job.RecipeID,
@@ -144,6 +157,10 @@ func (r *RecipeJobRepository) scanJobs(rows pgx.Rows) ([]models.RecipeJob, error
 			&job.ID,
 			&job.UserID,
 			&job.URL,
+			&job.SourceType,
+			&job.SourceContent,
+			&job.SourceFilename,
+			&job.SourceRecipeID,
 			&job.Status,
 			&job.ErrorMessage,
 			&job.RecipeID,
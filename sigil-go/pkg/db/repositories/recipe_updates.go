@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const recipeUpdateColumns = `id, user_id, recipe_id, source, diff, status, created_at, resolved_at`
+
+type RecipeUpdateRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRecipeUpdateRepository(pool *pgxpool.Pool) *RecipeUpdateRepository {
+	return &RecipeUpdateRepository{pool: pool}
+}
+
+func (r *RecipeUpdateRepository) Create(
+	ctx context.Context,
+	update models.RecipeUpdate,
+) (models.RecipeUpdate, error) {
+	source, err := json.Marshal(update.Source)
+	if err != nil {
+		return models.RecipeUpdate{}, err
+	}
+	diff, err := json.Marshal(update.Diff)
+	if err != nil {
+		return models.RecipeUpdate{}, err
+	}
+
+	query := `
+		INSERT INTO recipe_updates (id, user_id, recipe_id, source, diff, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + recipeUpdateColumns
+
+	rows, err := r.pool.Query(ctx, query,
+		update.ID,
+		update.UserID,
+		update.RecipeID,
+		source,
+		diff,
+		update.Status,
+		update.CreatedAt,
+	)
+	if err != nil {
+		return models.RecipeUpdate{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RecipeUpdate])
+}
+
+// FetchByID returns a recipe update, pgx.ErrNoRows if it doesn't exist
+func (r *RecipeUpdateRepository) FetchByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (models.RecipeUpdate, error) {
+	query := `SELECT ` + recipeUpdateColumns + ` FROM recipe_updates WHERE id = $1`
+
+	rows, err := r.pool.Query(ctx, query, id)
+	if err != nil {
+		return models.RecipeUpdate{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RecipeUpdate])
+}
+
+// FetchLatest returns the user's most recent update of a recipe, whatever
+// its status, pgx.ErrNoRows if there is none
+func (r *RecipeUpdateRepository) FetchLatest(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipeID uuid.UUID,
+) (models.RecipeUpdate, error) {
+	query := `
+		SELECT ` + recipeUpdateColumns + `
+		FROM recipe_updates
+		WHERE user_id = $1 AND recipe_id = $2
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	rows, err := r.pool.Query(ctx, query, userID, recipeID)
+	if err != nil {
+		return models.RecipeUpdate{}, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RecipeUpdate])
+}
+
+// ListPending returns the user's pending updates, newest first. A nil recipe
+// ID lists the updates of all of the user's recipes.
+func (r *RecipeUpdateRepository) ListPending(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipeID *uuid.UUID,
+) ([]models.RecipeUpdate, error) {
+	query := `
+		SELECT ` + recipeUpdateColumns + `
+		FROM recipe_updates
+		WHERE user_id = $1 AND status = 'pending'
+		  AND ($2::uuid IS NULL OR recipe_id = $2)
+		ORDER BY created_at DESC`
+
+	rows, err := r.pool.Query(ctx, query, userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.RecipeUpdate])
+}
+
+// Resolve marks an update as applied or dismissed
+func (r *RecipeUpdateRepository) Resolve(
+	ctx context.Context,
+	id uuid.UUID,
+	status string,
+	resolvedAt time.Time,
+) error {
+	_, err := r.pool.Exec(ctx,
+		"UPDATE recipe_updates SET status = $2, resolved_at = $3 WHERE id = $1",
+		id, status, resolvedAt,
+	)
+	return err
+}
+
+// DeletePending removes the user's pending updates of a recipe, they are
+// replaced when the source is read again
+func (r *RecipeUpdateRepository) DeletePending(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipeID uuid.UUID,
+) error {
+	_, err := r.pool.Exec(ctx,
+		"DELETE FROM recipe_updates WHERE user_id = $1 AND recipe_id = $2 AND status = 'pending'",
+		userID, recipeID,
+	)
+	return err
+}
@@ -3,6 +3,9 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 	"tofoss/sigil-go/pkg/models"
 
 	"github.com/google/uuid"
@@ -22,32 +25,166 @@ func (r *RecipeRepository) Create(
 	ctx context.Context,
 	recipe models.Recipe,
 ) (models.Recipe, error) {
-	ingredientsJSON, err := json.Marshal(recipe.Ingredients)
+	return insertRecipe(ctx, r.pool, recipe)
+}
+
+// CreateWithNote stores a recipe along with a note showing it, linked to each
+// other
+func (r *RecipeRepository) CreateWithNote(
+	ctx context.Context,
+	recipe models.Recipe,
+	note models.Note,
+) (models.Recipe, models.Note, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return models.Recipe{}, models.Note{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := insertRecipe(ctx, tx, recipe)
+	if err != nil {
+		return models.Recipe{}, models.Note{}, fmt.Errorf("failed to create recipe: %w", err)
+	}
+
+	createdNote, err := upsertNote(ctx, tx, note)
+	if err != nil {
+		return models.Recipe{}, models.Note{}, fmt.Errorf("failed to create note: %w", err)
+	}
+
+	if err := linkRecipeToNote(ctx, tx, created.ID, createdNote.ID); err != nil {
+		return models.Recipe{}, models.Note{}, fmt.Errorf("failed to link recipe to note: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Recipe{}, models.Note{}, err
+	}
+	return created, createdNote, nil
+}
+
+// Copy stores the recipe under a new ID in place of the original for the
+// user: the given notes are linked to the copy instead of the original, and
+// the user's cook log, collections, planned meals and pending source updates
+// of the original are moved to the copy.
+func (r *RecipeRepository) Copy(
+	ctx context.Context,
+	recipe models.Recipe,
+	originalID uuid.UUID,
+	userID uuid.UUID,
+	noteIDs []uuid.UUID,
+) (models.Recipe, error) {
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return models.Recipe{}, err
 	}
+	defer tx.Rollback(ctx)
 
-	stepsJSON, err := json.Marshal(recipe.Steps)
+	created, err := insertRecipe(ctx, tx, recipe)
 	if err != nil {
 		return models.Recipe{}, err
 	}
 
-	query := `
-		INSERT INTO recipes (id, name, summary, servings, prep_time, source_url, ingredients, steps, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
-		RETURNING id, name, summary, servings, prep_time, source_url, ingredients, steps, created_at, updated_at`
+	for _, noteID := range noteIDs {
+		if err := linkRecipeToNote(ctx, tx, created.ID, noteID); err != nil {
+			return models.Recipe{}, err
+		}
+		if _, err := tx.Exec(ctx, unlinkRecipeQuery, originalID, noteID); err != nil {
+			return models.Recipe{}, err
+		}
+	}
 
-	rows, err := r.pool.Query(ctx, query,
-		recipe.ID,
-		recipe.Name,
-		recipe.Summary,
-		recipe.Servings,
-		recipe.PrepTime,
-		recipe.SourceURL,
-		ingredientsJSON,
-		stepsJSON,
-		recipe.CreatedAt,
-		recipe.UpdatedAt,
+	// The cook log is kept per user and recipe, the user's history moves
+	query := "UPDATE recipe_cook_log SET recipe_id = $2 WHERE recipe_id = $1 AND user_id = $3"
+	if _, err := tx.Exec(ctx, query, originalID, created.ID, userID); err != nil {
+		return models.Recipe{}, err
+	}
+
+	// The user's collections move to the copy, other users' keep the original
+	query = `
+		UPDATE recipe_collection_recipes cr
+		SET recipe_id = $2
+		FROM recipe_collections c
+		WHERE cr.collection_id = c.id AND cr.recipe_id = $1 AND c.user_id = $3`
+	if _, err := tx.Exec(ctx, query, originalID, created.ID, userID); err != nil {
+		return models.Recipe{}, err
+	}
+
+	// Planned meals follow the recipe, calendar clients are told they changed
+	query = `
+		UPDATE meal_plan_meals m
+		SET recipe_id = $2, sequence = m.sequence + 1, updated_at = $4
+		FROM meal_plans p
+		WHERE m.meal_plan_id = p.id AND m.recipe_id = $1 AND p.user_id = $3`
+	if _, err := tx.Exec(ctx, query, originalID, created.ID, userID, recipe.UpdatedAt); err != nil {
+		return models.Recipe{}, err
+	}
+
+	// Updates the user hasn't reviewed yet are applied to the copy
+	query = "UPDATE recipe_updates SET recipe_id = $2 WHERE recipe_id = $1 AND user_id = $3 AND status = 'pending'"
+	if _, err := tx.Exec(ctx, query, originalID, created.ID, userID); err != nil {
+		return models.Recipe{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Recipe{}, err
+	}
+	return created, nil
+}
+
+// RemoveFromUser takes a recipe others still use out of the user's notes.
+// The user's planned meals, cook log, collection entries and source updates
+// of the recipe go with it, the user no longer has access to the recipe.
+func (r *RecipeRepository) RemoveFromUser(
+	ctx context.Context,
+	recipeID uuid.UUID,
+	userID uuid.UUID,
+	noteIDs []uuid.UUID,
+) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, noteID := range noteIDs {
+		if _, err := tx.Exec(ctx, unlinkRecipeQuery, recipeID, noteID); err != nil {
+			return err
+		}
+	}
+
+	queries := []string{
+		`DELETE FROM meal_plan_meals m
+		USING meal_plans p
+		WHERE m.meal_plan_id = p.id AND m.recipe_id = $1 AND p.user_id = $2`,
+		"DELETE FROM recipe_cook_log WHERE recipe_id = $1 AND user_id = $2",
+		`DELETE FROM recipe_collection_recipes cr
+		USING recipe_collections c
+		WHERE cr.collection_id = c.id AND cr.recipe_id = $1 AND c.user_id = $2`,
+		"DELETE FROM recipe_updates WHERE recipe_id = $1 AND user_id = $2",
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, recipeID, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func insertRecipe(ctx context.Context, q querier, recipe models.Recipe) (models.Recipe, error) {
+	values, err := recipeValues(recipe)
+	if err != nil {
+		return models.Recipe{}, err
+	}
+
+	query := `
+		INSERT INTO recipes AS r (id, name, summary, servings, prep_time, cook_time, total_time, prep_minutes, cook_minutes, total_minutes,
+			cuisine, course, dietary, dietary_manual, equipment, source_url, image_id, step_images, ingredients, steps, step_sections,
+			source_snapshot, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+		RETURNING ` + recipeColumns
+
+	rows, err := q.Query(ctx, query,
+		append([]any{recipe.ID}, append(values, recipe.CreatedAt, recipe.UpdatedAt)...)...,
 	)
 
 	if err != nil {
@@ -55,39 +192,29 @@ func (r *RecipeRepository) Create(
 	}
 	defer rows.Close()
 
-	return r.scanRecipe(rows)
+	return scanRecipe(rows)
 }
 
 func (r *RecipeRepository) Update(
 	ctx context.Context,
 	recipe models.Recipe,
 ) (models.Recipe, error) {
-	ingredientsJSON, err := json.Marshal(recipe.Ingredients)
-	if err != nil {
-		return models.Recipe{}, err
-	}
-
-	stepsJSON, err := json.Marshal(recipe.Steps)
+	values, err := recipeValues(recipe)
 	if err != nil {
 		return models.Recipe{}, err
 	}
 
 	query := `
-		UPDATE recipes 
-		SET name = $2, summary = $3, servings = $4, prep_time = $5, source_url = $6, ingredients = $7, steps = $8, updated_at = $9
-		WHERE id = $1 
-		RETURNING id, name, summary, servings, prep_time, source_url, ingredients, steps, created_at, updated_at`
+		UPDATE recipes r
+		SET name = $2, summary = $3, servings = $4, prep_time = $5, cook_time = $6, total_time = $7,
+			prep_minutes = $8, cook_minutes = $9, total_minutes = $10, cuisine = $11, course = $12,
+			dietary = $13, dietary_manual = $14, equipment = $15, source_url = $16, image_id = $17, step_images = $18,
+			ingredients = $19, steps = $20, step_sections = $21, source_snapshot = $22, updated_at = $23
+		WHERE id = $1
+		RETURNING ` + recipeColumns
 
 	rows, err := r.pool.Query(ctx, query,
-		recipe.ID,
-		recipe.Name,
-		recipe.Summary,
-		recipe.Servings,
-		recipe.PrepTime,
-		recipe.SourceURL,
-		ingredientsJSON,
-		stepsJSON,
-		recipe.UpdatedAt,
+		append([]any{recipe.ID}, append(values, recipe.UpdatedAt)...)...,
 	)
 
 	if err != nil {
@@ -95,14 +222,14 @@ func (r *RecipeRepository) Update(
 	}
 	defer rows.Close()
 
-	return r.scanRecipe(rows)
+	return scanRecipe(rows)
 }
 
 func (r *RecipeRepository) FetchByID(
 	ctx context.Context,
 	recipeID uuid.UUID,
 ) (models.Recipe, error) {
-	query := "SELECT id, name, summary, servings, prep_time, source_url, ingredients, steps, created_at, updated_at FROM recipes WHERE id = $1"
+	query := "SELECT " + recipeColumns + " FROM recipes r WHERE r.id = $1"
 
 	rows, err := r.pool.Query(ctx, query, recipeID)
 	if err != nil {
@@ -110,7 +237,7 @@ func (r *RecipeRepository) FetchByID(
 	}
 	defer rows.Close()
 
-	return r.scanRecipe(rows)
+	return scanRecipe(rows)
 }
 
 func (r *RecipeRepository) FetchByNoteID(
@@ -118,7 +245,7 @@ func (r *RecipeRepository) FetchByNoteID(
 	noteID uuid.UUID,
 ) ([]models.Recipe, error) {
 	query := `
-		SELECT r.id, r.name, r.summary, r.servings, r.prep_time, r.source_url, r.ingredients, r.steps, r.created_at, r.updated_at 
+		SELECT ` + recipeColumns + `
 		FROM recipes r 
 		JOIN note_recipes nr ON r.id = nr.recipe_id 
 		WHERE nr.note_id = $1`
@@ -137,7 +264,7 @@ func (r *RecipeRepository) FetchByUserID(
 	userID uuid.UUID,
 ) ([]models.Recipe, error) {
 	query := `
-		SELECT DISTINCT r.id, r.name, r.summary, r.servings, r.prep_time, r.source_url, r.ingredients, r.steps, r.created_at, r.updated_at 
+		SELECT DISTINCT ` + recipeColumns + `
 		FROM recipes r 
 		JOIN note_recipes nr ON r.id = nr.recipe_id
 		JOIN notes n ON nr.note_id = n.id 
@@ -153,6 +280,34 @@ func (r *RecipeRepository) FetchByUserID(
 	return r.scanRecipes(rows)
 }
 
+// FetchByCollection returns the recipes in a collection that are still in the
+// user's notes, by name
+func (r *RecipeRepository) FetchByCollection(
+	ctx context.Context,
+	collectionID uuid.UUID,
+	userID uuid.UUID,
+) ([]models.Recipe, error) {
+	query := `
+		SELECT ` + recipeColumns + `
+		FROM recipes r
+		JOIN recipe_collection_recipes cr ON cr.recipe_id = r.id
+		WHERE cr.collection_id = $1
+		  AND EXISTS (
+			SELECT 1 FROM note_recipes nr
+			JOIN notes n ON nr.note_id = n.id
+			WHERE nr.recipe_id = r.id AND n.user_id = $2
+		  )
+		ORDER BY LOWER(r.name), r.created_at`
+
+	rows, err := r.pool.Query(ctx, query, collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanRecipes(rows)
+}
+
 func (r *RecipeRepository) Delete(
 	ctx context.Context,
 	recipeID uuid.UUID,
@@ -161,13 +316,291 @@ func (r *RecipeRepository) Delete(
 	return err
 }
 
+// RecipeFilter narrows down a recipe listing. Empty fields match everything.
+type RecipeFilter struct {
+	Name            string
+	Ingredient      string
+	MaxTotalMinutes *int       // recipes without a total time don't match
+	Cuisine         string     // matched case-insensitively
+	Course          string     // matched case-insensitively
+	Dietary         []string   // recipes with all of the labels
+	Equipment       string     // recipes using equipment containing the text
+	Collection      *uuid.UUID // recipes in one of the user's collections
+}
+
+// RecipeSort is the field a recipe listing is ordered by
+type RecipeSort string
+
+const (
+	RecipeSortCreated     RecipeSort = "created"
+	RecipeSortName        RecipeSort = "name"
+	RecipeSortTimesCooked RecipeSort = "timesCooked"
+	RecipeSortRating      RecipeSort = "rating"
+	RecipeSortLastCooked  RecipeSort = "lastCooked"
+)
+
+// recipeSortColumns maps sort fields to the columns of the listing query
+var recipeSortColumns = map[RecipeSort]string{
+	RecipeSortCreated:     "r.created_at",
+	RecipeSortName:        "LOWER(r.name)",
+	RecipeSortTimesCooked: "NULLIF(s.times_cooked, 0)", // never cooked is NULL, sorted last
+	RecipeSortRating:      "s.average_rating",
+	RecipeSortLastCooked:  "s.last_cooked",
+}
+
+// RecipeOrder is the order of a recipe listing
+type RecipeOrder struct {
+	Sort      RecipeSort
+	Ascending bool
+}
+
+// ValidRecipeSort reports whether a recipe listing can be ordered by sort
+func ValidRecipeSort(sort RecipeSort) bool {
+	_, ok := recipeSortColumns[sort]
+	return ok
+}
+
+// ListByUserID returns a page of the recipes linked to the user's notes, with
+// stats from the user's cook log. Recipes never cooked or rated come last when
+// ordering by the cook log.
+func (r *RecipeRepository) ListByUserID(
+	ctx context.Context,
+	userID uuid.UUID,
+	filter RecipeFilter,
+	order RecipeOrder,
+	limit int,
+	offset int,
+) ([]models.Recipe, error) {
+	column, ok := recipeSortColumns[order.Sort]
+	if !ok {
+		column = recipeSortColumns[RecipeSortCreated]
+	}
+	direction := "DESC"
+	if order.Ascending {
+		direction = "ASC"
+	}
+
+	dietary := filter.Dietary
+	if dietary == nil {
+		dietary = []string{}
+	}
+	dietaryJSON, err := json.Marshal(dietary)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + recipeColumns + `,
+			s.times_cooked, s.average_rating, s.last_cooked
+		FROM recipes r
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS times_cooked,
+				AVG(rating)::float8 AS average_rating,
+				MAX(cooked_on)::text AS last_cooked
+			FROM recipe_cook_log
+			WHERE recipe_id = r.id AND user_id = $1
+		) s
+		WHERE EXISTS (
+			SELECT 1 FROM note_recipes nr
+			JOIN notes n ON nr.note_id = n.id
+			WHERE nr.recipe_id = r.id AND n.user_id = $1
+		)
+		  AND ($2 = '' OR r.name ILIKE '%' || $2 || '%')
+		  AND ($3 = '' OR EXISTS (
+			SELECT 1 FROM jsonb_array_elements(r.ingredients) AS i
+			WHERE i->>'name' ILIKE '%' || $3 || '%'
+		  ))
+		  AND ($6::int IS NULL OR r.total_minutes <= $6)
+		  AND ($7 = '' OR LOWER(r.cuisine) = LOWER($7))
+		  AND ($8 = '' OR LOWER(r.course) = LOWER($8))
+		  AND r.dietary @> $9::jsonb
+		  AND ($10 = '' OR EXISTS (
+			SELECT 1 FROM jsonb_array_elements_text(r.equipment) AS e
+			WHERE e ILIKE '%' || $10 || '%'
+		  ))
+		  AND ($11::uuid IS NULL OR EXISTS (
+			SELECT 1 FROM recipe_collection_recipes cr
+			JOIN recipe_collections c ON c.id = cr.collection_id
+			WHERE cr.recipe_id = r.id AND c.id = $11 AND c.user_id = $1
+		  ))
+		ORDER BY ` + column + ` ` + direction + ` NULLS LAST, r.created_at DESC, r.id
+		LIMIT $4 OFFSET $5`
+
+	rows, err := r.pool.Query(ctx, query,
+		userID,
+		escapeLikePattern(filter.Name),
+		escapeLikePattern(filter.Ingredient),
+		limit,
+		offset,
+		filter.MaxTotalMinutes,
+		filter.Cuisine,
+		filter.Course,
+		dietaryJSON,
+		escapeLikePattern(filter.Equipment),
+		filter.Collection,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := []models.Recipe{}
+	for rows.Next() {
+		row := recipeRow{}
+		stats := models.RecipeStats{}
+
+		err := rows.Scan(append(row.targets(),
+			&stats.TimesCooked,
+			&stats.AverageRating,
+			&stats.LastCooked,
+		)...)
+		if err != nil {
+			return nil, err
+		}
+
+		recipe, err := row.decode()
+		if err != nil {
+			return nil, err
+		}
+
+		recipe.Stats = &stats
+		recipes = append(recipes, recipe)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return recipes, nil
+}
+
+// FetchUserNoteIDs returns the user's notes the recipe is linked to. Recipes
+// don't have an owner, a user has access to the recipes of their notes.
+func (r *RecipeRepository) FetchUserNoteIDs(
+	ctx context.Context,
+	recipeID uuid.UUID,
+	userID uuid.UUID,
+) ([]uuid.UUID, error) {
+	query := `
+		SELECT nr.note_id
+		FROM note_recipes nr
+		JOIN notes n ON nr.note_id = n.id
+		WHERE nr.recipe_id = $1 AND n.user_id = $2
+		ORDER BY nr.created_at`
+
+	rows, err := r.pool.Query(ctx, query, recipeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// FetchUserIDs returns the users who have the recipe in their notes
+func (r *RecipeRepository) FetchUserIDs(ctx context.Context, recipeID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT n.user_id
+		FROM note_recipes nr
+		JOIN notes n ON nr.note_id = n.id
+		WHERE nr.recipe_id = $1`
+
+	rows, err := r.pool.Query(ctx, query, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// IsShared reports whether anyone besides the user can see the recipe, either
+// through their own notes or through the URL cache used by imports
+func (r *RecipeRepository) IsShared(
+	ctx context.Context,
+	recipeID uuid.UUID,
+	userID uuid.UUID,
+) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM note_recipes nr
+			JOIN notes n ON nr.note_id = n.id
+			WHERE nr.recipe_id = $1 AND n.user_id <> $2
+		) OR EXISTS (
+			SELECT 1 FROM recipe_url_cache WHERE recipe_id = $1
+		)`
+
+	var shared bool
+	err := r.pool.QueryRow(ctx, query, recipeID, userID).Scan(&shared)
+	return shared, err
+}
+
+// RecipeSourceCheck is a recipe to read again from its source URL, along
+// with the users who have it in their notes
+type RecipeSourceCheck struct {
+	RecipeID  uuid.UUID
+	SourceURL string
+	UserIDs   []uuid.UUID
+}
+
+// FetchDueForSourceCheck returns recipes imported from a URL whose source
+// hasn't been read since checkedBefore, the longest unchecked first. Recipes
+// without a snapshot of their source, like those imported from Paprika, are
+// left out: there's no telling the user's edits from changes at the source.
+func (r *RecipeRepository) FetchDueForSourceCheck(
+	ctx context.Context,
+	checkedBefore time.Time,
+	limit int,
+) ([]RecipeSourceCheck, error) {
+	query := `
+		SELECT r.id, r.source_url, ARRAY_AGG(DISTINCT n.user_id)
+		FROM recipes r
+		JOIN note_recipes nr ON r.id = nr.recipe_id
+		JOIN notes n ON nr.note_id = n.id
+		WHERE r.source_url IS NOT NULL
+		  AND r.source_snapshot IS NOT NULL
+		  AND COALESCE(r.source_checked_at, r.created_at) < $1
+		GROUP BY r.id
+		ORDER BY COALESCE(r.source_checked_at, r.created_at)
+		LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, checkedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []RecipeSourceCheck
+	for rows.Next() {
+		var check RecipeSourceCheck
+		if err := rows.Scan(&check.RecipeID, &check.SourceURL, &check.UserIDs); err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+	return checks, rows.Err()
+}
+
+// MarkSourceChecked records when a recipe's source was last read
+func (r *RecipeRepository) MarkSourceChecked(
+	ctx context.Context,
+	recipeID uuid.UUID,
+	checkedAt time.Time,
+) error {
+	_, err := r.pool.Exec(ctx, "UPDATE recipes SET source_checked_at = $2 WHERE id = $1", recipeID, checkedAt)
+	return err
+}
+
 // LinkRecipeToNote creates a relationship between a recipe and note
 func (r *RecipeRepository) LinkRecipeToNote(
 	ctx context.Context,
 	recipeID uuid.UUID,
 	noteID uuid.UUID,
 ) error {
-	_, err := r.pool.Exec(ctx, "INSERT INTO note_recipes (recipe_id, note_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", recipeID, noteID)
+	return linkRecipeToNote(ctx, r.pool, recipeID, noteID)
+}
+
+func linkRecipeToNote(ctx context.Context, q querier, recipeID uuid.UUID, noteID uuid.UUID) error {
+	_, err := q.Exec(ctx, "INSERT INTO note_recipes (recipe_id, note_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", recipeID, noteID)
 	return err
 }
 
@@ -177,79 +610,185 @@ func (r *RecipeRepository) UnlinkRecipeFromNote(
 	recipeID uuid.UUID,
 	noteID uuid.UUID,
 ) error {
-	_, err := r.pool.Exec(ctx, "DELETE FROM note_recipes WHERE recipe_id = $1 AND note_id = $2", recipeID, noteID)
+	_, err := r.pool.Exec(ctx, unlinkRecipeQuery, recipeID, noteID)
 	return err
 }
 
-// scanRecipe scans a single recipe row with JSON unmarshaling
-func (r *RecipeRepository) scanRecipe(rows pgx.Rows) (models.Recipe, error) {
-	recipe := models.Recipe{}
-	var ingredientsJSON []byte
-	var stepsJSON []byte
+const unlinkRecipeQuery = "DELETE FROM note_recipes WHERE recipe_id = $1 AND note_id = $2"
+
+// recipeColumns are the columns recipeRow scans, from the recipes table
+// aliased as r
+const recipeColumns = `r.id, r.name, r.summary, r.servings, r.prep_time, r.cook_time, r.total_time,
+	r.prep_minutes, r.cook_minutes, r.total_minutes, r.cuisine, r.course, r.dietary, r.dietary_manual,
+	r.equipment, r.source_url, r.image_id, r.step_images, r.ingredients, r.steps,
+	r.step_sections, r.source_snapshot, r.created_at, r.updated_at`
+
+// recipeValues returns the values written to the recipe columns from name to
+// source_snapshot, with the JSON columns encoded
+func recipeValues(recipe models.Recipe) ([]any, error) {
+	dietary := recipe.Dietary
+	if dietary == nil {
+		dietary = []string{}
+	}
+	equipment := recipe.Equipment
+	if equipment == nil {
+		equipment = []string{}
+	}
+	stepImages := recipe.StepImages
+	if stepImages == nil {
+		stepImages = []models.StepImage{}
+	}
+	stepSections := recipe.StepSections
+	if stepSections == nil {
+		stepSections = []models.StepSection{}
+	}
 
-	if !rows.Next() {
-		return models.Recipe{}, pgx.ErrNoRows
+	// Recipes from elsewhere than a URL have no snapshot
+	var source []byte
+	if recipe.Source != nil {
+		data, err := json.Marshal(recipe.Source)
+		if err != nil {
+			return nil, err
+		}
+		source = data
 	}
 
-	err := rows.Scan(
-		&recipe.ID,
-		&recipe.Name,
-		&recipe.Summary,
-		&recipe.Servings,
-		&recipe.PrepTime,
-		&recipe.SourceURL,
-		&ingredientsJSON,
-		&stepsJSON,
-		&recipe.CreatedAt,
-		&recipe.UpdatedAt,
-	)
-	if err != nil {
-		return models.Recipe{}, err
+	var encoded [6][]byte
+	for i, value := range []any{dietary, equipment, stepImages, recipe.Ingredients, recipe.Steps, stepSections} {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = data
 	}
 
-	// Unmarshal JSON fields
-	if err := json.Unmarshal(ingredientsJSON, &recipe.Ingredients); err != nil {
-		return models.Recipe{}, err
+	return []any{
+		recipe.Name,
+		recipe.Summary,
+		recipe.Servings,
+		recipe.PrepTime,
+		recipe.CookTime,
+		recipe.TotalTime,
+		recipe.PrepMinutes,
+		recipe.CookMinutes,
+		recipe.TotalMinutes,
+		recipe.Cuisine,
+		recipe.Course,
+		encoded[0],
+		recipe.DietaryManual,
+		encoded[1],
+		recipe.SourceURL,
+		recipe.ImageID,
+		encoded[2],
+		encoded[3],
+		encoded[4],
+		encoded[5],
+		source,
+	}, nil
+}
+
+// recipeRow holds a recipe while it's scanned, the JSON columns are decoded
+// into it afterwards
+type recipeRow struct {
+	recipe          models.Recipe
+	dietaryJSON     []byte
+	equipmentJSON   []byte
+	stepImagesJSON  []byte
+	ingredientsJSON []byte
+	stepsJSON       []byte
+	sectionsJSON    []byte
+	sourceJSON      []byte
+}
+
+// targets returns the scan targets of recipeColumns
+func (row *recipeRow) targets() []any {
+	return []any{
+		&row.recipe.ID,
+		&row.recipe.Name,
+		&row.recipe.Summary,
+		&row.recipe.Servings,
+		&row.recipe.PrepTime,
+		&row.recipe.CookTime,
+		&row.recipe.TotalTime,
+		&row.recipe.PrepMinutes,
+		&row.recipe.CookMinutes,
+		&row.recipe.TotalMinutes,
+		&row.recipe.Cuisine,
+		&row.recipe.Course,
+		&row.dietaryJSON,
+		&row.recipe.DietaryManual,
+		&row.equipmentJSON,
+		&row.recipe.SourceURL,
+		&row.recipe.ImageID,
+		&row.stepImagesJSON,
+		&row.ingredientsJSON,
+		&row.stepsJSON,
+		&row.sectionsJSON,
+		&row.sourceJSON,
+		&row.recipe.CreatedAt,
+		&row.recipe.UpdatedAt,
+	}
+}
+
+// decode unmarshals the JSON columns and returns the recipe
+func (row *recipeRow) decode() (models.Recipe, error) {
+	recipe := row.recipe
+	fields := []struct {
+		data   []byte
+		target any
+	}{
+		{row.dietaryJSON, &recipe.Dietary},
+		{row.equipmentJSON, &recipe.Equipment},
+		{row.stepImagesJSON, &recipe.StepImages},
+		{row.ingredientsJSON, &recipe.Ingredients},
+		{row.stepsJSON, &recipe.Steps},
+		{row.sectionsJSON, &recipe.StepSections},
+	}
+	for _, field := range fields {
+		if err := json.Unmarshal(field.data, field.target); err != nil {
+			return models.Recipe{}, err
+		}
+	}
+	if row.sourceJSON != nil {
+		recipe.Source = &models.RecipeSnapshot{}
+		if err := json.Unmarshal(row.sourceJSON, recipe.Source); err != nil {
+			return models.Recipe{}, err
+		}
+	}
+	return recipe, nil
+}
+
+// scanRecipe scans a single recipe row with JSON unmarshaling
+func scanRecipe(rows pgx.Rows) (models.Recipe, error) {
+	if !rows.Next() {
+		return models.Recipe{}, pgx.ErrNoRows
 	}
 
-	if err := json.Unmarshal(stepsJSON, &recipe.Steps); err != nil {
+	row := recipeRow{}
+	if err := rows.Scan(row.targets()...); err != nil {
 		return models.Recipe{}, err
 	}
 
-	return recipe, nil
+	return row.decode()
 }
 
 // scanRecipes scans multiple recipe rows
 func (r *RecipeRepository) scanRecipes(rows pgx.Rows) ([]models.Recipe, error) {
+	return scanRecipeRows(rows)
+}
+
+// scanRecipeRows scans rows of recipeColumns
+func scanRecipeRows(rows pgx.Rows) ([]models.Recipe, error) {
 	var recipes []models.Recipe
 
 	for rows.Next() {
-		recipe := models.Recipe{}
-		var ingredientsJSON []byte
-		var stepsJSON []byte
-
-		err := rows.Scan(
-			&recipe.ID,
-			&recipe.Name,
-			&recipe.Summary,
-			&recipe.Servings,
-			&recipe.PrepTime,
-			&recipe.SourceURL,
-			&ingredientsJSON,
-			&stepsJSON,
-			&recipe.CreatedAt,
-			&recipe.UpdatedAt,
-		)
-		if err != nil {
+		row := recipeRow{}
+		if err := rows.Scan(row.targets()...); err != nil {
 			return nil, err
 		}
 
-		// Unmarshal JSON fields
-		if err := json.Unmarshal(ingredientsJSON, &recipe.Ingredients); err != nil {
-			return nil, err
-		}
-
-		if err := json.Unmarshal(stepsJSON, &recipe.Steps); err != nil {
+		recipe, err := row.decode()
+		if err != nil {
 			return nil, err
 		}
 
@@ -261,4 +800,10 @@ func (r *RecipeRepository) scanRecipes(rows pgx.Rows) ([]models.Recipe, error) {
 	}
 
 	return recipes, nil
-}
\ No newline at end of file
+}
+
+// escapeLikePattern escapes the wildcards of a LIKE pattern so user input is
+// matched literally
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -209,6 +209,52 @@ func (r *ShoppingListRepository) getItemsByListID(ctx context.Context, listID uu
 	return items, nil
 }
 
+// GetItemForUser retrieves an item of one of the user's shopping lists,
+// pgx.ErrNoRows if the user has no such item
+func (r *ShoppingListRepository) GetItemForUser(ctx context.Context, itemID uuid.UUID, userID uuid.UUID) (*models.ShoppingListEntry, error) {
+	query := `
+		SELECT i.id, i.shopping_list_id, i.item_name, i.display_name,
+		       i.quantity_min, i.quantity_max, i.quantity_unit,
+		       i.notes, i.checked, i.position, i.section_header, i.created_at
+		FROM shopping_list_items i
+		JOIN shopping_lists l ON i.shopping_list_id = l.id
+		WHERE i.id = $1 AND l.user_id = $2`
+
+	var item models.ShoppingListEntry
+	var quantityMin, quantityMax *float64
+	var quantityUnit *string
+
+	err := r.pool.QueryRow(ctx, query, itemID, userID).Scan(
+		&item.ID,
+		&item.ShoppingListID,
+		&item.ItemName,
+		&item.DisplayName,
+		&quantityMin,
+		&quantityMax,
+		&quantityUnit,
+		&item.Notes,
+		&item.Checked,
+		&item.Position,
+		&item.SectionHeader,
+		&item.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if quantityMin != nil || quantityMax != nil || quantityUnit != nil {
+		item.Quantity = &models.Quantity{
+			Min: quantityMin,
+			Max: quantityMax,
+		}
+		if quantityUnit != nil {
+			item.Quantity.Unit = *quantityUnit
+		}
+	}
+
+	return &item, nil
+}
+
 // Create creates a new shopping list
 func (r *ShoppingListRepository) Create(ctx context.Context, list models.ShoppingList) (*models.ShoppingList, error) {
 	query := `
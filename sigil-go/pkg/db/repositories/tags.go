@@ -65,3 +65,30 @@ func (r *TagRepository) FetchAll(
 
 	return pgx.CollectRows(rows, pgx.RowToStructByName[models.Tag])
 }
+
+// FetchOrCreate returns the tag with the given name, creating it if it doesn't exist
+func (r *TagRepository) FetchOrCreate(
+	ctx context.Context,
+	tagName string,
+) (models.Tag, error) {
+	query := `
+		WITH inserted AS (
+			INSERT INTO tags (name)
+			VALUES ($1)
+			ON CONFLICT (name) DO NOTHING
+			RETURNING id, name
+		)
+		SELECT id, name FROM inserted
+		UNION ALL
+		SELECT id, name FROM tags WHERE name = $1
+		LIMIT 1
+	`
+
+	rows, err := r.pool.Query(ctx, query, tagName)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Tag])
+}
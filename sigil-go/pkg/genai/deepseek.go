@@ -4,35 +4,68 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 
 	deepseek "github.com/cohesion-org/deepseek-go"
 )
 
 type DeepseekClient struct {
-	client *deepseek.Client
+	client      *deepseek.Client
+	model       string
+	temperature *float64
 }
 
-func NewDeepseekClient() DeepseekClient {
-	client := deepseek.NewClient("")
+// NewDeepseekClient creates a client for the Deepseek API. The API key falls
+// back to the DEEPSEEK_API_KEY environment variable. AI features are
+// optional, so a missing key only fails the chat requests.
+func NewDeepseekClient(cfg Config) (*DeepseekClient, error) {
+	model := cfg.Model
+	if model == "" {
+		model = deepseek.DeepSeekChat
+	}
+
+	if cfg.APIKey == "" && os.Getenv("DEEPSEEK_API_KEY") == "" {
+		log.Printf("DEEPSEEK_API_KEY is not set, AI features are unavailable")
+		return &DeepseekClient{model: model}, nil
+	}
 
-	return DeepseekClient{
-		client,
+	var options []deepseek.Option
+	if cfg.BaseURL != "" {
+		options = append(options, deepseek.WithBaseURL(cfg.BaseURL))
+	}
+
+	client, err := deepseek.NewClientWithOptions(cfg.APIKey, options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create deepseek client: %w", err)
 	}
+
+	return &DeepseekClient{
+		client:      client,
+		model:       model,
+		temperature: cfg.Temperature,
+	}, nil
 }
 
 func (d *DeepseekClient) Chat(
-	systemContent, userContent string,
-	JSONMode bool,
 	ctx context.Context,
+	systemContent, userContent string,
+	jsonMode bool,
 ) (string, error) {
+	if d.client == nil {
+		return "", fmt.Errorf("deepseek API key is not configured")
+	}
+
 	request := &deepseek.ChatCompletionRequest{
-		Model:    deepseek.DeepSeekChat,
-		JSONMode: JSONMode,
+		Model:    d.model,
+		JSONMode: jsonMode,
 		Messages: []deepseek.ChatCompletionMessage{
 			{Role: deepseek.ChatMessageRoleSystem, Content: systemContent},
 			{Role: deepseek.ChatMessageRoleUser, Content: userContent},
 		},
 	}
+	if d.temperature != nil {
+		request.Temperature = float32(*d.temperature)
+	}
 
 	response, err := d.client.CreateChatCompletion(ctx, request)
 	if err != nil {
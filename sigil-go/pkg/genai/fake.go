@@ -0,0 +1,67 @@
+package genai
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeRequest is a chat request received by a FakeProvider
+type FakeRequest struct {
+	SystemContent string
+	UserContent   string
+	JSONMode      bool
+}
+
+// FakeProvider answers without calling a model, for tests and for running
+// without an AI service. Responses are returned in order and the last one is
+// repeated. Without responses it answers with an empty JSON object in JSON
+// mode and echoes the user content otherwise.
+type FakeProvider struct {
+	mu        sync.Mutex
+	responses []string
+	requests  []FakeRequest
+	// Err is returned instead of a response when set
+	Err error
+}
+
+func NewFakeProvider(responses ...string) *FakeProvider {
+	return &FakeProvider{responses: responses}
+}
+
+func (f *FakeProvider) Chat(
+	ctx context.Context,
+	systemContent, userContent string,
+	jsonMode bool,
+) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, FakeRequest{
+		SystemContent: systemContent,
+		UserContent:   userContent,
+		JSONMode:      jsonMode,
+	})
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	if index := len(f.requests) - 1; len(f.responses) > 0 {
+		return f.responses[min(index, len(f.responses)-1)], nil
+	}
+	if jsonMode {
+		return "{}", nil
+	}
+	return userContent, nil
+}
+
+// Requests returns the requests received so far
+func (f *FakeProvider) Requests() []FakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]FakeRequest(nil), f.requests...)
+}
@@ -0,0 +1,141 @@
+package genai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// OpenAIClient talks to any server implementing the OpenAI chat completions
+// API, like llama.cpp, Ollama and vLLM
+type OpenAIClient struct {
+	httpClient  *http.Client
+	baseURL     string
+	model       string
+	apiKey      string
+	temperature *float64
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	Stream         bool                  `json:"stream"`
+}
+
+type openAIResponse struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		TotalTokens int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewOpenAIClient creates a client for an OpenAI compatible server. The base
+// URL includes the API version, e.g. http://localhost:11434/v1 for Ollama.
+// Local servers usually don't need an API key.
+func NewOpenAIClient(cfg Config) (*OpenAIClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required for OpenAI compatible providers")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("model is required for OpenAI compatible providers")
+	}
+
+	return &OpenAIClient{
+		httpClient:  &http.Client{},
+		baseURL:     strings.TrimRight(cfg.BaseURL, "/"),
+		model:       cfg.Model,
+		apiKey:      cfg.APIKey,
+		temperature: cfg.Temperature,
+	}, nil
+}
+
+func (c *OpenAIClient) Chat(
+	ctx context.Context,
+	systemContent, userContent string,
+	jsonMode bool,
+) (string, error) {
+	request := openAIRequest{
+		Model: c.model,
+		Messages: []openAIMessage{
+			{Role: "system", Content: systemContent},
+			{Role: "user", Content: userContent},
+		},
+		Temperature: c.temperature,
+	}
+	if jsonMode {
+		request.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("could not encode chat request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("could not create chat request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not complete chat %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not read chat response: %w", err)
+	}
+
+	var response openAIResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("chat request failed with status %d", resp.StatusCode)
+		}
+		return "", fmt.Errorf("could not decode chat response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if response.Error != nil && response.Error.Message != "" {
+			return "", fmt.Errorf("chat request failed with status %d: %s", resp.StatusCode, response.Error.Message)
+		}
+		return "", fmt.Errorf("chat request failed with status %d", resp.StatusCode)
+	}
+
+	log.Printf(
+		"Chat %s request was successful. Used %d tokens.\n",
+		response.ID,
+		response.Usage.TotalTokens,
+	)
+
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("no choices returned from API")
+	}
+
+	return response.Choices[0].Message.Content, nil
+}
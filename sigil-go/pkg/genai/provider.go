@@ -0,0 +1,45 @@
+package genai
+
+import (
+	"context"
+	"fmt"
+)
+
+// Provider names used in the configuration
+const (
+	ProviderDeepseek = "deepseek"
+	ProviderOpenAI   = "openai"
+	ProviderFake     = "fake"
+)
+
+// Provider is a chat model that answers a single system and user message
+type Provider interface {
+	// Chat returns the model's answer. With jsonMode the model is asked to
+	// answer with a JSON object.
+	Chat(ctx context.Context, systemContent, userContent string, jsonMode bool) (string, error)
+}
+
+// Config selects and configures a provider. Empty fields use the provider's
+// defaults.
+type Config struct {
+	Provider string
+	BaseURL  string
+	Model    string
+	APIKey   string
+	// Temperature is left to the model when nil
+	Temperature *float64
+}
+
+// NewProvider creates the provider selected in the config
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "", ProviderDeepseek:
+		return NewDeepseekClient(cfg)
+	case ProviderOpenAI:
+		return NewOpenAIClient(cfg)
+	case ProviderFake:
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown AI provider: %s", cfg.Provider)
+	}
+}
@@ -0,0 +1,110 @@
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIClientChat(t *testing.T) {
+	var received openAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "chat-1", "choices": [{"message": {"role": "assistant", "content": "{\"name\": \"Soup\"}"}}], "usage": {"total_tokens": 12}}`))
+	}))
+	defer server.Close()
+
+	temperature := 0.2
+	client, err := NewOpenAIClient(Config{
+		BaseURL:     server.URL + "/v1/",
+		Model:       "llama3",
+		APIKey:      "secret",
+		Temperature: &temperature,
+	})
+	require.NoError(t, err)
+
+	answer, err := client.Chat(context.Background(), "system", "user", true)
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"name": "Soup"}`, answer)
+	assert.Equal(t, "llama3", received.Model)
+	assert.Equal(t, []openAIMessage{{Role: "system", Content: "system"}, {Role: "user", Content: "user"}}, received.Messages)
+	require.NotNil(t, received.Temperature)
+	assert.Equal(t, 0.2, *received.Temperature)
+	require.NotNil(t, received.ResponseFormat)
+	assert.Equal(t, "json_object", received.ResponseFormat.Type)
+}
+
+func TestOpenAIClientChatError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		// Optional settings are left out so servers use their defaults
+		assert.NotContains(t, request, "temperature")
+		assert.NotContains(t, request, "response_format")
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": {"message": "model \"missing\" not found"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewOpenAIClient(Config{BaseURL: server.URL, Model: "missing"})
+	require.NoError(t, err)
+
+	_, err = client.Chat(context.Background(), "system", "user", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `model "missing" not found`)
+}
+
+func TestFakeProvider(t *testing.T) {
+	ctx := context.Background()
+
+	echo := NewFakeProvider()
+	answer, err := echo.Chat(ctx, "system", "hello", false)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", answer)
+	answer, err = echo.Chat(ctx, "system", "hello", true)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", answer)
+
+	scripted := NewFakeProvider("first", "second")
+	for _, expected := range []string{"first", "second", "second"} {
+		answer, err := scripted.Chat(ctx, "system", "user", true)
+		require.NoError(t, err)
+		assert.Equal(t, expected, answer)
+	}
+	assert.Len(t, scripted.Requests(), 3)
+	assert.Equal(t, FakeRequest{SystemContent: "system", UserContent: "user", JSONMode: true}, scripted.Requests()[0])
+
+	failing := NewFakeProvider("unused")
+	failing.Err = errors.New("unavailable")
+	_, err = failing.Chat(ctx, "system", "user", false)
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestNewProvider(t *testing.T) {
+	provider, err := NewProvider(Config{Provider: ProviderFake})
+	require.NoError(t, err)
+	assert.IsType(t, &FakeProvider{}, provider)
+
+	provider, err = NewProvider(Config{Provider: ProviderOpenAI, BaseURL: "http://localhost:11434/v1", Model: "llama3"})
+	require.NoError(t, err)
+	assert.IsType(t, &OpenAIClient{}, provider)
+
+	_, err = NewProvider(Config{Provider: ProviderOpenAI, BaseURL: "http://localhost:11434/v1"})
+	assert.Error(t, err)
+
+	_, err = NewProvider(Config{Provider: "unknown"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/handlers/errors"
+	"tofoss/sigil-go/pkg/handlers/responses"
+	"tofoss/sigil-go/pkg/services"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type CalendarHandler struct {
+	feedRepo        repositories.CalendarFeedRepositoryInterface
+	calendarService *services.CalendarService
+}
+
+func NewCalendarHandler(
+	feedRepo repositories.CalendarFeedRepositoryInterface,
+	calendarService *services.CalendarService,
+) CalendarHandler {
+	return CalendarHandler{feedRepo, calendarService}
+}
+
+// CreateCalendarFeed creates a secret URL for subscribing to the user's
+// planned meals. The URL is only shown once, creating a new one revokes it.
+func (h *CalendarHandler) CreateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to create calendar feed, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	token, feed, err := h.calendarService.CreateFeed(r.Context(), userID)
+	if err != nil {
+		log.Printf("failed to create calendar feed: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	url := calendarFeedURL(r, token)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responses.CalendarFeedResponse{
+		URL:       url,
+		TimedURL:  url + "?timed=true",
+		CreatedAt: feed.CreatedAt,
+	})
+}
+
+// FetchCalendarFeed tells whether the user has a calendar feed
+func (h *CalendarHandler) FetchCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to fetch calendar feed, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	feed, err := h.feedRepo.FetchByUserID(r.Context(), userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			errors.NotFound(w, "calendar feed not found")
+			return
+		}
+		log.Printf("failed to fetch calendar feed: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(feed)
+}
+
+// DeleteCalendarFeed revokes the user's calendar feed
+func (h *CalendarHandler) DeleteCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to delete calendar feed, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	if err := h.feedRepo.Delete(r.Context(), userID); err != nil {
+		log.Printf("failed to delete calendar feed: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeCalendarFeed serves planned meals as an iCalendar feed. Calendar apps
+// can't log in, the token in the URL authenticates them.
+func (h *CalendarHandler) ServeCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		errors.NotFound(w, "calendar feed not found")
+		return
+	}
+
+	timed, _ := strconv.ParseBool(r.URL.Query().Get("timed"))
+	calendar, err := h.calendarService.Feed(r.Context(), token, timed)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			errors.NotFound(w, "calendar feed not found")
+			return
+		}
+		log.Printf("failed to serve calendar feed: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="meal-plan.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(calendar))
+}
+
+// calendarFeedURL returns the address of a feed as seen by the client,
+// which may be behind a reverse proxy
+func calendarFeedURL(r *http.Request, token string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	host := r.Host
+	if forwardedHost := r.Header.Get("X-Forwarded-Host"); forwardedHost != "" {
+		host = forwardedHost
+	}
+
+	return scheme + "://" + host + "/calendar/" + token + ".ics"
+}
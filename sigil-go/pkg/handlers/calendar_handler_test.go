@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarFeedURL(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8081/meal-plans/calendar-feed", nil)
+	assert.Equal(t, "http://localhost:8081/calendar/abc.ics", calendarFeedURL(req, "abc"))
+
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "sigil.example.com")
+	assert.Equal(t, "https://sigil.example.com/calendar/abc.ics", calendarFeedURL(req, "abc"))
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/handlers/errors"
+	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/handlers/responses"
+	"tofoss/sigil-go/pkg/services"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ClipHandler struct {
+	clipService  *services.ClipService
+	notebookRepo repositories.NotebookRepositoryInterface
+	sectionRepo  repositories.SectionRepositoryInterface
+}
+
+func NewClipHandler(
+	clipService *services.ClipService,
+	notebookRepo repositories.NotebookRepositoryInterface,
+	sectionRepo repositories.SectionRepositoryInterface,
+) ClipHandler {
+	return ClipHandler{
+		clipService:  clipService,
+		notebookRepo: notebookRepo,
+		sectionRepo:  sectionRepo,
+	}
+}
+
+// ClipURL saves the main content of a web page as a note
+func (h *ClipHandler) ClipURL(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to clip url, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var req requests.ClipURL
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("unable to decode clip request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	if err := utils.ValidateExternalURL(req.URL); err != nil {
+		log.Printf("invalid clip URL: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	if req.SectionID != nil && req.NotebookID == nil {
+		log.Printf("section %s given without a notebook", req.SectionID)
+		errors.BadRequest(w)
+		return
+	}
+
+	if req.NotebookID != nil {
+		notebook, err := h.notebookRepo.FetchNotebook(r.Context(), *req.NotebookID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				errors.NotFound(w, "Notebook not found")
+				return
+			}
+			log.Printf("unable to fetch notebook %s: %v", req.NotebookID, err)
+			errors.InternalServerError(w)
+			return
+		}
+		if notebook.UserID != userID {
+			errors.Unauthenticated(w)
+			return
+		}
+	}
+
+	if req.SectionID != nil {
+		section, err := h.sectionRepo.FetchSection(r.Context(), *req.SectionID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				errors.NotFound(w, "Section not found")
+				return
+			}
+			log.Printf("unable to fetch section %s: %v", req.SectionID, err)
+			errors.InternalServerError(w)
+			return
+		}
+		if section.NotebookID != *req.NotebookID {
+			log.Printf("section %s is not in notebook %s", section.ID, req.NotebookID)
+			errors.BadRequest(w)
+			return
+		}
+	}
+
+	// Rendering the page and downloading its images can take longer than the
+	// server's default write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("unable to extend write deadline for clip: %v", err)
+	}
+
+	clip, err := h.clipService.ClipURL(r.Context(), userID, req.URL, req.NotebookID, req.SectionID)
+	if err != nil {
+		log.Printf("failed to clip %s: %v", req.URL, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responses.ClipResponse{Note: clip.Note, Warning: clip.Warning})
+}
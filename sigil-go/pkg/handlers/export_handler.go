@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"tofoss/sigil-go/pkg/handlers/errors"
+	"tofoss/sigil-go/pkg/services"
+	"tofoss/sigil-go/pkg/utils"
+)
+
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+func NewExportHandler(exportService *services.ExportService) ExportHandler {
+	return ExportHandler{exportService: exportService}
+}
+
+// ExportAccount streams a zip archive with all of the user's data
+func (h *ExportHandler) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to export account, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	// Large accounts take longer than the server's default write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("unable to extend write deadline for export: %v", err)
+	}
+
+	filename := fmt.Sprintf("sigil-export-%s.zip", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+
+	if err := h.exportService.ExportAccount(r.Context(), userID, w); err != nil {
+		// The response is already streaming, abort the connection so the client
+		// doesn't mistake a truncated archive for a complete one
+		log.Printf("export for user %s failed: %v", userID, err)
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/handlers/errors"
+	"tofoss/sigil-go/pkg/handlers/responses"
+	"tofoss/sigil-go/pkg/importer"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/services"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	FormImportFormatKey = "format"
+
+	// importMemoryLimit is how much of an upload is kept in memory before
+	// spilling to a temporary file
+	importMemoryLimit = 32 << 20
+)
+
+type ImportHandler struct {
+	jobRepo       *repositories.ImportJobRepository
+	uploadRoot    string
+	maxImportSize int64
+}
+
+func NewImportHandler(
+	jobRepo *repositories.ImportJobRepository,
+	uploadRoot string,
+	maxImportSize int64,
+) ImportHandler {
+	return ImportHandler{jobRepo: jobRepo, uploadRoot: uploadRoot, maxImportSize: maxImportSize}
+}
+
+// CreateImport stores an uploaded export archive and queues it for import
+func (h *ImportHandler) CreateImport(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to create import, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	// Archives can take longer to upload than the server's default read timeout
+	if err := http.NewResponseController(w).SetReadDeadline(time.Time{}); err != nil {
+		log.Printf("unable to extend read deadline for import: %v", err)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxImportSize)
+	defer r.Body.Close()
+
+	if err := r.ParseMultipartForm(importMemoryLimit); err != nil {
+		log.Printf("could not parse import upload: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile(FormFileKey)
+	if err != nil {
+		log.Printf("could not read import upload: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	defer file.Close()
+
+	format := r.FormValue(FormImportFormatKey)
+	if format == "" {
+		format = importer.DetectFormat(header.Filename)
+	}
+	if !importer.IsSupportedFormat(format) {
+		log.Printf("unsupported import format: %s", format)
+		errors.BadRequest(w)
+		return
+	}
+
+	job := models.ImportJob{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Format:    format,
+		Filename:  utils.SanitizeFilename(filepath.Base(header.Filename)),
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.storeArchive(job, file); err != nil {
+		log.Printf("failed to store import archive: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	createdJob, err := h.jobRepo.Create(r.Context(), job)
+	if err != nil {
+		log.Printf("failed to create import job: %v", err)
+		os.RemoveAll(services.ImportDir(h.uploadRoot, job.ID))
+		errors.InternalServerError(w)
+		return
+	}
+
+	response := responses.CreateImportResponse{
+		JobID: createdJob.ID.String(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetImportJob returns the progress and error report of an import
+func (h *ImportHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to get import job, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	jobIDStr := chi.URLParam(r, "id")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		log.Printf("invalid job ID: %s", jobIDStr)
+		errors.BadRequest(w)
+		return
+	}
+
+	job, err := h.jobRepo.FetchByID(r.Context(), jobID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			errors.NotFound(w, "job not found")
+			return
+		}
+		log.Printf("failed to fetch import job %s: %v", jobID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	// Verify user owns this job
+	if job.UserID != userID {
+		log.Printf("user %s does not own import job %s", userID, jobID)
+		errors.Unauthenticated(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(job)
+}
+
+func (h *ImportHandler) storeArchive(job models.ImportJob, file io.Reader) error {
+	archivePath := services.ImportArchivePath(h.uploadRoot, job)
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0o700); err != nil {
+		return err
+	}
+
+	dst, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, file)
+	return err
+}
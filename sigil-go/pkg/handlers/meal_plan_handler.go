@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/handlers/errors"
+	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/handlers/responses"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/services"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	mealPlanDateLayout = "2006-01-02"
+	// maxMealPlanDays keeps plans to about a month
+	maxMealPlanDays = 31
+)
+
+type MealPlanHandler struct {
+	mealPlanRepo    repositories.MealPlanRepositoryInterface
+	mealPlanService *services.MealPlanService
+}
+
+func NewMealPlanHandler(
+	mealPlanRepo repositories.MealPlanRepositoryInterface,
+	mealPlanService *services.MealPlanService,
+) MealPlanHandler {
+	return MealPlanHandler{mealPlanRepo, mealPlanService}
+}
+
+// ListMealPlans lists the user's meal plans, the latest first
+func (h *MealPlanHandler) ListMealPlans(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to list meal plans, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	limit := 20
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
+			limit = parsedLimit
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	plans, err := h.mealPlanRepo.ListByUserID(r.Context(), userID, limit, offset)
+	if err != nil {
+		log.Printf("unable to list meal plans: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(plans)
+}
+
+func (h *MealPlanHandler) FetchMealPlan(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to fetch meal plan, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	plan, ok := h.fetchUsersMealPlan(w, r, userID)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(plan)
+}
+
+func (h *MealPlanHandler) CreateMealPlan(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to create meal plan, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var req requests.MealPlan
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode meal plan request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	plan, err := mealPlanFromRequest(req, nil)
+	if err != nil {
+		log.Printf("invalid meal plan: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	if !h.checkRecipeAccess(w, r, userID, plan.Meals) {
+		return
+	}
+
+	now := time.Now()
+	plan.ID = uuid.New()
+	plan.UserID = userID
+	plan.CreatedAt = now
+	plan.UpdatedAt = now
+
+	created, err := h.mealPlanRepo.Create(r.Context(), plan)
+	if err != nil {
+		log.Printf("failed to create meal plan: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// UpdateMealPlan replaces the dates and meals of a meal plan. Meals sent
+// with their id keep it.
+func (h *MealPlanHandler) UpdateMealPlan(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to update meal plan, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	current, ok := h.fetchUsersMealPlan(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req requests.MealPlan
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode meal plan request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	plan, err := mealPlanFromRequest(req, current.Meals)
+	if err != nil {
+		log.Printf("invalid meal plan: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	if !h.checkRecipeAccess(w, r, userID, plan.Meals) {
+		return
+	}
+
+	plan.ID = current.ID
+	plan.UserID = current.UserID
+	plan.CreatedAt = current.CreatedAt
+	plan.UpdatedAt = time.Now()
+
+	updated, err := h.mealPlanRepo.Update(r.Context(), plan)
+	if err != nil {
+		log.Printf("failed to update meal plan %s: %v", plan.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *MealPlanHandler) DeleteMealPlan(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to delete meal plan, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	plan, ok := h.fetchUsersMealPlan(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.mealPlanRepo.Delete(r.Context(), plan.ID); err != nil {
+		log.Printf("failed to delete meal plan %s: %v", plan.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GenerateShoppingList creates a shopping list with the ingredients of every
+// meal in the plan
+func (h *MealPlanHandler) GenerateShoppingList(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to generate shopping list, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	plan, ok := h.fetchUsersMealPlan(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if len(plan.Meals) == 0 {
+		log.Printf("meal plan %s has no meals", plan.ID)
+		errors.BadRequest(w)
+		return
+	}
+
+	list, skipped, err := h.mealPlanService.GenerateShoppingList(r.Context(), userID, plan)
+	if err != nil {
+		log.Printf("failed to generate shopping list for meal plan %s: %v", plan.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responses.GeneratedShoppingListResponse{
+		ShoppingList: list,
+		Skipped:      skipped,
+	})
+}
+
+// FetchStockedItems lists the items the user always has in stock
+func (h *MealPlanHandler) FetchStockedItems(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to fetch stocked items, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	items, err := h.mealPlanRepo.FetchStockedItems(r.Context(), userID)
+	if err != nil {
+		log.Printf("failed to fetch stocked items: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(requests.StockedItems{Items: items})
+}
+
+// UpdateStockedItems replaces the items the user always has in stock. They
+// are left out of shopping lists generated from meal plans.
+func (h *MealPlanHandler) UpdateStockedItems(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to update stocked items, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var req requests.StockedItems
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode stocked items request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	items := []string{}
+	for _, item := range req.Items {
+		name := utils.NormalizeItemName(item)
+		if name != "" && !slices.Contains(items, name) {
+			items = append(items, name)
+		}
+	}
+	slices.Sort(items)
+
+	if err := h.mealPlanRepo.ReplaceStockedItems(r.Context(), userID, items); err != nil {
+		log.Printf("failed to update stocked items: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(requests.StockedItems{Items: items})
+}
+
+func (h *MealPlanHandler) fetchUsersMealPlan(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID uuid.UUID,
+) (models.MealPlan, bool) {
+	planIDStr := chi.URLParam(r, "id")
+	planID, err := uuid.Parse(planIDStr)
+	if err != nil {
+		log.Printf("invalid meal plan ID: %s", planIDStr)
+		errors.BadRequest(w)
+		return models.MealPlan{}, false
+	}
+
+	plan, err := h.mealPlanRepo.FetchByID(r.Context(), planID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			errors.NotFound(w, "meal plan not found")
+			return models.MealPlan{}, false
+		}
+		log.Printf("failed to fetch meal plan %s: %v", planID, err)
+		errors.InternalServerError(w)
+		return models.MealPlan{}, false
+	}
+
+	if plan.UserID != userID {
+		log.Printf("user %s does not own meal plan %s", userID, planID)
+		errors.Forbidden(w)
+		return models.MealPlan{}, false
+	}
+
+	return plan, true
+}
+
+// checkRecipeAccess makes sure the user can see every planned recipe
+func (h *MealPlanHandler) checkRecipeAccess(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID uuid.UUID,
+	meals []models.PlannedMeal,
+) bool {
+	checked := make(map[uuid.UUID]bool)
+	for _, meal := range meals {
+		if checked[meal.RecipeID] {
+			continue
+		}
+		checked[meal.RecipeID] = true
+
+		ok, err := h.mealPlanService.CanPlanRecipe(r.Context(), userID, meal.RecipeID)
+		if err != nil {
+			log.Printf("failed to check access to recipe %s: %v", meal.RecipeID, err)
+			errors.InternalServerError(w)
+			return false
+		}
+		if !ok {
+			log.Printf("user %s has no access to recipe %s", userID, meal.RecipeID)
+			errors.BadRequest(w)
+			return false
+		}
+	}
+	return true
+}
+
+// mealPlanFromRequest validates a meal plan request. Meals keep their id when
+// it belongs to one of the existing meals, so calendars can follow them, and
+// their sequence is bumped when they changed.
+func mealPlanFromRequest(req requests.MealPlan, existing []models.PlannedMeal) (models.MealPlan, error) {
+	start, err := time.Parse(mealPlanDateLayout, strings.TrimSpace(req.StartDate))
+	if err != nil {
+		return models.MealPlan{}, fmt.Errorf("invalid start date %q", req.StartDate)
+	}
+	end, err := time.Parse(mealPlanDateLayout, strings.TrimSpace(req.EndDate))
+	if err != nil {
+		return models.MealPlan{}, fmt.Errorf("invalid end date %q", req.EndDate)
+	}
+	if end.Before(start) {
+		return models.MealPlan{}, fmt.Errorf("end date %s is before start date %s", req.EndDate, req.StartDate)
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > maxMealPlanDays {
+		return models.MealPlan{}, fmt.Errorf("meal plan spans %d days, at most %d are allowed", days, maxMealPlanDays)
+	}
+
+	plan := models.MealPlan{
+		Title:     strings.TrimSpace(req.Title),
+		StartDate: start.Format(mealPlanDateLayout),
+		EndDate:   end.Format(mealPlanDateLayout),
+		Meals:     []models.PlannedMeal{},
+	}
+	if plan.Title == "" {
+		plan.Title = "Meal plan " + start.Format("January 2, 2006")
+	}
+
+	existingMeals := make(map[uuid.UUID]models.PlannedMeal)
+	for _, meal := range existing {
+		existingMeals[meal.ID] = meal
+	}
+
+	now := time.Now()
+
+	positions := make(map[string]int)
+	for _, reqMeal := range req.Meals {
+		date, err := time.Parse(mealPlanDateLayout, strings.TrimSpace(reqMeal.Date))
+		if err != nil {
+			return models.MealPlan{}, fmt.Errorf("invalid meal date %q", reqMeal.Date)
+		}
+		if date.Before(start) || date.After(end) {
+			return models.MealPlan{}, fmt.Errorf("meal date %s is outside the plan", reqMeal.Date)
+		}
+
+		slot := strings.ToLower(strings.TrimSpace(reqMeal.Slot))
+		if !slices.Contains(models.MealSlots, slot) {
+			return models.MealPlan{}, fmt.Errorf("invalid meal slot %q", reqMeal.Slot)
+		}
+
+		if reqMeal.RecipeID == uuid.Nil {
+			return models.MealPlan{}, fmt.Errorf("meal on %s has no recipe", reqMeal.Date)
+		}
+
+		if reqMeal.Servings != nil && *reqMeal.Servings <= 0 {
+			return models.MealPlan{}, fmt.Errorf("invalid servings %d", *reqMeal.Servings)
+		}
+
+		meal := models.PlannedMeal{
+			ID:        uuid.New(),
+			Date:      date.Format(mealPlanDateLayout),
+			Slot:      slot,
+			RecipeID:  reqMeal.RecipeID,
+			Servings:  reqMeal.Servings,
+			UpdatedAt: now,
+		}
+		if reqMeal.ID != nil {
+			if previous, found := existingMeals[*reqMeal.ID]; found {
+				meal.ID = previous.ID
+				meal.Sequence = previous.Sequence
+				meal.UpdatedAt = previous.UpdatedAt
+				if plannedMealChanged(previous, meal) {
+					meal.Sequence++
+					meal.UpdatedAt = now
+				}
+				delete(existingMeals, meal.ID)
+			}
+		}
+
+		key := meal.Date + " " + meal.Slot
+		meal.Position = positions[key]
+		positions[key]++
+
+		plan.Meals = append(plan.Meals, meal)
+	}
+
+	return plan, nil
+}
+
+// plannedMealChanged reports whether a meal moved or now serves something else
+func plannedMealChanged(previous, meal models.PlannedMeal) bool {
+	if previous.Date != meal.Date || previous.Slot != meal.Slot || previous.RecipeID != meal.RecipeID {
+		return true
+	}
+	if (previous.Servings == nil) != (meal.Servings == nil) {
+		return true
+	}
+	return previous.Servings != nil && *previous.Servings != *meal.Servings
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockMealPlanRepository is a mock implementation for testing
+type mockMealPlanRepository struct {
+	fetchByIDFunc           func(ctx context.Context, id uuid.UUID) (models.MealPlan, error)
+	replaceStockedItemsFunc func(ctx context.Context, userID uuid.UUID, itemNames []string) error
+}
+
+func (m *mockMealPlanRepository) Create(ctx context.Context, plan models.MealPlan) (models.MealPlan, error) {
+	return models.MealPlan{}, errors.New("Create not mocked")
+}
+
+func (m *mockMealPlanRepository) Update(ctx context.Context, plan models.MealPlan) (models.MealPlan, error) {
+	return models.MealPlan{}, errors.New("Update not mocked")
+}
+
+func (m *mockMealPlanRepository) FetchByID(ctx context.Context, id uuid.UUID) (models.MealPlan, error) {
+	if m.fetchByIDFunc != nil {
+		return m.fetchByIDFunc(ctx, id)
+	}
+	return models.MealPlan{}, errors.New("FetchByID not mocked")
+}
+
+func (m *mockMealPlanRepository) ListByUserID(ctx context.Context, userID uuid.UUID, limit int, offset int) ([]models.MealPlan, error) {
+	return nil, errors.New("ListByUserID not mocked")
+}
+
+func (m *mockMealPlanRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return errors.New("Delete not mocked")
+}
+
+func (m *mockMealPlanRepository) FetchCalendarMeals(ctx context.Context, userID uuid.UUID, since string) ([]models.CalendarMeal, error) {
+	return nil, errors.New("FetchCalendarMeals not mocked")
+}
+
+func (m *mockMealPlanRepository) FetchStockedItems(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return nil, errors.New("FetchStockedItems not mocked")
+}
+
+func (m *mockMealPlanRepository) ReplaceStockedItems(ctx context.Context, userID uuid.UUID, itemNames []string) error {
+	if m.replaceStockedItemsFunc != nil {
+		return m.replaceStockedItemsFunc(ctx, userID, itemNames)
+	}
+	return errors.New("ReplaceStockedItems not mocked")
+}
+
+func mealPlanRequest(method, target, id string, body []byte, userID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	ctx := context.WithValue(req.Context(), utils.UserIDKey, userID)
+	ctx = context.WithValue(ctx, utils.UsernameKey, "testuser")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+}
+
+func TestFetchMealPlan(t *testing.T) {
+	testUserID := uuid.New()
+	planID := uuid.New()
+
+	tests := []struct {
+		name           string
+		planID         string
+		plan           models.MealPlan
+		fetchErr       error
+		expectedStatus int
+	}{
+		{
+			name:           "Own meal plan",
+			planID:         planID.String(),
+			plan:           models.MealPlan{ID: planID, UserID: testUserID, Title: "Week 12", Meals: []models.PlannedMeal{}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid ID",
+			planID:         "invalid-uuid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Not found",
+			planID:         planID.String(),
+			fetchErr:       pgx.ErrNoRows,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Other user's meal plan",
+			planID:         planID.String(),
+			plan:           models.MealPlan{ID: planID, UserID: uuid.New()},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockMealPlanRepository{
+				fetchByIDFunc: func(ctx context.Context, id uuid.UUID) (models.MealPlan, error) {
+					return tt.plan, tt.fetchErr
+				},
+			}
+			handler := NewMealPlanHandler(repo, nil)
+
+			w := httptest.NewRecorder()
+			handler.FetchMealPlan(w, mealPlanRequest(http.MethodGet, "/meal-plans/"+tt.planID, tt.planID, nil, testUserID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var plan models.MealPlan
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&plan))
+				assert.Equal(t, tt.plan.Title, plan.Title)
+			}
+		})
+	}
+}
+
+func TestGenerateShoppingListWithoutMeals(t *testing.T) {
+	testUserID := uuid.New()
+	planID := uuid.New()
+	repo := &mockMealPlanRepository{
+		fetchByIDFunc: func(ctx context.Context, id uuid.UUID) (models.MealPlan, error) {
+			return models.MealPlan{ID: planID, UserID: testUserID, Meals: []models.PlannedMeal{}}, nil
+		},
+	}
+	handler := NewMealPlanHandler(repo, nil)
+
+	w := httptest.NewRecorder()
+	handler.GenerateShoppingList(w, mealPlanRequest(http.MethodPost, "/meal-plans/"+planID.String()+"/shopping-list", planID.String(), nil, testUserID))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateStockedItems(t *testing.T) {
+	testUserID := uuid.New()
+	var saved []string
+	repo := &mockMealPlanRepository{
+		replaceStockedItemsFunc: func(ctx context.Context, userID uuid.UUID, itemNames []string) error {
+			assert.Equal(t, testUserID, userID)
+			saved = itemNames
+			return nil
+		},
+	}
+	handler := NewMealPlanHandler(repo, nil)
+
+	body, _ := json.Marshal(requests.StockedItems{Items: []string{" Salt", "pepper", "salt", "", "Olive oil"}})
+	w := httptest.NewRecorder()
+	handler.UpdateStockedItems(w, mealPlanRequest(http.MethodPut, "/meal-plans/stocked-items", "", body, testUserID))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"olive oil", "pepper", "salt"}, saved)
+
+	var response requests.StockedItems
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, saved, response.Items)
+}
+
+func TestMealPlanFromRequest(t *testing.T) {
+	recipeID := uuid.New()
+	existingID := uuid.New()
+	two := 2
+	zero := 0
+
+	t.Run("Valid plan", func(t *testing.T) {
+		req := requests.MealPlan{
+			StartDate: "2025-03-17",
+			EndDate:   "2025-03-23",
+			Meals: []requests.PlannedMeal{
+				{Date: "2025-03-17", Slot: "Dinner", RecipeID: recipeID, Servings: &two},
+				{Date: "2025-03-17", Slot: "dinner", RecipeID: recipeID, ID: &existingID},
+				{Date: "2025-03-18", Slot: "lunch", RecipeID: recipeID, ID: &recipeID},
+			},
+		}
+
+		plan, err := mealPlanFromRequest(req, []models.PlannedMeal{{ID: existingID}})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Meal plan March 17, 2025", plan.Title)
+		require.Len(t, plan.Meals, 3)
+		assert.Equal(t, models.MealSlotDinner, plan.Meals[0].Slot)
+		assert.Equal(t, &two, plan.Meals[0].Servings)
+		assert.Equal(t, 0, plan.Meals[0].Position)
+		assert.Equal(t, 1, plan.Meals[1].Position)
+		assert.Equal(t, 0, plan.Meals[2].Position)
+
+		// Only ids of the plan's own meals are kept
+		assert.Equal(t, existingID, plan.Meals[1].ID)
+		assert.NotEqual(t, recipeID, plan.Meals[2].ID)
+		assert.NotEqual(t, uuid.Nil, plan.Meals[0].ID)
+	})
+
+	t.Run("Changed meals get a new sequence", func(t *testing.T) {
+		movedID, keptID := uuid.New(), uuid.New()
+		updatedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+		existing := []models.PlannedMeal{
+			{ID: movedID, Date: "2025-03-17", Slot: "dinner", RecipeID: recipeID, Sequence: 2, UpdatedAt: updatedAt},
+			{ID: keptID, Date: "2025-03-18", Slot: "dinner", RecipeID: recipeID, Sequence: 1, UpdatedAt: updatedAt},
+		}
+		req := requests.MealPlan{
+			StartDate: "2025-03-17",
+			EndDate:   "2025-03-23",
+			Meals: []requests.PlannedMeal{
+				{ID: &movedID, Date: "2025-03-19", Slot: "dinner", RecipeID: recipeID},
+				{ID: &keptID, Date: "2025-03-18", Slot: "dinner", RecipeID: recipeID},
+			},
+		}
+
+		plan, err := mealPlanFromRequest(req, existing)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, plan.Meals[0].Sequence)
+		assert.True(t, plan.Meals[0].UpdatedAt.After(updatedAt))
+		assert.Equal(t, 1, plan.Meals[1].Sequence)
+		assert.Equal(t, updatedAt, plan.Meals[1].UpdatedAt)
+	})
+
+	invalid := []struct {
+		name string
+		req  requests.MealPlan
+	}{
+		{"Missing start date", requests.MealPlan{EndDate: "2025-03-23"}},
+		{"End before start", requests.MealPlan{StartDate: "2025-03-23", EndDate: "2025-03-17"}},
+		{"Too long", requests.MealPlan{StartDate: "2025-01-01", EndDate: "2025-03-01"}},
+		{"Meal outside plan", requests.MealPlan{StartDate: "2025-03-17", EndDate: "2025-03-23", Meals: []requests.PlannedMeal{
+			{Date: "2025-03-24", Slot: "dinner", RecipeID: recipeID},
+		}}},
+		{"Unknown slot", requests.MealPlan{StartDate: "2025-03-17", EndDate: "2025-03-23", Meals: []requests.PlannedMeal{
+			{Date: "2025-03-17", Slot: "brunch", RecipeID: recipeID},
+		}}},
+		{"Missing recipe", requests.MealPlan{StartDate: "2025-03-17", EndDate: "2025-03-23", Meals: []requests.PlannedMeal{
+			{Date: "2025-03-17", Slot: "dinner"},
+		}}},
+		{"No servings", requests.MealPlan{StartDate: "2025-03-17", EndDate: "2025-03-23", Meals: []requests.PlannedMeal{
+			{Date: "2025-03-17", Slot: "dinner", RecipeID: recipeID, Servings: &zero},
+		}}},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mealPlanFromRequest(tt.req, nil)
+			assert.Error(t, err)
+		})
+	}
+}
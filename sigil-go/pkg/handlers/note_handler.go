@@ -7,7 +7,6 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 	"tofoss/sigil-go/pkg/db/repositories"
 	"tofoss/sigil-go/pkg/handlers/errors"
@@ -642,7 +641,7 @@ func (h *NoteHandler) ConvertNoteToShoppingList(w http.ResponseWriter, r *http.R
 			req.Mode = "new"
 		} else {
 			// Merge items into existing shopping list
-			mergedContent := h.mergeItemsIntoShoppingList(lastList.Content, items, lastList.Items, note.Title)
+			mergedContent := utils.MergeEntriesIntoShoppingList(lastList.Content, items, note.Title).Content
 
 			// Re-parse merged content
 			mergedItems, err := utils.ParseShoppingList(mergedContent)
@@ -725,65 +724,3 @@ func (h *NoteHandler) ConvertNoteToShoppingList(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(shoppingList)
 }
-
-// mergeItemsIntoShoppingList merges new items into existing shopping list content
-func (h *NoteHandler) mergeItemsIntoShoppingList(
-	currentContent string,
-	newItems []models.ShoppingListEntry,
-	existingItems []models.ShoppingListEntry,
-	noteTitle string,
-) string {
-	// Build a map of existing items for quick lookup
-	existingMap := make(map[string]*models.ShoppingListEntry)
-	for i := range existingItems {
-		existingMap[existingItems[i].ItemName] = &existingItems[i]
-	}
-
-	// Generate markdown for new items that don't exist or have different quantities
-	var itemsToAdd []string
-	for _, newItem := range newItems {
-		// Check if item exists
-		if existing, found := existingMap[newItem.ItemName]; found && existing.Quantity != nil && newItem.Quantity != nil {
-			// Item exists with quantity - try to merge
-			if existing.Quantity.Unit == newItem.Quantity.Unit {
-				// Same unit - merge by adding quantities
-				newQty := *existing.Quantity.Min
-				if newItem.Quantity.Min != nil {
-					newQty += *newItem.Quantity.Min
-				}
-				// Update existing item quantity in place (will be reflected when content is regenerated)
-				newMin := newQty
-				existing.Quantity.Min = &newMin
-				existing.Quantity.Max = &newMin
-				continue
-			}
-		}
-
-		// Add as new item
-		itemMarkdown := "- [ ] "
-		if newItem.Checked {
-			itemMarkdown = "- [x] "
-		}
-		if newItem.Quantity != nil {
-			itemMarkdown += utils.FormatQuantity(*newItem.Quantity) + " "
-		}
-		itemMarkdown += newItem.DisplayName
-		if newItem.Notes != "" {
-			itemMarkdown += " (" + newItem.Notes + ")"
-		}
-		itemsToAdd = append(itemsToAdd, itemMarkdown)
-	}
-
-	// Append new items to the end of the content
-	if len(itemsToAdd) > 0 {
-		if currentContent != "" && !strings.HasSuffix(currentContent, "\n") {
-			currentContent += "\n"
-		}
-		currentContent += fmt.Sprintf("\n## %s\n", noteTitle)
-		for _, item := range itemsToAdd {
-			currentContent += item + "\n"
-		}
-	}
-
-	return currentContent
-}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/handlers/errors"
+	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/services"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type PantryHandler struct {
+	pantryRepo    repositories.PantryRepositoryInterface
+	pantryService *services.PantryService
+}
+
+func NewPantryHandler(
+	pantryRepo repositories.PantryRepositoryInterface,
+	pantryService *services.PantryService,
+) PantryHandler {
+	return PantryHandler{pantryRepo, pantryService}
+}
+
+func (h *PantryHandler) ListPantryItems(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to list pantry items, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	items, err := h.pantryRepo.ListByUserID(r.Context(), userID)
+	if err != nil {
+		log.Printf("unable to list pantry items: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *PantryHandler) CreatePantryItem(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to create pantry item, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var req requests.PantryItem
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode pantry item request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	item, err := pantryItemFromRequest(req)
+	if err != nil {
+		log.Printf("invalid pantry item: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	now := time.Now()
+	item.ID = uuid.New()
+	item.UserID = userID
+	item.CreatedAt = now
+	item.UpdatedAt = now
+
+	created, err := h.pantryRepo.Create(r.Context(), item)
+	if err != nil {
+		log.Printf("failed to create pantry item: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (h *PantryHandler) UpdatePantryItem(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to update pantry item, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	current, ok := h.fetchUsersPantryItem(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req requests.PantryItem
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode pantry item request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	item, err := pantryItemFromRequest(req)
+	if err != nil {
+		log.Printf("invalid pantry item: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	item.ID = current.ID
+	item.UserID = current.UserID
+	item.CreatedAt = current.CreatedAt
+	item.UpdatedAt = time.Now()
+
+	updated, err := h.pantryRepo.Update(r.Context(), item)
+	if err != nil {
+		log.Printf("failed to update pantry item %s: %v", item.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *PantryHandler) DeletePantryItem(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to delete pantry item, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	item, ok := h.fetchUsersPantryItem(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.pantryRepo.Delete(r.Context(), item.ID); err != nil {
+		log.Printf("failed to delete pantry item %s: %v", item.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SuggestRecipes ranks the user's recipes by how many of their ingredients
+// are already at home
+func (h *PantryHandler) SuggestRecipes(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to suggest recipes, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
+			limit = parsedLimit
+		}
+	}
+
+	suggestions, err := h.pantryService.SuggestRecipes(r.Context(), userID, limit)
+	if err != nil {
+		log.Printf("failed to suggest recipes: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(suggestions)
+}
+
+func (h *PantryHandler) fetchUsersPantryItem(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID uuid.UUID,
+) (models.PantryItem, bool) {
+	itemIDStr := chi.URLParam(r, "id")
+	itemID, err := uuid.Parse(itemIDStr)
+	if err != nil {
+		log.Printf("invalid pantry item ID: %s", itemIDStr)
+		errors.BadRequest(w)
+		return models.PantryItem{}, false
+	}
+
+	item, err := h.pantryRepo.FetchByID(r.Context(), itemID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			errors.NotFound(w, "pantry item not found")
+			return models.PantryItem{}, false
+		}
+		log.Printf("failed to fetch pantry item %s: %v", itemID, err)
+		errors.InternalServerError(w)
+		return models.PantryItem{}, false
+	}
+
+	if item.UserID != userID {
+		log.Printf("user %s does not own pantry item %s", userID, itemID)
+		errors.Forbidden(w)
+		return models.PantryItem{}, false
+	}
+
+	return item, true
+}
+
+// pantryItemFromRequest validates a pantry item request
+func pantryItemFromRequest(req requests.PantryItem) (models.PantryItem, error) {
+	name := strings.TrimSpace(req.Name)
+	quantity := req.Quantity
+	unit := strings.TrimSpace(req.Unit)
+	if quantity == nil && unit == "" {
+		// "2 L milk"
+		if ingredient := utils.ParseIngredient(name); ingredient.Quantity != nil && ingredient.Quantity.Max != nil {
+			name = ingredient.Name
+			quantity = ingredient.Quantity.Max
+			unit = ingredient.Quantity.Unit
+		}
+	}
+
+	if name == "" {
+		return models.PantryItem{}, fmt.Errorf("pantry item has no name")
+	}
+	if quantity != nil && *quantity < 0 {
+		return models.PantryItem{}, fmt.Errorf("invalid quantity %v", *quantity)
+	}
+
+	item := models.PantryItem{
+		ItemName:    utils.NormalizeItemName(name),
+		DisplayName: name,
+		Quantity:    quantity,
+		Unit:        unit,
+	}
+
+	if req.Location != nil {
+		if location := strings.TrimSpace(*req.Location); location != "" {
+			item.Location = &location
+		}
+	}
+
+	if req.BestBefore != nil && strings.TrimSpace(*req.BestBefore) != "" {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(*req.BestBefore))
+		if err != nil {
+			return models.PantryItem{}, fmt.Errorf("invalid best before date %q", *req.BestBefore)
+		}
+		bestBefore := date.Format("2006-01-02")
+		item.BestBefore = &bestBefore
+	}
+
+	return item, nil
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPantryRepository is a mock implementation for testing
+type mockPantryRepository struct {
+	fetchByIDFunc func(ctx context.Context, id uuid.UUID) (models.PantryItem, error)
+	deleteFunc    func(ctx context.Context, id uuid.UUID) error
+}
+
+func (m *mockPantryRepository) Create(ctx context.Context, item models.PantryItem) (models.PantryItem, error) {
+	return models.PantryItem{}, errors.New("Create not mocked")
+}
+
+func (m *mockPantryRepository) Update(ctx context.Context, item models.PantryItem) (models.PantryItem, error) {
+	return models.PantryItem{}, errors.New("Update not mocked")
+}
+
+func (m *mockPantryRepository) FetchByID(ctx context.Context, id uuid.UUID) (models.PantryItem, error) {
+	if m.fetchByIDFunc != nil {
+		return m.fetchByIDFunc(ctx, id)
+	}
+	return models.PantryItem{}, errors.New("FetchByID not mocked")
+}
+
+func (m *mockPantryRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]models.PantryItem, error) {
+	return nil, errors.New("ListByUserID not mocked")
+}
+
+func (m *mockPantryRepository) FetchByItemName(ctx context.Context, userID uuid.UUID, itemName string) ([]models.PantryItem, error) {
+	return nil, errors.New("FetchByItemName not mocked")
+}
+
+func (m *mockPantryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if m.deleteFunc != nil {
+		return m.deleteFunc(ctx, id)
+	}
+	return errors.New("Delete not mocked")
+}
+
+func TestDeletePantryItem(t *testing.T) {
+	testUserID := uuid.New()
+	itemID := uuid.New()
+
+	tests := []struct {
+		name           string
+		owner          uuid.UUID
+		expectedStatus int
+		deleted        bool
+	}{
+		{"Own item", testUserID, http.StatusNoContent, true},
+		{"Other user's item", uuid.New(), http.StatusForbidden, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted := false
+			repo := &mockPantryRepository{
+				fetchByIDFunc: func(ctx context.Context, id uuid.UUID) (models.PantryItem, error) {
+					return models.PantryItem{ID: id, UserID: tt.owner}, nil
+				},
+				deleteFunc: func(ctx context.Context, id uuid.UUID) error {
+					deleted = true
+					return nil
+				},
+			}
+			handler := NewPantryHandler(repo, nil)
+
+			req := httptest.NewRequest(http.MethodDelete, "/pantry/"+itemID.String(), nil)
+			ctx := context.WithValue(req.Context(), utils.UserIDKey, testUserID)
+			ctx = context.WithValue(ctx, utils.UsernameKey, "testuser")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", itemID.String())
+			req = req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			handler.DeletePantryItem(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.deleted, deleted)
+		})
+	}
+}
+
+func TestPantryItemFromRequest(t *testing.T) {
+	stringPtr := func(s string) *string { return &s }
+
+	t.Run("Quantity in the name", func(t *testing.T) {
+		item, err := pantryItemFromRequest(requests.PantryItem{
+			Name:       "2 L Milk",
+			Location:   stringPtr(" Fridge "),
+			BestBefore: stringPtr("2025-03-20"),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "milk", item.ItemName)
+		assert.Equal(t, "Milk", item.DisplayName)
+		require.NotNil(t, item.Quantity)
+		assert.Equal(t, 2.0, *item.Quantity)
+		assert.Equal(t, "L", item.Unit)
+		assert.Equal(t, "Fridge", *item.Location)
+		assert.Equal(t, "2025-03-20", *item.BestBefore)
+	})
+
+	t.Run("Separate quantity", func(t *testing.T) {
+		item, err := pantryItemFromRequest(requests.PantryItem{Name: "Flour", Quantity: floatPtr(1), Unit: "kg", Location: stringPtr("")})
+		require.NoError(t, err)
+
+		assert.Equal(t, "flour", item.ItemName)
+		assert.Equal(t, 1.0, *item.Quantity)
+		assert.Equal(t, "kg", item.Unit)
+		assert.Nil(t, item.Location)
+		assert.Nil(t, item.BestBefore)
+	})
+
+	invalid := []requests.PantryItem{
+		{Name: " "},
+		{Name: "Flour", Quantity: floatPtr(-1)},
+		{Name: "Flour", BestBefore: stringPtr("20.03.2025")},
+	}
+	for _, req := range invalid {
+		_, err := pantryItemFromRequest(req)
+		assert.Error(t, err, req)
+	}
+}
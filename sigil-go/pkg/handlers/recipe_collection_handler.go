@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/handlers/errors"
+	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/services"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxCollectionNameLength limits collection names, they are used as titles
+// and file names
+const maxCollectionNameLength = 100
+
+type RecipeCollectionHandler struct {
+	collectionRepo    repositories.RecipeCollectionRepositoryInterface
+	recipeRepo        *repositories.RecipeRepository
+	collectionService *services.RecipeCollectionService
+}
+
+func NewRecipeCollectionHandler(
+	collectionRepo repositories.RecipeCollectionRepositoryInterface,
+	recipeRepo *repositories.RecipeRepository,
+	collectionService *services.RecipeCollectionService,
+) RecipeCollectionHandler {
+	return RecipeCollectionHandler{collectionRepo, recipeRepo, collectionService}
+}
+
+// ListRecipeCollections returns the user's collections with their recipe
+// counts and cover images, optionally only the ones the recipe in the
+// recipeId query parameter is in
+func (h *RecipeCollectionHandler) ListRecipeCollections(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to list recipe collections, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var recipeID *uuid.UUID
+	if recipeIDStr := r.URL.Query().Get("recipeId"); recipeIDStr != "" {
+		id, err := uuid.Parse(recipeIDStr)
+		if err != nil {
+			log.Printf("invalid recipe ID: %s", recipeIDStr)
+			errors.BadRequest(w)
+			return
+		}
+		recipeID = &id
+	}
+
+	collections, err := h.collectionRepo.ListByUserID(r.Context(), userID, recipeID)
+	if err != nil {
+		log.Printf("unable to list recipe collections: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(collections)
+}
+
+func (h *RecipeCollectionHandler) CreateRecipeCollection(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to create recipe collection, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var req requests.RecipeCollection
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode recipe collection request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	collection, err := recipeCollectionFromRequest(req)
+	if err != nil {
+		log.Printf("invalid recipe collection: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	if !h.checkCollectionName(w, r, userID, collection) {
+		return
+	}
+
+	now := time.Now()
+	collection.ID = uuid.New()
+	collection.UserID = userID
+	collection.CreatedAt = now
+	collection.UpdatedAt = now
+
+	created, err := h.collectionRepo.Create(r.Context(), collection)
+	if err != nil {
+		log.Printf("failed to create recipe collection: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (h *RecipeCollectionHandler) FetchRecipeCollection(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to fetch recipe collection, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	collection, ok := h.fetchUsersCollection(w, r, userID)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(collection)
+}
+
+// UpdateRecipeCollection renames a collection or changes its description
+func (h *RecipeCollectionHandler) UpdateRecipeCollection(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to update recipe collection, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	current, ok := h.fetchUsersCollection(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req requests.RecipeCollection
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode recipe collection request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	collection, err := recipeCollectionFromRequest(req)
+	if err != nil {
+		log.Printf("invalid recipe collection: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	collection.ID = current.ID
+	collection.UserID = current.UserID
+	collection.CreatedAt = current.CreatedAt
+	collection.UpdatedAt = time.Now()
+
+	if !h.checkCollectionName(w, r, userID, collection) {
+		return
+	}
+
+	updated, err := h.collectionRepo.Update(r.Context(), collection)
+	if err != nil {
+		log.Printf("failed to update recipe collection %s: %v", collection.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteRecipeCollection deletes a collection, its recipes are kept
+func (h *RecipeCollectionHandler) DeleteRecipeCollection(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to delete recipe collection, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	collection, ok := h.fetchUsersCollection(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.collectionRepo.Delete(r.Context(), collection.ID); err != nil {
+		log.Printf("failed to delete recipe collection %s: %v", collection.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddRecipeToCollection adds one of the user's recipes to a collection and
+// returns the collection with its new count and cover
+func (h *RecipeCollectionHandler) AddRecipeToCollection(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to add recipe to collection, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	recipeID, ok := parseCollectionRecipeID(w, r)
+	if !ok {
+		return
+	}
+
+	collection, ok := h.fetchUsersCollection(w, r, userID)
+	if !ok {
+		return
+	}
+
+	noteIDs, err := h.recipeRepo.FetchUserNoteIDs(r.Context(), recipeID, userID)
+	if err != nil {
+		log.Printf("failed to fetch notes of recipe %s: %v", recipeID, err)
+		errors.InternalServerError(w)
+		return
+	}
+	if len(noteIDs) == 0 {
+		errors.NotFound(w, "recipe not found")
+		return
+	}
+
+	if err := h.collectionRepo.AddRecipe(r.Context(), collection.ID, recipeID, time.Now()); err != nil {
+		log.Printf("failed to add recipe %s to collection %s: %v", recipeID, collection.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	h.writeCollection(w, r, collection.ID)
+}
+
+// RemoveRecipeFromCollection takes a recipe out of a collection and returns
+// the collection with its new count and cover
+func (h *RecipeCollectionHandler) RemoveRecipeFromCollection(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to remove recipe from collection, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	recipeID, ok := parseCollectionRecipeID(w, r)
+	if !ok {
+		return
+	}
+
+	collection, ok := h.fetchUsersCollection(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.collectionRepo.RemoveRecipe(r.Context(), collection.ID, recipeID); err != nil {
+		log.Printf("failed to remove recipe %s from collection %s: %v", recipeID, collection.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	h.writeCollection(w, r, collection.ID)
+}
+
+// ExportCookbook writes a collection as a printable HTML cookbook with a
+// table of contents and a page per recipe
+func (h *RecipeCollectionHandler) ExportCookbook(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to export cookbook, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	collection, ok := h.fetchUsersCollection(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var cookbook bytes.Buffer
+	if err := h.collectionService.WriteCookbook(r.Context(), &cookbook, userID, collection); err != nil {
+		log.Printf("failed to write cookbook of collection %s: %v", collection.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.html"`, utils.SanitizeFilename(collection.Name)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(cookbook.Bytes())
+}
+
+// writeCollection writes a collection as it is stored
+func (h *RecipeCollectionHandler) writeCollection(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	collection, err := h.collectionRepo.FetchByID(r.Context(), id)
+	if err != nil {
+		log.Printf("failed to fetch recipe collection %s: %v", id, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(collection)
+}
+
+// checkCollectionName makes sure the user has no other collection with the
+// same name. Errors are written to the response.
+func (h *RecipeCollectionHandler) checkCollectionName(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID uuid.UUID,
+	collection models.RecipeCollection,
+) bool {
+	existing, err := h.collectionRepo.FetchByName(r.Context(), userID, collection.Name)
+	if err != nil && err != pgx.ErrNoRows {
+		log.Printf("failed to fetch recipe collection %q: %v", collection.Name, err)
+		errors.InternalServerError(w)
+		return false
+	}
+	if err == nil && existing.ID != collection.ID {
+		errors.Conflict(w, "a recipe collection with this name already exists")
+		return false
+	}
+	return true
+}
+
+func (h *RecipeCollectionHandler) fetchUsersCollection(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID uuid.UUID,
+) (models.RecipeCollection, bool) {
+	collectionIDStr := chi.URLParam(r, "id")
+	collectionID, err := uuid.Parse(collectionIDStr)
+	if err != nil {
+		log.Printf("invalid recipe collection ID: %s", collectionIDStr)
+		errors.BadRequest(w)
+		return models.RecipeCollection{}, false
+	}
+
+	collection, err := h.collectionRepo.FetchByID(r.Context(), collectionID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			errors.NotFound(w, "recipe collection not found")
+			return models.RecipeCollection{}, false
+		}
+		log.Printf("failed to fetch recipe collection %s: %v", collectionID, err)
+		errors.InternalServerError(w)
+		return models.RecipeCollection{}, false
+	}
+
+	if collection.UserID != userID {
+		log.Printf("user %s does not own recipe collection %s", userID, collectionID)
+		errors.Forbidden(w)
+		return models.RecipeCollection{}, false
+	}
+
+	return collection, true
+}
+
+// parseCollectionRecipeID reads the recipe in the URL. Errors are written to
+// the response.
+func parseCollectionRecipeID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	recipeIDStr := chi.URLParam(r, "recipeId")
+	recipeID, err := uuid.Parse(recipeIDStr)
+	if err != nil {
+		log.Printf("invalid recipe ID: %s", recipeIDStr)
+		errors.BadRequest(w)
+		return uuid.Nil, false
+	}
+	return recipeID, true
+}
+
+// recipeCollectionFromRequest validates a recipe collection request
+func recipeCollectionFromRequest(req requests.RecipeCollection) (models.RecipeCollection, error) {
+	name := strings.Join(strings.Fields(req.Name), " ")
+	if name == "" {
+		return models.RecipeCollection{}, fmt.Errorf("recipe collection name is required")
+	}
+	if len([]rune(name)) > maxCollectionNameLength {
+		return models.RecipeCollection{}, fmt.Errorf("recipe collection name is longer than %d characters", maxCollectionNameLength)
+	}
+
+	collection := models.RecipeCollection{Name: name}
+	if req.Description != nil {
+		if description := strings.TrimSpace(*req.Description); description != "" {
+			collection.Description = &description
+		}
+	}
+	return collection, nil
+}
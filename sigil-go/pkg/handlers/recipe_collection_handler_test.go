@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRecipeCollectionRepository is a mock implementation for testing
+type mockRecipeCollectionRepository struct {
+	collections []models.RecipeCollection
+	deleted     []uuid.UUID
+}
+
+func (m *mockRecipeCollectionRepository) Create(ctx context.Context, collection models.RecipeCollection) (models.RecipeCollection, error) {
+	m.collections = append(m.collections, collection)
+	return collection, nil
+}
+
+func (m *mockRecipeCollectionRepository) Update(ctx context.Context, collection models.RecipeCollection) (models.RecipeCollection, error) {
+	return collection, nil
+}
+
+func (m *mockRecipeCollectionRepository) FetchByID(ctx context.Context, id uuid.UUID) (models.RecipeCollection, error) {
+	for _, collection := range m.collections {
+		if collection.ID == id {
+			return collection, nil
+		}
+	}
+	return models.RecipeCollection{}, pgx.ErrNoRows
+}
+
+func (m *mockRecipeCollectionRepository) FetchByName(ctx context.Context, userID uuid.UUID, name string) (models.RecipeCollection, error) {
+	for _, collection := range m.collections {
+		if collection.UserID == userID && strings.EqualFold(collection.Name, name) {
+			return collection, nil
+		}
+	}
+	return models.RecipeCollection{}, pgx.ErrNoRows
+}
+
+func (m *mockRecipeCollectionRepository) ListByUserID(ctx context.Context, userID uuid.UUID, recipeID *uuid.UUID) ([]models.RecipeCollection, error) {
+	return nil, errors.New("ListByUserID not mocked")
+}
+
+func (m *mockRecipeCollectionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func (m *mockRecipeCollectionRepository) AddRecipe(ctx context.Context, collectionID uuid.UUID, recipeID uuid.UUID, addedAt time.Time) error {
+	return errors.New("AddRecipe not mocked")
+}
+
+func (m *mockRecipeCollectionRepository) RemoveRecipe(ctx context.Context, collectionID uuid.UUID, recipeID uuid.UUID) error {
+	return errors.New("RemoveRecipe not mocked")
+}
+
+func collectionRequest(method, target, body string, userID uuid.UUID, params map[string]string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), utils.UserIDKey, userID)
+	ctx = context.WithValue(ctx, utils.UsernameKey, "testuser")
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+}
+
+func TestCreateRecipeCollection(t *testing.T) {
+	testUserID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"New name", `{"name": " Christmas ", "description": "Family favourites"}`, http.StatusCreated},
+		{"Name taken", `{"name": "weeknight"}`, http.StatusConflict},
+		{"Name taken by another user", `{"name": "Mum's"}`, http.StatusCreated},
+		{"Missing name", `{"description": "No name"}`, http.StatusBadRequest},
+		{"Invalid body", `{"name": `, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRecipeCollectionRepository{collections: []models.RecipeCollection{
+				{ID: uuid.New(), UserID: testUserID, Name: "Weeknight"},
+				{ID: uuid.New(), UserID: uuid.New(), Name: "Mum's"},
+			}}
+			handler := NewRecipeCollectionHandler(repo, nil, nil)
+
+			w := httptest.NewRecorder()
+			handler.CreateRecipeCollection(w, collectionRequest(http.MethodPost, "/recipe-collections", tt.body, testUserID, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestUpdateRecipeCollectionKeepsOwnName(t *testing.T) {
+	testUserID := uuid.New()
+	collectionID := uuid.New()
+	repo := &mockRecipeCollectionRepository{collections: []models.RecipeCollection{
+		{ID: collectionID, UserID: testUserID, Name: "Weeknight"},
+		{ID: uuid.New(), UserID: testUserID, Name: "Christmas"},
+	}}
+	handler := NewRecipeCollectionHandler(repo, nil, nil)
+	params := map[string]string{"id": collectionID.String()}
+
+	w := httptest.NewRecorder()
+	handler.UpdateRecipeCollection(w, collectionRequest(http.MethodPut, "/recipe-collections/"+collectionID.String(),
+		`{"name": "weeknight", "description": "Quick dinners"}`, testUserID, params))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.UpdateRecipeCollection(w, collectionRequest(http.MethodPut, "/recipe-collections/"+collectionID.String(),
+		`{"name": "Christmas"}`, testUserID, params))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestDeleteRecipeCollection(t *testing.T) {
+	testUserID := uuid.New()
+	collectionID := uuid.New()
+
+	tests := []struct {
+		name           string
+		owner          uuid.UUID
+		id             string
+		expectedStatus int
+		deleted        bool
+	}{
+		{"Own collection", testUserID, collectionID.String(), http.StatusNoContent, true},
+		{"Other user's collection", uuid.New(), collectionID.String(), http.StatusForbidden, false},
+		{"Unknown collection", testUserID, uuid.NewString(), http.StatusNotFound, false},
+		{"Invalid id", testUserID, "not-a-uuid", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRecipeCollectionRepository{collections: []models.RecipeCollection{
+				{ID: collectionID, UserID: tt.owner, Name: "Weeknight"},
+			}}
+			handler := NewRecipeCollectionHandler(repo, nil, nil)
+
+			w := httptest.NewRecorder()
+			handler.DeleteRecipeCollection(w, collectionRequest(http.MethodDelete, "/recipe-collections/"+tt.id, "", testUserID,
+				map[string]string{"id": tt.id}))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.deleted, len(repo.deleted) == 1)
+		})
+	}
+}
+
+func TestAddRecipeToCollectionInvalidRecipe(t *testing.T) {
+	handler := NewRecipeCollectionHandler(&mockRecipeCollectionRepository{}, nil, nil)
+
+	w := httptest.NewRecorder()
+	handler.AddRecipeToCollection(w, collectionRequest(http.MethodPut, "/recipe-collections/x/recipes/y", "", uuid.New(),
+		map[string]string{"id": uuid.NewString(), "recipeId": "not-a-uuid"}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRecipeCollectionFromRequest(t *testing.T) {
+	description := "  "
+	collection, err := recipeCollectionFromRequest(requests.RecipeCollection{Name: "  Mum's \n favourites ", Description: &description})
+	require.NoError(t, err)
+	assert.Equal(t, "Mum's favourites", collection.Name)
+	assert.Nil(t, collection.Description)
+
+	_, err = recipeCollectionFromRequest(requests.RecipeCollection{Name: " "})
+	assert.Error(t, err)
+	_, err = recipeCollectionFromRequest(requests.RecipeCollection{Name: strings.Repeat("a", 101)})
+	assert.Error(t, err)
+}
@@ -3,18 +3,25 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
-	"net"
 	"net/http"
 	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"tofoss/sigil-go/pkg/db/repositories"
 	"tofoss/sigil-go/pkg/handlers/errors"
 	"tofoss/sigil-go/pkg/handlers/requests"
 	"tofoss/sigil-go/pkg/handlers/responses"
 	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/nutrition"
+	"tofoss/sigil-go/pkg/recipeformat"
+	"tofoss/sigil-go/pkg/services"
 	"tofoss/sigil-go/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
@@ -22,18 +29,40 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+const (
+	// maxRecipeTextSize limits pasted recipes and uploaded recipe files
+	maxRecipeTextSize = 2 << 20
+
+	// maxRecipePromptLength limits descriptions and refinement instructions
+	// sent to the AI
+	maxRecipePromptLength = 1000
+
+	// maxRecipeImportSize limits Paprika and Cooklang files, Paprika archives
+	// embed the photos of every recipe
+	maxRecipeImportSize = 50 << 20
+
+	// maxRecipeImageSize limits uploaded recipe images
+	maxRecipeImageSize = 20 << 20
+)
+
 type RecipeHandler struct {
-	recipeRepo *repositories.RecipeRepository
-	jobRepo    *repositories.RecipeJobRepository
-	noteRepo   *repositories.NoteRepository
+	recipeRepo    *repositories.RecipeRepository
+	jobRepo       *repositories.RecipeJobRepository
+	noteRepo      *repositories.NoteRepository
+	cookLogRepo   *repositories.CookLogRepository
+	updateRepo    *repositories.RecipeUpdateRepository
+	recipeService *services.RecipeService
 }
 
 func NewRecipeHandler(
 	recipeRepo *repositories.RecipeRepository,
 	jobRepo *repositories.RecipeJobRepository,
 	noteRepo *repositories.NoteRepository,
+	cookLogRepo *repositories.CookLogRepository,
+	updateRepo *repositories.RecipeUpdateRepository,
+	recipeService *services.RecipeService,
 ) RecipeHandler {
-	return RecipeHandler{recipeRepo, jobRepo, noteRepo}
+	return RecipeHandler{recipeRepo, jobRepo, noteRepo, cookLogRepo, updateRepo, recipeService}
 }
 
 func (h *RecipeHandler) CreateRecipeFromURL(w http.ResponseWriter, r *http.Request) {
@@ -53,7 +82,7 @@ func (h *RecipeHandler) CreateRecipeFromURL(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Basic URL validation
-	if err := h.validateURL(req.URL); err != nil {
+	if err := utils.ValidateExternalURL(req.URL); err != nil {
 		log.Printf("invalid URL provided: %s, error: %v", req.URL, err)
 		errors.BadRequest(w)
 		return
@@ -62,11 +91,12 @@ func (h *RecipeHandler) CreateRecipeFromURL(w http.ResponseWriter, r *http.Reque
 	// Create job
 	now := time.Now()
 	job := models.RecipeJob{
-		ID:        uuid.New(),
-		UserID:    userID,
-		URL:       req.URL,
-		Status:    "pending",
-		CreatedAt: now,
+		ID:         uuid.New(),
+		UserID:     userID,
+		URL:        req.URL,
+		SourceType: models.RecipeSourceURL,
+		Status:     "pending",
+		CreatedAt:  now,
 	}
 
 	createdJob, err := h.jobRepo.Create(r.Context(), job)
@@ -85,6 +115,233 @@ func (h *RecipeHandler) CreateRecipeFromURL(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(response)
 }
 
+// CreateRecipeFromText queues a recipe job for recipe text pasted by the user,
+// e.g. from an email
+func (h *RecipeHandler) CreateRecipeFromText(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to create recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRecipeTextSize)
+	var req requests.CreateRecipeFromText
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode create recipe from text request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	if strings.TrimSpace(req.Text) == "" {
+		log.Printf("no recipe text provided")
+		errors.BadRequest(w)
+		return
+	}
+
+	h.createSourceJob(w, r, models.RecipeJob{
+		UserID:        userID,
+		SourceType:    models.RecipeSourceText,
+		SourceContent: &req.Text,
+	})
+}
+
+// UploadRecipeFile queues a recipe job for an uploaded HTML or plain text
+// file, like a saved web page or a recipe typed up from a family cookbook
+func (h *RecipeHandler) UploadRecipeFile(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to upload recipe file, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	// Leave room for the multipart headers
+	r.Body = http.MaxBytesReader(w, r.Body, maxRecipeTextSize+(64<<10))
+	defer r.Body.Close()
+
+	file, header, err := r.FormFile(FormFileKey)
+	if err != nil {
+		log.Printf("could not read recipe upload: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxRecipeTextSize+1))
+	if err != nil || len(content) > maxRecipeTextSize {
+		log.Printf("could not read recipe upload: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	sourceType, ok := recipeFileSourceType(header.Filename, content)
+	if !ok {
+		log.Printf("unsupported recipe file: %s", header.Filename)
+		errors.BadRequest(w)
+		return
+	}
+
+	filename := utils.SanitizeFilename(filepath.Base(header.Filename))
+	sourceContent := string(content)
+	h.createSourceJob(w, r, models.RecipeJob{
+		UserID:         userID,
+		SourceType:     sourceType,
+		SourceContent:  &sourceContent,
+		SourceFilename: &filename,
+	})
+}
+
+// createSourceJob queues a recipe job that doesn't import a URL
+func (h *RecipeHandler) createSourceJob(w http.ResponseWriter, r *http.Request, job models.RecipeJob) {
+	job.ID = uuid.New()
+	job.Status = "pending"
+	job.CreatedAt = time.Now()
+
+	createdJob, err := h.jobRepo.Create(r.Context(), job)
+	if err != nil {
+		log.Printf("failed to create recipe job: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(responses.CreateRecipeResponse{JobID: createdJob.ID.String()})
+}
+
+// recipeFileSourceType tells HTML files from plain text files by their
+// extension, or by their content when the extension is unknown. Other files,
+// like PDFs and images, aren't supported.
+func recipeFileSourceType(filename string, content []byte) (string, bool) {
+	if !utf8.Valid(content) {
+		return "", false
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".html", ".htm", ".xhtml":
+		return models.RecipeSourceHTML, true
+	case ".txt", ".text", ".md", ".markdown":
+		return models.RecipeSourceText, true
+	}
+
+	contentType := http.DetectContentType(content)
+	switch {
+	case strings.HasPrefix(contentType, "text/html"):
+		return models.RecipeSourceHTML, true
+	case strings.HasPrefix(contentType, "text/plain"):
+		return models.RecipeSourceText, true
+	}
+	return "", false
+}
+
+// GenerateRecipe queues a recipe job that has the AI create a recipe from a
+// dish name or description
+func (h *RecipeHandler) GenerateRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to generate recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var req requests.GenerateRecipe
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode generate recipe request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	generation, err := recipeGenerationFromRequest(req)
+	if err != nil {
+		log.Printf("invalid recipe generation request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	content, err := json.Marshal(generation)
+	if err != nil {
+		log.Printf("failed to encode recipe generation request: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+	sourceContent := string(content)
+
+	h.createSourceJob(w, r, models.RecipeJob{
+		UserID:        userID,
+		SourceType:    models.RecipeSourceGenerate,
+		SourceContent: &sourceContent,
+	})
+}
+
+// RefineRecipe queues a recipe job that has the AI apply an instruction like
+// "make it vegetarian" to the recipe. The result is a new recipe and note, the
+// recipe itself is left as it is.
+func (h *RecipeHandler) RefineRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to refine recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var req requests.RefineRecipe
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode refine recipe request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	instruction := strings.TrimSpace(req.Instruction)
+	if instruction == "" || utf8.RuneCountInString(instruction) > maxRecipePromptLength {
+		log.Printf("invalid refinement instruction")
+		errors.BadRequest(w)
+		return
+	}
+
+	recipe, _, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	h.createSourceJob(w, r, models.RecipeJob{
+		UserID:         userID,
+		SourceType:     models.RecipeSourceRefine,
+		SourceContent:  &instruction,
+		SourceRecipeID: &recipe.ID,
+	})
+}
+
+// RefreshRecipe queues a recipe job that reads a recipe from its source URL
+// again. The changes found are kept as an update for each user of the recipe
+// to apply or dismiss, the recipe itself is left as it is.
+func (h *RecipeHandler) RefreshRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to refresh recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	recipe, _, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if recipe.SourceURL == nil {
+		log.Printf("recipe %s has no source URL to refresh from", recipe.ID)
+		errors.BadRequest(w)
+		return
+	}
+
+	h.createSourceJob(w, r, models.RecipeJob{
+		UserID:         userID,
+		URL:            *recipe.SourceURL,
+		SourceType:     models.RecipeSourceRefresh,
+		SourceRecipeID: &recipe.ID,
+	})
+}
+
 func (h *RecipeHandler) GetRecipeJobStatus(w http.ResponseWriter, r *http.Request) {
 	userID, _, err := utils.UserContext(r)
 	if err != nil {
@@ -143,90 +400,1253 @@ func (h *RecipeHandler) GetRecipeJobStatus(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
-// validateURL performs comprehensive URL validation and SSRF protection
-func (h *RecipeHandler) validateURL(urlStr string) error {
-	// Parse URL
-	parsedURL, err := url.Parse(urlStr)
+// ListRecipes returns a page of the user's recipes, optionally filtered by
+// name, ingredient, maxTime in minutes, cuisine, course, dietary labels and
+// equipment. The sort query parameter orders them by created, name,
+// timesCooked, rating or lastCooked, the order query parameter is asc or desc.
+func (h *RecipeHandler) ListRecipes(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
 	if err != nil {
-		return err
+		log.Printf("unable to list recipes, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
 	}
 
-	// Require http/https scheme
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return fmt.Errorf("URL must use http or https scheme")
+	filter, err := parseRecipeFilter(r.URL.Query())
+	if err != nil {
+		log.Printf("invalid recipe filter: %v", err)
+		errors.BadRequest(w)
+		return
 	}
 
-	hostname := strings.ToLower(parsedURL.Hostname())
-
-	// Block localhost aliases
-	if hostname == "localhost" || hostname == "0.0.0.0" {
-		return fmt.Errorf("private/localhost URLs are not allowed")
+	order, err := parseRecipeOrder(r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
+	if err != nil {
+		log.Printf("invalid recipe order: %v", err)
+		errors.BadRequest(w)
+		return
 	}
 
-	// Try to parse as IP address
-	ip := net.ParseIP(hostname)
-	if ip != nil {
-		// Direct IP address - check if it's private
-		if isPrivateIP(ip) {
-			return fmt.Errorf("private IP addresses are not allowed")
-		}
-	} else {
-		// Hostname - resolve it and check all IPs
-		ips, err := net.LookupIP(hostname)
-		if err != nil {
-			return fmt.Errorf("failed to resolve hostname: %w", err)
+	// Parse pagination parameters with defaults
+	limit := 50
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
+			limit = parsedLimit
 		}
+	}
 
-		for _, resolvedIP := range ips {
-			if isPrivateIP(resolvedIP) {
-				return fmt.Errorf("hostname resolves to private IP address")
-			}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
 		}
 	}
 
-	return nil
+	recipes, err := h.recipeRepo.ListByUserID(r.Context(), userID, filter, order, limit, offset)
+	if err != nil {
+		log.Printf("unable to list recipes: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(recipes)
 }
 
-// isPrivateIP checks if an IP address is private/internal
-func isPrivateIP(ip net.IP) bool {
-	// Loopback addresses
-	if ip.IsLoopback() {
-		return true
+func (h *RecipeHandler) FetchRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to fetch recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	recipe, noteIDs, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
 	}
 
-	// Link-local addresses (169.254.0.0/16 for IPv4, fe80::/10 for IPv6)
-	if ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
-		return true
+	stats, err := h.cookLogRepo.FetchStats(r.Context(), userID, recipe.ID)
+	if err != nil {
+		log.Printf("failed to fetch cook log stats of recipe %s: %v", recipe.ID, err)
+		errors.InternalServerError(w)
+		return
 	}
+	recipe.Stats = &stats
+	recipe.Nutrition = estimateNutrition(recipe)
 
-	// Check for private IPv4 ranges
-	if ip4 := ip.To4(); ip4 != nil {
-		// 10.0.0.0/8
-		if ip4[0] == 10 {
-			return true
-		}
-		// 172.16.0.0/12
-		if ip4[0] == 172 && ip4[1] >= 16 && ip4[1] <= 31 {
-			return true
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responses.RecipeResponse{Recipe: recipe, NoteIDs: noteIDs})
+}
+
+// ScaleRecipe returns a recipe scaled to the servings query parameter, or by
+// the factor query parameter, with quantities converted to the units query
+// parameter (metric, us or original)
+func (h *RecipeHandler) ScaleRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to scale recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	scale, err := parseRecipeScaling(r.URL.Query())
+	if err != nil {
+		log.Printf("invalid scaling: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	recipe, _, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	scaled, factor, err := scale.apply(recipe)
+	if err != nil {
+		log.Printf("unable to scale recipe %s: %v", recipe.ID, err)
+		errors.BadRequest(w)
+		return
+	}
+	scaled.Nutrition = estimateNutrition(scaled)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responses.ScaledRecipeResponse{
+		Recipe:   scaled,
+		Factor:   factor,
+		Units:    string(scale.system),
+		Markdown: utils.RecipeToMarkdown(scaled),
+	})
+}
+
+// CookRecipeStep returns one step of a recipe for cooking it, with the
+// step's timers and the ingredients it uses scaled like in ScaleRecipe
+func (h *RecipeHandler) CookRecipeStep(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to cook recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	query := r.URL.Query()
+	number := 1
+	if stepStr := query.Get("step"); stepStr != "" {
+		number, err = strconv.Atoi(stepStr)
+		if err != nil || number <= 0 {
+			log.Printf("invalid step: %s", stepStr)
+			errors.BadRequest(w)
+			return
 		}
-		// 192.168.0.0/16
-		if ip4[0] == 192 && ip4[1] == 168 {
-			return true
+	}
+
+	scale, err := parseRecipeScaling(query)
+	if err != nil {
+		log.Printf("invalid scaling: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	recipe, _, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if number > len(recipe.Steps) {
+		log.Printf("recipe %s has no step %d", recipe.ID, number)
+		errors.BadRequest(w)
+		return
+	}
+
+	scaled, factor, err := scale.apply(recipe)
+	if err != nil {
+		log.Printf("unable to scale recipe %s: %v", recipe.ID, err)
+		errors.BadRequest(w)
+		return
+	}
+
+	// Ingredients are matched against the unscaled recipe, converting units
+	// doesn't change the names
+	step := utils.StructureSteps(recipe)[number-1]
+	ingredients := make([]models.Ingredient, 0, len(step.Ingredients))
+	for _, i := range step.Ingredients {
+		ingredients = append(ingredients, scaled.Ingredients[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responses.CookStepResponse{
+		RecipeID:    recipe.ID,
+		Name:        recipe.Name,
+		Servings:    scaled.Servings,
+		Step:        step,
+		TotalSteps:  len(recipe.Steps),
+		Ingredients: ingredients,
+		Factor:      factor,
+		Units:       string(scale.system),
+	})
+}
+
+// recipeScaling is how a recipe should be scaled, read from the units,
+// factor and servings query parameters
+type recipeScaling struct {
+	system   utils.UnitSystem
+	factor   float64
+	servings int // replaces the factor when set
+}
+
+func parseRecipeScaling(query url.Values) (recipeScaling, error) {
+	system, err := utils.ParseUnitSystem(query.Get("units"))
+	if err != nil {
+		return recipeScaling{}, err
+	}
+	scale := recipeScaling{system: system, factor: 1}
+
+	if factorStr := query.Get("factor"); factorStr != "" {
+		scale.factor, err = strconv.ParseFloat(factorStr, 64)
+		if err != nil || scale.factor <= 0 || scale.factor > 100 {
+			return recipeScaling{}, fmt.Errorf("invalid scaling factor: %s", factorStr)
 		}
-		// 127.0.0.0/8 (loopback, but double-check)
-		if ip4[0] == 127 {
-			return true
+	}
+
+	if servingsStr := query.Get("servings"); servingsStr != "" {
+		scale.servings, err = strconv.Atoi(servingsStr)
+		if err != nil || scale.servings <= 0 {
+			return recipeScaling{}, fmt.Errorf("invalid servings: %s", servingsStr)
 		}
 	}
+	return scale, nil
+}
 
-	// Check for private IPv6 ranges
-	if ip.To4() == nil {
-		// Unique local addresses (fc00::/7)
-		if len(ip) >= 2 && (ip[0]&0xfe) == 0xfc {
-			return true
+// apply scales the recipe, returning the scaled recipe and the factor used
+func (s recipeScaling) apply(recipe models.Recipe) (models.Recipe, float64, error) {
+	factor := s.factor
+	if s.servings > 0 {
+		if recipe.Servings == nil || *recipe.Servings <= 0 {
+			return models.Recipe{}, 0, fmt.Errorf("recipe has no servings to scale from")
 		}
+		factor = float64(s.servings) / float64(*recipe.Servings)
+	}
+
+	scaled := utils.ScaleRecipe(recipe, factor, s.system)
+	if s.servings > 0 {
+		servings := s.servings
+		scaled.Servings = &servings
+	}
+	return scaled, factor, nil
+}
+
+// CreateRecipe creates a recipe from the request body instead of a URL, along
+// with a note showing it
+func (h *RecipeHandler) CreateRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to create recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var req requests.Recipe
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode create recipe request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	recipe, err := recipeFromRequest(req)
+	if err != nil {
+		log.Printf("invalid recipe: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	created, note, err := h.recipeService.CreateRecipe(r.Context(), userID, recipe)
+	if err != nil {
+		log.Printf("failed to create recipe: %v", err)
+		errors.InternalServerError(w)
+		return
 	}
+	created.Stats = &models.RecipeStats{}
+	created.Nutrition = estimateNutrition(created)
 
-	return false
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responses.RecipeResponse{Recipe: created, NoteIDs: []uuid.UUID{note.ID}})
 }
 
+// UpdateRecipe replaces a recipe. Setting the regenerateNote query parameter
+// rewrites the linked notes from the updated recipe.
+func (h *RecipeHandler) UpdateRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to update recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var req requests.Recipe
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode update recipe request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	h.saveRecipe(w, r, userID, func(requests.Recipe) (requests.Recipe, error) {
+		return req, nil
+	})
+}
+
+// PatchRecipe updates the fields present in the request body. Setting the
+// regenerateNote query parameter rewrites the linked notes.
+func (h *RecipeHandler) PatchRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to patch recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(body) {
+		log.Printf("could not read patch recipe request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	h.saveRecipe(w, r, userID, func(current requests.Recipe) (requests.Recipe, error) {
+		// Fields missing from the body keep their current value
+		err := json.Unmarshal(body, &current)
+		return current, err
+	})
+}
+
+func (h *RecipeHandler) DeleteRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to delete recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	recipe, noteIDs, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.recipeService.DeleteRecipe(r.Context(), userID, recipe.ID, noteIDs); err != nil {
+		log.Printf("failed to delete recipe %s: %v", recipe.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReplaceRecipeImage replaces a recipe's image with an uploaded one. The notes
+// linked to the recipe show it in place of the previous image, setting the
+// regenerateNote query parameter rewrites them from the recipe instead.
+func (h *RecipeHandler) ReplaceRecipeImage(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to replace recipe image, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	// Leave room for the multipart headers
+	r.Body = http.MaxBytesReader(w, r.Body, maxRecipeImageSize+(64<<10))
+	defer r.Body.Close()
+
+	file, _, err := r.FormFile(FormFileKey)
+	if err != nil {
+		log.Printf("could not read recipe image upload: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	defer file.Close()
+
+	image, err := io.ReadAll(io.LimitReader(file, maxRecipeImageSize+1))
+	if err != nil || len(image) > maxRecipeImageSize {
+		log.Printf("could not read recipe image upload: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	if mimeType := http.DetectContentType(image); services.SupportedImageTypes[mimeType] == "" {
+		log.Printf("unsupported recipe image type: %s", mimeType)
+		errors.BadRequest(w)
+		return
+	}
+
+	recipe, noteIDs, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	regenerateNote, _ := strconv.ParseBool(r.URL.Query().Get("regenerateNote"))
+	updated, err := h.recipeService.ReplaceImage(r.Context(), userID, recipe, noteIDs, image, regenerateNote)
+	if err != nil {
+		log.Printf("failed to replace image of recipe %s: %v", recipe.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	stats, err := h.cookLogRepo.FetchStats(r.Context(), userID, updated.ID)
+	if err != nil {
+		log.Printf("failed to fetch cook log stats of recipe %s: %v", updated.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+	updated.Stats = &stats
+	updated.Nutrition = estimateNutrition(updated)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responses.RecipeResponse{Recipe: updated, NoteIDs: noteIDs})
+}
+
+// ListCookLog returns the user's cook log of a recipe, most recent first
+func (h *RecipeHandler) ListCookLog(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to list cook log, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	recipe, _, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	entries, err := h.cookLogRepo.ListByRecipe(r.Context(), userID, recipe.ID)
+	if err != nil {
+		log.Printf("failed to list cook log of recipe %s: %v", recipe.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+// CreateCookLogEntry records the user cooking a recipe, today unless another
+// date is given
+func (h *RecipeHandler) CreateCookLogEntry(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to create cook log entry, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	recipe, _, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req requests.CookLogEntry
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode cook log entry request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	now := time.Now()
+	entry, err := cookLogEntryFromRequest(req, now)
+	if err != nil {
+		log.Printf("invalid cook log entry: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	entry.ID = uuid.New()
+	entry.UserID = userID
+	entry.RecipeID = recipe.ID
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+
+	created, err := h.cookLogRepo.Create(r.Context(), entry)
+	if err != nil {
+		log.Printf("failed to create cook log entry of recipe %s: %v", recipe.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (h *RecipeHandler) UpdateCookLogEntry(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to update cook log entry, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	current, ok := h.fetchUsersCookLogEntry(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req requests.CookLogEntry
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("could not decode cook log entry request: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	now := time.Now()
+	if req.CookedOn == nil {
+		req.CookedOn = &current.CookedOn
+	}
+	entry, err := cookLogEntryFromRequest(req, now)
+	if err != nil {
+		log.Printf("invalid cook log entry: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	entry.ID = current.ID
+	entry.UserID = current.UserID
+	entry.RecipeID = current.RecipeID
+	entry.CreatedAt = current.CreatedAt
+	entry.UpdatedAt = now
+
+	updated, err := h.cookLogRepo.Update(r.Context(), entry)
+	if err != nil {
+		log.Printf("failed to update cook log entry %s: %v", entry.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *RecipeHandler) DeleteCookLogEntry(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to delete cook log entry, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	entry, ok := h.fetchUsersCookLogEntry(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.cookLogRepo.Delete(r.Context(), entry.ID); err != nil {
+		log.Printf("failed to delete cook log entry %s: %v", entry.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRecipeUpdates returns the user's pending recipe updates, newest first,
+// optionally only those of the recipe in the recipeId query parameter
+func (h *RecipeHandler) ListRecipeUpdates(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to list recipe updates, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	var recipeID *uuid.UUID
+	if recipeIDStr := r.URL.Query().Get("recipeId"); recipeIDStr != "" {
+		id, err := uuid.Parse(recipeIDStr)
+		if err != nil {
+			log.Printf("invalid recipe ID: %s", recipeIDStr)
+			errors.BadRequest(w)
+			return
+		}
+		recipeID = &id
+	}
+
+	updates, err := h.updateRepo.ListPending(r.Context(), userID, recipeID)
+	if err != nil {
+		log.Printf("failed to list recipe updates: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updates)
+}
+
+// ApplyRecipeUpdate applies the changes found at a recipe's source. The mode
+// query parameter merges them into the recipe (the default), keeping the
+// user's own edits, or creates a new version of the recipe with a note of its
+// own. Merging with the regenerateNote query parameter rewrites the linked
+// notes from the updated recipe.
+func (h *RecipeHandler) ApplyRecipeUpdate(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to apply recipe update, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	query := r.URL.Query()
+	mode := query.Get("mode")
+	if mode != "" && mode != "merge" && mode != "version" {
+		log.Printf("unknown recipe update mode: %s", mode)
+		errors.BadRequest(w)
+		return
+	}
+
+	update, ok := h.fetchUsersRecipeUpdate(w, r, userID)
+	if !ok {
+		return
+	}
+
+	recipe, err := h.recipeRepo.FetchByID(r.Context(), update.RecipeID)
+	if err != nil {
+		log.Printf("failed to fetch recipe %s: %v", update.RecipeID, err)
+		errors.InternalServerError(w)
+		return
+	}
+	noteIDs, err := h.recipeRepo.FetchUserNoteIDs(r.Context(), recipe.ID, userID)
+	if err != nil {
+		log.Printf("failed to fetch notes of recipe %s: %v", recipe.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+	if len(noteIDs) == 0 {
+		errors.NotFound(w, "recipe not found")
+		return
+	}
+
+	if mode == "version" {
+		created, note, err := h.recipeService.CreateSourceVersion(r.Context(), userID, update, recipe)
+		if err != nil {
+			log.Printf("failed to create new version of recipe %s: %v", recipe.ID, err)
+			errors.InternalServerError(w)
+			return
+		}
+		created.Stats = &models.RecipeStats{}
+		created.Nutrition = estimateNutrition(created)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(responses.RecipeResponse{Recipe: created, NoteIDs: []uuid.UUID{note.ID}})
+		return
+	}
+
+	regenerateNote, _ := strconv.ParseBool(query.Get("regenerateNote"))
+	updated, err := h.recipeService.MergeSourceUpdate(r.Context(), userID, update, recipe, noteIDs, regenerateNote)
+	if err != nil {
+		log.Printf("failed to apply update %s to recipe %s: %v", update.ID, recipe.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	stats, err := h.cookLogRepo.FetchStats(r.Context(), userID, updated.ID)
+	if err != nil {
+		log.Printf("failed to fetch cook log stats of recipe %s: %v", updated.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+	updated.Stats = &stats
+	updated.Nutrition = estimateNutrition(updated)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responses.RecipeResponse{Recipe: updated, NoteIDs: noteIDs})
+}
+
+// DismissRecipeUpdate leaves a recipe as it is. The same changes aren't
+// brought up again, only further changes at the source are.
+func (h *RecipeHandler) DismissRecipeUpdate(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to dismiss recipe update, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	update, ok := h.fetchUsersRecipeUpdate(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.updateRepo.Resolve(r.Context(), update.ID, models.RecipeUpdateDismissed, time.Now()); err != nil {
+		log.Printf("failed to dismiss recipe update %s: %v", update.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExportRecipe writes a recipe in the format query parameter: jsonld (the
+// default), paprika or cooklang
+func (h *RecipeHandler) ExportRecipe(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to export recipe, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	format, ok := recipeExportFormat(r.URL.Query().Get("format"))
+	if !ok {
+		log.Printf("unsupported recipe export format: %s", format)
+		errors.BadRequest(w)
+		return
+	}
+
+	recipe, _, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	recipes := []models.Recipe{recipe}
+	photos := h.recipeService.ExportPhotos(r.Context(), userID, format, recipes)
+	writeRecipeExport(w, format, utils.SanitizeFilename(recipe.Name), recipes, photos)
+}
+
+// ExportRecipes writes all of the user's recipes in the format query
+// parameter, see ExportRecipe
+func (h *RecipeHandler) ExportRecipes(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to export recipes, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	format, ok := recipeExportFormat(r.URL.Query().Get("format"))
+	if !ok {
+		log.Printf("unsupported recipe export format: %s", format)
+		errors.BadRequest(w)
+		return
+	}
+
+	recipes, err := h.recipeRepo.FetchByUserID(r.Context(), userID)
+	if err != nil {
+		log.Printf("failed to fetch recipes of user %s: %v", userID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	filename := fmt.Sprintf("sigil-recipes-%s", time.Now().Format("2006-01-02"))
+	photos := h.recipeService.ExportPhotos(r.Context(), userID, format, recipes)
+	writeRecipeExport(w, format, filename, recipes, photos)
+}
+
+// ImportRecipes creates recipes from an uploaded Paprika archive, Cooklang
+// file or zip archive of Cooklang files. The format form value overrides the
+// format detected from the file name. Paprika photos are kept as the recipes'
+// images.
+func (h *RecipeHandler) ImportRecipes(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := utils.UserContext(r)
+	if err != nil {
+		log.Printf("unable to import recipes, user not logged in: %v", err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	// Leave room for the multipart headers
+	r.Body = http.MaxBytesReader(w, r.Body, maxRecipeImportSize+(64<<10))
+	defer r.Body.Close()
+
+	file, header, err := r.FormFile(FormFileKey)
+	if err != nil {
+		log.Printf("could not read recipe import upload: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	defer file.Close()
+
+	format := r.FormValue(FormImportFormatKey)
+	if format == "" {
+		format = recipeformat.DetectFormat(header.Filename)
+	}
+	if !recipeformat.IsImportFormat(format) {
+		log.Printf("unsupported recipe import format: %s", format)
+		errors.BadRequest(w)
+		return
+	}
+
+	content, err := io.ReadAll(io.LimitReader(file, maxRecipeImportSize+1))
+	if err != nil || len(content) > maxRecipeImportSize {
+		log.Printf("could not read recipe import upload: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	recipes, err := recipeformat.Import(format, header.Filename, content)
+	if err != nil {
+		log.Printf("could not import %s recipes from %s: %v", format, header.Filename, err)
+		errors.BadRequest(w)
+		return
+	}
+
+	// Recipes are saved one by one, a recipe that fails is reported instead of
+	// undoing the others, so importing the file again only needs the failed ones
+	response := responses.RecipeImportResponse{
+		Recipes: make([]responses.RecipeResponse, 0, len(recipes)),
+		Failed:  []string{},
+	}
+	for _, recipe := range recipes {
+		created, note, err := h.recipeService.ImportRecipe(r.Context(), userID, recipe)
+		if err != nil {
+			log.Printf("failed to create imported recipe %q: %v", recipe.Recipe.Name, err)
+			response.Failed = append(response.Failed, recipe.Recipe.Name)
+			continue
+		}
+		created.Stats = &models.RecipeStats{}
+		response.Recipes = append(response.Recipes, responses.RecipeResponse{Recipe: created, NoteIDs: []uuid.UUID{note.ID}})
+	}
+
+	if len(response.Recipes) == 0 && len(response.Failed) > 0 {
+		errors.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// recipeExportFormat defaults to JSON-LD
+func recipeExportFormat(format string) (string, bool) {
+	if format == "" {
+		format = recipeformat.FormatJSONLD
+	}
+	return format, recipeformat.IsSupportedFormat(format)
+}
+
+// writeRecipeExport writes recipes as a file download named after filename
+func writeRecipeExport(
+	w http.ResponseWriter,
+	format, filename string,
+	recipes []models.Recipe,
+	photos map[uuid.UUID][]byte,
+) {
+	ext, contentType := recipeformat.FileType(format, len(recipes))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s%s"`, filename, ext))
+	w.WriteHeader(http.StatusOK)
+
+	if err := recipeformat.Export(w, format, recipes, photos); err != nil {
+		// The response is already written, abort the connection so the client
+		// doesn't mistake a truncated file for a complete one
+		log.Printf("failed to export recipes: %v", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// saveRecipe applies edit to the recipe in the URL and saves the result
+func (h *RecipeHandler) saveRecipe(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID uuid.UUID,
+	edit func(current requests.Recipe) (requests.Recipe, error),
+) {
+	current, noteIDs, ok := h.fetchUsersRecipe(w, r, userID)
+	if !ok {
+		return
+	}
+
+	req, err := edit(recipeToRequest(current))
+	if err != nil {
+		log.Printf("could not apply recipe changes: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+
+	recipe, err := recipeFromRequest(req)
+	if err != nil {
+		log.Printf("invalid recipe: %v", err)
+		errors.BadRequest(w)
+		return
+	}
+	recipe.ID = current.ID
+	recipe.CreatedAt = current.CreatedAt
+	// Images aren't part of the request, they are replaced on their own. The
+	// source snapshot stays as it was read, so the changes count as the user's.
+	recipe.ImageID = current.ImageID
+	recipe.Source = current.Source
+	recipe.StepImages = slices.DeleteFunc(slices.Clone(current.StepImages), func(image models.StepImage) bool {
+		return image.Step >= len(recipe.Steps)
+	})
+
+	regenerateNote, _ := strconv.ParseBool(r.URL.Query().Get("regenerateNote"))
+	updated, err := h.recipeService.UpdateRecipe(r.Context(), userID, recipe, noteIDs, regenerateNote)
+	if err != nil {
+		log.Printf("failed to update recipe %s: %v", current.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+
+	stats, err := h.cookLogRepo.FetchStats(r.Context(), userID, updated.ID)
+	if err != nil {
+		log.Printf("failed to fetch cook log stats of recipe %s: %v", updated.ID, err)
+		errors.InternalServerError(w)
+		return
+	}
+	updated.Stats = &stats
+	updated.Nutrition = estimateNutrition(updated)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responses.RecipeResponse{Recipe: updated, NoteIDs: noteIDs})
+}
+
+// fetchUsersRecipe fetches the recipe in the URL along with the user's notes
+// linked to it. Recipes aren't owned directly, a user has access to a recipe
+// through their notes. Errors are written to the response.
+func (h *RecipeHandler) fetchUsersRecipe(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID uuid.UUID,
+) (models.Recipe, []uuid.UUID, bool) {
+	recipeIDStr := chi.URLParam(r, "id")
+	recipeID, err := uuid.Parse(recipeIDStr)
+	if err != nil {
+		log.Printf("invalid recipe ID: %s", recipeIDStr)
+		errors.BadRequest(w)
+		return models.Recipe{}, nil, false
+	}
+
+	recipe, err := h.recipeRepo.FetchByID(r.Context(), recipeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			errors.NotFound(w, "recipe not found")
+			return models.Recipe{}, nil, false
+		}
+		log.Printf("failed to fetch recipe %s: %v", recipeID, err)
+		errors.InternalServerError(w)
+		return models.Recipe{}, nil, false
+	}
+
+	noteIDs, err := h.recipeRepo.FetchUserNoteIDs(r.Context(), recipeID, userID)
+	if err != nil {
+		log.Printf("failed to fetch notes of recipe %s: %v", recipeID, err)
+		errors.InternalServerError(w)
+		return models.Recipe{}, nil, false
+	}
+	if len(noteIDs) == 0 {
+		log.Printf("user %s has no access to recipe %s", userID, recipeID)
+		errors.Unauthenticated(w)
+		return models.Recipe{}, nil, false
+	}
+
+	return recipe, noteIDs, true
+}
+
+// fetchUsersCookLogEntry fetches the cook log entry in the URL. Errors are
+// written to the response.
+func (h *RecipeHandler) fetchUsersCookLogEntry(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID uuid.UUID,
+) (models.CookLogEntry, bool) {
+	entryIDStr := chi.URLParam(r, "id")
+	entryID, err := uuid.Parse(entryIDStr)
+	if err != nil {
+		log.Printf("invalid cook log entry ID: %s", entryIDStr)
+		errors.BadRequest(w)
+		return models.CookLogEntry{}, false
+	}
+
+	entry, err := h.cookLogRepo.FetchByID(r.Context(), entryID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			errors.NotFound(w, "cook log entry not found")
+			return models.CookLogEntry{}, false
+		}
+		log.Printf("failed to fetch cook log entry %s: %v", entryID, err)
+		errors.InternalServerError(w)
+		return models.CookLogEntry{}, false
+	}
+
+	if entry.UserID != userID {
+		log.Printf("user %s does not own cook log entry %s", userID, entryID)
+		errors.Forbidden(w)
+		return models.CookLogEntry{}, false
+	}
+
+	return entry, true
+}
+
+// fetchUsersRecipeUpdate fetches the pending recipe update in the URL.
+// Errors are written to the response.
+func (h *RecipeHandler) fetchUsersRecipeUpdate(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID uuid.UUID,
+) (models.RecipeUpdate, bool) {
+	updateIDStr := chi.URLParam(r, "id")
+	updateID, err := uuid.Parse(updateIDStr)
+	if err != nil {
+		log.Printf("invalid recipe update ID: %s", updateIDStr)
+		errors.BadRequest(w)
+		return models.RecipeUpdate{}, false
+	}
+
+	update, err := h.updateRepo.FetchByID(r.Context(), updateID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			errors.NotFound(w, "recipe update not found")
+			return models.RecipeUpdate{}, false
+		}
+		log.Printf("failed to fetch recipe update %s: %v", updateID, err)
+		errors.InternalServerError(w)
+		return models.RecipeUpdate{}, false
+	}
+
+	if update.UserID != userID {
+		log.Printf("user %s does not own recipe update %s", userID, updateID)
+		errors.Forbidden(w)
+		return models.RecipeUpdate{}, false
+	}
+
+	if update.Status != models.RecipeUpdatePending {
+		errors.Conflict(w, "recipe update is already "+update.Status)
+		return models.RecipeUpdate{}, false
+	}
+
+	return update, true
+}
+
+// cookLogEntryFromRequest validates a cook log entry request. Entries without
+// a date are for today.
+func cookLogEntryFromRequest(req requests.CookLogEntry, now time.Time) (models.CookLogEntry, error) {
+	entry := models.CookLogEntry{
+		CookedOn: now.Format("2006-01-02"),
+		Rating:   req.Rating,
+		Servings: req.Servings,
+	}
+
+	if req.CookedOn != nil {
+		cookedOn, err := time.Parse("2006-01-02", strings.TrimSpace(*req.CookedOn))
+		if err != nil {
+			return models.CookLogEntry{}, fmt.Errorf("invalid date %q", *req.CookedOn)
+		}
+		entry.CookedOn = cookedOn.Format("2006-01-02")
+	}
+	if entry.Rating != nil && (*entry.Rating < 1 || *entry.Rating > 5) {
+		return models.CookLogEntry{}, fmt.Errorf("rating must be between 1 and 5")
+	}
+	if entry.Servings != nil && *entry.Servings <= 0 {
+		return models.CookLogEntry{}, fmt.Errorf("servings must be positive")
+	}
+	if req.Notes != nil {
+		if notes := strings.TrimSpace(*req.Notes); notes != "" {
+			entry.Notes = &notes
+		}
+	}
+
+	return entry, nil
+}
+
+// recipeGenerationFromRequest validates a recipe generation request. Empty
+// dietary restrictions and ingredients are dropped.
+func recipeGenerationFromRequest(req requests.GenerateRecipe) (models.RecipeGeneration, error) {
+	generation := models.RecipeGeneration{
+		Description: strings.TrimSpace(req.Description),
+		Servings:    req.Servings,
+		Dietary:     nonEmptyStrings(req.Dietary),
+		Ingredients: nonEmptyStrings(req.Ingredients),
+	}
+
+	if generation.Description == "" {
+		return models.RecipeGeneration{}, fmt.Errorf("description is required")
+	}
+	length := utf8.RuneCountInString(generation.Description) +
+		utf8.RuneCountInString(strings.Join(generation.Dietary, "")) +
+		utf8.RuneCountInString(strings.Join(generation.Ingredients, ""))
+	if length > maxRecipePromptLength {
+		return models.RecipeGeneration{}, fmt.Errorf("request is too long")
+	}
+	if generation.Servings != nil && (*generation.Servings <= 0 || *generation.Servings > 100) {
+		return models.RecipeGeneration{}, fmt.Errorf("servings must be between 1 and 100")
+	}
+
+	return generation, nil
+}
+
+// nonEmptyStrings trims values and drops the empty ones
+func nonEmptyStrings(values []string) []string {
+	result := []string{}
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// parseRecipeOrder parses the sort and order query parameters of a recipe
+// listing. Names are sorted A to Z by default, everything else with the
+// newest, most cooked and best rated first.
+// parseRecipeFilter reads the filters of a recipe listing. Dietary labels can
+// be repeated or comma separated, like dietary=vegetarian,gluten-free.
+func parseRecipeFilter(query url.Values) (repositories.RecipeFilter, error) {
+	filter := repositories.RecipeFilter{
+		Name:       strings.TrimSpace(query.Get("name")),
+		Ingredient: strings.TrimSpace(query.Get("ingredient")),
+		Cuisine:    strings.TrimSpace(query.Get("cuisine")),
+		Course:     strings.TrimSpace(query.Get("course")),
+		Equipment:  strings.TrimSpace(query.Get("equipment")),
+	}
+
+	if maxTime := query.Get("maxTime"); maxTime != "" {
+		minutes, err := strconv.Atoi(maxTime)
+		if err != nil || minutes <= 0 {
+			return repositories.RecipeFilter{}, fmt.Errorf("invalid max time %q", maxTime)
+		}
+		filter.MaxTotalMinutes = &minutes
+	}
+
+	if collection := query.Get("collection"); collection != "" {
+		collectionID, err := uuid.Parse(collection)
+		if err != nil {
+			return repositories.RecipeFilter{}, fmt.Errorf("invalid collection %q", collection)
+		}
+		filter.Collection = &collectionID
+	}
+
+	for _, value := range query["dietary"] {
+		for _, label := range strings.Split(value, ",") {
+			label = strings.ToLower(strings.TrimSpace(label))
+			if label == "" {
+				continue
+			}
+			if !slices.Contains(models.DietaryLabels, label) {
+				return repositories.RecipeFilter{}, fmt.Errorf("unknown dietary label %q", label)
+			}
+			filter.Dietary = append(filter.Dietary, label)
+		}
+	}
+
+	return filter, nil
+}
+
+func parseRecipeOrder(sort, order string) (repositories.RecipeOrder, error) {
+	recipeOrder := repositories.RecipeOrder{Sort: repositories.RecipeSortCreated}
+	if sort != "" {
+		recipeOrder.Sort = repositories.RecipeSort(sort)
+		if !repositories.ValidRecipeSort(recipeOrder.Sort) {
+			return repositories.RecipeOrder{}, fmt.Errorf("unknown sort field %q", sort)
+		}
+	}
+
+	switch order {
+	case "":
+		recipeOrder.Ascending = recipeOrder.Sort == repositories.RecipeSortName
+	case "asc":
+		recipeOrder.Ascending = true
+	case "desc":
+		recipeOrder.Ascending = false
+	default:
+		return repositories.RecipeOrder{}, fmt.Errorf("unknown order %q", order)
+	}
+
+	return recipeOrder, nil
+}
+
+// estimateNutrition estimates a recipe's nutrition for a response
+func estimateNutrition(recipe models.Recipe) *models.RecipeNutrition {
+	estimate := nutrition.Estimate(recipe)
+	return &estimate
+}
+
+func recipeToRequest(recipe models.Recipe) requests.Recipe {
+	req := requests.Recipe{
+		Name:         recipe.Name,
+		Summary:      recipe.Summary,
+		Servings:     recipe.Servings,
+		PrepTime:     recipe.PrepTime,
+		CookTime:     recipe.CookTime,
+		TotalTime:    recipe.TotalTime,
+		Cuisine:      recipe.Cuisine,
+		Course:       recipe.Course,
+		Equipment:    recipe.Equipment,
+		SourceURL:    recipe.SourceURL,
+		Ingredients:  recipe.Ingredients,
+		Steps:        recipe.Steps,
+		StepSections: recipe.StepSections,
+	}
+	// Inferred labels are left out so they follow ingredient changes
+	if recipe.DietaryManual {
+		req.Dietary = &recipe.Dietary
+	}
+	return req
+}
+
+// recipeFromRequest validates a recipe from a request
+func recipeFromRequest(req requests.Recipe) (models.Recipe, error) {
+	recipe := models.Recipe{
+		Name:         strings.TrimSpace(req.Name),
+		Summary:      req.Summary,
+		Servings:     req.Servings,
+		PrepTime:     req.PrepTime,
+		CookTime:     req.CookTime,
+		TotalTime:    req.TotalTime,
+		Cuisine:      req.Cuisine,
+		Course:       req.Course,
+		Equipment:    req.Equipment,
+		SourceURL:    req.SourceURL,
+		Ingredients:  make([]models.Ingredient, 0, len(req.Ingredients)),
+		Steps:        req.Steps,
+		StepSections: req.StepSections,
+	}
+
+	if recipe.Name == "" {
+		return models.Recipe{}, fmt.Errorf("recipe name is required")
+	}
+	if recipe.Servings != nil && *recipe.Servings <= 0 {
+		return models.Recipe{}, fmt.Errorf("servings must be positive")
+	}
+	for _, ingredient := range req.Ingredients {
+		if strings.TrimSpace(ingredient.Name) == "" {
+			return models.Recipe{}, fmt.Errorf("ingredient name is required")
+		}
+		recipe.Ingredients = append(recipe.Ingredients, structureIngredient(ingredient))
+	}
+	if recipe.Steps == nil {
+		recipe.Steps = []string{}
+	}
+	if req.Dietary != nil {
+		for _, label := range *req.Dietary {
+			if !slices.Contains(models.DietaryLabels, label) {
+				return models.Recipe{}, fmt.Errorf("unknown dietary label %q", label)
+			}
+		}
+		recipe.Dietary = *req.Dietary
+		recipe.DietaryManual = true
+	}
+
+	return recipe, nil
+}
+
+// structureIngredient parses ingredients typed as a single line, like
+// "2 dl milk, lukewarm", into quantity, name and notes
+func structureIngredient(ingredient models.Ingredient) models.Ingredient {
+	if ingredient.Quantity != nil {
+		return ingredient
+	}
+
+	parsed := utils.ParseIngredient(ingredient.Name)
+	parsed.IsOptional = parsed.IsOptional || ingredient.IsOptional
+	if ingredient.Notes != "" {
+		if parsed.Notes != "" {
+			parsed.Notes += ", "
+		}
+		parsed.Notes += ingredient.Notes
+	}
+	return parsed
+}
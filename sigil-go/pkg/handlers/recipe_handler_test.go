@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestRecipeFromRequest(t *testing.T) {
+	zero := 0
+	four := 4
+
+	tests := []struct {
+		name     string
+		req      requests.Recipe
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name: "valid recipe",
+			req: requests.Recipe{
+				Name:        "  Pancakes ",
+				Servings:    &four,
+				Ingredients: []models.Ingredient{{Name: "flour"}},
+				Steps:       []string{"Mix", "Fry"},
+			},
+			wantName: "Pancakes",
+		},
+		{
+			name:     "name only",
+			req:      requests.Recipe{Name: "Toast"},
+			wantName: "Toast",
+		},
+		{
+			name:    "missing name",
+			req:     requests.Recipe{Name: "   "},
+			wantErr: true,
+		},
+		{
+			name:    "zero servings",
+			req:     requests.Recipe{Name: "Toast", Servings: &zero},
+			wantErr: true,
+		},
+		{
+			name:    "unnamed ingredient",
+			req:     requests.Recipe{Name: "Toast", Ingredients: []models.Ingredient{{Notes: "sliced"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown dietary label",
+			req:     requests.Recipe{Name: "Toast", Dietary: &[]string{"paleo"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipe, err := recipeFromRequest(tt.req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if recipe.Name != tt.wantName {
+				t.Errorf("Expected name %q, got %q", tt.wantName, recipe.Name)
+			}
+			// Empty lists are stored as JSON arrays, not null
+			if recipe.Ingredients == nil || recipe.Steps == nil {
+				t.Error("Expected ingredients and steps to be non-nil")
+			}
+		})
+	}
+}
+
+func TestRecipeFromRequestParsesIngredients(t *testing.T) {
+	two := 2.0
+	recipe, err := recipeFromRequest(requests.Recipe{
+		Name: "Pancakes",
+		Ingredients: []models.Ingredient{
+			{Name: "2 dl milk, lukewarm"},
+			{Name: "½ ts salt", Notes: "fine"},
+			{Name: "eggs", Quantity: &models.Quantity{Min: &two, Max: &two}},
+			{Name: "Butter"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(recipe.Ingredients) != 4 {
+		t.Fatalf("Expected 4 ingredients, got %d", len(recipe.Ingredients))
+	}
+
+	milk := recipe.Ingredients[0]
+	if milk.Name != "milk" || milk.Notes != "lukewarm" || milk.Quantity == nil || *milk.Quantity.Min != 2 || milk.Quantity.Unit != "dl" {
+		t.Errorf("Expected 2 dl milk with notes, got %+v", milk)
+	}
+
+	salt := recipe.Ingredients[1]
+	if salt.Name != "salt" || salt.Notes != "fine" || salt.Quantity == nil || *salt.Quantity.Max != 0.5 || salt.Quantity.Unit != "ts" {
+		t.Errorf("Expected 1/2 ts salt with notes, got %+v", salt)
+	}
+
+	// Structured ingredients are kept as they are
+	if recipe.Ingredients[2].Name != "eggs" || recipe.Ingredients[2].Quantity.Unit != "" {
+		t.Errorf("Expected eggs to be unchanged, got %+v", recipe.Ingredients[2])
+	}
+	if recipe.Ingredients[3].Name != "Butter" || recipe.Ingredients[3].Quantity != nil {
+		t.Errorf("Expected butter without quantity, got %+v", recipe.Ingredients[3])
+	}
+}
+
+func TestRecipeFromRequestDietary(t *testing.T) {
+	recipe, err := recipeFromRequest(requests.Recipe{Name: "Toast"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if recipe.DietaryManual {
+		t.Error("Expected dietary labels to be inferred without labels in the request")
+	}
+
+	recipe, err = recipeFromRequest(requests.Recipe{Name: "Toast", Dietary: &[]string{models.DietaryVegetarian}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !recipe.DietaryManual || len(recipe.Dietary) != 1 || recipe.Dietary[0] != models.DietaryVegetarian {
+		t.Errorf("Expected manual vegetarian label, got %v (manual %v)", recipe.Dietary, recipe.DietaryManual)
+	}
+
+	// Inferred labels aren't sent back, so edits infer them again
+	if req := recipeToRequest(models.Recipe{Name: "Toast", Dietary: []string{models.DietaryVegan}}); req.Dietary != nil {
+		t.Errorf("Expected no dietary labels for inferred labels, got %v", *req.Dietary)
+	}
+}
+
+func TestRecipeHandlerBadRequests(t *testing.T) {
+	handler := NewRecipeHandler(nil, nil, nil, nil, nil, nil)
+	testUserID := uuid.New()
+
+	tests := []struct {
+		name     string
+		method   string
+		id       string
+		body     string
+		handle   func(http.ResponseWriter, *http.Request)
+		expected int
+	}{
+		{"fetch with invalid id", http.MethodGet, "not-a-uuid", "", handler.FetchRecipe, http.StatusBadRequest},
+		{"scale with invalid id", http.MethodGet, "not-a-uuid", "", handler.ScaleRecipe, http.StatusBadRequest},
+		{"scale with invalid factor", http.MethodGet, uuid.NewString() + "?factor=-1", "", handler.ScaleRecipe, http.StatusBadRequest},
+		{"scale with invalid servings", http.MethodGet, uuid.NewString() + "?servings=many", "", handler.ScaleRecipe, http.StatusBadRequest},
+		{"scale with unknown units", http.MethodGet, uuid.NewString() + "?units=cubits", "", handler.ScaleRecipe, http.StatusBadRequest},
+		{"cook with invalid id", http.MethodGet, "not-a-uuid", "", handler.CookRecipeStep, http.StatusBadRequest},
+		{"cook with invalid step", http.MethodGet, uuid.NewString() + "?step=0", "", handler.CookRecipeStep, http.StatusBadRequest},
+		{"cook with invalid servings", http.MethodGet, uuid.NewString() + "?step=2&servings=-4", "", handler.CookRecipeStep, http.StatusBadRequest},
+		{"delete with invalid id", http.MethodDelete, "not-a-uuid", "", handler.DeleteRecipe, http.StatusBadRequest},
+		{"create without name", http.MethodPost, "", `{"steps": ["Mix"]}`, handler.CreateRecipe, http.StatusBadRequest},
+		{"create with invalid body", http.MethodPost, "", `{"name": `, handler.CreateRecipe, http.StatusBadRequest},
+		{"update with invalid body", http.MethodPut, uuid.NewString(), `[]`, handler.UpdateRecipe, http.StatusBadRequest},
+		{"patch with invalid body", http.MethodPatch, uuid.NewString(), `{"name"`, handler.PatchRecipe, http.StatusBadRequest},
+		{"create from empty text", http.MethodPost, "text", `{"text": "  "}`, handler.CreateRecipeFromText, http.StatusBadRequest},
+		{"upload without file", http.MethodPost, "upload", "", handler.UploadRecipeFile, http.StatusBadRequest},
+		{"generate without description", http.MethodPost, "generate", `{"servings": 4}`, handler.GenerateRecipe, http.StatusBadRequest},
+		{"refine without instruction", http.MethodPost, uuid.NewString(), `{"instruction": " "}`, handler.RefineRecipe, http.StatusBadRequest},
+		{"list with unknown sort", http.MethodGet, "?sort=popularity", "", handler.ListRecipes, http.StatusBadRequest},
+		{"list with unknown order", http.MethodGet, "?sort=rating&order=up", "", handler.ListRecipes, http.StatusBadRequest},
+		{"list with invalid max time", http.MethodGet, "?maxTime=half+an+hour", "", handler.ListRecipes, http.StatusBadRequest},
+		{"list with unknown dietary label", http.MethodGet, "?dietary=vegetarian,paleo", "", handler.ListRecipes, http.StatusBadRequest},
+		{"list with invalid collection", http.MethodGet, "?collection=weeknight", "", handler.ListRecipes, http.StatusBadRequest},
+		{"cook log with invalid id", http.MethodGet, "not-a-uuid/cook-log", "", handler.ListCookLog, http.StatusBadRequest},
+		{"update cook log entry with invalid id", http.MethodPut, "cook-log/not-a-uuid", `{}`, handler.UpdateCookLogEntry, http.StatusBadRequest},
+		{"delete cook log entry with invalid id", http.MethodDelete, "cook-log/not-a-uuid", "", handler.DeleteCookLogEntry, http.StatusBadRequest},
+		{"export with unknown format", http.MethodGet, uuid.NewString() + "?format=pdf", "", handler.ExportRecipe, http.StatusBadRequest},
+		{"export with invalid id", http.MethodGet, "not-a-uuid?format=cooklang", "", handler.ExportRecipe, http.StatusBadRequest},
+		{"export all with unknown format", http.MethodGet, "export?format=mealmaster", "", handler.ExportRecipes, http.StatusBadRequest},
+		{"import without file", http.MethodPost, "import", "", handler.ImportRecipes, http.StatusBadRequest},
+		{"replace image without file", http.MethodPut, uuid.NewString() + "/image", "", handler.ReplaceRecipeImage, http.StatusBadRequest},
+		{"refresh with invalid id", http.MethodPost, "not-a-uuid/refresh", "", handler.RefreshRecipe, http.StatusBadRequest},
+		{"list updates with invalid recipe id", http.MethodGet, "updates?recipeId=not-a-uuid", "", handler.ListRecipeUpdates, http.StatusBadRequest},
+		{"apply update with unknown mode", http.MethodPost, uuid.NewString() + "?mode=replace", "", handler.ApplyRecipeUpdate, http.StatusBadRequest},
+		{"apply update with invalid id", http.MethodPost, "not-a-uuid", "", handler.ApplyRecipeUpdate, http.StatusBadRequest},
+		{"dismiss update with invalid id", http.MethodPost, "not-a-uuid", "", handler.DismissRecipeUpdate, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/recipes/"+tt.id, bytes.NewBufferString(tt.body))
+			rctx := chi.NewRouteContext()
+			id, _, _ := strings.Cut(tt.id, "?")
+			rctx.URLParams.Add("id", id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			ctx := context.WithValue(req.Context(), utils.UserIDKey, testUserID)
+			ctx = context.WithValue(ctx, utils.UsernameKey, "testuser")
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			tt.handle(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestParseRecipeOrder(t *testing.T) {
+	tests := []struct {
+		sort     string
+		order    string
+		expected repositories.RecipeOrder
+		wantErr  bool
+	}{
+		{"", "", repositories.RecipeOrder{Sort: repositories.RecipeSortCreated}, false},
+		{"name", "", repositories.RecipeOrder{Sort: repositories.RecipeSortName, Ascending: true}, false},
+		{"name", "desc", repositories.RecipeOrder{Sort: repositories.RecipeSortName}, false},
+		{"rating", "", repositories.RecipeOrder{Sort: repositories.RecipeSortRating}, false},
+		{"lastCooked", "asc", repositories.RecipeOrder{Sort: repositories.RecipeSortLastCooked, Ascending: true}, false},
+		{"timesCooked", "desc", repositories.RecipeOrder{Sort: repositories.RecipeSortTimesCooked}, false},
+		{"r.id; DROP TABLE recipes", "", repositories.RecipeOrder{}, true},
+		{"rating", "sideways", repositories.RecipeOrder{}, true},
+	}
+
+	for _, tt := range tests {
+		order, err := parseRecipeOrder(tt.sort, tt.order)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected an error for sort %q order %q", tt.sort, tt.order)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for sort %q order %q: %v", tt.sort, tt.order, err)
+			continue
+		}
+		if order != tt.expected {
+			t.Errorf("Expected %+v for sort %q order %q, got %+v", tt.expected, tt.sort, tt.order, order)
+		}
+	}
+}
+
+func TestRecipeScalingWithoutServings(t *testing.T) {
+	scale := recipeScaling{system: utils.UnitSystemOriginal, factor: 1, servings: 4}
+	zero, negative := 0, -2
+
+	for name, servings := range map[string]*int{"none": nil, "zero": &zero, "negative": &negative} {
+		if _, _, err := scale.apply(models.Recipe{Servings: servings}); err == nil {
+			t.Errorf("Expected an error scaling a recipe with %s servings", name)
+		}
+	}
+
+	two := 2
+	scaled, factor, err := scale.apply(models.Recipe{Servings: &two})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if factor != 2 || *scaled.Servings != 4 {
+		t.Errorf("Expected factor 2 and 4 servings, got %v and %d", factor, *scaled.Servings)
+	}
+}
+
+func TestCookLogEntryFromRequest(t *testing.T) {
+	now := time.Date(2026, 3, 2, 19, 30, 0, 0, time.UTC)
+	date := func(s string) *string { return &s }
+	number := func(n int) *int { return &n }
+
+	entry, err := cookLogEntryFromRequest(requests.CookLogEntry{Rating: number(4), Notes: date("  too salty ")}, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if entry.CookedOn != "2026-03-02" {
+		t.Errorf("Expected entries without a date to be for today, got %q", entry.CookedOn)
+	}
+	if entry.Notes == nil || *entry.Notes != "too salty" {
+		t.Errorf("Expected trimmed notes, got %v", entry.Notes)
+	}
+
+	entry, err = cookLogEntryFromRequest(requests.CookLogEntry{CookedOn: date("2026-02-14"), Servings: number(2), Notes: date(" ")}, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if entry.CookedOn != "2026-02-14" || entry.Rating != nil || entry.Notes != nil {
+		t.Errorf("Expected an unrated entry on 2026-02-14 without notes, got %+v", entry)
+	}
+
+	invalid := []requests.CookLogEntry{
+		{CookedOn: date("14.02.2026")},
+		{Rating: number(0)},
+		{Rating: number(6)},
+		{Servings: number(0)},
+	}
+	for _, req := range invalid {
+		if _, err := cookLogEntryFromRequest(req, now); err == nil {
+			t.Errorf("Expected an error for %+v", req)
+		}
+	}
+}
+
+func TestReplaceRecipeImageRejectsNonImages(t *testing.T) {
+	handler := NewRecipeHandler(nil, nil, nil, nil, nil, nil)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile(FormFileKey, "photo.jpg")
+	part.Write([]byte("not an image"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/recipes/"+uuid.NewString()+"/image", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req = req.WithContext(context.WithValue(req.Context(), utils.UserIDKey, uuid.New()))
+	req = req.WithContext(context.WithValue(req.Context(), utils.UsernameKey, "testuser"))
+
+	w := httptest.NewRecorder()
+	handler.ReplaceRecipeImage(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestRecipeFileSourceType(t *testing.T) {
+	tests := []struct {
+		filename string
+		content  string
+		expected string
+		ok       bool
+	}{
+		{"pancakes.html", "<html><body>Pancakes</body></html>", models.RecipeSourceHTML, true},
+		{"Pancakes.HTM", "Pancakes", models.RecipeSourceHTML, true},
+		{"pancakes.txt", "Pancakes\n\nIngredients:\n2 eggs", models.RecipeSourceText, true},
+		{"pancakes.md", "# Pancakes", models.RecipeSourceText, true},
+		{"saved-page", "<!DOCTYPE html><html><body>Pancakes</body></html>", models.RecipeSourceHTML, true},
+		{"recipe", "Pancakes with 2 eggs", models.RecipeSourceText, true},
+		{"cookbook.pdf", "%PDF-1.7\n%\xe2\xe3\xcf\xd3", "", false},
+		{"photo.txt", "\xff\xd8\xff\xe0 JFIF", "", false},
+	}
+
+	for _, tt := range tests {
+		sourceType, ok := recipeFileSourceType(tt.filename, []byte(tt.content))
+		if ok != tt.ok || sourceType != tt.expected {
+			t.Errorf("Expected %q (%v) for %s, got %q (%v)", tt.expected, tt.ok, tt.filename, sourceType, ok)
+		}
+	}
+}
+
+func TestRecipeGenerationFromRequest(t *testing.T) {
+	four := 4
+	zero := 0
+
+	generation, err := recipeGenerationFromRequest(requests.GenerateRecipe{
+		Description: "  Chicken curry ",
+		Servings:    &four,
+		Dietary:     []string{" gluten-free", ""},
+		Ingredients: []string{"chicken thighs", " ", "coconut milk"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if generation.Description != "Chicken curry" || *generation.Servings != 4 {
+		t.Errorf("Expected curry for 4, got %+v", generation)
+	}
+	if strings.Join(generation.Dietary, "|") != "gluten-free" || strings.Join(generation.Ingredients, "|") != "chicken thighs|coconut milk" {
+		t.Errorf("Expected empty constraints to be dropped, got %+v", generation)
+	}
+
+	invalid := []requests.GenerateRecipe{
+		{Description: " "},
+		{Description: "Curry", Servings: &zero},
+		{Description: strings.Repeat("curry ", 200)},
+	}
+	for _, req := range invalid {
+		if _, err := recipeGenerationFromRequest(req); err == nil {
+			t.Errorf("Expected an error for %+v", req)
+		}
+	}
+}
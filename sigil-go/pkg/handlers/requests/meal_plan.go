@@ -0,0 +1,24 @@
+package requests
+
+import "github.com/google/uuid"
+
+// MealPlan creates a meal plan or replaces one. Dates are formatted as
+// YYYY-MM-DD.
+type MealPlan struct {
+	Title     string        `json:"title"` // Optional, defaults to one naming the start date
+	StartDate string        `json:"startDate"`
+	EndDate   string        `json:"endDate"`
+	Meals     []PlannedMeal `json:"meals"`
+}
+
+type PlannedMeal struct {
+	ID       *uuid.UUID `json:"id"` // Set to keep a meal of the plan being updated
+	Date     string     `json:"date"`
+	Slot     string     `json:"slot"` // breakfast, lunch or dinner
+	RecipeID uuid.UUID  `json:"recipeId"`
+	Servings *int       `json:"servings"` // Optional, defaults to the recipe's servings
+}
+
+type StockedItems struct {
+	Items []string `json:"items"`
+}
@@ -12,3 +12,9 @@ type Note struct {
 type ConvertNoteToShoppingList struct {
 	Mode string `json:"mode"` // "new" or "merge"
 }
+
+type ClipURL struct {
+	URL        string     `json:"url"`
+	NotebookID *uuid.UUID `json:"notebookId,omitempty"`
+	SectionID  *uuid.UUID `json:"sectionId,omitempty"`
+}
@@ -0,0 +1,11 @@
+package requests
+
+// PantryItem creates or replaces a pantry item. Without a quantity, the
+// quantity is read from the name, e.g. "2 L milk".
+type PantryItem struct {
+	Name       string   `json:"name"`
+	Quantity   *float64 `json:"quantity"`
+	Unit       string   `json:"unit"`
+	Location   *string  `json:"location"`   // Optional: fridge, freezer, ...
+	BestBefore *string  `json:"bestBefore"` // Optional, YYYY-MM-DD
+}
@@ -1,5 +1,52 @@
 package requests
 
+import "tofoss/sigil-go/pkg/models"
+
 type CreateRecipe struct {
 	URL string `json:"url"`
-}
\ No newline at end of file
+}
+
+type CreateRecipeFromText struct {
+	Text string `json:"text"`
+}
+
+// GenerateRecipe asks the AI for a recipe matching a dish name or description
+type GenerateRecipe struct {
+	Description string   `json:"description"`
+	Servings    *int     `json:"servings"`
+	Dietary     []string `json:"dietary"`
+	Ingredients []string `json:"ingredients"` // available ingredients to use
+}
+
+// RefineRecipe asks the AI to change a recipe, e.g. "make it vegetarian"
+type RefineRecipe struct {
+	Instruction string `json:"instruction"`
+}
+
+// Recipe is the editable part of a recipe, used for manual creation and edits
+type Recipe struct {
+	Name      string  `json:"name"`
+	Summary   *string `json:"summary"`
+	Servings  *int    `json:"servings"`
+	PrepTime  *string `json:"prepTime"`
+	CookTime  *string `json:"cookTime"`
+	TotalTime *string `json:"totalTime"`
+	Cuisine   *string `json:"cuisine"`
+	Course    *string `json:"course"`
+	// Dietary labels set by the user. Null infers them from the ingredients.
+	Dietary     *[]string           `json:"dietary"`
+	Equipment   []string            `json:"equipment"`
+	SourceURL   *string             `json:"sourceUrl"`
+	Ingredients []models.Ingredient `json:"ingredients"`
+	Steps       []string            `json:"steps"`
+	// Headings like "For the sauce", each starting at a step
+	StepSections []models.StepSection `json:"stepSections"`
+}
+
+// CookLogEntry records cooking a recipe. The date is formatted as YYYY-MM-DD.
+type CookLogEntry struct {
+	CookedOn *string `json:"cookedOn"`
+	Rating   *int    `json:"rating"`
+	Servings *int    `json:"servings"`
+	Notes    *string `json:"notes"`
+}
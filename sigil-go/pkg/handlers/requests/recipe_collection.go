@@ -0,0 +1,7 @@
+package requests
+
+// RecipeCollection creates or renames a recipe collection
+type RecipeCollection struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"` // Optional, shown in the cookbook
+}
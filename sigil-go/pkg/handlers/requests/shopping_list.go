@@ -11,7 +11,8 @@ type UpdateShoppingList struct {
 }
 
 type ToggleShoppingListItem struct {
-	Checked bool `json:"checked"`
+	Checked     bool `json:"checked"`
+	AddToPantry bool `json:"addToPantry"` // Add checked items to the user's pantry
 }
 
 type MergeRecipe struct {
@@ -0,0 +1,10 @@
+package responses
+
+import "time"
+
+type CalendarFeedResponse struct {
+	// URL shows meals as all-day events, TimedURL at the time of the meal
+	URL       string    `json:"url"`
+	TimedURL  string    `json:"timedUrl"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,5 @@
+package responses
+
+type CreateImportResponse struct {
+	JobID string `json:"jobId"`
+}
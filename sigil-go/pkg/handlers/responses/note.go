@@ -6,3 +6,10 @@ type FetchNoteResponse struct {
 	models.Note
 	IsEditable bool `json:"isEditable"`
 }
+
+// ClipResponse is a clipped web page, with a warning when the note couldn't
+// be filed where it was asked to
+type ClipResponse struct {
+	models.Note
+	Warning string `json:"warning,omitempty"`
+}
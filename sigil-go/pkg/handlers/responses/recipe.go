@@ -2,6 +2,8 @@ package responses
 
 import (
 	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
 )
 
 type CreateRecipeResponse struct {
@@ -12,4 +14,33 @@ type RecipeJobResponse struct {
 	Job    models.RecipeJob `json:"job"`
 	Recipe *models.Recipe   `json:"recipe,omitempty"`
 	Note   *models.Note     `json:"note,omitempty"`
-}
\ No newline at end of file
+}
+type RecipeResponse struct {
+	models.Recipe
+	NoteIDs []uuid.UUID `json:"noteIds"`
+}
+
+type RecipeImportResponse struct {
+	Recipes []RecipeResponse `json:"recipes"`
+	Failed  []string         `json:"failed"` // names of the recipes that couldn't be saved
+}
+
+type ScaledRecipeResponse struct {
+	models.Recipe
+	Factor   float64 `json:"factor"`
+	Units    string  `json:"units"`
+	Markdown string  `json:"markdown"`
+}
+
+// CookStepResponse is one step of a recipe in cook mode, with the scaled
+// ingredients the step uses
+type CookStepResponse struct {
+	RecipeID    uuid.UUID           `json:"recipeId"`
+	Name        string              `json:"name"`
+	Servings    *int                `json:"servings"`
+	Step        models.RecipeStep   `json:"step"`
+	TotalSteps  int                 `json:"totalSteps"`
+	Ingredients []models.Ingredient `json:"ingredients"`
+	Factor      float64             `json:"factor"`
+	Units       string              `json:"units"`
+}
@@ -0,0 +1,18 @@
+package responses
+
+import "tofoss/sigil-go/pkg/models"
+
+type MergeRecipeResponse struct {
+	ShoppingList *models.ShoppingList `json:"shoppingList"`
+	// Merged and Added hold the names of the items that were merged into
+	// existing items and appended to the list
+	Merged []string `json:"merged"`
+	Added  []string `json:"added"`
+}
+
+type GeneratedShoppingListResponse struct {
+	ShoppingList *models.ShoppingList `json:"shoppingList"`
+	// Skipped holds the names of the ingredients left out because the user
+	// always has them in stock
+	Skipped []string `json:"skipped"`
+}
@@ -5,31 +5,35 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"tofoss/sigil-go/pkg/db/repositories"
 	"tofoss/sigil-go/pkg/handlers/errors"
 	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/handlers/responses"
 	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/services"
 	"tofoss/sigil-go/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type ShoppingListHandler struct {
 	shoppingListRepo repositories.ShoppingListRepositoryInterface
 	noteRepo         repositories.NoteRepositoryInterface
 	recipeRepo       repositories.RecipeRepositoryInterface
+	pantryService    *services.PantryService
 }
 
 func NewShoppingListHandler(
 	shoppingListRepo repositories.ShoppingListRepositoryInterface,
 	noteRepo repositories.NoteRepositoryInterface,
 	recipeRepo repositories.RecipeRepositoryInterface,
+	pantryService *services.PantryService,
 ) ShoppingListHandler {
-	return ShoppingListHandler{shoppingListRepo, noteRepo, recipeRepo}
+	return ShoppingListHandler{shoppingListRepo, noteRepo, recipeRepo, pantryService}
 }
 
 // ListShoppingLists retrieves all shopping lists for the user
@@ -325,9 +329,10 @@ func (h *ShoppingListHandler) DeleteShoppingList(w http.ResponseWriter, r *http.
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// ToggleItemCheck toggles the checked status of a shopping list item
+// ToggleItemCheck toggles the checked status of a shopping list item, and
+// optionally adds checked items to the pantry
 func (h *ShoppingListHandler) ToggleItemCheck(w http.ResponseWriter, r *http.Request) {
-	_, _, err := utils.UserContext(r)
+	userID, _, err := utils.UserContext(r)
 	if err != nil {
 		log.Printf("unable to toggle item, user not logged in: %v", err)
 		errors.InternalServerError(w)
@@ -350,6 +355,21 @@ func (h *ShoppingListHandler) ToggleItemCheck(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	addToPantry := req.Checked && req.AddToPantry
+	var item *models.ShoppingListEntry
+	if addToPantry {
+		item, err = h.shoppingListRepo.GetItemForUser(r.Context(), itemID, userID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				errors.NotFound(w, "shopping list item not found")
+				return
+			}
+			log.Printf("failed to fetch shopping list item %s: %v", itemID, err)
+			errors.InternalServerError(w)
+			return
+		}
+	}
+
 	// TODO: Verify user owns the shopping list that contains this item
 	// For now, we'll just update the item
 
@@ -360,6 +380,15 @@ func (h *ShoppingListHandler) ToggleItemCheck(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Items already checked off were added when they were checked
+	if addToPantry && !item.Checked {
+		if _, err := h.pantryService.AddShoppingListItem(r.Context(), userID, *item); err != nil {
+			log.Printf("failed to add shopping list item %s to pantry: %v", itemID, err)
+			errors.InternalServerError(w)
+			return
+		}
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -450,7 +479,8 @@ func (h *ShoppingListHandler) MergeRecipeIngredients(w http.ResponseWriter, r *h
 	}
 
 	// Merge recipe ingredients into shopping list content
-	updatedContent := h.mergeRecipeIntoMarkdown(shoppingList.Content, recipe.Ingredients, shoppingList.Items)
+	merge := utils.MergeIntoShoppingList(shoppingList.Content, recipe.Ingredients, recipe.Name)
+	updatedContent := merge.Content
 
 	// Re-parse the shopping list
 	items, err := utils.ParseShoppingList(updatedContent)
@@ -488,65 +518,9 @@ func (h *ShoppingListHandler) MergeRecipeIngredients(w http.ResponseWriter, r *h
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(updated)
-}
-
-// mergeRecipeIntoMarkdown merges recipe ingredients into the note's markdown content
-func (h *ShoppingListHandler) mergeRecipeIntoMarkdown(
-	currentContent string,
-	ingredients []models.Ingredient,
-	existingItems []models.ShoppingListEntry,
-) string {
-	// Build a map of existing items for quick lookup
-	existingMap := make(map[string]*models.ShoppingListEntry)
-	for i := range existingItems {
-		existingMap[existingItems[i].ItemName] = &existingItems[i]
-	}
-
-	// Generate markdown for new ingredients
-	var newItems []string
-	for _, ingredient := range ingredients {
-		normalizedName := utils.NormalizeItemName(ingredient.Name)
-
-		// Check if item exists
-		if existing, found := existingMap[normalizedName]; found && existing.Quantity != nil && ingredient.Quantity != nil {
-			// Item exists with quantity - try to merge
-			if existing.Quantity.Unit == ingredient.Quantity.Unit {
-				// Same unit - merge by adding quantities
-				newQty := *existing.Quantity.Min
-				if ingredient.Quantity.Min != nil {
-					newQty += *ingredient.Quantity.Min
-				}
-				// Update existing item in place (will be reflected in markdown regeneration)
-				newMin := newQty
-				existing.Quantity.Min = &newMin
-				existing.Quantity.Max = &newMin
-				continue
-			}
-		}
-
-		// Add as new item
-		itemMarkdown := "- [ ] "
-		if ingredient.Quantity != nil {
-			itemMarkdown += utils.FormatQuantity(*ingredient.Quantity) + " "
-		}
-		itemMarkdown += ingredient.Name
-		if ingredient.Notes != "" {
-			itemMarkdown += " (" + ingredient.Notes + ")"
-		}
-		newItems = append(newItems, itemMarkdown)
-	}
-
-	// Append new items to the end of the content
-	if len(newItems) > 0 {
-		if currentContent != "" && !strings.HasSuffix(currentContent, "\n") {
-			currentContent += "\n"
-		}
-		currentContent += "\n## From Recipe\n"
-		for _, item := range newItems {
-			currentContent += item + "\n"
-		}
-	}
-
-	return currentContent
+	json.NewEncoder(w).Encode(responses.MergeRecipeResponse{
+		ShoppingList: updated,
+		Merged:       merge.Merged,
+		Added:        merge.Added,
+	})
 }
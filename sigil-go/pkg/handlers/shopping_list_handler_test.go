@@ -12,11 +12,13 @@ import (
 
 	"tofoss/sigil-go/pkg/db/repositories"
 	"tofoss/sigil-go/pkg/handlers/requests"
+	"tofoss/sigil-go/pkg/handlers/responses"
 	"tofoss/sigil-go/pkg/models"
 	"tofoss/sigil-go/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // mockShoppingListRepository is a mock implementation for testing
@@ -28,6 +30,7 @@ type mockShoppingListRepository struct {
 	updateFunc              func(ctx context.Context, list models.ShoppingList) (*models.ShoppingList, error)
 	deleteFunc              func(ctx context.Context, id uuid.UUID) error
 	updateItemCheckFunc     func(ctx context.Context, itemID uuid.UUID, checked bool) error
+	getItemForUserFunc      func(ctx context.Context, itemID uuid.UUID, userID uuid.UUID) (*models.ShoppingListEntry, error)
 	getUserVocabularyFunc   func(ctx context.Context, userID uuid.UUID, prefix string, limit int) ([]models.VocabularyItem, error)
 	addToVocabularyFunc     func(ctx context.Context, userID uuid.UUID, itemName string) error
 	hashContentFunc         func(content string) string
@@ -82,6 +85,13 @@ func (m *mockShoppingListRepository) UpdateItemCheckStatus(ctx context.Context,
 	return errors.New("UpdateItemCheckStatus not mocked")
 }
 
+func (m *mockShoppingListRepository) GetItemForUser(ctx context.Context, itemID uuid.UUID, userID uuid.UUID) (*models.ShoppingListEntry, error) {
+	if m.getItemForUserFunc != nil {
+		return m.getItemForUserFunc(ctx, itemID, userID)
+	}
+	return nil, errors.New("GetItemForUser not mocked")
+}
+
 func (m *mockShoppingListRepository) GetUserVocabulary(ctx context.Context, userID uuid.UUID, prefix string, limit int) ([]models.VocabularyItem, error) {
 	if m.getUserVocabularyFunc != nil {
 		return m.getUserVocabularyFunc(ctx, userID, prefix, limit)
@@ -216,6 +226,7 @@ func TestToggleItemCheck(t *testing.T) {
 		itemID           string
 		requestBody      requests.ToggleShoppingListItem
 		mockUpdateError  error
+		mockItemError    error
 		expectedStatus   int
 	}{
 		{
@@ -239,6 +250,16 @@ func TestToggleItemCheck(t *testing.T) {
 			itemID:         "invalid-uuid",
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:   "Adding an item of another user to the pantry returns 404",
+			itemID: testItemID.String(),
+			requestBody: requests.ToggleShoppingListItem{
+				Checked:     true,
+				AddToPantry: true,
+			},
+			mockItemError:  pgx.ErrNoRows,
+			expectedStatus: http.StatusNotFound,
+		},
 		{
 			name:   "Database error returns 500",
 			itemID: testItemID.String(),
@@ -264,12 +285,18 @@ func TestToggleItemCheck(t *testing.T) {
 					capturedChecked = checked
 					return tt.mockUpdateError
 				},
+				getItemForUserFunc: func(ctx context.Context, itemID uuid.UUID, userID uuid.UUID) (*models.ShoppingListEntry, error) {
+					if userID != testUserID {
+						t.Errorf("Expected user ID %s, got %s", testUserID, userID)
+					}
+					return nil, tt.mockItemError
+				},
 			}
 
 			mockNoteRepo := &mockNoteRepositoryForShopping{}
 			mockRecipeRepo := &mockRecipeRepository{}
 
-			handler := NewShoppingListHandler(mockShoppingListRepo, mockNoteRepo, mockRecipeRepo)
+			handler := NewShoppingListHandler(mockShoppingListRepo, mockNoteRepo, mockRecipeRepo, nil)
 
 			// Create request body
 			body, _ := json.Marshal(tt.requestBody)
@@ -371,7 +398,7 @@ func TestGetVocabularySuggestions(t *testing.T) {
 			mockNoteRepo := &mockNoteRepositoryForShopping{}
 			mockRecipeRepo := &mockRecipeRepository{}
 
-			handler := NewShoppingListHandler(mockShoppingListRepo, mockNoteRepo, mockRecipeRepo)
+			handler := NewShoppingListHandler(mockShoppingListRepo, mockNoteRepo, mockRecipeRepo, nil)
 
 			// Create request
 			url := "/shopping-list/vocabulary"
@@ -429,7 +456,7 @@ func TestMergeRecipeIngredients(t *testing.T) {
 		mockShoppingError  error
 		mockRecipeError    error
 		expectedStatus     int
-		validateResponse   func(t *testing.T, list *models.ShoppingList)
+		validateResponse   func(t *testing.T, response *responses.MergeRecipeResponse)
 	}{
 		{
 			name:           "Successfully merge recipe ingredients",
@@ -455,9 +482,47 @@ func TestMergeRecipeIngredients(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusOK,
-			validateResponse: func(t *testing.T, list *models.ShoppingList) {
-				if list == nil {
-					t.Error("Expected shopping list in response")
+			validateResponse: func(t *testing.T, response *responses.MergeRecipeResponse) {
+				if response.ShoppingList == nil {
+					t.Fatal("Expected shopping list in response")
+				}
+				if len(response.Added) != 1 || response.Added[0] != "Sugar" {
+					t.Errorf("Expected Sugar to be added, got %v", response.Added)
+				}
+				if len(response.Merged) != 0 {
+					t.Errorf("Expected nothing to be merged, got %v", response.Merged)
+				}
+			},
+		},
+		{
+			name:           "Merges quantities into existing items",
+			shoppingListID: testShoppingListID.String(),
+			requestBody: requests.MergeRecipe{
+				RecipeID: testRecipeID.String(),
+			},
+			mockShoppingList: &models.ShoppingList{
+				ID:      testShoppingListID,
+				UserID:  testUserID,
+				Title:   "Groceries",
+				Content: "- [ ] 500 g Flour\n- [ ] 1 dl milk\n",
+			},
+			mockRecipe: models.Recipe{
+				ID:   testRecipeID,
+				Name: "Pancakes",
+				Ingredients: []models.Ingredient{
+					{Name: "flour", Quantity: &models.Quantity{Min: floatPtr(1), Max: floatPtr(1), Unit: "kg"}},
+					{Name: "Milk", Quantity: &models.Quantity{Min: floatPtr(2), Max: floatPtr(3), Unit: "dl"}},
+					{Name: "Eggs", Quantity: &models.Quantity{Min: floatPtr(2), Max: floatPtr(2)}},
+				},
+			},
+			expectedStatus: http.StatusOK,
+			validateResponse: func(t *testing.T, response *responses.MergeRecipeResponse) {
+				expected := "- [ ] 1.5 kg Flour\n- [ ] 3-4 dl milk\n\n## Pancakes\n- [ ] 2 Eggs\n"
+				if response.ShoppingList.Content != expected {
+					t.Errorf("Expected content %q, got %q", expected, response.ShoppingList.Content)
+				}
+				if len(response.Merged) != 2 || len(response.Added) != 1 {
+					t.Errorf("Expected 2 merged and 1 added items, got %v and %v", response.Merged, response.Added)
 				}
 			},
 		},
@@ -505,7 +570,7 @@ func TestMergeRecipeIngredients(t *testing.T) {
 				},
 			}
 
-			handler := NewShoppingListHandler(mockShoppingListRepo, mockNoteRepo, mockRecipeRepo)
+			handler := NewShoppingListHandler(mockShoppingListRepo, mockNoteRepo, mockRecipeRepo, nil)
 
 			// Create request body
 			body, _ := json.Marshal(tt.requestBody)
@@ -534,13 +599,13 @@ func TestMergeRecipeIngredients(t *testing.T) {
 
 			// For successful responses, validate the result
 			if tt.expectedStatus == http.StatusOK {
-				var list models.ShoppingList
-				if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+				var response responses.MergeRecipeResponse
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
 					t.Fatalf("Failed to decode response: %v", err)
 				}
 
 				if tt.validateResponse != nil {
-					tt.validateResponse(t, &list)
+					tt.validateResponse(t, &response)
 				}
 			}
 		})
@@ -613,7 +678,7 @@ func TestGetShoppingList(t *testing.T) {
 
 			mockRecipeRepo := &mockRecipeRepository{}
 
-			handler := NewShoppingListHandler(mockShoppingListRepo, mockNoteRepo, mockRecipeRepo)
+			handler := NewShoppingListHandler(mockShoppingListRepo, mockNoteRepo, mockRecipeRepo, nil)
 
 			// Create request
 			req := httptest.NewRequest(http.MethodGet, "/shopping-list/"+tt.shoppingListID, nil)
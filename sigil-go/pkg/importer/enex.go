@@ -0,0 +1,348 @@
+package importer
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/parser"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// enexTimeLayout is the timestamp format used in ENEX files, always in UTC
+const enexTimeLayout = "20060102T150405Z"
+
+// ENEXSource reads Evernote exports. Evernote exports every notebook to its
+// own .enex file, so each file becomes a notebook named after the file.
+type ENEXSource struct {
+	fsys   fs.FS
+	closer io.Closer
+}
+
+func NewENEXSource(fsys fs.FS, closer io.Closer) *ENEXSource {
+	return &ENEXSource{fsys: fsys, closer: closer}
+}
+
+func (s *ENEXSource) Close() error {
+	return s.closer.Close()
+}
+
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Tags      []string       `xml:"tag"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data struct {
+		Encoding string `xml:"encoding,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"data"`
+	Mime       string `xml:"mime"`
+	Attributes struct {
+		FileName string `xml:"file-name"`
+	} `xml:"resource-attributes"`
+}
+
+// enexAttachment is a decoded resource, referenced from ENML by the MD5 hash
+// of its content
+type enexAttachment struct {
+	Attachment
+	mime string
+}
+
+// Documents implements Source
+func (s *ENEXSource) Documents() ([]Document, []models.ImportError, error) {
+	var files []string
+	err := fs.WalkDir(s.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != "." && isHidden(d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if strings.EqualFold(path.Ext(p), ".enex") && !isHidden(d.Name()) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("no .enex files found in export")
+	}
+
+	var documents []Document
+	var importErrors []models.ImportError
+	for _, file := range files {
+		docs, errs, err := s.readFile(file)
+		importErrors = append(importErrors, errs...)
+		if err != nil {
+			importErrors = append(importErrors, models.ImportError{Path: file, Message: err.Error()})
+		}
+		documents = append(documents, docs...)
+	}
+
+	return documents, importErrors, nil
+}
+
+// readFile decodes the notes of a single .enex file. Notes decoded before a
+// malformed part of the file are still returned.
+func (s *ENEXSource) readFile(name string) ([]Document, []models.ImportError, error) {
+	file, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	notebook := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	keys := make(map[string]bool)
+
+	var documents []Document
+	var importErrors []models.ImportError
+
+	decoder := xml.NewDecoder(file)
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return documents, importErrors, fmt.Errorf("invalid ENEX file: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		var note enexNote
+		if err := decoder.DecodeElement(&note, &start); err != nil {
+			return documents, importErrors, fmt.Errorf("invalid ENEX note: %w", err)
+		}
+
+		doc, warnings := s.document(name, notebook, note)
+		doc.Key = uniqueKey(keys, doc.Key)
+		documents = append(documents, doc)
+		importErrors = append(importErrors, warnings...)
+	}
+
+	return documents, importErrors, nil
+}
+
+func (s *ENEXSource) document(fileName, notebook string, note enexNote) (Document, []models.ImportError) {
+	title := strings.TrimSpace(note.Title)
+	if title == "" {
+		title = "Untitled"
+	}
+
+	doc := Document{
+		Key:       fileName + "/" + title,
+		Title:     title,
+		Notebook:  notebook,
+		Tags:      normalizeTags(note.Tags),
+		CreatedAt: parseENEXTime(note.Created),
+		UpdatedAt: parseENEXTime(note.Updated),
+	}
+
+	var warnings []models.ImportError
+	resources := make(map[string]enexAttachment, len(note.Resources))
+	var order []string
+	for _, resource := range note.Resources {
+		attachment, hash, err := decodeResource(fileName, resource)
+		if err != nil {
+			warnings = append(warnings, models.ImportError{Path: doc.Key, Message: err.Error()})
+			continue
+		}
+		if _, exists := resources[hash]; !exists {
+			order = append(order, hash)
+		}
+		resources[hash] = attachment
+	}
+
+	referenced := make(map[string]bool)
+	content, err := convertENML(note.Content, func(hash string) (enexAttachment, bool) {
+		attachment, ok := resources[hash]
+		if ok {
+			referenced[hash] = true
+		}
+		return attachment, ok
+	})
+	if err != nil {
+		warnings = append(warnings, models.ImportError{Path: doc.Key, Message: err.Error()})
+	}
+
+	// Resources that aren't placed in the note body are listed at the end so
+	// they aren't lost
+	var unplaced []string
+	for _, hash := range order {
+		attachment := resources[hash]
+		doc.Attachments = append(doc.Attachments, attachment.Attachment)
+		if !referenced[hash] {
+			unplaced = append(unplaced, "- "+mediaMarkdown(attachment))
+		}
+	}
+	if len(unplaced) > 0 {
+		content = strings.TrimSpace(content + "\n\n" + strings.Join(unplaced, "\n"))
+	}
+
+	doc.Content = content
+	return doc, warnings
+}
+
+func decodeResource(fileName string, resource enexResource) (enexAttachment, string, error) {
+	encoding := strings.TrimSpace(resource.Data.Encoding)
+	if encoding != "" && encoding != "base64" {
+		return enexAttachment{}, "", fmt.Errorf("unsupported resource encoding: %s", encoding)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(resource.Data.Value), ""))
+	if err != nil {
+		return enexAttachment{}, "", fmt.Errorf("invalid resource data: %w", err)
+	}
+	if len(data) > maxAttachmentSize {
+		return enexAttachment{}, "", fmt.Errorf("resource is larger than %d bytes", maxAttachmentSize)
+	}
+
+	sum := md5.Sum(data)
+	hash := hex.EncodeToString(sum[:])
+
+	name := strings.TrimSpace(resource.Attributes.FileName)
+	if name == "" {
+		name = hash
+	}
+
+	return enexAttachment{
+		Attachment: Attachment{
+			// Identical resources across notes of a file are only stored once
+			Key:  fileName + "/" + hash,
+			Name: name,
+			Load: func() ([]byte, error) { return data, nil },
+		},
+		mime: strings.TrimSpace(resource.Mime),
+	}, hash, nil
+}
+
+// convertENML converts Evernote's note markup to markdown. Media elements
+// reference resources by hash and are resolved through lookup.
+func convertENML(content string, lookup func(hash string) (enexAttachment, bool)) (string, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("invalid note content: %w", err)
+	}
+	normalizeENML(doc)
+
+	converter := &parser.MarkdownConverter{
+		// Evernote elements are self-closing, which the HTML parser doesn't know
+		// about, so content following them ends up as their children
+		RenderElement: func(n *html.Node, children func() string) (string, bool) {
+			switch n.Data {
+			case "en-media":
+				attachment, ok := lookup(enmlAttr(n, "hash"))
+				if !ok {
+					return children(), true
+				}
+				return mediaMarkdown(attachment) + children(), true
+			case "en-todo":
+				if enmlAttr(n, "checked") == "true" {
+					return "- [x] " + children(), true
+				}
+				return "- [ ] " + children(), true
+			case "en-crypt":
+				return "*Encrypted content was not imported*", true
+			}
+			return "", false
+		},
+	}
+
+	return converter.ConvertNode(doc), nil
+}
+
+// normalizeENML rewrites markup newer Evernote versions express through styles
+// into plain HTML: checklists and code blocks
+func normalizeENML(n *html.Node) {
+	if n.Type == html.ElementNode {
+		style := strings.ReplaceAll(enmlAttr(n, "style"), " ", "")
+		switch {
+		case n.Data == "li" && strings.Contains(style, "--en-checked:"):
+			checkbox := &html.Node{
+				Type:     html.ElementNode,
+				Data:     "input",
+				DataAtom: atom.Input,
+				Attr:     []html.Attribute{{Key: "type", Val: "checkbox"}},
+			}
+			if strings.Contains(style, "--en-checked:true") {
+				checkbox.Attr = append(checkbox.Attr, html.Attribute{Key: "checked"})
+			}
+			// Checklist items wrap their text in a div, the checkbox goes inside
+			// it to stay on the same line
+			target := n
+			if first := n.FirstChild; first != nil && first.Type == html.ElementNode && first.Data == "div" {
+				target = first
+			}
+			target.InsertBefore(checkbox, target.FirstChild)
+		case n.Data == "div" && strings.Contains(style, "--en-codeblock:true"):
+			n.Data = "pre"
+			n.DataAtom = atom.Pre
+		}
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		normalizeENML(child)
+	}
+}
+
+func mediaMarkdown(attachment enexAttachment) string {
+	name := strings.NewReplacer("[", "", "]", "").Replace(attachment.Name)
+	link := "[" + name + "](" + AttachmentLink(attachment.Key) + ")"
+	if strings.HasPrefix(attachment.mime, "image/") {
+		return "!" + link
+	}
+	return link
+}
+
+func enmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func parseENEXTime(value string) *time.Time {
+	t, err := time.Parse(enexTimeLayout, strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// uniqueKey appends a counter to keys that were already used, since notes in
+// an export don't need to have unique titles
+func uniqueKey(used map[string]bool, key string) string {
+	unique := key
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s (%d)", key, i)
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openENEX(t *testing.T, path string) map[string]Document {
+	t.Helper()
+
+	source, err := Open(FormatENEX, path)
+	require.NoError(t, err)
+	t.Cleanup(func() { source.Close() })
+
+	return documentsByKey(t, source)
+}
+
+func TestENEXSourceNotebooksAndMetadata(t *testing.T) {
+	docs := openENEX(t, "testdata/enex")
+	require.Len(t, docs, 3)
+
+	packing := docs["Travel.enex/Packing list"]
+	assert.Equal(t, "Packing list", packing.Title)
+	assert.Equal(t, "Travel", packing.Notebook)
+	assert.Equal(t, "", packing.Section)
+	assert.Equal(t, []string{"travel", "checklist"}, packing.Tags)
+	require.NotNil(t, packing.CreatedAt)
+	assert.Equal(t, time.Date(2023, 5, 14, 8, 15, 0, 0, time.UTC), *packing.CreatedAt)
+	require.NotNil(t, packing.UpdatedAt)
+	assert.Equal(t, time.Date(2023, 6, 2, 17, 45, 12, 0, time.UTC), *packing.UpdatedAt)
+
+	pancakes := docs["Recipes.enex/Pancakes"]
+	assert.Equal(t, "Recipes", pancakes.Notebook)
+	assert.Nil(t, pancakes.UpdatedAt)
+}
+
+func TestENEXSourceContent(t *testing.T) {
+	docs := openENEX(t, "testdata/enex")
+
+	packing := docs["Travel.enex/Packing list"]
+	require.Len(t, packing.Attachments, 1)
+	attachment := packing.Attachments[0]
+	assert.Equal(t, "map.png", attachment.Name)
+
+	expected := "## Before leaving\n\n" +
+		"- [x] Passport\n\n" +
+		"- [ ] Charger\n\n" +
+		"- [ ] Sunscreen\n\n" +
+		"Map of the area:\n\n" +
+		"![map.png](" + AttachmentLink(attachment.Key) + ")\n\n" +
+		"See [the **guide**](https://example.com/guide) & bring cash."
+	assert.Equal(t, expected, packing.Content)
+
+	budget := docs["Travel.enex/Budget"]
+	assert.Equal(t, "| Item | Cost |\n| --- | --- |\n| Hotel | 400 |\n\n```\ntotal = 400\nsplit = total / 2\n```", budget.Content)
+}
+
+func TestENEXSourceDecodesResources(t *testing.T) {
+	docs := openENEX(t, "testdata/enex")
+
+	attachment := docs["Travel.enex/Packing list"].Attachments[0]
+	data, err := attachment.Load()
+	require.NoError(t, err)
+
+	// Resources are referenced by the MD5 hash of their content
+	sum := md5.Sum(data)
+	assert.Equal(t, "ee76702403cd15dbc71587365494cbe5", hex.EncodeToString(sum[:]))
+	assert.Equal(t, []byte("\x89PNG"), data[:4])
+}
+
+func TestENEXSourceSingleFile(t *testing.T) {
+	docs := openENEX(t, "testdata/enex/Recipes.enex")
+	require.Len(t, docs, 1)
+	assert.Equal(t, "Recipes", docs["Recipes.enex/Pancakes"].Notebook)
+	assert.Equal(t, "Mix *flour*, milk and eggs.\n\n1. Whisk\n2. Fry", docs["Recipes.enex/Pancakes"].Content)
+}
+
+func TestENEXSourceUnreferencedResourcesAndDuplicateTitles(t *testing.T) {
+	note := enexNote{Title: "Scan", Content: "<en-note><div>Receipt</div></en-note>"}
+	note.Resources = []enexResource{{Mime: "application/pdf"}}
+	note.Resources[0].Data.Encoding = "base64"
+	note.Resources[0].Data.Value = "JVBERi0xLjQK"
+	note.Resources[0].Attributes.FileName = "receipt [1].pdf"
+
+	source := &ENEXSource{}
+	doc, warnings := source.document("Inbox.enex", "Inbox", note)
+	assert.Empty(t, warnings)
+	require.Len(t, doc.Attachments, 1)
+	assert.Equal(t, "Receipt\n\n- [receipt 1.pdf]("+AttachmentLink(doc.Attachments[0].Key)+")", doc.Content)
+
+	keys := make(map[string]bool)
+	assert.Equal(t, "Inbox.enex/Scan", uniqueKey(keys, "Inbox.enex/Scan"))
+	assert.Equal(t, "Inbox.enex/Scan (2)", uniqueKey(keys, "Inbox.enex/Scan"))
+}
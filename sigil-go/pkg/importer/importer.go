@@ -0,0 +1,303 @@
+// Package importer converts exports from other note apps into sigil notes.
+//
+// Every format is read by a Source which turns the export into a list of
+// Documents. The Pipeline then persists documents the same way regardless of
+// where they came from: notebooks and sections are created by name, tags are
+// attached, attachments are stored through the file store and links between
+// documents are rewritten to point at the created notes.
+package importer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+)
+
+// Supported import formats
+const (
+	FormatMarkdown = "markdown"
+	FormatENEX     = "enex"
+	FormatJoplin   = "joplin"
+	FormatNotion   = "notion"
+)
+
+const (
+	// Placeholder schemes used in document content until the pipeline knows the
+	// IDs of the created notes and files
+	noteLinkScheme       = "sigil-import-note:"
+	attachmentLinkScheme = "sigil-import-file:"
+
+	maxNoteSize       = 10 << 20
+	maxAttachmentSize = 50 << 20
+)
+
+// Document is a single note read from an export, before it is persisted
+type Document struct {
+	// Key identifies the document within its source, e.g. its path in the archive
+	Key       string
+	Title     string
+	Content   string
+	Notebook  string
+	Section   string
+	Tags      []string
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+	Published bool
+
+	// Attachments referenced from Content through AttachmentLink
+	Attachments []Attachment
+}
+
+// Attachment is a file referenced from a document
+type Attachment struct {
+	// Key identifies the attachment within its source so it is only stored once
+	Key  string
+	Name string
+	Load func() ([]byte, error)
+}
+
+// Source reads the documents contained in an export
+type Source interface {
+	// Documents returns every document in the export. Items that can't be read
+	// are reported as import errors rather than failing the whole import.
+	Documents() ([]Document, []models.ImportError, error)
+	Close() error
+}
+
+var placeholderLinkRegex = regexp.MustCompile(`\((sigil-import-(?:note|file):)([^)\s]*)\)`)
+
+// NoteLink returns the link target used in document content to reference
+// another document from the same source
+func NoteLink(key string) string {
+	return noteLinkScheme + url.PathEscape(key)
+}
+
+// AttachmentLink returns the link target used in document content to reference
+// an attachment
+func AttachmentLink(key string) string {
+	return attachmentLinkScheme + url.PathEscape(key)
+}
+
+// replacePlaceholderLinks rewrites placeholder link targets of the given scheme.
+// Links the replace function doesn't know are left untouched.
+func replacePlaceholderLinks(content, scheme string, replace func(key string) (string, bool)) string {
+	if !strings.Contains(content, scheme) {
+		return content
+	}
+
+	return placeholderLinkRegex.ReplaceAllStringFunc(content, func(match string) string {
+		parts := placeholderLinkRegex.FindStringSubmatch(match)
+		if parts[1] != scheme {
+			return match
+		}
+
+		key, err := url.PathUnescape(parts[2])
+		if err != nil {
+			return match
+		}
+
+		target, ok := replace(key)
+		if !ok {
+			return match
+		}
+		return "(" + target + ")"
+	})
+}
+
+// IsSupportedFormat reports whether an import format is known
+func IsSupportedFormat(format string) bool {
+	switch format {
+	case FormatMarkdown, FormatENEX, FormatJoplin, FormatNotion:
+		return true
+	}
+	return false
+}
+
+// DetectFormat guesses the format of an export from its file name, falling back
+// to markdown for archives and folders
+func DetectFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".enex":
+		return FormatENEX
+	case ".jex":
+		return FormatJoplin
+	}
+	return FormatMarkdown
+}
+
+// Open creates a source for the export at path, which is an archive, an
+// extracted directory or a single exported file
+func Open(format, path string) (Source, error) {
+	if !IsSupportedFormat(format) {
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+
+	// Joplin exports are tar archives rather than zips
+	open := openFS
+	if format == FormatJoplin {
+		open = openTar
+	}
+
+	fsys, closer, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatENEX:
+		return NewENEXSource(fsys, closer), nil
+	case FormatJoplin:
+		return NewJoplinSource(fsys, closer), nil
+	case FormatNotion:
+		return NewNotionSource(fsys, closer), nil
+	default:
+		return NewMarkdownSource(fsys, closer), nil
+	}
+}
+
+// openFS exposes a zip archive, a directory or a single file as a filesystem
+func openFS(path string) (fs.FS, io.Closer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.IsDir() {
+		return os.DirFS(path), io.NopCloser(nil), nil
+	}
+
+	archive, err := zip.OpenReader(path)
+	if errors.Is(err, zip.ErrFormat) {
+		return fileFS{path: path}, io.NopCloser(nil), nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	return archive, archive, nil
+}
+
+// openTar extracts a tar archive into a temporary directory, which is removed
+// when the returned closer is closed. Directories are used as they are.
+func openTar(path string) (fs.FS, io.Closer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return os.DirFS(path), io.NopCloser(nil), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	dir, err := os.MkdirTemp("", "sigil-import-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	closer := tempDir(dir)
+
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			closer.Close()
+			return nil, nil, fmt.Errorf("failed to open archive: %w", err)
+		}
+
+		name := filepath.FromSlash(header.Name)
+		if header.Typeflag != tar.TypeReg || !filepath.IsLocal(name) {
+			continue
+		}
+		if err := extractFile(filepath.Join(dir, name), reader); err != nil {
+			closer.Close()
+			return nil, nil, fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+	}
+
+	return os.DirFS(dir), closer, nil
+}
+
+func extractFile(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// tempDir is a temporary directory that is removed on Close
+type tempDir string
+
+func (d tempDir) Close() error {
+	return os.RemoveAll(string(d))
+}
+
+// fileFS exposes a single file as a filesystem containing only that file, so
+// plain exports are read the same way as archives
+type fileFS struct {
+	path string
+}
+
+func (f fileFS) Open(name string) (fs.File, error) {
+	switch name {
+	case ".":
+		return os.Open(filepath.Dir(f.path))
+	case filepath.Base(f.path):
+		return os.Open(f.path)
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (f fileFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	return []fs.DirEntry{fs.FileInfoToDirEntry(info)}, nil
+}
+
+// readFile reads a file from fsys, refusing files larger than limit
+func readFile(fsys fs.FS, name string, limit int64) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("file is larger than %d bytes", limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,261 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+)
+
+// Joplin item types, see BaseModel.TYPE_* in Joplin
+const (
+	joplinTypeNote     = "1"
+	joplinTypeFolder   = "2"
+	joplinTypeResource = "4"
+	joplinTypeTag      = "5"
+	joplinTypeNoteTag  = "6"
+)
+
+var (
+	joplinMetadataRegex = regexp.MustCompile(`^([a-z0-9_]+): ?(.*)$`)
+	// Joplin links to notes and resources by ID: [text](:/0123abcd...)
+	joplinLinkRegex = regexp.MustCompile(`\(:/([0-9a-fA-F]{32})(#[^)\s]*)?\)`)
+)
+
+// JoplinSource reads Joplin exports, either a JEX archive or a RAW export
+// directory. Top-level folders become notebooks and nested folders sections.
+type JoplinSource struct {
+	fsys   fs.FS
+	closer io.Closer
+}
+
+func NewJoplinSource(fsys fs.FS, closer io.Closer) *JoplinSource {
+	return &JoplinSource{fsys: fsys, closer: closer}
+}
+
+func (s *JoplinSource) Close() error {
+	return s.closer.Close()
+}
+
+// joplinItem is a single serialized Joplin item: a title line, an optional
+// body and a block of metadata lines
+type joplinItem struct {
+	title    string
+	body     string
+	metadata map[string]string
+}
+
+func (item joplinItem) id() string {
+	return item.metadata["id"]
+}
+
+// Documents implements Source
+func (s *JoplinSource) Documents() ([]Document, []models.ImportError, error) {
+	entries, err := fs.ReadDir(s.fsys, ".")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	var importErrors []models.ImportError
+	var notes []joplinItem
+	folders := make(map[string]joplinItem)
+	resources := make(map[string]joplinItem)
+	tags := make(map[string]string)
+	noteTags := make(map[string][]string)
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isMarkdownFile(entry.Name()) {
+			continue
+		}
+
+		data, err := readFile(s.fsys, entry.Name(), maxNoteSize)
+		if err != nil {
+			importErrors = append(importErrors, models.ImportError{Path: entry.Name(), Message: err.Error()})
+			continue
+		}
+
+		item := parseJoplinItem(string(data))
+		if item.metadata["encryption_applied"] == "1" {
+			importErrors = append(importErrors, models.ImportError{Path: entry.Name(), Message: "encrypted items can't be imported"})
+			continue
+		}
+
+		switch item.metadata["type_"] {
+		case joplinTypeNote:
+			notes = append(notes, item)
+		case joplinTypeFolder:
+			folders[item.id()] = item
+		case joplinTypeResource:
+			resources[item.id()] = item
+		case joplinTypeTag:
+			tags[item.id()] = item.title
+		case joplinTypeNoteTag:
+			noteID := item.metadata["note_id"]
+			noteTags[noteID] = append(noteTags[noteID], item.metadata["tag_id"])
+		}
+	}
+
+	if len(notes) == 0 && len(folders) == 0 {
+		return nil, nil, fmt.Errorf("no Joplin items found in export")
+	}
+
+	resourceFiles := s.resourceFiles()
+
+	// Notes are keyed by their folder path and title, which reads better in
+	// import reports than Joplin's IDs
+	keys := make(map[string]string, len(notes))
+	usedKeys := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		folderPath := joplinFolderPath(folders, note.metadata["parent_id"])
+		keys[note.id()] = uniqueKey(usedKeys, path.Join(append(folderPath, joplinTitle(note))...))
+	}
+
+	documents := make([]Document, 0, len(notes))
+	for _, note := range notes {
+		doc := Document{
+			Key:       keys[note.id()],
+			Title:     joplinTitle(note),
+			CreatedAt: joplinTime(note.metadata, "user_created_time", "created_time"),
+			UpdatedAt: joplinTime(note.metadata, "user_updated_time", "updated_time"),
+		}
+
+		folderPath := joplinFolderPath(folders, note.metadata["parent_id"])
+		if len(folderPath) > 0 {
+			doc.Notebook = folderPath[0]
+			doc.Section = strings.Join(folderPath[1:], " / ")
+		}
+
+		var tagNames []string
+		for _, tagID := range noteTags[note.id()] {
+			if name, ok := tags[tagID]; ok {
+				tagNames = append(tagNames, name)
+			}
+		}
+		doc.Tags = normalizeTags(tagNames)
+
+		attachments := make(map[string]bool)
+		doc.Content = transformOutsideCode(note.body, func(text string) string {
+			return joplinLinkRegex.ReplaceAllStringFunc(text, func(match string) string {
+				id := strings.ToLower(joplinLinkRegex.FindStringSubmatch(match)[1])
+				if key, ok := keys[id]; ok {
+					return "(" + NoteLink(key) + ")"
+				}
+
+				resource, ok := resources[id]
+				filePath, hasFile := resourceFiles[id]
+				if !ok || !hasFile {
+					return match
+				}
+				if !attachments[id] {
+					attachments[id] = true
+					doc.Attachments = append(doc.Attachments, Attachment{
+						Key:  filePath,
+						Name: joplinResourceName(resource, filePath),
+						Load: func() ([]byte, error) {
+							return readFile(s.fsys, filePath, maxAttachmentSize)
+						},
+					})
+				}
+				return "(" + AttachmentLink(filePath) + ")"
+			})
+		})
+
+		documents = append(documents, doc)
+	}
+
+	return documents, importErrors, nil
+}
+
+// resourceFiles maps resource IDs to their files in the resources folder,
+// which are named after the ID with the original extension
+func (s *JoplinSource) resourceFiles() map[string]string {
+	files := make(map[string]string)
+	entries, err := fs.ReadDir(s.fsys, "resources")
+	if err != nil {
+		return files
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		files[strings.ToLower(id)] = path.Join("resources", entry.Name())
+	}
+	return files
+}
+
+// parseJoplinItem splits a serialized item into its title, body and the
+// metadata block at the end
+func parseJoplinItem(content string) joplinItem {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	item := joplinItem{metadata: make(map[string]string)}
+	end := len(lines)
+	for end > 0 {
+		match := joplinMetadataRegex.FindStringSubmatch(lines[end-1])
+		if match == nil {
+			break
+		}
+		item.metadata[match[1]] = match[2]
+		end--
+	}
+
+	header := lines[:end]
+	if len(header) > 0 {
+		item.title = strings.TrimSpace(header[0])
+	}
+	if len(header) > 2 {
+		item.body = strings.TrimSpace(strings.Join(header[2:], "\n"))
+	}
+	return item
+}
+
+// joplinFolderPath returns the titles of a folder and its parents, starting
+// with the top-level folder
+func joplinFolderPath(folders map[string]joplinItem, folderID string) []string {
+	var titles []string
+	seen := make(map[string]bool)
+	for folderID != "" && !seen[folderID] {
+		folder, ok := folders[folderID]
+		if !ok {
+			break
+		}
+		seen[folderID] = true
+		titles = append([]string{joplinTitle(folder)}, titles...)
+		folderID = folder.metadata["parent_id"]
+	}
+	return titles
+}
+
+func joplinTitle(item joplinItem) string {
+	if item.title == "" {
+		return "Untitled"
+	}
+	return item.title
+}
+
+func joplinResourceName(resource joplinItem, filePath string) string {
+	for _, name := range []string{resource.metadata["filename"], resource.title} {
+		if strings.TrimSpace(name) != "" {
+			return strings.TrimSpace(name)
+		}
+	}
+	return path.Base(filePath)
+}
+
+// joplinTime returns the first metadata key holding a valid timestamp
+func joplinTime(metadata map[string]string, keys ...string) *time.Time {
+	for _, key := range keys {
+		if t, err := time.Parse(time.RFC3339, metadata[key]); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoplinSourceStructure(t *testing.T) {
+	source, err := Open(FormatJoplin, "testdata/joplin")
+	require.NoError(t, err)
+	defer source.Close()
+
+	docs := documentsByKey(t, source)
+	require.Len(t, docs, 2)
+
+	roadmap := docs["Work/Projects/Roadmap"]
+	assert.Equal(t, "Roadmap", roadmap.Title)
+	assert.Equal(t, "Work", roadmap.Notebook)
+	assert.Equal(t, "Projects", roadmap.Section)
+	assert.Equal(t, []string{"planning"}, roadmap.Tags)
+	require.NotNil(t, roadmap.CreatedAt)
+	assert.Equal(t, time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC), *roadmap.CreatedAt)
+	require.NotNil(t, roadmap.UpdatedAt)
+	assert.Equal(t, time.Date(2021, 5, 6, 12, 30, 0, 0, time.UTC), *roadmap.UpdatedAt)
+
+	standup := docs["Work/Standup"]
+	assert.Equal(t, "Work", standup.Notebook)
+	assert.Equal(t, "", standup.Section)
+	assert.Empty(t, standup.Tags)
+}
+
+func TestJoplinSourceLinksAndResources(t *testing.T) {
+	source, err := Open(FormatJoplin, "testdata/joplin")
+	require.NoError(t, err)
+	defer source.Close()
+
+	docs := documentsByKey(t, source)
+
+	roadmap := docs["Work/Projects/Roadmap"]
+	require.Len(t, roadmap.Attachments, 1)
+	attachment := roadmap.Attachments[0]
+	assert.Equal(t, "diagram.png", attachment.Name)
+
+	assert.Equal(t, "Plans for the year, see [standup]("+NoteLink("Work/Standup")+") for updates.\n\n"+
+		"![diagram.png]("+AttachmentLink(attachment.Key)+")\n\n"+
+		"Status: draft", roadmap.Content)
+
+	data, err := attachment.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("png-data"), data)
+
+	assert.Contains(t, docs["Work/Standup"].Content, "`:/bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb1`")
+}
+
+func TestJoplinSourceJEXArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "export.jex")
+	writeTar(t, archivePath, "testdata/joplin")
+
+	source, err := Open(FormatJoplin, archivePath)
+	require.NoError(t, err)
+
+	docs := documentsByKey(t, source)
+	assert.Len(t, docs, 2)
+	data, err := docs["Work/Projects/Roadmap"].Attachments[0].Load()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("png-data"), data)
+
+	// The extracted files are removed with the source
+	tempDir := string(source.(*JoplinSource).closer.(tempDir))
+	require.NoError(t, source.Close())
+	assert.NoDirExists(t, tempDir)
+}
+
+func TestParseJoplinItem(t *testing.T) {
+	item := parseJoplinItem("Title\n\nBody\n\nNote: kept\n\nid: 123\nparent_id: \ntype_: 1\n")
+
+	assert.Equal(t, "Title", item.title)
+	assert.Equal(t, "Body\n\nNote: kept", item.body)
+	assert.Equal(t, map[string]string{"id": "123", "parent_id": "", "type_": "1"}, item.metadata)
+}
+
+func writeTar(t *testing.T, archivePath, dir string) {
+	t.Helper()
+
+	file, err := os.Create(archivePath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	writer := tar.NewWriter(file)
+	require.NoError(t, writer.AddFS(os.DirFS(dir)))
+	require.NoError(t, writer.Close())
+}
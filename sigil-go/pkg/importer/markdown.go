@@ -0,0 +1,474 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+)
+
+var (
+	// ![[image.png]] or ![[image.png|300]]
+	embedRegex = regexp.MustCompile(`!\[\[([^\[\]]+)\]\]`)
+	// [[Note]], [[Note|Alias]] or [[Note#Heading]]
+	wikilinkRegex = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+	// [text](target), ![alt](target), [text](<target with spaces>) and [text](target "title")
+	markdownLinkRegex = regexp.MustCompile(`(!?)\[([^\[\]]*)\]\((<[^<>]+>|[^()\s]+)(\s+"[^"]*")?\)`)
+	// #tag, #nested/tag - must contain at least one non-digit like in Obsidian
+	inlineTagRegex = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_\-/]*[\p{L}_\-/][\p{L}\p{N}_\-/]*)`)
+	// Anything with a scheme (http:, mailto:, data:) is left alone
+	schemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.\-]*:`)
+)
+
+// MarkdownSource reads a folder of markdown files such as an Obsidian vault.
+// Top-level folders become notebooks and nested folders become sections.
+type MarkdownSource struct {
+	fsys   fs.FS
+	closer io.Closer
+
+	// root is the folder inside fsys that holds the vault
+	root  string
+	notes []string
+	// lookups from lowercased path and name to the path in fsys
+	notesByPath  map[string]string
+	notesByName  map[string]string
+	filesByPath  map[string]string
+	filesByName  map[string]string
+	notesByAlias map[string]string
+}
+
+func NewMarkdownSource(fsys fs.FS, closer io.Closer) *MarkdownSource {
+	return &MarkdownSource{
+		fsys:         fsys,
+		closer:       closer,
+		notesByPath:  make(map[string]string),
+		notesByName:  make(map[string]string),
+		filesByPath:  make(map[string]string),
+		filesByName:  make(map[string]string),
+		notesByAlias: make(map[string]string),
+	}
+}
+
+func (s *MarkdownSource) Close() error {
+	return s.closer.Close()
+}
+
+// Documents implements Source
+func (s *MarkdownSource) Documents() ([]Document, []models.ImportError, error) {
+	if err := s.index(); err != nil {
+		return nil, nil, err
+	}
+
+	var documents []Document
+	var importErrors []models.ImportError
+
+	// Front matter is read up front so aliases can be resolved from any note
+	raw := make(map[string]string, len(s.notes))
+	frontMatters := make(map[string]map[string]any, len(s.notes))
+	for _, notePath := range s.notes {
+		data, err := readFile(s.fsys, notePath, maxNoteSize)
+		if err != nil {
+			importErrors = append(importErrors, models.ImportError{Path: s.relative(notePath), Message: err.Error()})
+			continue
+		}
+
+		frontMatter, body, err := utils.SplitFrontMatter(string(data))
+		if err != nil {
+			importErrors = append(importErrors, models.ImportError{
+				Path:    s.relative(notePath),
+				Message: fmt.Sprintf("invalid front matter, imported as plain text: %v", err),
+			})
+			frontMatter, body = nil, string(data)
+		}
+
+		raw[notePath] = body
+		frontMatters[notePath] = frontMatter
+		for _, alias := range stringList(frontMatter["aliases"]) {
+			s.addLookup(s.notesByAlias, strings.ToLower(alias), notePath)
+		}
+	}
+
+	for _, notePath := range s.notes {
+		body, ok := raw[notePath]
+		if !ok {
+			continue
+		}
+		documents = append(documents, s.document(notePath, frontMatters[notePath], body))
+	}
+
+	return documents, importErrors, nil
+}
+
+// index walks the filesystem and records every note and attachment
+func (s *MarkdownSource) index() error {
+	s.root = s.detectRoot()
+
+	return fs.WalkDir(s.fsys, s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != s.root && isHidden(d.Name()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		withoutExt := strings.TrimSuffix(p, path.Ext(p))
+		if isMarkdownFile(p) {
+			s.notes = append(s.notes, p)
+			s.notesByPath[strings.ToLower(s.relative(withoutExt))] = p
+			s.addLookup(s.notesByName, strings.ToLower(path.Base(withoutExt)), p)
+		} else {
+			s.filesByPath[strings.ToLower(p)] = p
+			s.addLookup(s.filesByName, strings.ToLower(path.Base(p)), p)
+		}
+		return nil
+	})
+}
+
+// detectRoot skips the wrapping folder that zipping a vault usually adds
+func (s *MarkdownSource) detectRoot() string {
+	entries, err := fs.ReadDir(s.fsys, ".")
+	if err != nil {
+		return "."
+	}
+
+	var dirs []fs.DirEntry
+	for _, entry := range entries {
+		if isHidden(entry.Name()) {
+			continue
+		}
+		if !entry.IsDir() {
+			return "."
+		}
+		dirs = append(dirs, entry)
+	}
+	if len(dirs) != 1 {
+		return "."
+	}
+
+	// Only treat the folder as the vault itself when it looks like one,
+	// otherwise it's a notebook
+	children, err := fs.ReadDir(s.fsys, dirs[0].Name())
+	if err != nil {
+		return "."
+	}
+	for _, child := range children {
+		if child.IsDir() && (child.Name() == ".obsidian" || !isHidden(child.Name())) {
+			return dirs[0].Name()
+		}
+	}
+	return "."
+}
+
+// addLookup keeps the shortest path for a name, which is how Obsidian resolves
+// ambiguous links
+func (s *MarkdownSource) addLookup(lookup map[string]string, key, p string) {
+	if existing, ok := lookup[key]; ok && len(existing) <= len(p) {
+		return
+	}
+	lookup[key] = p
+}
+
+func (s *MarkdownSource) relative(p string) string {
+	if s.root == "." {
+		return p
+	}
+	return strings.TrimPrefix(p, s.root+"/")
+}
+
+func (s *MarkdownSource) document(notePath string, frontMatter map[string]any, body string) Document {
+	relPath := s.relative(notePath)
+	doc := Document{
+		Key:   notePath,
+		Title: strings.TrimSuffix(path.Base(notePath), path.Ext(notePath)),
+	}
+
+	if title, ok := frontMatter["title"].(string); ok && strings.TrimSpace(title) != "" {
+		doc.Title = strings.TrimSpace(title)
+	}
+	if published, ok := frontMatter["published"].(bool); ok {
+		doc.Published = published
+	}
+
+	doc.CreatedAt = firstTime(frontMatter, "created", "date", "created_at")
+	doc.UpdatedAt = firstTime(frontMatter, "updated", "modified", "updated_at", "lastmod")
+	if doc.UpdatedAt == nil {
+		if info, err := fs.Stat(s.fsys, notePath); err == nil && !info.ModTime().IsZero() {
+			modTime := info.ModTime()
+			doc.UpdatedAt = &modTime
+		}
+	}
+
+	dirs := strings.Split(path.Dir(relPath), "/")
+	if dirs[0] != "." {
+		doc.Notebook = dirs[0]
+		if len(dirs) > 1 {
+			doc.Section = strings.Join(dirs[1:], " / ")
+		}
+	}
+
+	tags := append(stringList(frontMatter["tags"]), stringList(frontMatter["tag"])...)
+	tags = append(tags, inlineTags(body)...)
+	doc.Tags = normalizeTags(tags)
+
+	attachments := make(map[string]bool)
+	addAttachment := func(filePath string) string {
+		if !attachments[filePath] {
+			attachments[filePath] = true
+			doc.Attachments = append(doc.Attachments, Attachment{
+				Key:  filePath,
+				Name: path.Base(filePath),
+				Load: func() ([]byte, error) {
+					return readFile(s.fsys, filePath, maxAttachmentSize)
+				},
+			})
+		}
+		return AttachmentLink(filePath)
+	}
+
+	doc.Content = transformOutsideCode(body, func(text string) string {
+		return s.rewriteLinks(text, path.Dir(notePath), addAttachment)
+	})
+
+	return doc
+}
+
+func (s *MarkdownSource) rewriteLinks(text, dir string, addAttachment func(string) string) string {
+	text = embedRegex.ReplaceAllStringFunc(text, func(match string) string {
+		target, label := splitWikilink(embedRegex.FindStringSubmatch(match)[1])
+		if filePath, ok := s.resolveFile(target, dir); ok {
+			return "![" + path.Base(filePath) + "](" + addAttachment(filePath) + ")"
+		}
+		if notePath, ok := s.resolveNote(target, dir); ok {
+			return "[" + label + "](" + NoteLink(notePath) + ")"
+		}
+		return match
+	})
+
+	text = wikilinkRegex.ReplaceAllStringFunc(text, func(match string) string {
+		target, label := splitWikilink(wikilinkRegex.FindStringSubmatch(match)[1])
+		if notePath, ok := s.resolveNote(target, dir); ok {
+			return "[" + label + "](" + NoteLink(notePath) + ")"
+		}
+		if filePath, ok := s.resolveFile(target, dir); ok {
+			return "[" + label + "](" + addAttachment(filePath) + ")"
+		}
+		return match
+	})
+
+	return markdownLinkRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := markdownLinkRegex.FindStringSubmatch(match)
+		bang, label, target := parts[1], parts[2], strings.Trim(parts[3], "<>")
+
+		if strings.HasPrefix(target, "#") || strings.HasPrefix(target, "/") || schemeRegex.MatchString(target) {
+			return match
+		}
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		target, _, _ = strings.Cut(target, "#")
+
+		if isMarkdownFile(target) {
+			if notePath, ok := s.resolveNote(strings.TrimSuffix(target, path.Ext(target)), dir); ok {
+				return bang + "[" + label + "](" + NoteLink(notePath) + ")"
+			}
+			return match
+		}
+		if filePath, ok := s.resolveFile(target, dir); ok {
+			return bang + "[" + label + "](" + addAttachment(filePath) + ")"
+		}
+		return match
+	})
+}
+
+// resolveNote finds a note by path relative to the linking note, by path from
+// the vault root, by name or by alias
+func (s *MarkdownSource) resolveNote(target, dir string) (string, bool) {
+	target = strings.TrimSuffix(strings.TrimSpace(target), ".md")
+	if target == "" {
+		return "", false
+	}
+
+	candidates := []string{
+		strings.ToLower(s.relative(path.Join(dir, target))),
+		strings.ToLower(target),
+	}
+	for _, candidate := range candidates {
+		if notePath, ok := s.notesByPath[candidate]; ok {
+			return notePath, true
+		}
+	}
+
+	if !strings.Contains(target, "/") {
+		if notePath, ok := s.notesByName[strings.ToLower(target)]; ok {
+			return notePath, true
+		}
+	}
+	if notePath, ok := s.notesByAlias[strings.ToLower(target)]; ok {
+		return notePath, true
+	}
+	return "", false
+}
+
+// resolveFile finds an attachment relative to the linking note, from the vault
+// root or by file name
+func (s *MarkdownSource) resolveFile(target, dir string) (string, bool) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", false
+	}
+
+	candidates := []string{
+		strings.ToLower(path.Join(dir, target)),
+		strings.ToLower(path.Join(s.root, target)),
+	}
+	for _, candidate := range candidates {
+		if filePath, ok := s.filesByPath[candidate]; ok {
+			return filePath, true
+		}
+	}
+
+	if filePath, ok := s.filesByName[strings.ToLower(path.Base(target))]; ok {
+		return filePath, true
+	}
+	return "", false
+}
+
+// splitWikilink splits "Note#Heading|Alias" into the link target and the text to display
+func splitWikilink(inner string) (string, string) {
+	target, alias, hasAlias := strings.Cut(inner, "|")
+	target, _, _ = strings.Cut(target, "#")
+	target = strings.TrimSpace(target)
+
+	if hasAlias && strings.TrimSpace(alias) != "" {
+		return target, strings.TrimSpace(alias)
+	}
+	return target, path.Base(target)
+}
+
+// transformOutsideCode applies fn to all text that is not inside fenced code
+// blocks or inline code spans
+func transformOutsideCode(content string, fn func(string) string) string {
+	lines := strings.Split(content, "\n")
+	fence := ""
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		segments := strings.Split(line, "`")
+		for j := range segments {
+			// Odd segments are code spans, unless the last backtick is unmatched
+			inCode := j%2 == 1 && j != len(segments)-1
+			if !inCode {
+				segments[j] = fn(segments[j])
+			}
+		}
+		lines[i] = strings.Join(segments, "`")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// inlineTags returns the #tags used in markdown content
+func inlineTags(content string) []string {
+	var tags []string
+	transformOutsideCode(content, func(text string) string {
+		for _, match := range inlineTagRegex.FindAllStringSubmatch(text, -1) {
+			tags = append(tags, match[1])
+		}
+		return text
+	})
+	return tags
+}
+
+// normalizeTags strips leading hashes and drops duplicates, keeping order
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(tag), "#"))
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// stringList reads a front matter value that is either a list or a comma or
+// space separated string
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	case []any:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			} else if item != nil {
+				list = append(list, fmt.Sprint(item))
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+var frontMatterTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// firstTime returns the first front matter key holding a valid date
+func firstTime(frontMatter map[string]any, keys ...string) *time.Time {
+	for _, key := range keys {
+		switch v := frontMatter[key].(type) {
+		case time.Time:
+			return &v
+		case string:
+			for _, layout := range frontMatterTimeLayouts {
+				if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+					return &t
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isMarkdownFile(p string) bool {
+	ext := strings.ToLower(path.Ext(p))
+	return ext == ".md" || ext == ".markdown"
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "__MACOSX"
+}
@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"io"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func documentsByKey(t *testing.T, source Source) map[string]Document {
+	t.Helper()
+
+	documents, importErrors, err := source.Documents()
+	require.NoError(t, err)
+	assert.Empty(t, importErrors)
+
+	byKey := make(map[string]Document)
+	for _, doc := range documents {
+		byKey[doc.Key] = doc
+	}
+	return byKey
+}
+
+func TestMarkdownSourceFolderStructure(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Vault/.obsidian/app.json":            {Data: []byte("{}")},
+		"Vault/Inbox.md":                      {Data: []byte("Loose note")},
+		"Vault/Work/Meetings.md":              {Data: []byte("Notebook note")},
+		"Vault/Work/Projects/Sigil.md":        {Data: []byte("Section note")},
+		"Vault/Work/Projects/2024/Archive.md": {Data: []byte("Nested section note")},
+	}
+
+	docs := documentsByKey(t, NewMarkdownSource(fsys, io.NopCloser(nil)))
+	require.Len(t, docs, 4)
+
+	assert.Equal(t, "", docs["Vault/Inbox.md"].Notebook)
+	assert.Equal(t, "Inbox", docs["Vault/Inbox.md"].Title)
+
+	assert.Equal(t, "Work", docs["Vault/Work/Meetings.md"].Notebook)
+	assert.Equal(t, "", docs["Vault/Work/Meetings.md"].Section)
+
+	assert.Equal(t, "Work", docs["Vault/Work/Projects/Sigil.md"].Notebook)
+	assert.Equal(t, "Projects", docs["Vault/Work/Projects/Sigil.md"].Section)
+
+	assert.Equal(t, "Projects / 2024", docs["Vault/Work/Projects/2024/Archive.md"].Section)
+}
+
+func TestMarkdownSourceSingleFolderIsNotebook(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Recipes/Pancakes.md": {Data: []byte("Flour, milk, eggs")},
+	}
+
+	docs := documentsByKey(t, NewMarkdownSource(fsys, io.NopCloser(nil)))
+	assert.Equal(t, "Recipes", docs["Recipes/Pancakes.md"].Notebook)
+}
+
+func TestMarkdownSourceFrontMatter(t *testing.T) {
+	content := `---
+title: Weekly review
+tags: [review, "#work"]
+created: 2024-01-02T10:00:00Z
+updated: 2024-01-05
+published: true
+---
+Body with #inline-tag and #review again.
+
+` + "```" + `
+#not-a-tag inside code
+` + "```" + `
+Also ` + "`#code`" + ` and #2024 are ignored.`
+
+	fsys := fstest.MapFS{"review.md": {Data: []byte(content)}}
+
+	doc := documentsByKey(t, NewMarkdownSource(fsys, io.NopCloser(nil)))["review.md"]
+
+	assert.Equal(t, "Weekly review", doc.Title)
+	assert.True(t, doc.Published)
+	assert.Equal(t, []string{"review", "work", "inline-tag"}, doc.Tags)
+	require.NotNil(t, doc.CreatedAt)
+	assert.Equal(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), *doc.CreatedAt)
+	require.NotNil(t, doc.UpdatedAt)
+	assert.Equal(t, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), *doc.UpdatedAt)
+	assert.NotContains(t, doc.Content, "title: Weekly review")
+}
+
+func TestMarkdownSourceModTimeFallback(t *testing.T) {
+	modified := time.Date(2023, 6, 1, 8, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{"note.md": {Data: []byte("text"), ModTime: modified}}
+
+	doc := documentsByKey(t, NewMarkdownSource(fsys, io.NopCloser(nil)))["note.md"]
+
+	assert.Nil(t, doc.CreatedAt)
+	require.NotNil(t, doc.UpdatedAt)
+	assert.Equal(t, modified, *doc.UpdatedAt)
+}
+
+func TestMarkdownSourceWikilinks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Notes/Index.md": {Data: []byte("See [[Target]], [[Target|the target]], [[Sub/Deep#Heading]] and [[Missing]].\n" +
+			"Alias: [[Nickname]]. Relative: [other](Target.md) and [web](https://example.com)")},
+		"Notes/Target.md":   {Data: []byte("---\naliases: [Nickname]\n---\nTarget")},
+		"Notes/Sub/Deep.md": {Data: []byte("Deep")},
+	}
+
+	doc := documentsByKey(t, NewMarkdownSource(fsys, io.NopCloser(nil)))["Notes/Index.md"]
+
+	target := NoteLink("Notes/Target.md")
+	deep := NoteLink("Notes/Sub/Deep.md")
+	assert.Equal(t, "See [Target]("+target+"), [the target]("+target+"), [Deep]("+deep+") and [[Missing]].\n"+
+		"Alias: [Nickname]("+target+"). Relative: [other]("+target+") and [web](https://example.com)", doc.Content)
+}
+
+func TestMarkdownSourceAttachments(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Trip/Day one.md":   {Data: []byte("![[photo.png]]\n![Map](../assets/map%20v2.png)\n![remote](https://example.com/x.png)\n![[photo.png|300]]")},
+		"Trip/photo.png":    {Data: []byte("png-data")},
+		"assets/map v2.png": {Data: []byte("map-data")},
+	}
+
+	doc := documentsByKey(t, NewMarkdownSource(fsys, io.NopCloser(nil)))["Trip/Day one.md"]
+
+	photo := AttachmentLink("Trip/photo.png")
+	mapLink := AttachmentLink("assets/map v2.png")
+	assert.Equal(t, "![photo.png]("+photo+")\n![Map]("+mapLink+")\n![remote](https://example.com/x.png)\n![photo.png]("+photo+")", doc.Content)
+
+	require.Len(t, doc.Attachments, 2)
+	assert.Equal(t, "photo.png", doc.Attachments[0].Name)
+	data, err := doc.Attachments[0].Load()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("png-data"), data)
+}
+
+func TestMarkdownSourceInvalidFrontMatter(t *testing.T) {
+	fsys := fstest.MapFS{"broken.md": {Data: []byte("---\ntags: [unclosed\n---\nBody")}}
+
+	documents, importErrors, err := NewMarkdownSource(fsys, io.NopCloser(nil)).Documents()
+	require.NoError(t, err)
+	require.Len(t, documents, 1)
+	require.Len(t, importErrors, 1)
+	assert.Equal(t, "broken.md", importErrors[0].Path)
+	assert.Contains(t, documents[0].Content, "Body")
+}
+
+func TestReplacePlaceholderLinks(t *testing.T) {
+	content := "[a](" + NoteLink("dir/My (1) note.md") + ") ![b](" + AttachmentLink("x.png") + ") [c](" + NoteLink("unknown.md") + ")"
+
+	result := replacePlaceholderLinks(content, noteLinkScheme, func(key string) (string, bool) {
+		if key == "dir/My (1) note.md" {
+			return "/notes/1", true
+		}
+		return "", false
+	})
+
+	assert.Equal(t, "[a](/notes/1) ![b]("+AttachmentLink("x.png")+") [c]("+NoteLink("unknown.md")+")", result)
+}
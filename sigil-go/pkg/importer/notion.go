@@ -0,0 +1,363 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/parser"
+)
+
+var (
+	// Notion appends the page ID to every exported file and folder name
+	notionIDRegex = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+	// Page properties are listed as "Name: value" lines below the title
+	notionPropertyRegex = regexp.MustCompile(`^([^:\n]{1,64}):\s(.*)$`)
+)
+
+var notionTimeLayouts = []string{
+	"January 2, 2006 3:04 PM",
+	"January 2, 2006",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// NotionSource reads Notion "Markdown & CSV" exports. Pages with subpages
+// become notebooks at the top level and sections below it, databases become
+// markdown tables.
+type NotionSource struct {
+	fsys   fs.FS
+	closer io.Closer
+
+	root  string
+	pages map[string]bool
+	files map[string]bool
+	// databases maps a database CSV to its table, see databaseFiles
+	databases map[string]string
+}
+
+func NewNotionSource(fsys fs.FS, closer io.Closer) *NotionSource {
+	return &NotionSource{
+		fsys:      fsys,
+		closer:    closer,
+		pages:     make(map[string]bool),
+		files:     make(map[string]bool),
+		databases: make(map[string]string),
+	}
+}
+
+func (s *NotionSource) Close() error {
+	return s.closer.Close()
+}
+
+// Documents implements Source
+func (s *NotionSource) Documents() ([]Document, []models.ImportError, error) {
+	if err := s.index(); err != nil {
+		return nil, nil, err
+	}
+	if len(s.pages) == 0 && len(s.databases) == 0 {
+		return nil, nil, fmt.Errorf("no Notion pages found in export")
+	}
+
+	var documents []Document
+	var importErrors []models.ImportError
+
+	err := fs.WalkDir(s.fsys, s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		var doc Document
+		switch {
+		case s.pages[p]:
+			doc, err = s.page(p)
+		case s.databases[p] != "":
+			doc, err = s.database(p)
+		default:
+			return nil
+		}
+
+		if err != nil {
+			importErrors = append(importErrors, models.ImportError{Path: s.relative(p), Message: err.Error()})
+			return nil
+		}
+		documents = append(documents, doc)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	return documents, importErrors, nil
+}
+
+func (s *NotionSource) index() error {
+	s.root = s.detectRoot()
+
+	var csvFiles []string
+	err := fs.WalkDir(s.fsys, s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != s.root && isHidden(d.Name()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		switch {
+		case isMarkdownFile(p):
+			s.pages[p] = true
+		case strings.EqualFold(path.Ext(p), ".csv"):
+			csvFiles = append(csvFiles, p)
+		default:
+			s.files[p] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.databaseFiles(csvFiles)
+	return nil
+}
+
+// databaseFiles picks the CSV to import for every database. Newer exports
+// write both the current view and an "_all" file with every row, in which
+// case only the complete one is used.
+func (s *NotionSource) databaseFiles(csvFiles []string) {
+	all := make(map[string]bool)
+	for _, p := range csvFiles {
+		if base, ok := strings.CutSuffix(p, "_all.csv"); ok {
+			all[base] = true
+		}
+	}
+
+	for _, p := range csvFiles {
+		if base, ok := strings.CutSuffix(p, "_all.csv"); ok {
+			s.databases[p] = base + ".csv"
+			continue
+		}
+		if !all[strings.TrimSuffix(p, path.Ext(p))] {
+			s.databases[p] = p
+		}
+	}
+}
+
+// detectRoot skips folders that only wrap the export, like the one created
+// when the export is extracted and zipped again
+func (s *NotionSource) detectRoot() string {
+	root := "."
+	for {
+		entries, err := fs.ReadDir(s.fsys, root)
+		if err != nil {
+			return root
+		}
+
+		var dirs []fs.DirEntry
+		for _, entry := range entries {
+			if isHidden(entry.Name()) {
+				continue
+			}
+			if !entry.IsDir() {
+				return root
+			}
+			dirs = append(dirs, entry)
+		}
+		if len(dirs) != 1 {
+			return root
+		}
+		root = path.Join(root, dirs[0].Name())
+	}
+}
+
+func (s *NotionSource) relative(p string) string {
+	if s.root == "." {
+		return p
+	}
+	return strings.TrimPrefix(p, s.root+"/")
+}
+
+// key returns the document key for a page or database. Links resolve to it.
+func (s *NotionSource) key(p string) string {
+	if s.databases[p] != "" {
+		return s.relative(s.databases[p])
+	}
+	return s.relative(p)
+}
+
+// placement returns the notebook and section of a page. A page with subpages
+// is placed next to them, so its folder becomes the notebook or section.
+func (s *NotionSource) placement(p string) (string, string) {
+	dir := path.Dir(s.relative(p))
+	if s.hasSubpages(p) {
+		dir = strings.TrimSuffix(s.key(p), path.Ext(p))
+	}
+	if dir == "." {
+		return "", ""
+	}
+
+	parts := strings.Split(dir, "/")
+	for i, part := range parts {
+		parts[i] = notionName(part)
+	}
+	return parts[0], strings.Join(parts[1:], " / ")
+}
+
+// isDatabase reports whether a link points at a database, which may have been
+// imported from its "_all" file
+func (s *NotionSource) isDatabase(p string) bool {
+	return s.databases[p] != "" || s.databases[strings.TrimSuffix(p, path.Ext(p))+"_all.csv"] != ""
+}
+
+func (s *NotionSource) hasSubpages(p string) bool {
+	dir := strings.TrimSuffix(s.key(p), path.Ext(p))
+	info, err := fs.Stat(s.fsys, path.Join(s.root, dir))
+	return err == nil && info.IsDir()
+}
+
+func (s *NotionSource) page(p string) (Document, error) {
+	data, err := readFile(s.fsys, p, maxNoteSize)
+	if err != nil {
+		return Document{}, err
+	}
+
+	content := strings.ReplaceAll(strings.TrimPrefix(string(data), "\ufeff"), "\r\n", "\n")
+	doc := Document{
+		Key:   s.key(p),
+		Title: notionName(strings.TrimSuffix(path.Base(p), path.Ext(p))),
+	}
+	doc.Notebook, doc.Section = s.placement(p)
+
+	// Pages start with the title as a heading, which is redundant in a note
+	if first, rest, _ := strings.Cut(content, "\n"); strings.HasPrefix(first, "# ") {
+		doc.Title = strings.TrimSpace(strings.TrimPrefix(first, "# "))
+		content = strings.TrimLeft(rest, "\n")
+	}
+	s.readProperties(&doc, content)
+
+	attachments := make(map[string]bool)
+	dir := path.Dir(p)
+	doc.Content = strings.TrimSpace(transformOutsideCode(content, func(text string) string {
+		return markdownLinkRegex.ReplaceAllStringFunc(text, func(match string) string {
+			parts := markdownLinkRegex.FindStringSubmatch(match)
+			bang, label, target := parts[1], parts[2], strings.Trim(parts[3], "<>")
+			if strings.HasPrefix(target, "#") || schemeRegex.MatchString(target) {
+				return match
+			}
+			if unescaped, err := url.PathUnescape(target); err == nil {
+				target = unescaped
+			}
+			target, _, _ = strings.Cut(target, "#")
+
+			linked := path.Join(dir, target)
+			switch {
+			case s.pages[linked] || s.isDatabase(linked):
+				return bang + "[" + label + "](" + NoteLink(s.relative(linked)) + ")"
+			case s.files[linked]:
+				if !attachments[linked] {
+					attachments[linked] = true
+					doc.Attachments = append(doc.Attachments, Attachment{
+						Key:  linked,
+						Name: path.Base(linked),
+						Load: func() ([]byte, error) {
+							return readFile(s.fsys, linked, maxAttachmentSize)
+						},
+					})
+				}
+				return bang + "[" + label + "](" + AttachmentLink(linked) + ")"
+			}
+			return match
+		})
+	}))
+
+	return doc, nil
+}
+
+// readProperties picks up tags and dates from the property lines Notion writes
+// below the title of database pages. The lines are kept in the content.
+func (s *NotionSource) readProperties(doc *Document, content string) {
+	for _, line := range strings.Split(content, "\n") {
+		match := notionPropertyRegex.FindStringSubmatch(line)
+		if match == nil {
+			return
+		}
+
+		name, value := strings.ToLower(strings.TrimSpace(match[1])), strings.TrimSpace(match[2])
+		switch name {
+		case "tags", "tag":
+			doc.Tags = normalizeTags(strings.Split(value, ","))
+		case "created", "created time":
+			doc.CreatedAt = notionTime(value)
+		case "last edited time", "updated", "last edited":
+			doc.UpdatedAt = notionTime(value)
+		}
+	}
+}
+
+// database converts a database CSV into a note with a markdown table
+func (s *NotionSource) database(p string) (Document, error) {
+	data, err := readFile(s.fsys, p, maxNoteSize)
+	if err != nil {
+		return Document{}, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return Document{}, fmt.Errorf("invalid database CSV: %w", err)
+	}
+
+	name := strings.TrimSuffix(path.Base(s.databases[p]), path.Ext(p))
+	doc := Document{
+		Key:   s.key(p),
+		Title: notionName(name),
+	}
+	doc.Notebook, doc.Section = s.placement(p)
+
+	if len(records) > 0 {
+		for _, record := range records {
+			for i, cell := range record {
+				cell = strings.Join(strings.Fields(cell), " ")
+				record[i] = strings.ReplaceAll(cell, "|", `\|`)
+			}
+		}
+		doc.Content = parser.MarkdownTable(records[0], records[1:])
+	}
+
+	return doc, nil
+}
+
+// notionName strips the page ID Notion appends to file and folder names
+func notionName(name string) string {
+	if stripped := notionIDRegex.ReplaceAllString(name, ""); stripped != "" {
+		return stripped
+	}
+	return name
+}
+
+func notionTime(value string) *time.Time {
+	// Date ranges are written as "start → end"
+	value, _, _ = strings.Cut(value, " → ")
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "(UTC)"))
+	for _, layout := range notionTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	notionProjects = "Projects 0123456789abcdef0123456789abcdef"
+	notionTasks    = notionProjects + "/Tasks fedcba9876543210fedcba9876543210"
+)
+
+func openNotion(t *testing.T) map[string]Document {
+	t.Helper()
+
+	source, err := Open(FormatNotion, "testdata/notion")
+	require.NoError(t, err)
+	t.Cleanup(func() { source.Close() })
+
+	return documentsByKey(t, source)
+}
+
+func TestNotionSourceStructure(t *testing.T) {
+	docs := openNotion(t)
+	require.Len(t, docs, 5)
+
+	tests := []struct {
+		key      string
+		title    string
+		notebook string
+		section  string
+	}{
+		{"Inbox 33333333333333333333333333333333.md", "Inbox", "", ""},
+		{notionProjects + ".md", "Projects", "Projects", ""},
+		{notionProjects + "/Launch 11111111111111111111111111111111.md", "Launch", "Projects", ""},
+		{notionTasks + ".csv", "Tasks", "Projects", "Tasks"},
+		{notionTasks + "/Write docs 22222222222222222222222222222222.md", "Write docs", "Projects", "Tasks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			doc, ok := docs[tt.key]
+			require.True(t, ok, "missing %s", tt.key)
+			assert.Equal(t, tt.title, doc.Title)
+			assert.Equal(t, tt.notebook, doc.Notebook)
+			assert.Equal(t, tt.section, doc.Section)
+		})
+	}
+}
+
+func TestNotionSourcePageContent(t *testing.T) {
+	docs := openNotion(t)
+
+	projects := docs[notionProjects+".md"]
+	require.Len(t, projects.Attachments, 1)
+	assert.Equal(t, "cover.png", projects.Attachments[0].Name)
+
+	assert.Equal(t, "Overview of [Launch]("+NoteLink(notionProjects+"/Launch 11111111111111111111111111111111.md")+
+		") and the [Tasks]("+NoteLink(notionTasks+".csv")+") database.\n\n"+
+		"![cover.png]("+AttachmentLink(projects.Attachments[0].Key)+")\n\n"+
+		"More at [Notion](https://www.notion.so/Projects-0123456789abcdef0123456789abcdef).", projects.Content)
+}
+
+func TestNotionSourceProperties(t *testing.T) {
+	launch := openNotion(t)[notionProjects+"/Launch 11111111111111111111111111111111.md"]
+
+	assert.Equal(t, []string{"release", "q3"}, launch.Tags)
+	require.NotNil(t, launch.CreatedAt)
+	assert.Equal(t, time.Date(2023, 3, 3, 16, 5, 0, 0, time.UTC), *launch.CreatedAt)
+	require.NotNil(t, launch.UpdatedAt)
+	assert.Equal(t, time.Date(2023, 3, 5, 9, 30, 0, 0, time.UTC), *launch.UpdatedAt)
+	assert.Contains(t, launch.Content, "Launch plan")
+}
+
+func TestNotionSourceDatabaseTable(t *testing.T) {
+	tasks := openNotion(t)[notionTasks+".csv"]
+
+	// The complete _all export is used over the current view
+	expected := "| Name | Status | Notes |\n" +
+		"| --- | --- | --- |\n" +
+		"| Write docs | Done | Covers import \\| export |\n" +
+		"| Ship | In progress | Two lines |"
+	assert.Equal(t, expected, tasks.Content)
+}
+
+func TestNotionName(t *testing.T) {
+	assert.Equal(t, "Meeting notes", notionName("Meeting notes 0123456789abcdef0123456789abcdef"))
+	assert.Equal(t, "Plain", notionName("Plain"))
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", notionName("0123456789abcdef0123456789abcdef"))
+}
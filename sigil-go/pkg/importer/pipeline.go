@@ -0,0 +1,376 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// FileStore stores attachment content, see services.FileService
+type FileStore interface {
+	CreateFileFromBytes(ctx context.Context, data []byte, noteID *uuid.UUID, userID uuid.UUID) (*models.FileMetadata, error)
+}
+
+// TagStore resolves tag names to tags, see repositories.TagRepository
+type TagStore interface {
+	FetchOrCreate(ctx context.Context, tagName string) (models.Tag, error)
+}
+
+// Report summarizes the outcome of an import
+type Report struct {
+	Imported int
+	Errors   []models.ImportError
+}
+
+// Pipeline persists documents read from any source
+type Pipeline struct {
+	noteRepo     repositories.NoteRepositoryInterface
+	notebookRepo repositories.NotebookRepositoryInterface
+	sectionRepo  repositories.SectionRepositoryInterface
+	tags         TagStore
+	files        FileStore
+}
+
+func NewPipeline(
+	noteRepo repositories.NoteRepositoryInterface,
+	notebookRepo repositories.NotebookRepositoryInterface,
+	sectionRepo repositories.SectionRepositoryInterface,
+	tags TagStore,
+	files FileStore,
+) *Pipeline {
+	return &Pipeline{
+		noteRepo:     noteRepo,
+		notebookRepo: notebookRepo,
+		sectionRepo:  sectionRepo,
+		tags:         tags,
+		files:        files,
+	}
+}
+
+// importRun holds the state of a single import
+type importRun struct {
+	userID      uuid.UUID
+	noteIDs     map[string]uuid.UUID
+	notebooks   map[string]uuid.UUID
+	sections    map[string]uuid.UUID
+	attachments map[string]uuid.UUID
+}
+
+// Run imports documents for a user. Progress is called after each document.
+// Failing documents are recorded in the report and don't stop the import.
+func (p *Pipeline) Run(
+	ctx context.Context,
+	userID uuid.UUID,
+	documents []Document,
+	progress func(processed, total int),
+) (Report, error) {
+	run := &importRun{
+		userID:      userID,
+		noteIDs:     make(map[string]uuid.UUID, len(documents)),
+		notebooks:   make(map[string]uuid.UUID),
+		sections:    make(map[string]uuid.UUID),
+		attachments: make(map[string]uuid.UUID),
+	}
+
+	// Note IDs are assigned up front so documents can link to each other
+	for _, doc := range documents {
+		run.noteIDs[doc.Key] = uuid.New()
+	}
+
+	if err := p.loadNotebooks(ctx, run); err != nil {
+		return Report{}, err
+	}
+
+	report := Report{}
+	for i, doc := range documents {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		warnings, err := p.importDocument(ctx, run, doc)
+		report.Errors = append(report.Errors, warnings...)
+		if err != nil {
+			log.Printf("failed to import %s: %v", doc.Key, err)
+			report.Errors = append(report.Errors, models.ImportError{Path: doc.Key, Message: err.Error()})
+		} else {
+			report.Imported++
+		}
+
+		if progress != nil {
+			progress(i+1, len(documents))
+		}
+	}
+
+	return report, nil
+}
+
+// loadNotebooks indexes the user's existing notebooks so imports into a
+// notebook with the same name are merged into it
+func (p *Pipeline) loadNotebooks(ctx context.Context, run *importRun) error {
+	notebooks, err := p.notebookRepo.FetchUserNotebooks(ctx, run.userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch notebooks: %w", err)
+	}
+
+	for _, notebook := range notebooks {
+		key := strings.ToLower(notebook.Name)
+		if _, exists := run.notebooks[key]; !exists {
+			run.notebooks[key] = notebook.ID
+		}
+	}
+	return nil
+}
+
+// importDocument persists a single document. Problems that don't prevent the note
+// from being created, like an unsupported attachment, are returned as warnings.
+func (p *Pipeline) importDocument(ctx context.Context, run *importRun, doc Document) ([]models.ImportError, error) {
+	now := time.Now()
+	note := models.Note{
+		ID:        run.noteIDs[doc.Key],
+		UserID:    run.userID,
+		Title:     doc.Title,
+		Content:   p.rewriteNoteLinks(run, doc.Content),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Published: doc.Published,
+	}
+	if doc.UpdatedAt != nil {
+		note.UpdatedAt = *doc.UpdatedAt
+	}
+	if doc.CreatedAt != nil {
+		note.CreatedAt = *doc.CreatedAt
+	} else if doc.UpdatedAt != nil {
+		note.CreatedAt = *doc.UpdatedAt
+	}
+	if note.Published {
+		note.PublishedAt = &note.UpdatedAt
+	}
+
+	if _, err := p.noteRepo.Upsert(ctx, note); err != nil {
+		return nil, fmt.Errorf("failed to save note: %w", err)
+	}
+
+	// Files reference their note, so attachments are stored once the note exists
+	var warnings []models.ImportError
+	if len(doc.Attachments) > 0 {
+		var content string
+		content, warnings = p.storeAttachments(ctx, run, note.ID, doc, note.Content)
+		if content != note.Content {
+			note.Content = content
+			if _, err := p.noteRepo.Upsert(ctx, note); err != nil {
+				return warnings, fmt.Errorf("failed to save note: %w", err)
+			}
+		}
+	}
+
+	if err := p.assignTags(ctx, note.ID, doc.Tags); err != nil {
+		return warnings, err
+	}
+
+	if doc.Notebook != "" {
+		if err := p.placeNote(ctx, run, note.ID, doc.Notebook, doc.Section); err != nil {
+			return warnings, err
+		}
+	}
+
+	return warnings, nil
+}
+
+// rewriteNoteLinks points links to other documents at the notes created for them
+func (p *Pipeline) rewriteNoteLinks(run *importRun, content string) string {
+	return replacePlaceholderLinks(content, noteLinkScheme, func(key string) (string, bool) {
+		id, ok := run.noteIDs[key]
+		if !ok {
+			return "", false
+		}
+		return "/notes/" + id.String(), true
+	})
+}
+
+func (p *Pipeline) storeAttachments(
+	ctx context.Context,
+	run *importRun,
+	noteID uuid.UUID,
+	doc Document,
+	content string,
+) (string, []models.ImportError) {
+	var warnings []models.ImportError
+	targets := make(map[string]string, len(doc.Attachments))
+
+	for _, attachment := range doc.Attachments {
+		fileID, err := p.storeAttachment(ctx, run, noteID, attachment)
+		if err != nil {
+			log.Printf("failed to import attachment %s: %v", attachment.Key, err)
+			warnings = append(warnings, models.ImportError{Path: attachment.Key, Message: err.Error()})
+			// Keep the original file name so the reference stays readable
+			targets[attachment.Key] = url.PathEscape(attachment.Name)
+			continue
+		}
+		targets[attachment.Key] = "/files/" + fileID.String()
+	}
+
+	return replacePlaceholderLinks(content, attachmentLinkScheme, func(key string) (string, bool) {
+		target, ok := targets[key]
+		return target, ok
+	}), warnings
+}
+
+// storeAttachment stores an attachment once per import, even when several
+// documents reference it
+func (p *Pipeline) storeAttachment(ctx context.Context, run *importRun, noteID uuid.UUID, attachment Attachment) (uuid.UUID, error) {
+	if fileID, ok := run.attachments[attachment.Key]; ok {
+		return fileID, nil
+	}
+
+	data, err := attachment.Load()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	file, err := p.files.CreateFileFromBytes(ctx, data, &noteID, run.userID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	run.attachments[attachment.Key] = file.ID
+	return file.ID, nil
+}
+
+func (p *Pipeline) assignTags(ctx context.Context, noteID uuid.UUID, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	var tagIDs []uuid.UUID
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		tag, err := p.tags.FetchOrCreate(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to create tag %q: %w", name, err)
+		}
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			tagIDs = append(tagIDs, tag.ID)
+		}
+	}
+
+	if err := p.noteRepo.AssignTagsToNote(ctx, noteID, tagIDs); err != nil {
+		return fmt.Errorf("failed to assign tags: %w", err)
+	}
+	return nil
+}
+
+// placeNote adds a note to a notebook and optional section, creating them by name
+func (p *Pipeline) placeNote(ctx context.Context, run *importRun, noteID uuid.UUID, notebookName, sectionName string) error {
+	notebookKey := strings.ToLower(notebookName)
+	notebookID, ok := run.notebooks[notebookKey]
+	if !ok {
+		now := time.Now()
+		notebook, err := p.notebookRepo.Upsert(ctx, models.Notebook{
+			ID:        uuid.New(),
+			UserID:    run.userID,
+			Name:      notebookName,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create notebook %q: %w", notebookName, err)
+		}
+		notebookID = notebook.ID
+		run.notebooks[notebookKey] = notebookID
+	}
+
+	var sectionID *uuid.UUID
+	if sectionName != "" {
+		id, err := p.resolveSection(ctx, run, notebookID, sectionName)
+		if err != nil {
+			return err
+		}
+		sectionID = &id
+	}
+
+	if err := p.sectionRepo.AssignNoteToSection(ctx, noteID, notebookID, sectionID); err != nil {
+		return fmt.Errorf("failed to add note to notebook %q: %w", notebookName, err)
+	}
+	return nil
+}
+
+func (p *Pipeline) resolveSection(ctx context.Context, run *importRun, notebookID uuid.UUID, name string) (uuid.UUID, error) {
+	key := notebookID.String() + "/" + strings.ToLower(name)
+	if id, ok := run.sections[key]; ok {
+		return id, nil
+	}
+
+	sections, err := p.sectionRepo.FetchNotebookSections(ctx, notebookID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to fetch sections: %w", err)
+	}
+
+	position := 0
+	for _, section := range sections {
+		if strings.EqualFold(section.Name, name) {
+			run.sections[key] = section.ID
+			return section.ID, nil
+		}
+		if section.Position >= position {
+			position = section.Position + 1
+		}
+	}
+
+	now := time.Now()
+	section, err := p.sectionRepo.Upsert(ctx, models.Section{
+		NotebookID: notebookID,
+		Name:       name,
+		Position:   position,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create section %q: %w", name, err)
+	}
+
+	run.sections[key] = section.ID
+	return section.ID, nil
+}
+
+// Import reads the export at path and imports it for a user. Items the source
+// couldn't read are included in the report's errors.
+func (p *Pipeline) Import(
+	ctx context.Context,
+	userID uuid.UUID,
+	format string,
+	path string,
+	progress func(processed, total int),
+) (Report, error) {
+	source, err := Open(format, path)
+	if err != nil {
+		return Report{}, err
+	}
+	defer source.Close()
+
+	documents, sourceErrors, err := source.Documents()
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	if progress != nil {
+		progress(0, len(documents))
+	}
+
+	report, err := p.Run(ctx, userID, documents, progress)
+	report.Errors = append(sourceErrors, report.Errors...)
+	return report, err
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CookLogEntry records a user cooking a recipe. Dates are formatted as
+// YYYY-MM-DD.
+type CookLogEntry struct {
+	ID        uuid.UUID `json:"id"        db:"id"`
+	UserID    uuid.UUID `json:"userId"    db:"user_id"`
+	RecipeID  uuid.UUID `json:"recipeId"  db:"recipe_id"`
+	CookedOn  string    `json:"cookedOn"  db:"cooked_on"`
+	Rating    *int      `json:"rating"    db:"rating"`   // 1 to 5
+	Servings  *int      `json:"servings"  db:"servings"` // servings made
+	Notes     *string   `json:"notes"     db:"notes"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// RecipeStats sums up a user's cook log of a recipe
+type RecipeStats struct {
+	TimesCooked   int      `json:"timesCooked"   db:"times_cooked"`
+	AverageRating *float64 `json:"averageRating" db:"average_rating"`
+	LastCooked    *string  `json:"lastCooked"    db:"last_cooked"`
+}
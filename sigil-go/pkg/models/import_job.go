@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ImportJob struct {
+	ID             uuid.UUID     `json:"id"             db:"id"`
+	UserID         uuid.UUID     `json:"userId"         db:"user_id"`
+	Format         string        `json:"format"         db:"format"`
+	Filename       string        `json:"filename"       db:"filename"`
+	Status         string        `json:"status"         db:"status"`
+	TotalItems     int           `json:"totalItems"     db:"total_items"`
+	ProcessedItems int           `json:"processedItems" db:"processed_items"`
+	ImportedNotes  int           `json:"importedNotes"  db:"imported_notes"`
+	Errors         []ImportError `json:"errors"         db:"errors"`
+	ErrorMessage   *string       `json:"errorMessage"   db:"error_message"`
+	CreatedAt      time.Time     `json:"createdAt"      db:"created_at"`
+	CompletedAt    *time.Time    `json:"completedAt"    db:"completed_at"`
+}
+
+// ImportError describes a single item that could not be imported
+type ImportError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Meals of a day a recipe can be planned for
+const (
+	MealSlotBreakfast = "breakfast"
+	MealSlotLunch     = "lunch"
+	MealSlotDinner    = "dinner"
+)
+
+// MealSlots lists the meals of a day in the order they're eaten
+var MealSlots = []string{MealSlotBreakfast, MealSlotLunch, MealSlotDinner}
+
+// MealPlan holds the recipes planned for the meals of a range of days.
+// Dates are formatted as YYYY-MM-DD.
+type MealPlan struct {
+	ID        uuid.UUID     `json:"id"        db:"id"`
+	UserID    uuid.UUID     `json:"userId"    db:"user_id"`
+	Title     string        `json:"title"     db:"title"`
+	StartDate string        `json:"startDate" db:"start_date"`
+	EndDate   string        `json:"endDate"   db:"end_date"`
+	Meals     []PlannedMeal `json:"meals"     db:"-"`
+	CreatedAt time.Time     `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time     `json:"updatedAt" db:"updated_at"`
+}
+
+// PlannedMeal is a recipe planned for a meal of a day
+type PlannedMeal struct {
+	ID         uuid.UUID `json:"id"         db:"id"`
+	MealPlanID uuid.UUID `json:"mealPlanId" db:"meal_plan_id"`
+	Date       string    `json:"date"       db:"date"`
+	Slot       string    `json:"slot"       db:"slot"`
+	RecipeID   uuid.UUID `json:"recipeId"   db:"recipe_id"`
+	RecipeName string    `json:"recipeName" db:"-"`
+	Servings   *int      `json:"servings"   db:"servings"` // Overrides the recipe's servings
+	Position   int       `json:"position"   db:"position"`
+	Sequence   int       `json:"sequence"   db:"sequence"` // Bumped when the date, slot, recipe or servings change
+	UpdatedAt  time.Time `json:"updatedAt"  db:"updated_at"`
+}
+
+// CalendarMeal is a planned meal with the recipe details shown in calendars
+type CalendarMeal struct {
+	PlannedMeal
+	RecipeSummary   *string
+	RecipePrepTime  *string
+	RecipeUpdatedAt time.Time
+	// NoteID is the user's note showing the recipe
+	NoteID *uuid.UUID
+}
+
+// CalendarFeed is a user's secret calendar feed URL. Only a hash of the token
+// in the URL is stored.
+type CalendarFeed struct {
+	UserID    uuid.UUID `json:"userId"    db:"user_id"`
+	TokenHash string    `json:"-"         db:"token_hash"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+// Nutrients are the energy and macronutrients of a food, ingredient or recipe
+type Nutrients struct {
+	Calories float64 `json:"calories"` // kcal
+	Protein  float64 `json:"protein"`  // grams
+	Fat      float64 `json:"fat"`      // grams
+	Carbs    float64 `json:"carbs"`    // grams
+}
+
+// How an ingredient was counted in a nutrition estimate
+const (
+	NutritionEstimated     = "estimated"     // matched a food with a known weight
+	NutritionUnknownFood   = "unknownFood"   // not in the food table
+	NutritionUnknownAmount = "unknownAmount" // no quantity, or a unit that can't be weighed
+	NutritionOptional      = "optional"      // optional ingredients aren't counted
+)
+
+// RecipeNutrition is an estimate of a recipe's nutrition from the bundled food
+// table. Ingredients that couldn't be estimated are left out of the totals.
+type RecipeNutrition struct {
+	Total       Nutrients             `json:"total"`
+	PerServing  *Nutrients            `json:"perServing"` // null for recipes without servings
+	Confidence  float64               `json:"confidence"` // share of counted ingredients that were estimated, 0-1
+	Ingredients []IngredientNutrition `json:"ingredients"`
+}
+
+// IngredientNutrition reports how a single ingredient was estimated
+type IngredientNutrition struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	Food      string     `json:"food,omitempty"` // the food table entry the ingredient matched
+	Grams     *float64   `json:"grams"`
+	Nutrients *Nutrients `json:"nutrients"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PantryItem is food the user has at home. BestBefore is formatted as
+// YYYY-MM-DD.
+type PantryItem struct {
+	ID          uuid.UUID `json:"id"          db:"id"`
+	UserID      uuid.UUID `json:"userId"      db:"user_id"`
+	ItemName    string    `json:"itemName"    db:"item_name"` // Normalized: "milk"
+	DisplayName string    `json:"displayName" db:"display_name"`
+	Quantity    *float64  `json:"quantity"    db:"quantity"`
+	Unit        string    `json:"unit"        db:"unit"`
+	Location    *string   `json:"location"    db:"location"`
+	BestBefore  *string   `json:"bestBefore"  db:"best_before"`
+	CreatedAt   time.Time `json:"createdAt"   db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt"   db:"updated_at"`
+}
+
+// RecipeSuggestion is a recipe ranked by how many of its ingredients the user
+// has at home
+type RecipeSuggestion struct {
+	Recipe Recipe `json:"recipe"`
+	// Available holds the ingredients in the pantry or always in stock,
+	// Missing the ones to buy. Optional ingredients are never missing.
+	Available []string `json:"available"`
+	Missing   []string `json:"missing"`
+}
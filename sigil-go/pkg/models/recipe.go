@@ -7,16 +7,65 @@ import (
 )
 
 type Recipe struct {
-	ID          uuid.UUID    `json:"id"          db:"id"`
-	Name        string       `json:"name"        db:"name"`
-	Summary     *string      `json:"summary"     db:"summary"`
-	Servings    *int         `json:"servings"    db:"servings"`
-	PrepTime    *string      `json:"prepTime"    db:"prep_time"`
-	SourceURL   *string      `json:"sourceUrl"   db:"source_url"`
-	Ingredients []Ingredient `json:"ingredients" db:"ingredients"`
-	Steps       []string     `json:"steps"       db:"steps"`
-	CreatedAt   time.Time    `json:"createdAt"   db:"created_at"`
-	UpdatedAt   time.Time    `json:"updatedAt"   db:"updated_at"`
+	ID            uuid.UUID     `json:"id"            db:"id"`
+	Name          string        `json:"name"          db:"name"`
+	Summary       *string       `json:"summary"       db:"summary"`
+	Servings      *int          `json:"servings"      db:"servings"`
+	PrepTime      *string       `json:"prepTime"      db:"prep_time"`
+	CookTime      *string       `json:"cookTime"      db:"cook_time"`
+	TotalTime     *string       `json:"totalTime"     db:"total_time"`
+	PrepMinutes   *int          `json:"prepMinutes"   db:"prep_minutes"` // parsed from PrepTime
+	CookMinutes   *int          `json:"cookMinutes"   db:"cook_minutes"`
+	TotalMinutes  *int          `json:"totalMinutes"  db:"total_minutes"` // prep + cook when no total is given
+	Cuisine       *string       `json:"cuisine"       db:"cuisine"`
+	Course        *string       `json:"course"        db:"course"`
+	Dietary       []string      `json:"dietary"       db:"dietary"`        // DietaryVegetarian, DietaryVegan, ...
+	DietaryManual bool          `json:"dietaryManual" db:"dietary_manual"` // labels set by the user instead of inferred
+	Equipment     []string      `json:"equipment"     db:"equipment"`
+	SourceURL     *string       `json:"sourceUrl"     db:"source_url"`
+	ImageID       *uuid.UUID    `json:"imageId"       db:"image_id"` // shown as /files/<id>
+	StepImages    []StepImage   `json:"stepImages"    db:"step_images"`
+	Ingredients   []Ingredient  `json:"ingredients"   db:"ingredients"`
+	Steps         []string      `json:"steps"         db:"steps"`
+	StepSections  []StepSection `json:"stepSections"  db:"step_sections"`
+	CreatedAt     time.Time     `json:"createdAt"     db:"created_at"`
+	UpdatedAt     time.Time     `json:"updatedAt"     db:"updated_at"`
+	// The recipe as last read from SourceURL, nil for recipes from elsewhere
+	Source *RecipeSnapshot `json:"-" db:"source_snapshot"`
+	// Stats of the user's cook log, only set in responses to the user
+	Stats *RecipeStats `json:"stats,omitempty" db:"-"`
+	// Estimated from the ingredients, only set in responses
+	Nutrition *RecipeNutrition `json:"nutrition,omitempty" db:"-"`
+}
+
+// StepImage is the image of one of a recipe's steps
+type StepImage struct {
+	Step   int       `json:"step"` // index into Steps
+	FileID uuid.UUID `json:"fileId"`
+}
+
+// StepSection is a heading like "For the sauce" above a group of steps
+type StepSection struct {
+	Step int    `json:"step"` // index into Steps of the section's first step
+	Name string `json:"name"`
+}
+
+// RecipeStep is a step along with what's worked out from its text, the
+// timers it mentions and the ingredients it uses
+type RecipeStep struct {
+	Number      int         `json:"number"` // 1-based
+	Section     string      `json:"section,omitempty"`
+	Text        string      `json:"text"`
+	Timers      []StepTimer `json:"timers"`
+	Ingredients []int       `json:"ingredients"` // indexes into the recipe's ingredients
+	ImageID     *uuid.UUID  `json:"imageId"`
+}
+
+// StepTimer is a duration mentioned in a step, like "simmer for 20-25 minutes"
+type StepTimer struct {
+	Text       string `json:"text"` // as written, e.g. "20-25 minutes"
+	Seconds    int    `json:"seconds"`
+	MaxSeconds int    `json:"maxSeconds"` // the upper end of a range, otherwise Seconds
 }
 
 type Ingredient struct {
@@ -32,16 +81,49 @@ type Quantity struct {
 	Unit string   `json:"unit"` // required - tablespoons, grams, cups, cloves, etc.
 }
 
+// Dietary labels of a recipe
+const (
+	DietaryVegetarian = "vegetarian"
+	DietaryVegan      = "vegan"
+	DietaryGlutenFree = "gluten-free"
+	DietaryDairyFree  = "dairy-free"
+)
+
+// DietaryLabels are the known dietary labels, in the order they're listed
+var DietaryLabels = []string{DietaryVegetarian, DietaryVegan, DietaryGlutenFree, DietaryDairyFree}
+
+// Where a recipe job reads the recipe from
+const (
+	RecipeSourceURL      = "url"
+	RecipeSourceText     = "text"
+	RecipeSourceHTML     = "html"
+	RecipeSourceGenerate = "generate" // AI recipe from a description
+	RecipeSourceRefine   = "refine"   // AI revision of another recipe
+	RecipeSourceRefresh  = "refresh"  // another read of a recipe's source URL
+)
+
 type RecipeJob struct {
-	ID           uuid.UUID  `json:"id"           db:"id"`
-	UserID       uuid.UUID  `json:"userId"       db:"user_id"`
-	URL          string     `json:"url"          db:"url"`
-	Status       string     `json:"status"       db:"status"`
-	ErrorMessage *string    `json:"errorMessage" db:"error_message"`
-	RecipeID     *uuid.UUID `json:"recipeId"     db:"recipe_id"`
-	NoteID       *uuid.UUID `json:"noteId"       db:"note_id"`
-	CreatedAt    time.Time  `json:"createdAt"    db:"created_at"`
-	CompletedAt  *time.Time `json:"completedAt"  db:"completed_at"`
+	ID             uuid.UUID  `json:"id"             db:"id"`
+	UserID         uuid.UUID  `json:"userId"         db:"user_id"`
+	URL            string     `json:"url"            db:"url"`            // empty unless imported from a URL
+	SourceType     string     `json:"sourceType"     db:"source_type"`    // url, text, html, generate, refine or refresh
+	SourceContent  *string    `json:"-"              db:"source_content"` // the pasted text, uploaded file or AI request
+	SourceFilename *string    `json:"sourceFilename" db:"source_filename"`
+	SourceRecipeID *uuid.UUID `json:"sourceRecipeId" db:"source_recipe_id"` // the recipe being refined or refreshed
+	Status         string     `json:"status"         db:"status"`
+	ErrorMessage   *string    `json:"errorMessage"   db:"error_message"`
+	RecipeID       *uuid.UUID `json:"recipeId"       db:"recipe_id"`
+	NoteID         *uuid.UUID `json:"noteId"         db:"note_id"`
+	CreatedAt      time.Time  `json:"createdAt"      db:"created_at"`
+	CompletedAt    *time.Time `json:"completedAt"    db:"completed_at"`
+}
+
+// RecipeGeneration describes a recipe for the AI to create
+type RecipeGeneration struct {
+	Description string   `json:"description"` // dish name or short description
+	Servings    *int     `json:"servings"`
+	Dietary     []string `json:"dietary"`     // restrictions like "vegetarian" or "gluten-free"
+	Ingredients []string `json:"ingredients"` // available ingredients to use
 }
 
 type RecipeURLCache struct {
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecipeCollection is a named group of the user's recipes, like "Weeknight"
+// or "Christmas". Only recipes still in the user's notes are counted.
+type RecipeCollection struct {
+	ID           uuid.UUID  `json:"id"           db:"id"`
+	UserID       uuid.UUID  `json:"userId"       db:"user_id"`
+	Name         string     `json:"name"         db:"name"`
+	Description  *string    `json:"description"  db:"description"`
+	RecipeCount  int        `json:"recipeCount"  db:"recipe_count"`
+	CoverImageID *uuid.UUID `json:"coverImageId" db:"cover_image_id"` // image of the recipe added last that has one
+	CreatedAt    time.Time  `json:"createdAt"    db:"created_at"`
+	UpdatedAt    time.Time  `json:"updatedAt"    db:"updated_at"`
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecipeSnapshot is the content of a recipe compared when it's read again
+// from its source URL
+type RecipeSnapshot struct {
+	Name         string        `json:"name"`
+	Summary      *string       `json:"summary"`
+	Servings     *int          `json:"servings"`
+	PrepTime     *string       `json:"prepTime"`
+	CookTime     *string       `json:"cookTime"`
+	TotalTime    *string       `json:"totalTime"`
+	Cuisine      *string       `json:"cuisine"`
+	Course       *string       `json:"course"`
+	Equipment    []string      `json:"equipment"`
+	Ingredients  []Ingredient  `json:"ingredients"`
+	Steps        []string      `json:"steps"`
+	StepSections []StepSection `json:"stepSections"`
+}
+
+// Statuses of a recipe update
+const (
+	RecipeUpdatePending   = "pending"
+	RecipeUpdateApplied   = "applied"
+	RecipeUpdateDismissed = "dismissed"
+)
+
+// RecipeUpdate holds the changes found at a recipe's source URL for the user
+// to apply or dismiss. Recipes are shared between users, so updates are kept
+// per user.
+type RecipeUpdate struct {
+	ID         uuid.UUID      `json:"id"         db:"id"`
+	UserID     uuid.UUID      `json:"userId"     db:"user_id"`
+	RecipeID   uuid.UUID      `json:"recipeId"   db:"recipe_id"`
+	Source     RecipeSnapshot `json:"source"     db:"source"` // the recipe as read from the source
+	Diff       RecipeDiff     `json:"diff"       db:"diff"`
+	Status     string         `json:"status"     db:"status"` // pending, applied or dismissed
+	CreatedAt  time.Time      `json:"createdAt"  db:"created_at"`
+	ResolvedAt *time.Time     `json:"resolvedAt" db:"resolved_at"`
+}
+
+// Kinds of changes in a RecipeDiff
+const (
+	RecipeChangeAdded   = "added"
+	RecipeChangeRemoved = "removed"
+	RecipeChangeChanged = "changed"
+)
+
+// RecipeDiff lists what changed at a recipe's source since it was last read.
+// Changes the user also made edits to are conflicts, applying the update keeps
+// the user's version of those.
+type RecipeDiff struct {
+	Fields      []FieldChange      `json:"fields"`
+	Ingredients []IngredientChange `json:"ingredients"`
+	Steps       []StepChange       `json:"steps"`
+}
+
+// FieldChange is a change to a single value like the name or servings,
+// written as text
+type FieldChange struct {
+	Field    string `json:"field"` // name, summary, servings, prepTime, ...
+	Old      string `json:"old"`
+	New      string `json:"new"`
+	Conflict bool   `json:"conflict"`
+}
+
+// IngredientChange is an ingredient added, removed or changed. Ingredients
+// are matched by name.
+type IngredientChange struct {
+	Change   string      `json:"change"`
+	Old      *Ingredient `json:"old"` // nil when added
+	New      *Ingredient `json:"new"` // nil when removed
+	Conflict bool        `json:"conflict"`
+}
+
+// StepChange is a step added, removed or reworded
+type StepChange struct {
+	Change   string `json:"change"`
+	Step     int    `json:"step"` // index into the old steps when removed, otherwise the new ones
+	Old      string `json:"old"`
+	New      string `json:"new"`
+	Conflict bool   `json:"conflict"`
+}
+
+// Empty reports whether nothing changed
+func (d RecipeDiff) Empty() bool {
+	return len(d.Fields) == 0 && len(d.Ingredients) == 0 && len(d.Steps) == 0
+}
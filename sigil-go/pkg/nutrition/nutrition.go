@@ -0,0 +1,289 @@
+// Package nutrition estimates the nutrition of recipes offline, from a food
+// composition table bundled with the binary.
+//
+// Ingredient names are matched against the names in the table, quantities
+// are converted to grams through the unit's size and the food's density or
+// weight per piece. Ingredients that can't be matched or weighed are left out
+// of the totals and reported, so the estimate says how much to trust it.
+package nutrition
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+)
+
+//go:embed foods.csv
+var foodsCSV string
+
+// Food is an entry in the food table
+type Food struct {
+	Name       string // the first name listed for the food
+	Names      []string
+	Per100g    models.Nutrients
+	Density    float64 // grams per milliliter, zero when unknown
+	PieceGrams float64 // weight of one item, zero when unknown
+	// Trace foods, like salt and dried herbs, are used in amounts too small to
+	// matter. Without a quantity they count as zero grams.
+	Trace bool
+}
+
+// foodName is a normalized food name to match ingredients against
+type foodName struct {
+	words string
+	count int
+	food  *Food
+}
+
+// countUnitGrams holds typical weights of units that count things, for foods
+// without a weight per piece
+var countUnitGrams = map[string]float64{
+	"can":     400,
+	"slice":   30,
+	"stick":   113,
+	"bunch":   30,
+	"handful": 30,
+	"sprig":   1,
+	"pinch":   0.4,
+	"dash":    0.6,
+}
+
+var foodNames = mustLoadFoods(foodsCSV)
+
+// mustLoadFoods parses the embedded food table. Longer names come first so
+// "peanut butter" isn't matched as butter.
+func mustLoadFoods(data string) []foodName {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("invalid food table: %v", err))
+	}
+
+	var names []foodName
+	for i, record := range records[1:] {
+		food, err := parseFood(record)
+		if err != nil {
+			panic(fmt.Sprintf("invalid food table row %d: %v", i+2, err))
+		}
+		for _, name := range food.Names {
+			words := normalizeName(name)
+			names = append(names, foodName{words: words, count: len(strings.Fields(words)), food: food})
+		}
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		if names[i].count != names[j].count {
+			return names[i].count > names[j].count
+		}
+		return len(names[i].words) > len(names[j].words)
+	})
+	return names
+}
+
+func parseFood(record []string) (*Food, error) {
+	if len(record) != 8 {
+		return nil, fmt.Errorf("expected 8 columns, got %d", len(record))
+	}
+
+	values := make([]float64, 6)
+	for i, field := range record[1:7] {
+		if field == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		values[i] = value
+	}
+
+	names := strings.Split(record[0], "|")
+	return &Food{
+		Name:       names[0],
+		Names:      names,
+		Per100g:    models.Nutrients{Calories: values[0], Protein: values[1], Fat: values[2], Carbs: values[3]},
+		Density:    values[4],
+		PieceGrams: values[5],
+		Trace:      record[7] == "trace",
+	}, nil
+}
+
+// Match finds the food an ingredient name refers to. Names are matched as
+// whole words, so "chopped red onion" is an onion, and English plurals are
+// matched by their singular.
+func Match(name string) (Food, bool) {
+	words := normalizeName(name)
+	candidates := []string{" " + words + " "}
+	if singular := singularize(words); singular != words {
+		candidates = append(candidates, " "+singular+" ")
+	}
+
+	for _, foodName := range foodNames {
+		for _, candidate := range candidates {
+			if strings.Contains(candidate, " "+foodName.words+" ") {
+				return *foodName.food, true
+			}
+		}
+	}
+	return Food{}, false
+}
+
+// Estimate estimates the nutrition of a recipe, in total and per serving
+func Estimate(recipe models.Recipe) models.RecipeNutrition {
+	result := models.RecipeNutrition{Ingredients: make([]models.IngredientNutrition, 0, len(recipe.Ingredients))}
+
+	counted, estimated := 0, 0
+	for _, ingredient := range recipe.Ingredients {
+		report := estimateIngredient(ingredient)
+		result.Ingredients = append(result.Ingredients, report)
+
+		if report.Status == models.NutritionOptional {
+			continue
+		}
+		counted++
+		if report.Status == models.NutritionEstimated {
+			estimated++
+			result.Total = addNutrients(result.Total, *report.Nutrients)
+		}
+	}
+
+	result.Total = roundNutrients(result.Total)
+	if recipe.Servings != nil && *recipe.Servings > 0 {
+		perServing := roundNutrients(scaleNutrients(result.Total, 1/float64(*recipe.Servings)))
+		result.PerServing = &perServing
+	}
+	if counted > 0 {
+		result.Confidence = math.Round(float64(estimated)/float64(counted)*100) / 100
+	}
+
+	return result
+}
+
+func estimateIngredient(ingredient models.Ingredient) models.IngredientNutrition {
+	report := models.IngredientNutrition{Name: ingredient.Name}
+	if ingredient.IsOptional {
+		report.Status = models.NutritionOptional
+		return report
+	}
+
+	food, ok := Match(ingredient.Name)
+	if !ok {
+		report.Status = models.NutritionUnknownFood
+		return report
+	}
+	report.Food = food.Name
+
+	grams, ok := ingredientGrams(ingredient.Quantity, food)
+	if !ok {
+		report.Status = models.NutritionUnknownAmount
+		return report
+	}
+
+	nutrients := roundNutrients(scaleNutrients(food.Per100g, grams/100))
+	grams = math.Round(grams*10) / 10
+	report.Status = models.NutritionEstimated
+	report.Grams = &grams
+	report.Nutrients = &nutrients
+	return report
+}
+
+// ingredientGrams converts a quantity of food to grams. Ranges count as
+// their middle.
+func ingredientGrams(quantity *models.Quantity, food Food) (float64, bool) {
+	if quantity == nil || (quantity.Min == nil && quantity.Max == nil) {
+		return 0, food.Trace
+	}
+
+	var amount float64
+	switch {
+	case quantity.Min != nil && quantity.Max != nil:
+		amount = (*quantity.Min + *quantity.Max) / 2
+	case quantity.Min != nil:
+		amount = *quantity.Min
+	default:
+		amount = *quantity.Max
+	}
+
+	if grams, ok := utils.UnitGrams(quantity.Unit); ok {
+		return amount * grams, true
+	}
+	if milliliters, ok := utils.UnitMilliliters(quantity.Unit); ok {
+		density := food.Density
+		if density == 0 {
+			// Close enough for most liquids
+			density = 1
+		}
+		return amount * milliliters * density, true
+	}
+
+	unit, _ := utils.NormalizeUnit(quantity.Unit)
+	switch {
+	case (unit == "" || unit == "piece" || unit == "clove") && food.PieceGrams > 0:
+		return amount * food.PieceGrams, true
+	case countUnitGrams[unit] > 0:
+		return amount * countUnitGrams[unit], true
+	}
+	return 0, false
+}
+
+// normalizeName lowercases a name and keeps only its words
+func normalizeName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ")
+}
+
+// singularize makes English plurals singular, like "tomatoes" and "berries"
+func singularize(words string) string {
+	fields := strings.Fields(words)
+	for i, word := range fields {
+		switch {
+		case len(word) <= 3 || strings.HasSuffix(word, "ss"):
+		case strings.HasSuffix(word, "ies"):
+			fields[i] = strings.TrimSuffix(word, "ies") + "y"
+		case strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+			fields[i] = strings.TrimSuffix(word, "es")
+		case strings.HasSuffix(word, "s"):
+			fields[i] = strings.TrimSuffix(word, "s")
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+func addNutrients(a, b models.Nutrients) models.Nutrients {
+	return models.Nutrients{
+		Calories: a.Calories + b.Calories,
+		Protein:  a.Protein + b.Protein,
+		Fat:      a.Fat + b.Fat,
+		Carbs:    a.Carbs + b.Carbs,
+	}
+}
+
+func scaleNutrients(n models.Nutrients, factor float64) models.Nutrients {
+	return models.Nutrients{
+		Calories: n.Calories * factor,
+		Protein:  n.Protein * factor,
+		Fat:      n.Fat * factor,
+		Carbs:    n.Carbs * factor,
+	}
+}
+
+// roundNutrients rounds calories to whole kcal and the rest to a tenth of a
+// gram
+func roundNutrients(n models.Nutrients) models.Nutrients {
+	return models.Nutrients{
+		Calories: math.Round(n.Calories),
+		Protein:  math.Round(n.Protein*10) / 10,
+		Fat:      math.Round(n.Fat*10) / 10,
+		Carbs:    math.Round(n.Carbs*10) / 10,
+	}
+}
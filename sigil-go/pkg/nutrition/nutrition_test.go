@@ -0,0 +1,151 @@
+package nutrition
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func qty(amount float64, unit string) *models.Quantity {
+	return &models.Quantity{Min: &amount, Max: &amount, Unit: unit}
+}
+
+func TestFoodTable(t *testing.T) {
+	require.NotEmpty(t, foodNames)
+
+	seen := make(map[string]string)
+	for _, name := range foodNames {
+		assert.NotEmpty(t, name.words)
+		if other, found := seen[name.words]; found && other != name.food.Name {
+			t.Errorf("%q is listed for both %s and %s", name.words, other, name.food.Name)
+		}
+		seen[name.words] = name.food.Name
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"Flour":                 "flour",
+		"all-purpose flour":     "flour",
+		"hvetemel":              "flour",
+		"chopped red onions":    "onion",
+		"Tomatoes":              "tomato",
+		"fresh blueberries":     "blueberry",
+		"peanut butter":         "peanut butter",
+		"unsalted butter":       "butter",
+		"smør":                  "butter",
+		"egg noodles":           "noodles",
+		"eggs":                  "egg",
+		"chicken stock":         "stock",
+		"red bell pepper":       "bell pepper",
+		"freshly ground pepper": "pepper",
+		"crème fraîche":         "creme fraiche",
+	}
+
+	for name, expected := range tests {
+		food, ok := Match(name)
+		if assert.True(t, ok, name) {
+			assert.Equal(t, expected, food.Name, name)
+		}
+	}
+
+	_, ok := Match("dragon fruit")
+	assert.False(t, ok)
+}
+
+func TestEstimate(t *testing.T) {
+	servings := 2
+	recipe := models.Recipe{
+		Servings: &servings,
+		Ingredients: []models.Ingredient{
+			{Name: "sugar", Quantity: qty(100, "g")},
+			{Name: "eggs", Quantity: qty(2, "")},
+			{Name: "milk", Quantity: &models.Quantity{Min: floatPtr(1), Max: floatPtr(3), Unit: "dl"}},
+			{Name: "salt", Notes: "to taste"},
+			{Name: "dragon fruit", Quantity: qty(1, "")},
+			{Name: "butter", Quantity: qty(1, "package")},
+			{Name: "dark chocolate", Quantity: qty(50, "g"), IsOptional: true},
+		},
+	}
+
+	nutrition := Estimate(recipe)
+
+	// 100 g sugar, 100 g egg and 206 g milk
+	assert.Equal(t, models.Nutrients{Calories: 656, Protein: 19.2, Fat: 16.3, Carbs: 110.6}, nutrition.Total)
+	require.NotNil(t, nutrition.PerServing)
+	assert.Equal(t, models.Nutrients{Calories: 328, Protein: 9.6, Fat: 8.2, Carbs: 55.3}, *nutrition.PerServing)
+	// 4 of the 6 ingredients that count were estimated
+	assert.Equal(t, 0.67, nutrition.Confidence)
+
+	require.Len(t, nutrition.Ingredients, 7)
+	statuses := make([]string, len(nutrition.Ingredients))
+	for i, ingredient := range nutrition.Ingredients {
+		statuses[i] = ingredient.Status
+	}
+	assert.Equal(t, []string{
+		models.NutritionEstimated,
+		models.NutritionEstimated,
+		models.NutritionEstimated,
+		models.NutritionEstimated,
+		models.NutritionUnknownFood,
+		models.NutritionUnknownAmount,
+		models.NutritionOptional,
+	}, statuses)
+
+	eggs := nutrition.Ingredients[1]
+	assert.Equal(t, "egg", eggs.Food)
+	require.NotNil(t, eggs.Grams)
+	assert.Equal(t, 100.0, *eggs.Grams)
+	assert.Equal(t, 143.0, eggs.Nutrients.Calories)
+
+	salt := nutrition.Ingredients[3]
+	require.NotNil(t, salt.Grams)
+	assert.Equal(t, 0.0, *salt.Grams)
+	assert.Nil(t, nutrition.Ingredients[4].Grams)
+}
+
+func TestEstimateWithoutServings(t *testing.T) {
+	nutrition := Estimate(models.Recipe{Ingredients: []models.Ingredient{{Name: "olive oil", Quantity: qty(1, "tbsp")}}})
+
+	assert.Nil(t, nutrition.PerServing)
+	assert.Equal(t, 1.0, nutrition.Confidence)
+	// 1 tbsp is about 13.6 g of oil
+	assert.Equal(t, 120.0, nutrition.Total.Calories)
+	assert.Equal(t, 13.6, nutrition.Total.Fat)
+}
+
+func TestEstimateEmptyRecipe(t *testing.T) {
+	nutrition := Estimate(models.Recipe{})
+	assert.Equal(t, models.Nutrients{}, nutrition.Total)
+	assert.Equal(t, 0.0, nutrition.Confidence)
+	assert.Empty(t, nutrition.Ingredients)
+}
+
+func TestIngredientGrams(t *testing.T) {
+	garlic, _ := Match("garlic")
+	grams, ok := ingredientGrams(qty(3, "cloves"), garlic)
+	assert.True(t, ok)
+	assert.Equal(t, 12.0, grams)
+
+	tomatoes, _ := Match("crushed tomatoes")
+	grams, ok = ingredientGrams(qty(1, "boks"), tomatoes)
+	assert.True(t, ok)
+	assert.Equal(t, 400.0, grams)
+
+	flour, _ := Match("flour")
+	grams, ok = ingredientGrams(&models.Quantity{Min: floatPtr(1), Unit: "kg"}, flour)
+	assert.True(t, ok)
+	assert.Equal(t, 1000.0, grams)
+
+	_, ok = ingredientGrams(qty(2, ""), flour)
+	assert.False(t, ok)
+	_, ok = ingredientGrams(nil, flour)
+	assert.False(t, ok)
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
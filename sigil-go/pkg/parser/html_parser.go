@@ -23,6 +23,17 @@ func NewMainContentExtractor() *MainContentExtractor {
 }
 
 func (e *MainContentExtractor) ExtractFromURL(url string) (string, error) {
+	html, _, err := e.RenderURL(url)
+	if err != nil {
+		return "", err
+	}
+
+	return e.ExtractFromHTML(html)
+}
+
+// RenderURL loads a page in a headless browser and returns its HTML once
+// scripts have run, along with the URL the page ended up on after redirects
+func (e *MainContentExtractor) RenderURL(url string) (string, string, error) {
 	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
 		url = "https://" + url
 	}
@@ -33,16 +44,17 @@ func (e *MainContentExtractor) ExtractFromURL(url string) (string, error) {
 	ctx, cancel = context.WithTimeout(ctx, e.Timeout)
 	defer cancel()
 
-	var html string
+	var html, location string
 	err := chromedp.Run(ctx,
 		chromedp.Navigate(url),
 		chromedp.OuterHTML("html", &html),
+		chromedp.Location(&location),
 	)
 	if err != nil {
-		return "", fmt.Errorf("chromedp navigation error: %w", err)
+		return "", "", fmt.Errorf("chromedp navigation error: %w", err)
 	}
 
-	return e.ExtractFromHTML(html)
+	return html, location, nil
 }
 
 func (e *MainContentExtractor) ExtractFromHTML(html string) (string, error) {
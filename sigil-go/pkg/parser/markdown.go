@@ -0,0 +1,509 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// MarkdownConverter turns HTML into markdown. Headings, emphasis, links, images,
+// lists, task lists, quotes, code and tables are kept, everything else is
+// reduced to its text.
+type MarkdownConverter struct {
+	// RenderElement lets callers render specific elements themselves, such as
+	// Evernote's <en-media>. children renders the element's content the usual
+	// way. Returning false falls back to the default rendering.
+	RenderElement func(n *html.Node, children func() string) (string, bool)
+
+	// ResolveURL rewrites link and image targets, e.g. to make them absolute
+	ResolveURL func(string) string
+}
+
+// lineBreak marks a <br>. It becomes a hard line break when text follows on
+// the next line and is dropped otherwise.
+const lineBreak = "\x00"
+
+var (
+	whitespaceRegex = regexp.MustCompile(`[ \t\n\r\f\x{00a0}]+`)
+	listItemRegex   = regexp.MustCompile(`^\s*(?:[-*+]|\d+\.)\s`)
+	spacesRegex     = regexp.MustCompile(` {2,}`)
+)
+
+// skippedElements never contain readable content
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "head": true, "title": true,
+	"meta": true, "link": true, "iframe": true, "svg": true, "canvas": true,
+	"form": true, "button": true, "select": true, "textarea": true,
+	"template": true, "object": true, "embed": true,
+}
+
+// blockElements are separated from their surroundings by a blank line
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"header": true, "footer": true, "aside": true, "figure": true,
+	"figcaption": true, "address": true, "details": true, "summary": true,
+	"dl": true, "dt": true, "dd": true, "center": true,
+}
+
+// HTMLToMarkdown converts an HTML document or fragment to markdown
+func HTMLToMarkdown(content string) (string, error) {
+	converter := &MarkdownConverter{}
+	return converter.Convert(content)
+}
+
+// Convert parses content as HTML and converts it to markdown
+func (c *MarkdownConverter) Convert(content string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("error parsing HTML: %w", err)
+	}
+	return c.ConvertNode(doc), nil
+}
+
+// ConvertNode converts a parsed HTML node and its children to markdown
+func (c *MarkdownConverter) ConvertNode(n *html.Node) string {
+	return cleanMarkdown(c.render(n))
+}
+
+func (c *MarkdownConverter) render(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return whitespaceRegex.ReplaceAllString(n.Data, " ")
+	case html.DocumentNode:
+		return c.renderChildren(n)
+	case html.ElementNode:
+		return c.renderElement(n)
+	}
+	return ""
+}
+
+func (c *MarkdownConverter) renderChildren(n *html.Node) string {
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		rendered := c.render(child)
+		// Whitespace between blocks would otherwise indent the next line
+		if child.Type == html.TextNode && strings.HasSuffix(sb.String(), "\n") {
+			rendered = strings.TrimLeft(rendered, " ")
+		}
+		sb.WriteString(rendered)
+	}
+	return sb.String()
+}
+
+func (c *MarkdownConverter) renderElement(n *html.Node) string {
+	if c.RenderElement != nil {
+		children := func() string { return c.renderChildren(n) }
+		if out, ok := c.RenderElement(n, children); ok {
+			return out
+		}
+	}
+
+	tag := n.Data
+	switch {
+	case skippedElements[tag]:
+		return ""
+	case blockElements[tag]:
+		return "\n\n" + strings.TrimSpace(c.renderChildren(n)) + "\n\n"
+	}
+
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := singleLine(c.renderChildren(n))
+		if text == "" {
+			return ""
+		}
+		level := int(tag[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + text + "\n\n"
+	case "br":
+		return lineBreak + "\n"
+	case "hr":
+		return "\n\n---\n\n"
+	case "strong", "b":
+		return wrapInline("**", c.renderChildren(n))
+	case "em", "i":
+		return wrapInline("*", c.renderChildren(n))
+	case "s", "del", "strike":
+		return wrapInline("~~", c.renderChildren(n))
+	case "code", "kbd", "samp", "tt":
+		return inlineCode(textContent(n))
+	case "a":
+		return c.renderLink(n)
+	case "img":
+		return c.renderImage(n)
+	case "ul", "ol":
+		return c.renderList(n)
+	case "blockquote":
+		return renderQuote(cleanMarkdown(c.renderChildren(n)))
+	case "pre":
+		return renderCodeBlock(n)
+	case "table":
+		return c.renderTable(n)
+	case "input":
+		if attr(n, "type") != "checkbox" {
+			return ""
+		}
+		if hasAttr(n, "checked") {
+			return "[x] "
+		}
+		return "[ ] "
+	}
+
+	return c.renderChildren(n)
+}
+
+func (c *MarkdownConverter) resolve(target string) string {
+	if c.ResolveURL != nil {
+		return c.ResolveURL(target)
+	}
+	return target
+}
+
+func (c *MarkdownConverter) renderLink(n *html.Node) string {
+	content := c.renderChildren(n)
+	href := strings.TrimSpace(attr(n, "href"))
+	if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return content
+	}
+
+	text := singleLine(content)
+	if text == "" {
+		return ""
+	}
+	return "[" + text + "](" + escapeLinkTarget(c.resolve(href)) + ")"
+}
+
+func (c *MarkdownConverter) renderImage(n *html.Node) string {
+	src := strings.TrimSpace(attr(n, "src"))
+	if src == "" || strings.HasPrefix(src, "data:") {
+		// Lazy loading images keep the real source in a data attribute
+		src = strings.TrimSpace(attr(n, "data-src"))
+	}
+	if src == "" {
+		return ""
+	}
+
+	alt := singleLine(attr(n, "alt"))
+	alt = strings.NewReplacer("[", "", "]", "").Replace(alt)
+	return "![" + alt + "](" + escapeLinkTarget(c.resolve(src)) + ")"
+}
+
+func (c *MarkdownConverter) renderList(n *html.Node) string {
+	ordered := n.Data == "ol"
+	index := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil && ordered {
+		index = start
+	}
+
+	var items []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+
+		// Lists nested directly in a list belong to the previous item
+		if (child.Data == "ul" || child.Data == "ol") && len(items) > 0 {
+			nested := strings.TrimSpace(cleanMarkdown(c.renderList(child)))
+			items[len(items)-1] += "\n" + indent(nested, "  ")
+			continue
+		}
+		if child.Data != "li" {
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(index) + ". "
+			index++
+		}
+
+		content := tightenLists(strings.TrimSpace(cleanMarkdown(c.renderChildren(child))))
+		lines := strings.SplitN(content, "\n", 2)
+		item := marker + lines[0]
+		if len(lines) > 1 {
+			item += "\n" + indent(lines[1], strings.Repeat(" ", len(marker)))
+		}
+		items = append(items, item)
+	}
+
+	if len(items) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(items, "\n") + "\n\n"
+}
+
+func (c *MarkdownConverter) renderTable(n *html.Node) string {
+	var rows [][]string
+
+	var collectRows func(*html.Node)
+	collectRows = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "thead", "tbody", "tfoot":
+				collectRows(child)
+			case "tr":
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						text := singleLine(cleanMarkdown(c.renderChildren(cell)))
+						row = append(row, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	collectRows(n)
+
+	if len(rows) == 0 {
+		return ""
+	}
+	return "\n\n" + MarkdownTable(rows[0], rows[1:]) + "\n\n"
+}
+
+// MarkdownTable renders a markdown table. Rows shorter than the widest row are
+// padded with empty cells.
+func MarkdownTable(header []string, rows [][]string) string {
+	columns := len(header)
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	writeRow := func(sb *strings.Builder, cells []string) {
+		sb.WriteString("|")
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			sb.WriteString(" " + cell + " |")
+		}
+		sb.WriteString("\n")
+	}
+
+	var sb strings.Builder
+	writeRow(&sb, header)
+	sb.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	for _, row := range rows {
+		writeRow(&sb, row)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func renderQuote(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+}
+
+func renderCodeBlock(n *html.Node) string {
+	code := strings.Trim(textContent(n), "\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+
+	language := ""
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "code" {
+			language = codeLanguage(child)
+			break
+		}
+	}
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return "\n\n" + fence + language + "\n" + code + "\n" + fence + "\n\n"
+}
+
+// codeLanguage reads the language from class names like language-go or lang-go
+func codeLanguage(n *html.Node) string {
+	for _, class := range strings.Fields(attr(n, "class")) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(class, prefix) {
+				return strings.TrimPrefix(class, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+func inlineCode(code string) string {
+	code = whitespaceRegex.ReplaceAllString(code, " ")
+	if strings.TrimSpace(code) == "" {
+		return code
+	}
+
+	fence := "`"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// wrapInline surrounds content with an emphasis marker. Surrounding whitespace
+// is moved outside the markers, since markdown doesn't allow it inside.
+func wrapInline(marker, content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return content
+	}
+
+	leading, trailing := "", ""
+	if trimmed[0] != content[0] {
+		leading = " "
+	}
+	if trimmed[len(trimmed)-1] != content[len(content)-1] {
+		trailing = " "
+	}
+	return leading + marker + trimmed + marker + trailing
+}
+
+// textContent returns the raw text of a node, keeping line breaks
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		switch {
+		case node.Type == html.TextNode:
+			sb.WriteString(node.Data)
+		case node.Type == html.ElementNode && node.Data == "br":
+			sb.WriteString("\n")
+		default:
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				walk(child)
+			}
+			if node.Type == html.ElementNode && (blockElements[node.Data] || node.Data == "li") {
+				sb.WriteString("\n")
+			}
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// cleanMarkdown resolves line breaks, removes trailing whitespace and
+// collapses blank lines outside of code blocks
+func cleanMarkdown(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if !strings.HasSuffix(line, lineBreak) {
+			continue
+		}
+
+		line = strings.TrimRight(strings.ReplaceAll(line, lineBreak, ""), " \t")
+		if line != "" && i+1 < len(lines) && strings.TrimSpace(strings.ReplaceAll(lines[i+1], lineBreak, "")) != "" {
+			line += "\\"
+		}
+		lines[i] = line
+	}
+
+	var sb strings.Builder
+	fence := ""
+	blank := 0
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			sb.WriteString(line + "\n")
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, "`") == "" {
+				fence = ""
+			}
+			continue
+		}
+
+		if trimmed == "" {
+			blank++
+			if blank < 2 {
+				sb.WriteString("\n")
+			}
+			continue
+		}
+		blank = 0
+
+		if strings.HasPrefix(trimmed, "```") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, "`"))]
+		}
+		// Adjacent text nodes leave double spaces, indentation is kept for lists
+		body := strings.TrimLeft(line, " ")
+		body = spacesRegex.ReplaceAllString(strings.TrimRight(body, " \t"), " ")
+		sb.WriteString(line[:len(line)-len(strings.TrimLeft(line, " "))] + body + "\n")
+	}
+
+	return strings.Trim(sb.String(), "\n")
+}
+
+// tightenLists removes blank lines before nested lists so list items don't
+// turn into paragraphs
+func tightenLists(content string) string {
+	lines := strings.Split(content, "\n")
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if line == "" && i+1 < len(lines) && listItemRegex.MatchString(lines[i+1]) {
+			continue
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
+
+func indent(content, prefix string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func singleLine(content string) string {
+	content = strings.NewReplacer("\\\n", " ", lineBreak, "").Replace(content)
+	return strings.TrimSpace(whitespaceRegex.ReplaceAllString(content, " "))
+}
+
+// escapeLinkTarget encodes characters that would end a markdown link target
+func escapeLinkTarget(target string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(target)
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
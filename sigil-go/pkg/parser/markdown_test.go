@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func convert(t *testing.T, content string) string {
+	t.Helper()
+
+	markdown, err := HTMLToMarkdown(content)
+	require.NoError(t, err)
+	return markdown
+}
+
+func TestHTMLToMarkdownBlocks(t *testing.T) {
+	content := `<html><head><title>Ignored</title><style>p { color: red }</style></head><body>
+		<h1>Title</h1>
+		<p>Some <strong>bold</strong> and <em> spaced </em>text with <code>a_b</code>.</p>
+		<blockquote><p>Quoted</p><p>twice</p></blockquote>
+		<hr>
+		<pre><code class="language-go">func main() {
+
+	fmt.Println("hi")
+}</code></pre>
+		<script>alert("ignored")</script>
+	</body></html>`
+
+	expected := "# Title\n\n" +
+		"Some **bold** and *spaced* text with `a_b`.\n\n" +
+		"> Quoted\n>\n> twice\n\n" +
+		"---\n\n" +
+		"```go\nfunc main() {\n\n\tfmt.Println(\"hi\")\n}\n```"
+
+	assert.Equal(t, expected, convert(t, content))
+}
+
+func TestHTMLToMarkdownLists(t *testing.T) {
+	content := `<ul>
+		<li>One</li>
+		<li><p>Two</p><ol start="3"><li>Nested</li><li>Items</li></ol></li>
+		<li><input type="checkbox" checked> Done</li>
+		<li><input type="checkbox">Todo</li>
+	</ul>`
+
+	expected := "- One\n" +
+		"- Two\n" +
+		"  3. Nested\n" +
+		"  4. Items\n" +
+		"- [x] Done\n" +
+		"- [ ] Todo"
+
+	assert.Equal(t, expected, convert(t, content))
+}
+
+func TestHTMLToMarkdownLinksAndImages(t *testing.T) {
+	converter := &MarkdownConverter{
+		ResolveURL: func(target string) string {
+			if strings.HasPrefix(target, "/") {
+				return "https://example.com" + target
+			}
+			return target
+		},
+	}
+
+	markdown, err := converter.Convert(`<p><a href="/a page">Link</a> <a href="javascript:void(0)">plain</a> <a name="anchor"></a>` +
+		`<img src="/img (1).png" alt="An [image]"> <img src="data:image/gif;base64,R0lG" data-src="/lazy.png"></p>`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "[Link](https://example.com/a%20page) plain "+
+		"![An image](https://example.com/img%20%281%29.png) ![](https://example.com/lazy.png)", markdown)
+}
+
+func TestHTMLToMarkdownLineBreaks(t *testing.T) {
+	markdown := convert(t, `<div>First<br>second<br></div><div><br></div><div>Third</div>`)
+	assert.Equal(t, "First\\\nsecond\n\nThird", markdown)
+}
+
+func TestHTMLToMarkdownTable(t *testing.T) {
+	content := `<table>
+		<thead><tr><th>Name</th><th>Notes</th></tr></thead>
+		<tbody><tr><td>A | B</td><td><b>bold</b></td></tr><tr><td>Short</td></tr></tbody>
+	</table>`
+
+	expected := "| Name | Notes |\n" +
+		"| --- | --- |\n" +
+		"| A \\| B | **bold** |\n" +
+		"| Short | |"
+
+	assert.Equal(t, expected, convert(t, content))
+}
+
+func TestMarkdownConverterRenderElement(t *testing.T) {
+	converter := &MarkdownConverter{
+		RenderElement: func(n *html.Node, children func() string) (string, bool) {
+			if n.Data != "mention" {
+				return "", false
+			}
+			return "@" + children(), true
+		},
+	}
+
+	markdown, err := converter.Convert(`<p>Hi <mention>sam</mention>, <b>welcome</b></p>`)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi @sam, **welcome**", markdown)
+}
@@ -0,0 +1,352 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Article is the main content of a web page
+type Article struct {
+	Title    string
+	SiteName string
+	Content  *html.Node
+}
+
+var (
+	unlikelyCandidateRegex = regexp.MustCompile(`(?i)\bad-|\bads?\b|advert|banner|breadcrumb|comment|community|cookie|disqus|footer|masthead|menu|modal|newsletter|pager|pagination|popup|promo|related|share|sharing|sidebar|social|sponsor|subscribe|widget`)
+	positiveCandidateRegex = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|recipe|story|text`)
+	titleSeparatorRegex    = regexp.MustCompile(`\s+[|\-–—»·]\s+`)
+)
+
+// boilerplateElements are removed before looking for the main content
+var boilerplateElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true,
+	"form": true, "button": true, "nav": true, "aside": true, "footer": true,
+	"svg": true, "template": true, "select": true, "textarea": true,
+	"dialog": true, "link": true, "meta": true,
+}
+
+// boilerplateRoles mark landmarks that aren't part of the content
+var boilerplateRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true,
+	"complementary": true, "dialog": true, "alert": true, "search": true,
+}
+
+// ExtractArticle finds the main content of a page the way reader modes do.
+// Navigation, sidebars and similar boilerplate are removed and the element
+// holding most of the page's paragraph text is picked as the content.
+func ExtractArticle(content string) (Article, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return Article{}, fmt.Errorf("error parsing HTML: %w", err)
+	}
+
+	article := Article{
+		SiteName: metaContent(doc, "og:site_name", "application-name"),
+	}
+	article.Title = pageTitle(doc, article.SiteName)
+
+	body := findElement(doc, "body")
+	if body == nil {
+		return Article{}, fmt.Errorf("<body> tag not found in the HTML")
+	}
+
+	removeBoilerplate(body)
+	article.Content = mainContent(body)
+	cleanContent(article.Content, article.Title)
+
+	return article, nil
+}
+
+// pageTitle prefers the Open Graph title, which doesn't include the site name
+func pageTitle(doc *html.Node, siteName string) string {
+	if title := metaContent(doc, "og:title", "twitter:title"); title != "" {
+		return title
+	}
+
+	titleElement := findElement(doc, "title")
+	if titleElement == nil {
+		if heading := findElement(doc, "h1"); heading != nil {
+			return singleLine(textContent(heading))
+		}
+		return ""
+	}
+
+	title := singleLine(textContent(titleElement))
+	// Drop a trailing "| Site name"
+	if separators := titleSeparatorRegex.FindAllStringIndex(title, -1); len(separators) > 0 {
+		last := separators[len(separators)-1]
+		suffix := title[last[1]:]
+		isSiteName := siteName != "" && strings.EqualFold(suffix, siteName)
+		if isSiteName || utf8.RuneCountInString(suffix) < utf8.RuneCountInString(title)/3 {
+			title = title[:last[0]]
+		}
+	}
+	return title
+}
+
+// PageImage returns the URL of the image a page is shared with, as given by
+// its Open Graph or Twitter card tags
+func PageImage(content string) string {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return ""
+	}
+	return metaContent(doc, "og:image", "og:image:url", "og:image:secure_url", "twitter:image")
+}
+
+// CanonicalLink returns the href of a page's <link rel="canonical">, which
+// names the preferred URL of pages reachable under several URLs
+func CanonicalLink(content string) string {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return ""
+	}
+
+	var href string
+	walkElements(doc, func(n *html.Node) bool {
+		if href != "" {
+			return false
+		}
+		if n.Data == "link" && slices.Contains(strings.Fields(strings.ToLower(attr(n, "rel"))), "canonical") {
+			href = strings.TrimSpace(attr(n, "href"))
+		}
+		return true
+	})
+	return href
+}
+
+// metaContent returns the content of the first meta tag with one of the given
+// property or name values
+func metaContent(doc *html.Node, keys ...string) string {
+	values := make(map[string]string)
+	walkElements(doc, func(n *html.Node) bool {
+		if n.Data == "meta" {
+			key := attr(n, "property")
+			if key == "" {
+				key = attr(n, "name")
+			}
+			if _, exists := values[key]; !exists {
+				values[key] = strings.TrimSpace(attr(n, "content"))
+			}
+		}
+		return true
+	})
+
+	for _, key := range keys {
+		if value := values[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func removeBoilerplate(root *html.Node) {
+	var remove []*html.Node
+	walkElements(root, func(n *html.Node) bool {
+		if n == root {
+			return true
+		}
+		if isBoilerplate(n) {
+			remove = append(remove, n)
+			return false
+		}
+		return true
+	})
+
+	for _, n := range remove {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if boilerplateElements[n.Data] || boilerplateRoles[attr(n, "role")] {
+		return true
+	}
+	if hasAttr(n, "hidden") || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	if n.Data == "article" || n.Data == "main" {
+		return false
+	}
+
+	// Site headers usually hold the navigation, article headers hold the byline
+	if n.Data == "header" && findElement(n, "nav") != nil {
+		return true
+	}
+
+	classAndID := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyCandidateRegex.MatchString(classAndID) && !positiveCandidateRegex.MatchString(classAndID)
+}
+
+// mainContent picks the element holding the main content. Semantic markup is
+// trusted first, otherwise paragraphs are scored and their containers compete.
+func mainContent(body *html.Node) *html.Node {
+	if article := longestElement(body, func(n *html.Node) bool { return n.Data == "article" }); article != nil {
+		return article
+	}
+	if main := longestElement(body, func(n *html.Node) bool { return n.Data == "main" || attr(n, "role") == "main" }); main != nil {
+		return main
+	}
+
+	scores := make(map[*html.Node]float64)
+	// Candidates are kept in document order so ties are decided consistently
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if _, exists := scores[n]; !exists {
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	walkElements(body, func(n *html.Node) bool {
+		switch n.Data {
+		case "p", "pre", "td", "blockquote":
+		default:
+			return true
+		}
+
+		text := singleLine(textContent(n))
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			return true
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(length)/100, 3)
+		if parent := n.Parent; parent != nil && parent.Type == html.ElementNode {
+			addScore(parent, score)
+			if grandparent := parent.Parent; grandparent != nil && grandparent.Type == html.ElementNode {
+				addScore(grandparent, score/2)
+			}
+		}
+		return true
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range candidates {
+		score := (scores[n] + classWeight(n)) * (1 - linkDensity(n))
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+
+	if best == nil {
+		return body
+	}
+	return best
+}
+
+// classWeight rewards class names that suggest content and penalizes the rest
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, value := range []string{attr(n, "class"), attr(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if positiveCandidateRegex.MatchString(value) {
+			weight += 25
+		}
+		if unlikelyCandidateRegex.MatchString(value) {
+			weight -= 25
+		}
+	}
+	return weight
+}
+
+// cleanContent removes blocks made up mostly of links, like tag lists and
+// "read more" boxes, and the heading repeating the title
+func cleanContent(content *html.Node, title string) {
+	var remove []*html.Node
+	titleRemoved := false
+	walkElements(content, func(n *html.Node) bool {
+		if n == content {
+			return true
+		}
+
+		switch n.Data {
+		case "h1":
+			if !titleRemoved && title != "" && strings.EqualFold(singleLine(textContent(n)), title) {
+				titleRemoved = true
+				remove = append(remove, n)
+				return false
+			}
+		case "div", "section", "ul", "ol", "table":
+			length := utf8.RuneCountInString(singleLine(textContent(n)))
+			if length > 0 && length < 200 && linkDensity(n) > 0.65 && findElement(n, "img") == nil {
+				remove = append(remove, n)
+				return false
+			}
+		}
+		return true
+	})
+
+	for _, n := range remove {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+// linkDensity is the share of an element's text that is inside links
+func linkDensity(n *html.Node) float64 {
+	length := utf8.RuneCountInString(singleLine(textContent(n)))
+	if length == 0 {
+		return 0
+	}
+
+	linkLength := 0
+	walkElements(n, func(child *html.Node) bool {
+		if child.Data == "a" {
+			linkLength += utf8.RuneCountInString(singleLine(textContent(child)))
+			return false
+		}
+		return true
+	})
+	return float64(linkLength) / float64(length)
+}
+
+// longestElement returns the matching element with the most text
+func longestElement(root *html.Node, match func(*html.Node) bool) *html.Node {
+	var best *html.Node
+	bestLength := 0
+	walkElements(root, func(n *html.Node) bool {
+		if !match(n) {
+			return true
+		}
+		if length := utf8.RuneCountInString(singleLine(textContent(n))); length > bestLength {
+			best, bestLength = n, length
+		}
+		return false
+	})
+	return best
+}
+
+func findElement(root *html.Node, tag string) *html.Node {
+	var found *html.Node
+	walkElements(root, func(n *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if n.Data == tag {
+			found = n
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// walkElements calls fn for every element below and including root. Returning
+// false skips the element's children.
+func walkElements(root *html.Node, fn func(*html.Node) bool) {
+	if root.Type == html.ElementNode && !fn(root) {
+		return
+	}
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		walkElements(child, fn)
+	}
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func extractMarkdown(t *testing.T, content string) (Article, string) {
+	t.Helper()
+
+	article, err := ExtractArticle(content)
+	require.NoError(t, err)
+	return article, (&MarkdownConverter{}).ConvertNode(article.Content)
+}
+
+func TestExtractArticlePrefersArticleElement(t *testing.T) {
+	content := `<html><head>
+		<meta property="og:site_name" content="The Daily">
+		<title>Bread at home | The Daily</title>
+	</head><body>
+		<header><nav><a href="/">Home</a> <a href="/news">News</a></nav></header>
+		<div class="sidebar"><p>Popular stories you might have missed, all of them.</p></div>
+		<article>
+			<h1>Bread at home</h1>
+			<p>Baking bread takes time, patience and a warm kitchen.</p>
+			<ul class="tags"><li><a href="/t/bread">bread</a></li><li><a href="/t/baking">baking</a></li></ul>
+			<img src="/loaf.jpg" alt="Loaf">
+		</article>
+		<footer>Copyright</footer>
+	</body></html>`
+
+	article, markdown := extractMarkdown(t, content)
+
+	assert.Equal(t, "Bread at home", article.Title)
+	assert.Equal(t, "The Daily", article.SiteName)
+	assert.Equal(t, "Baking bread takes time, patience and a warm kitchen.\n\n![Loaf](/loaf.jpg)", markdown)
+}
+
+func TestExtractArticleScoresParagraphs(t *testing.T) {
+	content := `<html><head><title>A long title for the post - Blog</title></head><body>
+		<div id="menu"><a href="/">Home</a><a href="/about">About</a></div>
+		<div class="wrapper">
+			<div class="links"><p><a href="/a">A link that is long enough to be scored here</a></p></div>
+			<div class="post-body">
+				<p>The first paragraph, which has commas, and enough text to count.</p>
+				<p>The second paragraph is here too, with more text to make it win.</p>
+			</div>
+		</div>
+		<div class="comments"><p>A comment that is long enough to be scored, too.</p></div>
+	</body></html>`
+
+	article, markdown := extractMarkdown(t, content)
+
+	assert.Equal(t, "A long title for the post", article.Title)
+	assert.Equal(t,
+		"The first paragraph, which has commas, and enough text to count.\n\n"+
+			"The second paragraph is here too, with more text to make it win.",
+		markdown)
+}
+
+func TestExtractArticleTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     string
+		body     string
+		expected string
+	}{
+		{"open graph", `<meta property="og:title" content="OG title"><title>Other | Site</title>`, "", "OG title"},
+		{"site name suffix", `<meta property="og:site_name" content="Example"><title>Short - Example</title>`, "", "Short"},
+		{"keeps long suffix", `<title>Tea | How to brew a good cup of tea</title>`, "", "Tea | How to brew a good cup of tea"},
+		{"heading fallback", "", "<h1>Heading</h1>", "Heading"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article, err := ExtractArticle("<html><head>" + tt.head + "</head><body>" + tt.body + "</body></html>")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, article.Title)
+		})
+	}
+}
+
+func TestPageImage(t *testing.T) {
+	assert.Equal(t, "https://example.com/og.jpg", PageImage(`<html><head>
+		<meta name="twitter:image" content="https://example.com/card.jpg">
+		<meta property="og:image" content=" https://example.com/og.jpg ">
+	</head><body></body></html>`))
+	assert.Equal(t, "", PageImage(`<html><head><title>No image</title></head></html>`))
+}
+
+func TestCanonicalLink(t *testing.T) {
+	assert.Equal(t, "https://example.com/recipes/pancakes", CanonicalLink(`<html><head>
+		<link rel="stylesheet" href="/style.css">
+		<link rel="Canonical" href=" https://example.com/recipes/pancakes ">
+		<link rel="canonical" href="https://example.com/other">
+	</head><body></body></html>`))
+	assert.Equal(t, "", CanonicalLink(`<html><head><title>No canonical link</title></head></html>`))
+}
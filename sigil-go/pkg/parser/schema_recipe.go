@@ -0,0 +1,411 @@
+package parser
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// SchemaRecipe is a recipe embedded in a page as schema.org structured data
+type SchemaRecipe struct {
+	Name        string
+	Description string
+	// Yield holds every recipeYield value, like "4" and "4 servings"
+	Yield        []string
+	PrepTime     time.Duration
+	CookTime     time.Duration
+	TotalTime    time.Duration
+	Cuisine      string
+	Category     string   // the course, like "Dinner" or "Dessert"
+	Tools        []string // equipment like "oven" or "stand mixer"
+	Ingredients  []string
+	Instructions []string
+	// Image is the URL of the recipe's main image
+	Image string
+	// StepImages maps the index of an instruction to the URL of its image
+	StepImages map[int]string
+	// Sections maps the index of the first instruction of a section, like
+	// "For the sauce", to its name
+	Sections map[int]string
+}
+
+// Servings returns the first number in the recipe's yield
+func (r SchemaRecipe) Servings() (int, bool) {
+	for _, yield := range r.Yield {
+		if match := leadingNumberRegex.FindString(yield); match != "" {
+			if servings, err := strconv.Atoi(match); err == nil && servings > 0 {
+				return servings, true
+			}
+		}
+	}
+	return 0, false
+}
+
+var (
+	// ISO 8601 durations as used by schema.org, e.g. PT1H30M or P0DT0H20M
+	isoDurationRegex   = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+	leadingNumberRegex = regexp.MustCompile(`\d+`)
+)
+
+// ExtractSchemaRecipe finds a schema.org Recipe in a page's JSON-LD or
+// Microdata. Recipes without ingredients and instructions are ignored.
+func ExtractSchemaRecipe(content string) (SchemaRecipe, bool) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return SchemaRecipe{}, false
+	}
+
+	var items []map[string]any
+	walkElements(doc, func(n *html.Node) bool {
+		switch {
+		case n.Data == "script" && strings.EqualFold(strings.TrimSpace(attr(n, "type")), "application/ld+json"):
+			var data any
+			if err := json.Unmarshal([]byte(escapeControlCharacters(textContent(n))), &data); err == nil {
+				items = append(items, findSchemaRecipes(data)...)
+			}
+			return false
+		case hasAttr(n, "itemscope") && lastPathSegment(attr(n, "itemtype")) == "Recipe":
+			items = append(items, microdataItem(n))
+			return false
+		}
+		return true
+	})
+
+	for _, item := range items {
+		recipe := schemaRecipe(item)
+		if len(recipe.Ingredients) > 0 || len(recipe.Instructions) > 0 {
+			return recipe, true
+		}
+	}
+	return SchemaRecipe{}, false
+}
+
+// findSchemaRecipes returns the Recipe objects in a JSON-LD document. Recipes
+// can be the document itself, part of a list or @graph, or the main entity of
+// a web page.
+func findSchemaRecipes(data any) []map[string]any {
+	switch value := data.(type) {
+	case []any:
+		var recipes []map[string]any
+		for _, element := range value {
+			recipes = append(recipes, findSchemaRecipes(element)...)
+		}
+		return recipes
+	case map[string]any:
+		if hasRecipeType(value["@type"]) {
+			return []map[string]any{value}
+		}
+		var recipes []map[string]any
+		for _, key := range []string{"@graph", "mainEntity"} {
+			if nested, ok := value[key]; ok {
+				recipes = append(recipes, findSchemaRecipes(nested)...)
+			}
+		}
+		return recipes
+	}
+	return nil
+}
+
+func hasRecipeType(value any) bool {
+	for _, t := range schemaValues(value) {
+		if s, ok := t.(string); ok && lastPathSegment(s) == "Recipe" {
+			return true
+		}
+	}
+	return false
+}
+
+// lastPathSegment strips the vocabulary from types like
+// https://schema.org/Recipe or schema:Recipe
+func lastPathSegment(t string) string {
+	t = strings.TrimSpace(t)
+	if i := strings.LastIndexAny(t, "/:"); i >= 0 {
+		return t[i+1:]
+	}
+	return t
+}
+
+func schemaRecipe(item map[string]any) SchemaRecipe {
+	recipe := SchemaRecipe{
+		Name:        firstSchemaText(item["name"]),
+		Description: firstSchemaText(item["description"]),
+		PrepTime:    parseISODuration(firstSchemaText(item["prepTime"])),
+		CookTime:    parseISODuration(firstSchemaText(item["cookTime"])),
+		TotalTime:   parseISODuration(firstSchemaText(item["totalTime"])),
+		Cuisine:     firstListText(item["recipeCuisine"]),
+		Category:    firstListText(item["recipeCategory"]),
+		Image:       schemaImage(item["image"]),
+	}
+
+	for _, value := range schemaValues(item["tool"]) {
+		if text := schemaText(value); text != "" {
+			recipe.Tools = append(recipe.Tools, text)
+		}
+	}
+
+	for _, value := range schemaValues(item["recipeYield"]) {
+		if text := schemaText(value); text != "" {
+			recipe.Yield = append(recipe.Yield, text)
+		}
+	}
+
+	ingredients := item["recipeIngredient"]
+	if ingredients == nil {
+		// Older markup uses the deprecated ingredients property
+		ingredients = item["ingredients"]
+	}
+	for _, value := range schemaValues(ingredients) {
+		recipe.Ingredients = append(recipe.Ingredients, schemaLines(value)...)
+	}
+
+	recipe.addSteps(item["recipeInstructions"])
+	return recipe
+}
+
+// addSteps flattens recipeInstructions, which can be text, a list of HowToStep
+// or HowToSection objects, or a mix of them. A step's image is kept for the
+// first instruction line of the step, and a section's name for its first
+// step.
+func (r *SchemaRecipe) addSteps(value any) {
+	switch v := value.(type) {
+	case []any:
+		for _, element := range v {
+			r.addSteps(element)
+		}
+	case map[string]any:
+		// HowToSection and ItemList group their steps in itemListElement
+		if elements, ok := v["itemListElement"]; ok {
+			start := len(r.Instructions)
+			r.addSteps(elements)
+			if name := schemaText(v["name"]); name != "" && len(r.Instructions) > start {
+				r.addSection(start, name)
+			}
+			return
+		}
+		for _, key := range []string{"text", "name"} {
+			if steps := schemaLines(v[key]); len(steps) > 0 {
+				if image := schemaImage(v["image"]); image != "" {
+					if r.StepImages == nil {
+						r.StepImages = make(map[int]string)
+					}
+					r.StepImages[len(r.Instructions)] = image
+				}
+				r.Instructions = append(r.Instructions, steps...)
+				return
+			}
+		}
+	default:
+		r.Instructions = append(r.Instructions, schemaLines(value)...)
+	}
+}
+
+// addSection names the section starting at an instruction. Nested sections
+// are added first and keep their name.
+func (r *SchemaRecipe) addSection(step int, name string) {
+	if r.Sections == nil {
+		r.Sections = make(map[int]string)
+	}
+	if _, exists := r.Sections[step]; !exists {
+		r.Sections[step] = name
+	}
+}
+
+// schemaImage returns the first URL of an image property, which can be a URL,
+// an ImageObject or a list of either
+func schemaImage(value any) string {
+	for _, v := range schemaValues(value) {
+		switch image := v.(type) {
+		case string:
+			if url := strings.TrimSpace(image); url != "" {
+				return url
+			}
+		case map[string]any:
+			for _, key := range []string{"url", "contentUrl", "@id"} {
+				if url := schemaImage(image[key]); url != "" {
+					return url
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// schemaValues returns a property's values, properties can have one or many
+func schemaValues(value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+func firstSchemaText(value any) string {
+	for _, v := range schemaValues(value) {
+		if text := schemaText(v); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// firstListText returns the first text of a value that can be a list, either
+// as an array or as comma separated text like "Dinner, Main Course"
+func firstListText(value any) string {
+	first, _, _ := strings.Cut(firstSchemaText(value), ",")
+	return strings.TrimSpace(first)
+}
+
+// schemaText returns a value as a single line of plain text. Sites often put
+// HTML and entities in their structured data.
+func schemaText(value any) string {
+	return strings.Join(schemaLines(value), " ")
+}
+
+// schemaLines returns the non-empty lines of a text value, with markup removed
+func schemaLines(value any) []string {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any:
+		// Values can be wrapped as {"@value": "..."}
+		for _, key := range []string{"@value", "text", "name"} {
+			if lines := schemaLines(v[key]); len(lines) > 0 {
+				return lines
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	if strings.ContainsAny(text, "<&") {
+		if doc, err := html.Parse(strings.NewReader(text)); err == nil {
+			text = textContent(doc)
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = singleLine(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// microdataItem collects the properties of an itemscope element into the same
+// shape as a JSON-LD object
+func microdataItem(n *html.Node) map[string]any {
+	item := map[string]any{}
+	if itemType := attr(n, "itemtype"); itemType != "" {
+		item["@type"] = lastPathSegment(itemType)
+	}
+
+	var walk func(*html.Node)
+	walk = func(parent *html.Node) {
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if props := strings.Fields(attr(child, "itemprop")); len(props) > 0 {
+				value := microdataValue(child)
+				for _, prop := range props {
+					existing, _ := item[prop].([]any)
+					item[prop] = append(existing, value)
+				}
+			}
+			// Properties of nested items belong to them
+			if !hasAttr(child, "itemscope") {
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+
+	return item
+}
+
+func microdataValue(n *html.Node) any {
+	if hasAttr(n, "itemscope") {
+		return microdataItem(n)
+	}
+
+	switch n.Data {
+	case "meta":
+		return attr(n, "content")
+	case "a", "link":
+		return attr(n, "href")
+	case "img", "audio", "video", "source":
+		return attr(n, "src")
+	case "time":
+		if datetime := attr(n, "datetime"); datetime != "" {
+			return datetime
+		}
+	case "data", "meter":
+		return attr(n, "value")
+	}
+	if content := attr(n, "content"); content != "" {
+		return content
+	}
+	return textContent(n)
+}
+
+// parseISODuration parses durations like PT1H30M, returning zero for invalid
+// values
+func parseISODuration(value string) time.Duration {
+	match := isoDurationRegex.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(value)))
+	if match == nil {
+		return 0
+	}
+
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var duration time.Duration
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(match[i+1], 64)
+		if err != nil {
+			return 0
+		}
+		duration += time.Duration(amount * float64(unit))
+	}
+	return duration
+}
+
+// escapeControlCharacters escapes raw line breaks and tabs inside JSON
+// strings, which are invalid but common in hand written JSON-LD
+func escapeControlCharacters(data string) string {
+	var sb strings.Builder
+	inString, escaped := false, false
+	for _, r := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case inString && r == '\n':
+			sb.WriteString(`\n`)
+			continue
+		case inString && r == '\r':
+			continue
+		case inString && r == '\t':
+			sb.WriteString(`\t`)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSchemaRecipeJSONLD(t *testing.T) {
+	content := `<html><head>
+		<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Organization", "name": "Cooking Site"}</script>
+		<script type="application/ld+json">
+		{
+			"@context": "https://schema.org",
+			"@graph": [
+				{"@type": "WebPage", "name": "Pancakes | Cooking Site"},
+				{
+					"@type": ["Recipe", "NewsArticle"],
+					"name": "Fluffy Pancakes &amp; Syrup",
+					"description": "<p>Light and fluffy.</p>",
+					"recipeYield": ["4", "4 servings"],
+					"prepTime": "PT10M",
+					"cookTime": "PT0H20M",
+					"totalTime": "P0DT0H30M",
+					"recipeCuisine": "American",
+					"recipeCategory": "Breakfast, Brunch",
+					"tool": [{"@type": "HowToTool", "name": "Frying pan"}, "Whisk"],
+					"image": [{"@type": "ImageObject", "url": "https://example.com/pancakes.jpg"}, "https://example.com/pancakes-4x3.jpg"],
+					"recipeIngredient": ["200 g flour", "2 eggs", "300 ml milk"],
+					"recipeInstructions": [
+						{"@type": "HowToSection", "name": "Batter", "itemListElement": [
+							{"@type": "HowToStep", "text": "Whisk the flour,
+eggs and milk."},
+							{"@type": "HowToStep", "name": "Rest the batter."}
+						]},
+						{"@type": "HowToStep", "text": "Fry in a hot pan.", "image": "https://example.com/fry.jpg"}
+					]
+				}
+			]
+		}
+		</script>
+	</head><body><p>Page</p></body></html>`
+
+	recipe, ok := ExtractSchemaRecipe(content)
+	require.True(t, ok)
+
+	assert.Equal(t, "Fluffy Pancakes & Syrup", recipe.Name)
+	assert.Equal(t, "Light and fluffy.", recipe.Description)
+	assert.Equal(t, []string{"4", "4 servings"}, recipe.Yield)
+	assert.Equal(t, 10*time.Minute, recipe.PrepTime)
+	assert.Equal(t, 20*time.Minute, recipe.CookTime)
+	assert.Equal(t, 30*time.Minute, recipe.TotalTime)
+	assert.Equal(t, "American", recipe.Cuisine)
+	assert.Equal(t, "Breakfast", recipe.Category)
+	assert.Equal(t, []string{"Frying pan", "Whisk"}, recipe.Tools)
+	assert.Equal(t, []string{"200 g flour", "2 eggs", "300 ml milk"}, recipe.Ingredients)
+	assert.Equal(t, []string{"Whisk the flour,", "eggs and milk.", "Rest the batter.", "Fry in a hot pan."}, recipe.Instructions)
+	assert.Equal(t, "https://example.com/pancakes.jpg", recipe.Image)
+	assert.Equal(t, map[int]string{3: "https://example.com/fry.jpg"}, recipe.StepImages)
+	assert.Equal(t, map[int]string{0: "Batter"}, recipe.Sections)
+
+	servings, ok := recipe.Servings()
+	assert.True(t, ok)
+	assert.Equal(t, 4, servings)
+}
+
+func TestExtractSchemaRecipeTextInstructions(t *testing.T) {
+	content := `<html><head><script type="application/ld+json">
+		{"@type": "WebPage", "mainEntity": {
+			"@type": "http://schema.org/Recipe",
+			"name": "Toast",
+			"recipeYield": 2,
+			"ingredients": "2 slices of bread",
+			"recipeInstructions": "<ol><li>Toast the bread.</li><li>Butter it.</li></ol>"
+		}}
+	</script></head><body></body></html>`
+
+	recipe, ok := ExtractSchemaRecipe(content)
+	require.True(t, ok)
+
+	assert.Equal(t, "Toast", recipe.Name)
+	assert.Equal(t, []string{"2"}, recipe.Yield)
+	assert.Equal(t, []string{"2 slices of bread"}, recipe.Ingredients)
+	assert.Equal(t, []string{"Toast the bread.", "Butter it."}, recipe.Instructions)
+}
+
+func TestExtractSchemaRecipeMicrodata(t *testing.T) {
+	content := `<html><body>
+		<div itemscope itemtype="https://schema.org/Recipe">
+			<h1 itemprop="name">Lemonade</h1>
+			<img itemprop="image" src="/images/lemonade.jpg" alt="">
+			<meta itemprop="prepTime" content="PT5M">
+			<span itemprop="recipeYield">Serves 6</span>
+			<div itemprop="author" itemscope itemtype="https://schema.org/Person">
+				<span itemprop="name">Ann</span>
+			</div>
+			<ul>
+				<li itemprop="recipeIngredient">4 lemons</li>
+				<li itemprop="recipeIngredient">1 l <b>cold</b> water</li>
+			</ul>
+			<div itemprop="recipeInstructions">
+				<p>Squeeze the lemons.</p>
+				<p>Stir in the water.</p>
+			</div>
+		</div>
+	</body></html>`
+
+	recipe, ok := ExtractSchemaRecipe(content)
+	require.True(t, ok)
+
+	assert.Equal(t, "Lemonade", recipe.Name)
+	assert.Equal(t, 5*time.Minute, recipe.PrepTime)
+	assert.Equal(t, []string{"4 lemons", "1 l cold water"}, recipe.Ingredients)
+	assert.Equal(t, []string{"Squeeze the lemons.", "Stir in the water."}, recipe.Instructions)
+	assert.Equal(t, "/images/lemonade.jpg", recipe.Image)
+	assert.Nil(t, recipe.StepImages)
+
+	servings, ok := recipe.Servings()
+	assert.True(t, ok)
+	assert.Equal(t, 6, servings)
+}
+
+func TestExtractSchemaRecipeNotFound(t *testing.T) {
+	for name, content := range map[string]string{
+		"no structured data": `<html><body><p>Just a page</p></body></html>`,
+		"invalid JSON":       `<html><head><script type="application/ld+json">{"@type": "Recipe",</script></head></html>`,
+		"empty recipe":       `<html><head><script type="application/ld+json">{"@type": "Recipe", "name": "Nothing"}</script></head></html>`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, ok := ExtractSchemaRecipe(content)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestParseISODuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT15M":      15 * time.Minute,
+		"PT1H30M":    90 * time.Minute,
+		"P1DT2H":     26 * time.Hour,
+		"PT0.5H":     30 * time.Minute,
+		"pt45s":      45 * time.Second,
+		"P0DT0H0M":   0,
+		"15 minutes": 0,
+		"":           0,
+	}
+
+	for value, expected := range tests {
+		assert.Equal(t, expected, parseISODuration(value), value)
+	}
+}
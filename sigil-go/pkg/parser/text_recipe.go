@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Section headings of recipes written as text, in English and Norwegian
+var (
+	ingredientHeadings = map[string]bool{
+		"ingredients": true, "ingredient list": true, "you will need": true, "you'll need": true,
+		"ingredienser": true, "du trenger": true,
+	}
+	instructionHeadings = map[string]bool{
+		"instructions": true, "directions": true, "method": true, "steps": true, "preparation": true,
+		"fremgangsmåte": true, "framgangsmåte": true, "slik gjør du": true, "instruksjoner": true,
+	}
+	// Sections after the instructions that aren't steps
+	otherHeadings = map[string]bool{
+		"notes": true, "tips": true, "nutrition": true, "recipe info": true,
+		"notater": true, "tips og triks": true, "næringsinnhold": true,
+	}
+)
+
+var (
+	stepNumberRegex = regexp.MustCompile(`^(?i)(?:step\s+\d+[.:)]?|steg\s+\d+[.:)]?|\d+[.)])\s*`)
+	textListRegex   = regexp.MustCompile(`^[-*•]\s+`)
+	textYieldRegex  = regexp.MustCompile(`^(?i)(?:servings|serves|yield|makes|porsjoner|antall)\s*:?\s*(.+)$`)
+)
+
+// ParseTextRecipe reads a recipe written as plain text or markdown, like one
+// pasted from an email, into the same shape as structured data. The first line
+// is the name, and the recipe needs an ingredients and an instructions
+// heading, e.g. "Ingredients:" or "## Fremgangsmåte".
+func ParseTextRecipe(text string) (SchemaRecipe, bool) {
+	const (
+		intro = iota
+		ingredients
+		instructions
+		other
+	)
+
+	recipe := SchemaRecipe{}
+	var description []string
+	var stepSection string // the subheading of the next step
+	section := intro
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		heading := textHeading(line)
+		switch {
+		case ingredientHeadings[heading]:
+			section = ingredients
+			continue
+		case instructionHeadings[heading]:
+			section = instructions
+			continue
+		case otherHeadings[heading]:
+			section = other
+			continue
+		}
+
+		switch section {
+		case intro:
+			if recipe.Name == "" {
+				recipe.Name = stripMarkdown(strings.TrimLeft(line, "# "))
+			} else if match := textYieldRegex.FindStringSubmatch(stripMarkdown(line)); match != nil {
+				recipe.Yield = append(recipe.Yield, strings.TrimSpace(match[1]))
+			} else if !strings.HasPrefix(line, "#") {
+				description = append(description, stripMarkdown(line))
+			}
+		case ingredients:
+			// Subheadings like "For the sauce:" group ingredients
+			if strings.HasPrefix(line, "#") || (strings.HasSuffix(line, ":") && !strings.ContainsAny(line, "0123456789")) {
+				continue
+			}
+			recipe.Ingredients = append(recipe.Ingredients, textListRegex.ReplaceAllString(line, ""))
+		case instructions:
+			// Subheadings like "For the sauce:" start a section of steps
+			if strings.HasPrefix(line, "#") || (strings.HasSuffix(line, ":") && !strings.ContainsAny(line, "0123456789")) {
+				if name := strings.TrimSuffix(stripMarkdown(strings.TrimLeft(line, "# ")), ":"); name != "" {
+					stepSection = name
+				}
+				continue
+			}
+			if step := strings.TrimSpace(stepNumberRegex.ReplaceAllString(textListRegex.ReplaceAllString(line, ""), "")); step != "" {
+				if stepSection != "" {
+					recipe.addSection(len(recipe.Instructions), stepSection)
+					stepSection = ""
+				}
+				recipe.Instructions = append(recipe.Instructions, step)
+			}
+		}
+	}
+
+	recipe.Description = strings.Join(description, " ")
+	return recipe, len(recipe.Ingredients) > 0 && len(recipe.Instructions) > 0
+}
+
+// textHeading returns a line as a lowercase heading without markdown and
+// punctuation, so "## Ingredients" and "INGREDIENTS:" are the same
+func textHeading(line string) string {
+	heading := strings.ToLower(stripMarkdown(strings.TrimLeft(line, "# ")))
+	return strings.TrimSpace(strings.TrimSuffix(heading, ":"))
+}
+
+// stripMarkdown removes bold and italic markers
+func stripMarkdown(text string) string {
+	return strings.TrimSpace(strings.NewReplacer("**", "", "__", "").Replace(text))
+}
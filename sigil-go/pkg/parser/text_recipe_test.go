@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTextRecipe(t *testing.T) {
+	text := `Grandma's Pancakes
+
+Light and fluffy, every Sunday.
+Serves: 4
+
+Ingredients:
+- 3 dl flour
+- 2 eggs
+For the topping:
+• Blueberries (optional)
+
+Instructions:
+1. Whisk the eggs and flour.
+2) Fry in butter.
+Step 3: Serve with blueberries.
+
+Notes:
+Use buttermilk for extra fluff.`
+
+	recipe, ok := ParseTextRecipe(text)
+	assert.True(t, ok)
+	assert.Equal(t, "Grandma's Pancakes", recipe.Name)
+	assert.Equal(t, "Light and fluffy, every Sunday.", recipe.Description)
+	assert.Equal(t, []string{"4"}, recipe.Yield)
+	assert.Equal(t, []string{"3 dl flour", "2 eggs", "Blueberries (optional)"}, recipe.Ingredients)
+	assert.Equal(t, []string{"Whisk the eggs and flour.", "Fry in butter.", "Serve with blueberries."}, recipe.Instructions)
+}
+
+func TestParseTextRecipeMarkdown(t *testing.T) {
+	text := "# Vafler\n\n**Porsjoner:** 6\n\n## Ingredienser\n\n- 5 dl hvetemel\n- 3 egg\n\n## Fremgangsmåte\n\n1. Bland alt.\n2. Stek.\n"
+
+	recipe, ok := ParseTextRecipe(text)
+	assert.True(t, ok)
+	assert.Equal(t, "Vafler", recipe.Name)
+	assert.Equal(t, []string{"6"}, recipe.Yield)
+	assert.Equal(t, []string{"5 dl hvetemel", "3 egg"}, recipe.Ingredients)
+	assert.Equal(t, []string{"Bland alt.", "Stek."}, recipe.Instructions)
+}
+
+func TestParseTextRecipeStepSections(t *testing.T) {
+	text := "Lasagna\n\nIngredients:\n500 g mince\n50 g butter\n\nInstructions:\n" +
+		"For the meat sauce:\n1. Brown the mince.\n2. Simmer.\n### Béchamel\n3. Melt the butter."
+
+	recipe, ok := ParseTextRecipe(text)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Brown the mince.", "Simmer.", "Melt the butter."}, recipe.Instructions)
+	assert.Equal(t, map[int]string{0: "For the meat sauce", 2: "Béchamel"}, recipe.Sections)
+}
+
+func TestParseTextRecipeWithoutSections(t *testing.T) {
+	_, ok := ParseTextRecipe("Mix 3 dl flour with 2 eggs and fry them in butter.")
+	assert.False(t, ok)
+
+	_, ok = ParseTextRecipe("Pancakes\n\nIngredients:\n3 dl flour\n2 eggs")
+	assert.False(t, ok)
+}
@@ -0,0 +1,238 @@
+package recipeformat
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// Cookbook is a collection of recipes written as a single printable HTML
+// document
+type Cookbook struct {
+	Title       string
+	Description string
+	Recipes     []models.Recipe
+	// Images maps the file IDs of recipe images to the URLs they are shown
+	// from, usually data URLs so the document works offline. Images missing
+	// from the map are left out.
+	Images map[uuid.UUID]string
+}
+
+// cookbookStyle lays the cookbook out for print, each recipe starting on a
+// new page
+const cookbookStyle = `
+body { font-family: Georgia, serif; line-height: 1.5; max-width: 42em; margin: 2em auto; padding: 0 1em; color: #222; }
+h1, h2, h3, h4 { font-family: Helvetica, Arial, sans-serif; line-height: 1.2; }
+header { text-align: center; margin: 6em 0 4em; }
+header h1 { font-size: 2.6em; }
+nav ol { padding-left: 1.5em; }
+nav a { color: inherit; text-decoration: none; }
+.recipe { break-before: page; }
+img { max-width: 100%; max-height: 22em; display: block; margin: 0.5em 0; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+@media print { body { margin: 0; max-width: none; } }
+`
+
+var (
+	headingRegex   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletRegex    = regexp.MustCompile(`^[-*]\s+(.*)$`)
+	numberedRegex  = regexp.MustCompile(`^(\d+)\.\s+(.*)$`)
+	imageRegex     = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	strongRegex    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	emphasisRegex  = regexp.MustCompile(`\*([^*\s][^*]*?)\*`)
+	tableRuleRegex = regexp.MustCompile(`^\|(\s*:?-+:?\s*\|)+$`)
+)
+
+// WriteCookbook writes a cookbook with a title page, a table of contents and
+// the recipes as their notes show them
+func WriteCookbook(w io.Writer, cookbook Cookbook) error {
+	var doc strings.Builder
+	title := html.EscapeString(cookbook.Title)
+
+	doc.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	doc.WriteString("<title>" + title + "</title>\n")
+	doc.WriteString("<style>" + cookbookStyle + "</style>\n</head>\n<body>\n")
+
+	doc.WriteString("<header>\n<h1>" + title + "</h1>\n")
+	if cookbook.Description != "" {
+		doc.WriteString("<p>" + html.EscapeString(cookbook.Description) + "</p>\n")
+	}
+	doc.WriteString("</header>\n")
+
+	doc.WriteString("<nav>\n<h2>Contents</h2>\n<ol>\n")
+	for i, recipe := range cookbook.Recipes {
+		fmt.Fprintf(&doc, "<li><a href=\"#recipe-%d\">%s</a></li>\n", i+1, html.EscapeString(recipe.Name))
+	}
+	doc.WriteString("</ol>\n</nav>\n")
+
+	imageURL := func(src string) (string, bool) {
+		fileID, err := uuid.Parse(strings.TrimPrefix(src, "/files/"))
+		if err != nil {
+			return "", false
+		}
+		url, ok := cookbook.Images[fileID]
+		return url, ok
+	}
+	for i, recipe := range cookbook.Recipes {
+		fmt.Fprintf(&doc, "<section class=\"recipe\" id=\"recipe-%d\">\n", i+1)
+		// The cookbook title is the only top level heading
+		doc.WriteString(markdownHTML(utils.RecipeToMarkdown(recipe), 1, imageURL))
+		doc.WriteString("</section>\n")
+	}
+
+	doc.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, doc.String())
+	return err
+}
+
+// markdownHTML converts the markdown written by utils.RecipeToMarkdown to
+// HTML: headings, paragraphs, lists, tables, images and emphasis. Headings
+// are moved down by headingOffset levels. Images are shown from the URL
+// imageURL returns for them, or left out.
+func markdownHTML(markdown string, headingOffset int, imageURL func(src string) (string, bool)) string {
+	var out strings.Builder
+	var paragraph []string
+	var table [][]string
+	list := ""        // ul or ol while a list is open
+	itemOpen := false // whether the last list item is still open
+
+	inline := func(text string) string {
+		return inlineHTML(text, imageURL)
+	}
+	closeItem := func() {
+		if itemOpen {
+			out.WriteString("</li>\n")
+			itemOpen = false
+		}
+	}
+	closeBlocks := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + strings.Join(paragraph, "<br>\n") + "</p>\n")
+			paragraph = nil
+		}
+		closeItem()
+		if list != "" {
+			out.WriteString("</" + list + ">\n")
+			list = ""
+		}
+		if len(table) > 0 {
+			writeTableHTML(&out, table, inline)
+			table = nil
+		}
+	}
+	openList := func(tag, start string) {
+		if list == tag {
+			closeItem()
+			return
+		}
+		closeBlocks()
+		list = tag
+		if tag == "ol" && start != "1" {
+			out.WriteString("<ol start=\"" + start + "\">\n")
+		} else {
+			out.WriteString("<" + tag + ">\n")
+		}
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			// Blank lines end paragraphs and tables. Lists end at the next
+			// line that isn't a list item.
+			if list == "" {
+				closeBlocks()
+			}
+		case itemOpen && line != trimmed && !numberedRegex.MatchString(trimmed) && !bulletRegex.MatchString(trimmed):
+			// Indented lines, like step images, are part of the list item
+			if text := inline(trimmed); text != "" {
+				out.WriteString(" " + text)
+			}
+		case headingRegex.MatchString(trimmed):
+			closeBlocks()
+			match := headingRegex.FindStringSubmatch(trimmed)
+			level := strconv.Itoa(min(len(match[1])+headingOffset, 6))
+			out.WriteString("<h" + level + ">" + inline(match[2]) + "</h" + level + ">\n")
+		case numberedRegex.MatchString(trimmed):
+			match := numberedRegex.FindStringSubmatch(trimmed)
+			openList("ol", match[1])
+			out.WriteString("<li>" + inline(match[2]))
+			itemOpen = true
+		case bulletRegex.MatchString(trimmed):
+			match := bulletRegex.FindStringSubmatch(trimmed)
+			openList("ul", "")
+			out.WriteString("<li>" + inline(match[1]))
+			itemOpen = true
+		case strings.HasPrefix(trimmed, "|"):
+			if len(table) == 0 {
+				closeBlocks()
+			}
+			if !tableRuleRegex.MatchString(strings.ReplaceAll(trimmed, " ", "")) {
+				table = append(table, tableCells(trimmed))
+			}
+		default:
+			if list != "" || len(table) > 0 {
+				closeBlocks()
+			}
+			if text := inline(trimmed); text != "" {
+				paragraph = append(paragraph, text)
+			}
+		}
+	}
+	closeBlocks()
+
+	return out.String()
+}
+
+// inlineHTML escapes text and converts its images and emphasis
+func inlineHTML(text string, imageURL func(src string) (string, bool)) string {
+	text = html.EscapeString(text)
+	text = imageRegex.ReplaceAllStringFunc(text, func(image string) string {
+		match := imageRegex.FindStringSubmatch(image)
+		url, ok := imageURL(html.UnescapeString(match[2]))
+		if !ok {
+			return ""
+		}
+		return "<img src=\"" + html.EscapeString(url) + "\" alt=\"" + match[1] + "\">"
+	})
+	text = strongRegex.ReplaceAllString(text, "<strong>$1</strong>")
+	return emphasisRegex.ReplaceAllString(text, "<em>$1</em>")
+}
+
+// tableCells splits a markdown table row into its cells
+func tableCells(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// writeTableHTML writes a table, its first row as the header
+func writeTableHTML(out *strings.Builder, rows [][]string, inline func(string) string) {
+	out.WriteString("<table>\n")
+	for i, row := range rows {
+		cell := "td"
+		if i == 0 {
+			cell = "th"
+		}
+		out.WriteString("<tr>")
+		for _, text := range row {
+			out.WriteString("<" + cell + ">" + inline(text) + "</" + cell + ">")
+		}
+		out.WriteString("</tr>\n")
+	}
+	out.WriteString("</table>\n")
+}
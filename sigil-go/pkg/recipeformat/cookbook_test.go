@@ -0,0 +1,63 @@
+package recipeformat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCookbook(t *testing.T) {
+	imageID := uuid.New()
+	stepImageID := uuid.New()
+	missingImageID := uuid.New()
+
+	recipe := pancakes()
+	recipe.ImageID = &imageID
+	recipe.StepSections = []models.StepSection{{Step: 2, Name: "Frying"}}
+	recipe.StepImages = []models.StepImage{{Step: 0, FileID: stepImageID}, {Step: 2, FileID: missingImageID}}
+	toast := models.Recipe{Name: "Toast & <jam>", Steps: []string{"Toast the bread."}}
+
+	var buf bytes.Buffer
+	err := WriteCookbook(&buf, Cookbook{
+		Title:       "Mum's",
+		Description: "Recipes from home",
+		Recipes:     []models.Recipe{recipe, toast},
+		Images: map[uuid.UUID]string{
+			imageID:     "data:image/jpeg;base64,AAAA",
+			stepImageID: "data:image/png;base64,BBBB",
+		},
+	})
+	require.NoError(t, err)
+	doc := buf.String()
+
+	assert.True(t, strings.HasPrefix(doc, "<!DOCTYPE html>"))
+	assert.Contains(t, doc, "<title>Mum&#39;s</title>")
+	assert.Contains(t, doc, "<p>Recipes from home</p>")
+	assert.Contains(t, doc, "<li><a href=\"#recipe-1\">Pancakes</a></li>\n<li><a href=\"#recipe-2\">Toast &amp; &lt;jam&gt;</a></li>")
+
+	assert.Contains(t, doc, "<section class=\"recipe\" id=\"recipe-1\">\n<h2>Pancakes</h2>\n<p><img src=\"data:image/jpeg;base64,AAAA\" alt=\"Pancakes\"></p>")
+	assert.Contains(t, doc, "<strong>Servings:</strong> 4<br>\n<strong>Prep Time:</strong> 1 hour 30 minutes")
+	assert.Contains(t, doc, "<li>1/2-1 tsp cardamom <em>(optional)</em></li>\n</ul>")
+	assert.Contains(t, doc, "<ol>\n<li>Whisk the flour and milk until smooth. <img src=\"data:image/png;base64,BBBB\" alt=\"Step 1\"></li>")
+	assert.Contains(t, doc, "</ol>\n<h4>Frying</h4>\n<ol start=\"3\">\n<li>Fry thin pancakes in butter.</li>")
+	assert.NotContains(t, doc, missingImageID.String())
+	assert.NotContains(t, doc, "/files/")
+
+	assert.Contains(t, doc, "<h2>Toast &amp; &lt;jam&gt;</h2>")
+}
+
+func TestMarkdownHTMLTable(t *testing.T) {
+	markdown := "## Nutrition\n\n| | Calories | Protein |\n| --- | --- | --- |\n| Total | 500 kcal | 12 g |\n\n*Estimated from 80% of the ingredients.*\n"
+	noImages := func(string) (string, bool) { return "", false }
+
+	assert.Equal(t, "<h2>Nutrition</h2>\n"+
+		"<table>\n<tr><th></th><th>Calories</th><th>Protein</th></tr>\n<tr><td>Total</td><td>500 kcal</td><td>12 g</td></tr>\n</table>\n"+
+		"<p><em>Estimated from 80% of the ingredients.</em></p>\n",
+		markdownHTML(markdown, 0, noImages))
+}
@@ -0,0 +1,429 @@
+package recipeformat
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+)
+
+var (
+	// Ingredients (@), cookware (#) and timers (~). Names with spaces end in
+	// braces, e.g. "@olive oil{2%tbsp}(extra virgin)" or "~{10%minutes}".
+	cooklangTokenRegex   = regexp.MustCompile(`([@#~])([&?+\-]*)(?:([^@#~{}\n]*)\{([^}]*)\}(?:\(([^)]*)\))?|([\pL\pN_]+))`)
+	cooklangBlockComment = regexp.MustCompile(`(?s)\[-.*?-\]`)
+	// Characters that would end or start a token in an ingredient name or note
+	cooklangNameReplacer = strings.NewReplacer("{", "", "}", "", "@", "", "#", "", "~", "", "\n", " ")
+	cooklangNoteReplacer = strings.NewReplacer("(", "", ")", "", "\n", " ")
+)
+
+// Cooklang writes a recipe as a .cook file. Recipe details go in the front
+// matter and ingredients are marked up where the steps first mention them.
+// Ingredients no step mentions are listed before the steps.
+func Cooklang(recipe models.Recipe) string {
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	writeCooklangMetadata(&sb, "title", recipe.Name)
+	if recipe.Servings != nil {
+		writeCooklangMetadata(&sb, "servings", strconv.Itoa(*recipe.Servings))
+	}
+	writeCooklangMetadata(&sb, "description", stringValue(recipe.Summary))
+	writeCooklangMetadata(&sb, "source", stringValue(recipe.SourceURL))
+	writeCooklangMetadata(&sb, "prep time", stringValue(recipe.PrepTime))
+	writeCooklangMetadata(&sb, "cook time", stringValue(recipe.CookTime))
+	writeCooklangMetadata(&sb, "total time", stringValue(recipe.TotalTime))
+	writeCooklangMetadata(&sb, "cuisine", stringValue(recipe.Cuisine))
+	writeCooklangMetadata(&sb, "course", stringValue(recipe.Course))
+	writeCooklangMetadata(&sb, "equipment", strings.Join(recipe.Equipment, ", "))
+	sb.WriteString("---\n")
+
+	mentioned := make([]bool, len(recipe.Ingredients))
+	steps := make([]string, len(recipe.Steps))
+	for i, step := range recipe.Steps {
+		steps[i] = annotateCooklangStep(strings.Join(strings.Fields(step), " "), recipe.Ingredients, mentioned)
+	}
+
+	var unmentioned []string
+	for i, ingredient := range recipe.Ingredients {
+		if !mentioned[i] {
+			unmentioned = append(unmentioned, cooklangIngredient(ingredient.Name, ingredient))
+		}
+	}
+	if len(unmentioned) > 0 {
+		sb.WriteString("\n" + strings.Join(unmentioned, ", ") + "\n")
+	}
+
+	sections := make(map[int]string, len(recipe.StepSections))
+	for _, section := range recipe.StepSections {
+		sections[section.Step] = section.Name
+	}
+	for i, step := range steps {
+		if name, ok := sections[i]; ok {
+			sb.WriteString("\n== " + strings.Join(strings.Fields(name), " ") + " ==\n")
+		}
+		sb.WriteString("\n" + step + "\n")
+	}
+	return sb.String()
+}
+
+func writeCooklangMetadata(sb *strings.Builder, key, value string) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return
+	}
+	// Quote values YAML would otherwise misread
+	if strings.ContainsAny(value, ":#\"'") || strings.ContainsAny(value[:1], "[]{}&*!|>%@`-?,") {
+		value = strconv.Quote(value)
+	}
+	sb.WriteString(key + ": " + value + "\n")
+}
+
+// annotateCooklangStep marks up the first mention of each ingredient not
+// mentioned by an earlier step
+func annotateCooklangStep(step string, ingredients []models.Ingredient, mentioned []bool) string {
+	type mention struct {
+		start, end int
+		ingredient models.Ingredient
+	}
+	var mentions []mention
+	for i, ingredient := range ingredients {
+		name := strings.TrimSpace(ingredient.Name)
+		if mentioned[i] || name == "" {
+			continue
+		}
+		regex, err := regexp.Compile(`(?i)(?:^|[^\pL\pN])(` + regexp.QuoteMeta(name) + `)(?:[^\pL\pN]|$)`)
+		if err != nil {
+			continue
+		}
+		for _, match := range regex.FindAllStringSubmatchIndex(step, -1) {
+			overlaps := false
+			for _, m := range mentions {
+				if match[2] < m.end && m.start < match[3] {
+					overlaps = true
+					break
+				}
+			}
+			if !overlaps {
+				mentions = append(mentions, mention{match[2], match[3], ingredient})
+				mentioned[i] = true
+				break
+			}
+		}
+	}
+
+	var sb strings.Builder
+	last := 0
+	for last < len(step) {
+		next := -1
+		for i, m := range mentions {
+			if m.start >= last && (next < 0 || m.start < mentions[next].start) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		m := mentions[next]
+		sb.WriteString(step[last:m.start])
+		sb.WriteString(cooklangIngredient(m.ingredient.Name, m.ingredient))
+		last = m.end
+	}
+	sb.WriteString(step[last:])
+	return sb.String()
+}
+
+// cooklangIngredient writes an ingredient reference like "@flour{3%dl}"
+func cooklangIngredient(name string, ingredient models.Ingredient) string {
+	amount := ""
+	if ingredient.Quantity != nil {
+		amount = utils.FormatQuantity(models.Quantity{Min: ingredient.Quantity.Min, Max: ingredient.Quantity.Max})
+		if amount != "" && ingredient.Quantity.Unit != "" {
+			amount += "%" + ingredient.Quantity.Unit
+		}
+	}
+
+	notes := ingredient.Notes
+	if ingredient.IsOptional {
+		notes = strings.TrimSuffix("optional, "+notes, ", ")
+	}
+
+	reference := "@" + strings.TrimSpace(cooklangNameReplacer.Replace(name)) + "{" + cooklangNameReplacer.Replace(amount) + "}"
+	if notes = strings.TrimSpace(cooklangNoteReplacer.Replace(notes)); notes != "" {
+		reference += "(" + notes + ")"
+	}
+	return reference
+}
+
+// WriteCooklangArchive writes recipes as a zip archive of .cook files
+func WriteCooklangArchive(w io.Writer, recipes []models.Recipe) error {
+	zw := zip.NewWriter(w)
+	usedNames := make(map[string]bool)
+	for _, recipe := range recipes {
+		name := uniqueName(usedNames, utils.SanitizeFilename(recipe.Name), ".cook")
+		dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: recipe.UpdatedAt})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", name, err)
+		}
+		if _, err := io.WriteString(dst, Cooklang(recipe)); err != nil {
+			return fmt.Errorf("failed to write %s to archive: %w", name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// ReadCooklangArchive reads the .cook files in a zip archive
+func ReadCooklangArchive(data []byte) ([]models.Recipe, error) {
+	files, names, err := readZipFiles(data, ".cook")
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := make([]models.Recipe, 0, len(names))
+	for _, name := range names {
+		recipes = append(recipes, ParseCooklang(string(files[name]), cooklangName(name)))
+	}
+	return recipes, nil
+}
+
+// cooklangName names a recipe after its file, as Cooklang recipes are
+func cooklangName(filename string) string {
+	base := filepath.Base(filepath.ToSlash(filename))
+	return strings.TrimSpace(strings.TrimSuffix(base, filepath.Ext(base)))
+}
+
+// ParseCooklang reads a Cooklang recipe. Metadata comes from YAML front matter
+// or ">>" lines, each paragraph is a step. Paragraphs that only list
+// ingredients, like the ones Cooklang writes, aren't steps. The title in the
+// metadata takes precedence over name.
+func ParseCooklang(content, name string) models.Recipe {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = cooklangBlockComment.ReplaceAllString(content, "")
+
+	metadata := make(map[string]string)
+	if rest, found := strings.CutPrefix(content, "---\n"); found {
+		if frontMatter, body, found := strings.Cut(rest, "\n---"); found {
+			for _, line := range strings.Split(frontMatter, "\n") {
+				addCooklangMetadata(metadata, line)
+			}
+			_, content, _ = strings.Cut(body, "\n")
+		}
+	}
+
+	recipe := models.Recipe{Name: name, Ingredients: []models.Ingredient{}, Steps: []string{}}
+	var paragraph, notes []string
+	var section string // the section of the next step
+	flush := func() {
+		if len(paragraph) > 0 {
+			step := len(recipe.Steps)
+			parseCooklangStep(&recipe, strings.Join(paragraph, " "))
+			paragraph = nil
+			if section != "" && len(recipe.Steps) > step {
+				recipe.StepSections = append(recipe.StepSections, models.StepSection{Step: step, Name: section})
+				section = ""
+			}
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, ">>"):
+			addCooklangMetadata(metadata, strings.TrimPrefix(line, ">>"))
+			continue
+		case strings.HasPrefix(line, ">"):
+			flush()
+			if note := strings.TrimSpace(strings.TrimPrefix(line, ">")); note != "" {
+				notes = append(notes, note)
+			}
+			continue
+		}
+
+		if before, _, found := strings.Cut(line, "--"); found {
+			line = strings.TrimSpace(before)
+			if line == "" {
+				continue
+			}
+		}
+		if line == "" {
+			flush()
+			continue
+		}
+		// Sections are written as "= Dough" or "== Dough =="
+		if strings.HasPrefix(line, "=") {
+			flush()
+			if name := strings.Trim(line, "= "); name != "" {
+				section = name
+			}
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+
+	applyCooklangMetadata(&recipe, metadata)
+	if recipe.Summary == nil && len(notes) > 0 {
+		recipe.Summary = stringPtr(strings.Join(notes, "\n"))
+	}
+	if strings.TrimSpace(recipe.Name) == "" {
+		recipe.Name = untitledRecipe
+	}
+	return recipe
+}
+
+func addCooklangMetadata(metadata map[string]string, line string) {
+	key, value, found := strings.Cut(line, ":")
+	if !found {
+		return
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+		value = unquoted
+	} else if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	if key != "" && value != "" {
+		metadata[key] = value
+	}
+}
+
+func applyCooklangMetadata(recipe *models.Recipe, metadata map[string]string) {
+	if title := metadata["title"]; title != "" {
+		recipe.Name = title
+	}
+	for _, key := range []string{"servings", "serves", "yield"} {
+		if servings, ok := leadingNumber(metadata[key]); ok {
+			recipe.Servings = &servings
+			break
+		}
+	}
+	for _, key := range []string{"description", "introduction"} {
+		if description := metadata[key]; description != "" {
+			recipe.Summary = &description
+			break
+		}
+	}
+	for _, key := range []string{"source", "source.url", "url"} {
+		if source := metadata[key]; strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+			recipe.SourceURL = &source
+			break
+		}
+	}
+	recipe.PrepTime = firstCooklangMetadata(metadata, "prep time", "prep_time", "time.prep")
+	recipe.CookTime = firstCooklangMetadata(metadata, "cook time", "cook_time", "time.cook")
+	recipe.TotalTime = firstCooklangMetadata(metadata, "total time", "total_time", "time required", "time", "duration")
+	recipe.Cuisine = firstCooklangMetadata(metadata, "cuisine")
+	recipe.Course = firstCooklangMetadata(metadata, "course", "category")
+	for _, item := range strings.Split(metadata["equipment"], ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			recipe.Equipment = append(recipe.Equipment, item)
+		}
+	}
+}
+
+// firstCooklangMetadata returns the value of the first key that is set
+func firstCooklangMetadata(metadata map[string]string, keys ...string) *string {
+	for _, key := range keys {
+		if value := metadata[key]; value != "" {
+			return &value
+		}
+	}
+	return nil
+}
+
+// parseCooklangStep adds the ingredients referenced in a paragraph to the
+// recipe, and the paragraph's text as a step
+func parseCooklangStep(recipe *models.Recipe, paragraph string) {
+	var sb, between strings.Builder
+	last := 0
+	for _, match := range cooklangTokenRegex.FindAllStringSubmatchIndex(paragraph, -1) {
+		sb.WriteString(paragraph[last:match[0]])
+		between.WriteString(paragraph[last:match[0]])
+		last = match[1]
+
+		group := func(i int) string {
+			if match[2*i] < 0 {
+				return ""
+			}
+			return paragraph[match[2*i]:match[2*i+1]]
+		}
+		name := strings.TrimSpace(group(3) + group(6))
+		amount, unit, _ := strings.Cut(strings.Trim(group(4), " *="), "%")
+
+		switch group(1) {
+		case "@":
+			sb.WriteString(name)
+			if !strings.Contains(group(2), "&") {
+				addCooklangIngredient(recipe, cooklangIngredientFromParts(name, amount, unit, group(5), strings.Contains(group(2), "?")))
+			}
+		case "#":
+			sb.WriteString(name)
+			between.WriteString(name)
+			recipe.Equipment = append(recipe.Equipment, name)
+		case "~":
+			timer := strings.TrimSpace(amount + " " + unit)
+			if timer == "" {
+				timer = name
+			}
+			sb.WriteString(timer)
+			between.WriteString(timer)
+		}
+	}
+	sb.WriteString(paragraph[last:])
+	between.WriteString(paragraph[last:])
+
+	// Paragraphs listing ingredients, without any text between them, aren't steps
+	if strings.Trim(between.String(), " ,;.") != "" {
+		recipe.Steps = append(recipe.Steps, strings.Join(strings.Fields(sb.String()), " "))
+	}
+}
+
+func cooklangIngredientFromParts(name, amount, unit, notes string, optional bool) models.Ingredient {
+	ingredient := models.Ingredient{Name: name, IsOptional: optional}
+	amount, unit = strings.TrimSpace(amount), strings.TrimSpace(unit)
+	if amount != "" {
+		ingredient.Quantity = utils.ParseQuantity(amount, unit)
+		if ingredient.Quantity == nil {
+			// Amounts like "some" or "a handful"
+			notes = strings.TrimSuffix(strings.TrimSpace(amount+" "+unit)+", "+notes, ", ")
+		}
+	}
+
+	notes = strings.TrimSpace(notes)
+	if lower := strings.ToLower(notes); strings.HasPrefix(lower, "optional") {
+		ingredient.IsOptional = true
+		notes = strings.TrimLeft(notes[len("optional"):], " ,;")
+	}
+	ingredient.Notes = notes
+	return ingredient
+}
+
+// addCooklangIngredient adds an ingredient to the recipe. Ingredients used in
+// several steps are listed once, with their amounts added up when the units
+// allow it.
+func addCooklangIngredient(recipe *models.Recipe, ingredient models.Ingredient) {
+	for i := range recipe.Ingredients {
+		existing := &recipe.Ingredients[i]
+		if !strings.EqualFold(existing.Name, ingredient.Name) {
+			continue
+		}
+		switch {
+		case ingredient.Quantity == nil:
+			return
+		case existing.Quantity == nil:
+			existing.Quantity = ingredient.Quantity
+			return
+		}
+		if sum, ok := utils.AddQuantities(*existing.Quantity, *ingredient.Quantity); ok {
+			existing.Quantity = &sum
+			return
+		}
+	}
+	recipe.Ingredients = append(recipe.Ingredients, ingredient)
+}
@@ -0,0 +1,151 @@
+package recipeformat
+
+import (
+	"bytes"
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func qty(min, max float64, unit string) *models.Quantity {
+	return &models.Quantity{Min: &min, Max: &max, Unit: unit}
+}
+
+func pancakes() models.Recipe {
+	servings := 4
+	summary := "Thin Norwegian pancakes"
+	prepTime := "1 hour 30 minutes"
+	cookTime := "20 minutes"
+	cuisine := "Norwegian"
+	course := "dessert"
+	source := "https://example.com/pancakes"
+	return models.Recipe{
+		Name:      "Pancakes",
+		Summary:   &summary,
+		Servings:  &servings,
+		PrepTime:  &prepTime,
+		CookTime:  &cookTime,
+		Cuisine:   &cuisine,
+		Course:    &course,
+		Dietary:   []string{models.DietaryVegetarian},
+		Equipment: []string{"Frying pan", "Whisk"},
+		SourceURL: &source,
+		Ingredients: []models.Ingredient{
+			{Name: "flour", Quantity: qty(3, 3, "dl")},
+			{Name: "milk", Quantity: qty(6, 6, "dl"), Notes: "lukewarm"},
+			{Name: "eggs", Quantity: qty(3, 3, "")},
+			{Name: "salt", Notes: "to taste"},
+			{Name: "cardamom", Quantity: qty(0.5, 1, "tsp"), IsOptional: true},
+		},
+		Steps: []string{
+			"Whisk the flour and milk until smooth.",
+			"Add the eggs and let the batter rest.",
+			"Fry thin pancakes in butter.",
+		},
+	}
+}
+
+func TestCooklang(t *testing.T) {
+	expected := "---\n" +
+		"title: Pancakes\n" +
+		"servings: 4\n" +
+		"description: Thin Norwegian pancakes\n" +
+		"source: \"https://example.com/pancakes\"\n" +
+		"prep time: 1 hour 30 minutes\n" +
+		"cook time: 20 minutes\n" +
+		"cuisine: Norwegian\n" +
+		"course: dessert\n" +
+		"equipment: Frying pan, Whisk\n" +
+		"---\n" +
+		"\n@salt{}(to taste), @cardamom{0.5-1%tsp}(optional)\n" +
+		"\nWhisk the @flour{3%dl} and @milk{6%dl}(lukewarm) until smooth.\n" +
+		"\nAdd the @eggs{3} and let the batter rest.\n" +
+		"\nFry thin pancakes in butter.\n"
+	assert.Equal(t, expected, Cooklang(pancakes()))
+}
+
+func TestCooklangRoundTrip(t *testing.T) {
+	recipe := pancakes()
+	parsed := ParseCooklang(Cooklang(recipe), "ignored")
+
+	assert.Equal(t, recipe.Name, parsed.Name)
+	assert.Equal(t, recipe.Summary, parsed.Summary)
+	assert.Equal(t, recipe.Servings, parsed.Servings)
+	assert.Equal(t, recipe.PrepTime, parsed.PrepTime)
+	assert.Equal(t, recipe.CookTime, parsed.CookTime)
+	assert.Equal(t, recipe.Cuisine, parsed.Cuisine)
+	assert.Equal(t, recipe.Course, parsed.Course)
+	assert.Equal(t, recipe.Equipment, parsed.Equipment)
+	assert.Equal(t, recipe.SourceURL, parsed.SourceURL)
+	assert.Equal(t, recipe.Steps, parsed.Steps)
+	assert.ElementsMatch(t, recipe.Ingredients, parsed.Ingredients)
+}
+
+func TestParseCooklang(t *testing.T) {
+	content := ">> servings: 2\n" +
+		"-- a comment\n" +
+		"[- a block\ncomment -]\n" +
+		"= Dough\n" +
+		"Crack the @eggs{2} into a #mixing bowl{} and add @salt.\n" +
+		"Beat with a #fork. -- until fluffy\n" +
+		"\n" +
+		"> Best eaten warm.\n" +
+		"\n" +
+		"Fry in @olive oil{1%tbsp}(extra virgin) for ~{3%minutes}.\n" +
+		"Top with @?chives{some} and more @olive oil{1/2%tbsp}.\n"
+
+	recipe := ParseCooklang(content, "Scrambled eggs")
+
+	assert.Equal(t, "Scrambled eggs", recipe.Name)
+	require.NotNil(t, recipe.Servings)
+	assert.Equal(t, 2, *recipe.Servings)
+	require.NotNil(t, recipe.Summary)
+	assert.Equal(t, "Best eaten warm.", *recipe.Summary)
+	assert.Equal(t, []string{
+		"Crack the eggs into a mixing bowl and add salt. Beat with a fork.",
+		"Fry in olive oil for 3 minutes. Top with chives and more olive oil.",
+	}, recipe.Steps)
+	assert.Equal(t, []models.Ingredient{
+		{Name: "eggs", Quantity: qty(2, 2, "")},
+		{Name: "salt"},
+		{Name: "olive oil", Quantity: qty(1.5, 1.5, "tbsp"), Notes: "extra virgin"},
+		{Name: "chives", IsOptional: true, Notes: "some"},
+	}, recipe.Ingredients)
+	assert.Equal(t, []models.StepSection{{Step: 0, Name: "Dough"}}, recipe.StepSections)
+}
+
+func TestCooklangStepSections(t *testing.T) {
+	recipe := pancakes()
+	recipe.StepSections = []models.StepSection{{Step: 0, Name: "Batter"}, {Step: 2, Name: "Frying"}}
+
+	content := Cooklang(recipe)
+	assert.Contains(t, content, "\n== Batter ==\n\nWhisk the @flour")
+	assert.Contains(t, content, "\n== Frying ==\n\nFry thin pancakes")
+
+	parsed := ParseCooklang(content, "ignored")
+	assert.Equal(t, recipe.Steps, parsed.Steps)
+	assert.Equal(t, recipe.StepSections, parsed.StepSections)
+}
+
+func TestParseCooklangWithoutName(t *testing.T) {
+	recipe := ParseCooklang("Boil @water{1%l}.", "")
+	assert.Equal(t, untitledRecipe, recipe.Name)
+	assert.Equal(t, []string{"Boil water."}, recipe.Steps)
+}
+
+func TestCooklangArchiveRoundTrip(t *testing.T) {
+	soup := models.Recipe{Name: "Soup", Steps: []string{"Heat the stock."}, Ingredients: []models.Ingredient{{Name: "stock"}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, FormatCooklang, []models.Recipe{pancakes(), soup}, nil))
+
+	recipes, err := Import(FormatCooklang, "recipes.zip", buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, recipes, 2)
+	assert.Equal(t, "Pancakes", recipes[0].Recipe.Name)
+	assert.Equal(t, "Soup", recipes[1].Recipe.Name)
+	assert.Equal(t, []string{"Heat the stock."}, recipes[1].Recipe.Steps)
+}
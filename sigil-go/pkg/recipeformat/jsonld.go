@@ -0,0 +1,174 @@
+package recipeformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+)
+
+const schemaContext = "https://schema.org"
+
+// schemaDiets maps dietary labels to schema.org RestrictedDiet values. Dairy
+// free has no match, LowLactoseDiet would claim less than it means.
+var schemaDiets = map[string]string{
+	models.DietaryVegetarian: "https://schema.org/VegetarianDiet",
+	models.DietaryVegan:      "https://schema.org/VeganDiet",
+	models.DietaryGlutenFree: "https://schema.org/GlutenFreeDiet",
+}
+
+// jsonLDRecipe is a schema.org Recipe
+type jsonLDRecipe struct {
+	Context            string   `json:"@context,omitempty"`
+	Type               string   `json:"@type"`
+	Name               string   `json:"name"`
+	Description        string   `json:"description,omitempty"`
+	RecipeYield        string   `json:"recipeYield,omitempty"`
+	PrepTime           string   `json:"prepTime,omitempty"`
+	CookTime           string   `json:"cookTime,omitempty"`
+	TotalTime          string   `json:"totalTime,omitempty"`
+	RecipeCuisine      string   `json:"recipeCuisine,omitempty"`
+	RecipeCategory     string   `json:"recipeCategory,omitempty"`
+	SuitableForDiet    []string `json:"suitableForDiet,omitempty"`
+	Tool               []string `json:"tool,omitempty"`
+	RecipeIngredient   []string `json:"recipeIngredient"`
+	RecipeInstructions []any    `json:"recipeInstructions"` // jsonLDStep and jsonLDSection
+	URL                string   `json:"url,omitempty"`
+	DateCreated        string   `json:"dateCreated,omitempty"`
+	DateModified       string   `json:"dateModified,omitempty"`
+}
+
+type jsonLDStep struct {
+	Type string `json:"@type"`
+	Text string `json:"text"`
+}
+
+// jsonLDSection groups the steps under a heading like "For the sauce"
+type jsonLDSection struct {
+	Type            string       `json:"@type"`
+	Name            string       `json:"name"`
+	ItemListElement []jsonLDStep `json:"itemListElement"`
+}
+
+// jsonLDGraph holds several recipes in one document
+type jsonLDGraph struct {
+	Context string         `json:"@context"`
+	Graph   []jsonLDRecipe `json:"@graph"`
+}
+
+// WriteJSONLD writes recipes as a schema.org Recipe document. Several recipes
+// are written as a @graph.
+func WriteJSONLD(w io.Writer, recipes []models.Recipe) error {
+	var document any
+	if len(recipes) == 1 {
+		recipe := jsonLD(recipes[0])
+		recipe.Context = schemaContext
+		document = recipe
+	} else {
+		graph := jsonLDGraph{Context: schemaContext, Graph: make([]jsonLDRecipe, len(recipes))}
+		for i, recipe := range recipes {
+			graph.Graph[i] = jsonLD(recipe)
+		}
+		document = graph
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("failed to write JSON-LD: %w", err)
+	}
+	return nil
+}
+
+// jsonLD converts a recipe to a schema.org Recipe, without @context
+func jsonLD(recipe models.Recipe) jsonLDRecipe {
+	result := jsonLDRecipe{
+		Type:               "Recipe",
+		Name:               recipe.Name,
+		Description:        stringValue(recipe.Summary),
+		URL:                stringValue(recipe.SourceURL),
+		RecipeIngredient:   make([]string, len(recipe.Ingredients)),
+		RecipeInstructions: []any{},
+	}
+
+	if recipe.Servings != nil {
+		result.RecipeYield = strconv.Itoa(*recipe.Servings)
+	}
+	if recipe.PrepTime != nil {
+		result.PrepTime = isoDuration(utils.ParseDuration(*recipe.PrepTime))
+	}
+	if recipe.CookTime != nil {
+		result.CookTime = isoDuration(utils.ParseDuration(*recipe.CookTime))
+	}
+	if recipe.TotalMinutes != nil {
+		result.TotalTime = isoDuration(time.Duration(*recipe.TotalMinutes) * time.Minute)
+	} else if recipe.TotalTime != nil {
+		result.TotalTime = isoDuration(utils.ParseDuration(*recipe.TotalTime))
+	}
+	result.RecipeCuisine = stringValue(recipe.Cuisine)
+	result.RecipeCategory = stringValue(recipe.Course)
+	result.Tool = recipe.Equipment
+	for _, label := range recipe.Dietary {
+		if diet, ok := schemaDiets[label]; ok {
+			result.SuitableForDiet = append(result.SuitableForDiet, diet)
+		}
+	}
+	if !recipe.CreatedAt.IsZero() {
+		result.DateCreated = recipe.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if !recipe.UpdatedAt.IsZero() {
+		result.DateModified = recipe.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+
+	for i, ingredient := range recipe.Ingredients {
+		result.RecipeIngredient[i] = utils.FormatIngredient(ingredient)
+	}
+	sections := make(map[int]string, len(recipe.StepSections))
+	for _, section := range recipe.StepSections {
+		sections[section.Step] = section.Name
+	}
+	// Steps before the first section aren't part of one
+	var current *jsonLDSection
+	for i, step := range recipe.Steps {
+		if name, ok := sections[i]; ok {
+			if current != nil {
+				result.RecipeInstructions = append(result.RecipeInstructions, *current)
+			}
+			current = &jsonLDSection{Type: "HowToSection", Name: name}
+		}
+		howToStep := jsonLDStep{Type: "HowToStep", Text: step}
+		if current != nil {
+			current.ItemListElement = append(current.ItemListElement, howToStep)
+		} else {
+			result.RecipeInstructions = append(result.RecipeInstructions, howToStep)
+		}
+	}
+	if current != nil {
+		result.RecipeInstructions = append(result.RecipeInstructions, *current)
+	}
+
+	return result
+}
+
+// isoDuration formats a duration like PT1H30M, returning an empty string for
+// zero durations
+func isoDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	hours := int(d / time.Hour)
+	minutes := int((d % time.Hour) / time.Minute)
+
+	result := "PT"
+	if hours > 0 {
+		result += strconv.Itoa(hours) + "H"
+	}
+	if minutes > 0 {
+		result += strconv.Itoa(minutes) + "M"
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package recipeformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSONLD(t *testing.T) {
+	recipe := pancakes()
+	recipe.CreatedAt = time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSONLD(&buf, []models.Recipe{recipe}))
+
+	var document map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &document))
+	assert.Equal(t, "https://schema.org", document["@context"])
+	assert.Equal(t, "Recipe", document["@type"])
+	assert.Equal(t, "Pancakes", document["name"])
+	assert.Equal(t, "Thin Norwegian pancakes", document["description"])
+	assert.Equal(t, "4", document["recipeYield"])
+	assert.Equal(t, "PT1H30M", document["prepTime"])
+	assert.Equal(t, "PT20M", document["cookTime"])
+	assert.Equal(t, "Norwegian", document["recipeCuisine"])
+	assert.Equal(t, "dessert", document["recipeCategory"])
+	assert.Equal(t, []any{"https://schema.org/VegetarianDiet"}, document["suitableForDiet"])
+	assert.Equal(t, []any{"Frying pan", "Whisk"}, document["tool"])
+	assert.Equal(t, "https://example.com/pancakes", document["url"])
+	assert.Equal(t, "2024-03-01T18:30:00Z", document["dateCreated"])
+	assert.NotContains(t, document, "dateModified")
+	assert.Equal(t, []any{"3 dl flour", "6 dl milk, lukewarm", "3 eggs", "salt, to taste", "1/2-1 tsp cardamom (optional)"}, document["recipeIngredient"])
+	assert.Equal(t, map[string]any{"@type": "HowToStep", "text": "Fry thin pancakes in butter."}, document["recipeInstructions"].([]any)[2])
+}
+
+func TestWriteJSONLDGraph(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSONLD(&buf, []models.Recipe{pancakes(), {Name: "Soup"}}))
+
+	var document struct {
+		Context string           `json:"@context"`
+		Graph   []map[string]any `json:"@graph"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &document))
+	assert.Equal(t, "https://schema.org", document.Context)
+	require.Len(t, document.Graph, 2)
+	assert.Equal(t, "Soup", document.Graph[1]["name"])
+	assert.NotContains(t, document.Graph[1], "@context")
+	assert.Equal(t, []any{}, document.Graph[1]["recipeIngredient"])
+}
+
+func TestWriteJSONLDSections(t *testing.T) {
+	recipe := pancakes()
+	recipe.StepSections = []models.StepSection{{Step: 1, Name: "Frying"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSONLD(&buf, []models.Recipe{recipe}))
+
+	var document map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &document))
+	assert.Equal(t, []any{
+		map[string]any{"@type": "HowToStep", "text": "Whisk the flour and milk until smooth."},
+		map[string]any{
+			"@type": "HowToSection",
+			"name":  "Frying",
+			"itemListElement": []any{
+				map[string]any{"@type": "HowToStep", "text": "Add the eggs and let the batter rest."},
+				map[string]any{"@type": "HowToStep", "text": "Fry thin pancakes in butter."},
+			},
+		},
+	}, document["recipeInstructions"])
+}
+
+func TestISODuration(t *testing.T) {
+	assert.Equal(t, "PT2H5M", isoDuration(125*time.Minute))
+	assert.Equal(t, "PT45M", isoDuration(45*time.Minute))
+	assert.Equal(t, "", isoDuration(0))
+}
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, FormatPaprika, DetectFormat("My Recipes.paprikarecipes"))
+	assert.Equal(t, FormatCooklang, DetectFormat("soup.COOK"))
+	assert.Equal(t, FormatJSONLD, DetectFormat("recipes.json"))
+	assert.Equal(t, "", DetectFormat("recipe.pdf"))
+}
@@ -0,0 +1,248 @@
+package recipeformat
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// paprikaTimeLayout is how Paprika writes the time a recipe was created
+const paprikaTimeLayout = "2006-01-02 15:04:05"
+
+// paprikaRecipe is a recipe in a .paprikarecipes archive. Each recipe is a
+// gzipped JSON file with ingredients and directions as plain text.
+//
+// Notes are kept as a paragraph of the summary and the first two categories as
+// the course and cuisine. Ratings aren't imported, a rating belongs to a cook
+// log entry rather than the recipe, and neither are the extra photos in
+// photos, only the main one.
+type paprikaRecipe struct {
+	UID         string   `json:"uid"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Ingredients string   `json:"ingredients"`
+	Directions  string   `json:"directions"`
+	Notes       string   `json:"notes"`
+	Servings    string   `json:"servings"`
+	PrepTime    string   `json:"prep_time"`
+	CookTime    string   `json:"cook_time"`
+	TotalTime   string   `json:"total_time"`
+	Source      string   `json:"source"`
+	SourceURL   string   `json:"source_url"`
+	Categories  []string `json:"categories"`
+	Rating      int      `json:"rating"`
+	Created     string   `json:"created"`
+	Photo       string   `json:"photo"`
+	PhotoData   string   `json:"photo_data"`
+	PhotoHash   string   `json:"photo_hash"`
+	Hash        string   `json:"hash"`
+}
+
+// WritePaprika writes recipes as a .paprikarecipes archive. Photos maps the
+// file IDs of recipe images to their content, recipes whose image is missing
+// from it are written without a photo.
+func WritePaprika(w io.Writer, recipes []models.Recipe, photos map[uuid.UUID][]byte) error {
+	zw := zip.NewWriter(w)
+	usedNames := make(map[string]bool)
+	for _, recipe := range recipes {
+		var photo []byte
+		if recipe.ImageID != nil {
+			photo = photos[*recipe.ImageID]
+		}
+
+		data, err := paprikaFile(recipe, photo)
+		if err != nil {
+			return fmt.Errorf("failed to convert recipe %s: %w", recipe.ID, err)
+		}
+
+		name := uniqueName(usedNames, utils.SanitizeFilename(recipe.Name), ".paprikarecipe")
+		dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: recipe.UpdatedAt})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", name, err)
+		}
+		if _, err := dst.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s to archive: %w", name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// paprikaFile converts a recipe to a gzipped Paprika recipe
+func paprikaFile(recipe models.Recipe, photo []byte) ([]byte, error) {
+	ingredients := make([]string, len(recipe.Ingredients))
+	for i, ingredient := range recipe.Ingredients {
+		ingredients[i] = utils.FormatIngredient(ingredient)
+	}
+
+	paprika := paprikaRecipe{
+		UID:         strings.ToUpper(recipe.ID.String()),
+		Name:        recipe.Name,
+		Description: stringValue(recipe.Summary),
+		Ingredients: strings.Join(ingredients, "\n"),
+		Directions:  strings.Join(recipe.Steps, "\n\n"),
+		PrepTime:    stringValue(recipe.PrepTime),
+		CookTime:    stringValue(recipe.CookTime),
+		TotalTime:   stringValue(recipe.TotalTime),
+		SourceURL:   stringValue(recipe.SourceURL),
+		Categories:  []string{},
+		Created:     recipe.CreatedAt.Format(paprikaTimeLayout),
+	}
+	if recipe.Course != nil {
+		paprika.Categories = append(paprika.Categories, *recipe.Course)
+	}
+	if recipe.Cuisine != nil {
+		paprika.Categories = append(paprika.Categories, *recipe.Cuisine)
+	}
+	if len(photo) > 0 {
+		// Paprika reads the photo from photo_data, the name only has to be unique
+		photoHash := sha256.Sum256(photo)
+		paprika.Photo = paprika.UID + ".jpg"
+		paprika.PhotoData = base64.StdEncoding.EncodeToString(photo)
+		paprika.PhotoHash = strings.ToUpper(hex.EncodeToString(photoHash[:]))
+	}
+	if recipe.Servings != nil {
+		paprika.Servings = strconv.Itoa(*recipe.Servings)
+	}
+	if parsed, err := url.Parse(paprika.SourceURL); err == nil {
+		paprika.Source = strings.TrimPrefix(parsed.Hostname(), "www.")
+	}
+
+	// Paprika compares hashes to tell changed recipes apart when syncing
+	content, err := json.Marshal(paprika)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(content)
+	paprika.Hash = strings.ToUpper(hex.EncodeToString(hash[:]))
+
+	content, err = json.Marshal(paprika)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(content); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadPaprika reads the recipes in a .paprikarecipes archive, or a single
+// .paprikarecipe file
+func ReadPaprika(data []byte) ([]ImportedRecipe, error) {
+	if !isZip(data) {
+		recipe, err := readPaprikaRecipe(data)
+		if err != nil {
+			return nil, err
+		}
+		return []ImportedRecipe{recipe}, nil
+	}
+
+	files, names, err := readZipFiles(data, ".paprikarecipe")
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := make([]ImportedRecipe, 0, len(names))
+	for _, name := range names {
+		recipe, err := readPaprikaRecipe(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
+
+// readPaprikaRecipe reads a gzipped, or plain, Paprika recipe
+func readPaprikaRecipe(data []byte) (ImportedRecipe, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return ImportedRecipe{}, fmt.Errorf("failed to decompress recipe: %w", err)
+		}
+		defer gz.Close()
+		if data, err = readLimited(gz); err != nil {
+			return ImportedRecipe{}, fmt.Errorf("failed to decompress recipe: %w", err)
+		}
+	}
+
+	var paprika paprikaRecipe
+	if err := json.Unmarshal(data, &paprika); err != nil {
+		return ImportedRecipe{}, fmt.Errorf("failed to parse recipe: %w", err)
+	}
+
+	imported := ImportedRecipe{Recipe: paprikaToRecipe(paprika)}
+	// A broken photo shouldn't cost the recipe, it is imported without one
+	if photo, err := base64.StdEncoding.DecodeString(paprika.PhotoData); err == nil && len(photo) > 0 {
+		imported.Photo = photo
+	}
+	return imported, nil
+}
+
+func paprikaToRecipe(paprika paprikaRecipe) models.Recipe {
+	recipe := models.Recipe{
+		Name:        strings.TrimSpace(paprika.Name),
+		Summary:     stringPtr(strings.TrimSpace(paprika.Description) + "\n\n" + strings.TrimSpace(paprika.Notes)),
+		SourceURL:   stringPtr(paprika.SourceURL),
+		Ingredients: []models.Ingredient{},
+		Steps:       splitLines(paprika.Directions, true),
+	}
+	if recipe.Name == "" {
+		recipe.Name = untitledRecipe
+	}
+	if servings, ok := leadingNumber(paprika.Servings); ok {
+		recipe.Servings = &servings
+	}
+
+	recipe.PrepTime = stringPtr(paprika.PrepTime)
+	recipe.CookTime = stringPtr(paprika.CookTime)
+	recipe.TotalTime = stringPtr(paprika.TotalTime)
+
+	if len(paprika.Categories) > 0 {
+		recipe.Course = stringPtr(paprika.Categories[0])
+	}
+	if len(paprika.Categories) > 1 {
+		recipe.Cuisine = stringPtr(paprika.Categories[1])
+	}
+
+	if created, err := time.Parse(paprikaTimeLayout, paprika.Created); err == nil {
+		recipe.CreatedAt = created
+	}
+
+	for _, line := range splitLines(paprika.Ingredients, false) {
+		recipe.Ingredients = append(recipe.Ingredients, utils.ParseIngredient(line))
+	}
+
+	return recipe
+}
+
+// uniqueName appends a counter to name when it is already taken. Names are
+// compared case-insensitively so archives extract cleanly on any filesystem.
+func uniqueName(used map[string]bool, name, ext string) string {
+	candidate := name + ext
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s (%d)%s", name, i, ext)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
@@ -0,0 +1,152 @@
+package recipeformat
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePaprika(t *testing.T) {
+	recipe := pancakes()
+	recipe.ID = uuid.MustParse("0b7d6c1e-2f3a-4b5c-8d9e-0f1a2b3c4d5e")
+	recipe.CreatedAt = time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC)
+	imageID := uuid.New()
+	recipe.ImageID = &imageID
+
+	var buf bytes.Buffer
+	photos := map[uuid.UUID][]byte{imageID: []byte("\xff\xd8\xff\xe0 photo")}
+	require.NoError(t, WritePaprika(&buf, []models.Recipe{recipe, recipe}, photos))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, archive.File, 2)
+	assert.Equal(t, "Pancakes.paprikarecipe", archive.File[0].Name)
+	assert.Equal(t, "Pancakes (2).paprikarecipe", archive.File[1].Name)
+
+	file, err := archive.File[0].Open()
+	require.NoError(t, err)
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	var paprika paprikaRecipe
+	require.NoError(t, json.Unmarshal(data, &paprika))
+	assert.Equal(t, "0B7D6C1E-2F3A-4B5C-8D9E-0F1A2B3C4D5E", paprika.UID)
+	assert.Equal(t, "Pancakes", paprika.Name)
+	assert.Equal(t, "3 dl flour\n6 dl milk, lukewarm\n3 eggs\nsalt, to taste\n1/2-1 tsp cardamom (optional)", paprika.Ingredients)
+	assert.Equal(t, "Whisk the flour and milk until smooth.\n\nAdd the eggs and let the batter rest.\n\nFry thin pancakes in butter.", paprika.Directions)
+	assert.Equal(t, "4", paprika.Servings)
+	assert.Equal(t, "1 hour 30 minutes", paprika.PrepTime)
+	assert.Equal(t, "example.com", paprika.Source)
+	assert.Equal(t, "2024-03-01 18:30:00", paprika.Created)
+	assert.Equal(t, []string{"dessert", "Norwegian"}, paprika.Categories)
+	assert.Equal(t, "0B7D6C1E-2F3A-4B5C-8D9E-0F1A2B3C4D5E.jpg", paprika.Photo)
+	assert.Equal(t, "/9j/4CBwaG90bw==", paprika.PhotoData)
+	assert.Len(t, paprika.PhotoHash, 64)
+	assert.Len(t, paprika.Hash, 64)
+}
+
+func TestPaprikaRoundTrip(t *testing.T) {
+	recipe := pancakes()
+	recipe.CreatedAt = time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC)
+	imageID := uuid.New()
+	recipe.ImageID = &imageID
+	photo := []byte("\x89PNG\r\n\x1a\n photo")
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, FormatPaprika, []models.Recipe{recipe}, map[uuid.UUID][]byte{imageID: photo}))
+
+	recipes, err := Import(FormatPaprika, "export.paprikarecipes", buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, recipes, 1)
+	assert.Equal(t, photo, recipes[0].Photo)
+
+	imported := recipes[0].Recipe
+	assert.Equal(t, recipe.Name, imported.Name)
+	assert.Equal(t, recipe.Summary, imported.Summary)
+	assert.Equal(t, recipe.Servings, imported.Servings)
+	assert.Equal(t, recipe.PrepTime, imported.PrepTime)
+	assert.Equal(t, recipe.CookTime, imported.CookTime)
+	assert.Equal(t, recipe.SourceURL, imported.SourceURL)
+	assert.Equal(t, recipe.Ingredients, imported.Ingredients)
+	assert.Equal(t, recipe.Steps, imported.Steps)
+	assert.Equal(t, recipe.Course, imported.Course)
+	assert.Equal(t, recipe.Cuisine, imported.Cuisine)
+	assert.Equal(t, recipe.CreatedAt, imported.CreatedAt)
+}
+
+func TestPaprikaWithoutPhoto(t *testing.T) {
+	recipe := pancakes()
+	recipe.Course = nil
+	recipe.Cuisine = nil
+	imageID := uuid.New()
+	recipe.ImageID = &imageID
+
+	// The image couldn't be read
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, FormatPaprika, []models.Recipe{recipe}, nil))
+
+	recipes, err := Import(FormatPaprika, "export.paprikarecipes", buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, recipes, 1)
+	assert.Nil(t, recipes[0].Photo)
+	assert.Nil(t, recipes[0].Recipe.Course)
+	assert.Nil(t, recipes[0].Recipe.Cuisine)
+}
+
+func TestReadPaprikaRecipe(t *testing.T) {
+	// Recipes written by Paprika itself, not gzipped here for readability
+	data := `{"name": "", "ingredients": "2 cups flour\n\n1 tsp salt", "directions": "1. Mix.\n2. Bake for 20 minutes.",
+		"servings": "Makes 12 muffins", "prep_time": "", "total_time": "35 min", "source_url": "",
+		"notes": "Freeze the leftovers.", "categories": ["Breakfast"], "rating": 4, "photo_data": "not base64"}`
+
+	recipes, err := ReadPaprika([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, recipes, 1)
+	assert.Nil(t, recipes[0].Photo)
+
+	recipe := recipes[0].Recipe
+	assert.Equal(t, untitledRecipe, recipe.Name)
+	require.NotNil(t, recipe.Summary)
+	assert.Equal(t, "Freeze the leftovers.", *recipe.Summary)
+	require.NotNil(t, recipe.Course)
+	assert.Equal(t, "Breakfast", *recipe.Course)
+	assert.Nil(t, recipe.Cuisine)
+	assert.Nil(t, recipe.SourceURL)
+	require.NotNil(t, recipe.Servings)
+	assert.Equal(t, 12, *recipe.Servings)
+	assert.Nil(t, recipe.PrepTime)
+	require.NotNil(t, recipe.TotalTime)
+	assert.Equal(t, "35 min", *recipe.TotalTime)
+	assert.Equal(t, []string{"Mix.", "Bake for 20 minutes."}, recipe.Steps)
+	assert.Equal(t, []models.Ingredient{
+		{Name: "flour", Quantity: qty(2, 2, "cups")},
+		{Name: "salt", Quantity: qty(1, 1, "tsp")},
+	}, recipe.Ingredients)
+}
+
+func TestReadPaprikaNotes(t *testing.T) {
+	data := `{"name": "Muffins", "description": "Soft and fluffy.", "notes": "Freeze the leftovers.\n"}`
+
+	recipes, err := ReadPaprika([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, recipes, 1)
+	require.NotNil(t, recipes[0].Recipe.Summary)
+	assert.Equal(t, "Soft and fluffy.\n\nFreeze the leftovers.", *recipes[0].Recipe.Summary)
+}
+
+func TestReadPaprikaInvalid(t *testing.T) {
+	_, err := ReadPaprika([]byte("not a recipe"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,243 @@
+// Package recipeformat converts recipes to and from the formats other recipe
+// apps use to share them: schema.org JSON-LD, Paprika archives and Cooklang.
+// Collections of recipes can be written as printable HTML cookbooks.
+package recipeformat
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Supported recipe formats
+const (
+	FormatJSONLD   = "jsonld"
+	FormatPaprika  = "paprika"
+	FormatCooklang = "cooklang"
+)
+
+const (
+	// maxRecipeFileSize limits a single recipe read from an archive. Paprika
+	// recipes embed their photos, so they are larger than the text suggests.
+	maxRecipeFileSize = 20 << 20
+
+	untitledRecipe = "Untitled recipe"
+)
+
+// ImportedRecipe is a recipe read from another app's file, along with the
+// photo the file embeds, if any
+type ImportedRecipe struct {
+	Recipe models.Recipe
+	Photo  []byte
+}
+
+// stepNumberRegex matches list markers like "1." before a step
+var stepNumberRegex = regexp.MustCompile(`^(?:\d+[.)]|[-*•])\s+`)
+
+// IsSupportedFormat reports whether a recipe format can be exported
+func IsSupportedFormat(format string) bool {
+	switch format {
+	case FormatJSONLD, FormatPaprika, FormatCooklang:
+		return true
+	}
+	return false
+}
+
+// IsImportFormat reports whether recipes can be imported from a format
+func IsImportFormat(format string) bool {
+	return format == FormatPaprika || format == FormatCooklang
+}
+
+// DetectFormat guesses the format of a recipe file from its name, returning an
+// empty string for unknown files
+func DetectFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".paprikarecipes", ".paprikarecipe":
+		return FormatPaprika
+	case ".cook", ".zip":
+		return FormatCooklang
+	case ".json", ".jsonld":
+		return FormatJSONLD
+	}
+	return ""
+}
+
+// FileType returns the file extension and content type of an export of count
+// recipes
+func FileType(format string, count int) (string, string) {
+	switch format {
+	case FormatPaprika:
+		return ".paprikarecipes", "application/zip"
+	case FormatCooklang:
+		if count == 1 {
+			return ".cook", "text/plain; charset=utf-8"
+		}
+		return ".zip", "application/zip"
+	}
+	return ".json", "application/ld+json"
+}
+
+// Export writes recipes to w in format. A single Cooklang recipe is written as
+// a .cook file, several as a zip archive of them. Only Paprika archives embed
+// photos, the content of recipe images by file ID, see WritePaprika.
+func Export(w io.Writer, format string, recipes []models.Recipe, photos map[uuid.UUID][]byte) error {
+	switch format {
+	case FormatJSONLD:
+		return WriteJSONLD(w, recipes)
+	case FormatPaprika:
+		return WritePaprika(w, recipes, photos)
+	case FormatCooklang:
+		if len(recipes) == 1 {
+			_, err := io.WriteString(w, Cooklang(recipes[0]))
+			return err
+		}
+		return WriteCooklangArchive(w, recipes)
+	}
+	return fmt.Errorf("unsupported recipe format: %s", format)
+}
+
+// Import reads the recipes in a Paprika or Cooklang file. Ingredient lines are
+// parsed into quantities and names, the recipes aren't stored.
+func Import(format, filename string, data []byte) ([]ImportedRecipe, error) {
+	switch format {
+	case FormatPaprika:
+		return ReadPaprika(data)
+	case FormatCooklang:
+		if !isZip(data) {
+			return []ImportedRecipe{{Recipe: ParseCooklang(string(data), cooklangName(filename))}}, nil
+		}
+		recipes, err := ReadCooklangArchive(data)
+		if err != nil {
+			return nil, err
+		}
+		imported := make([]ImportedRecipe, len(recipes))
+		for i, recipe := range recipes {
+			imported[i] = ImportedRecipe{Recipe: recipe}
+		}
+		return imported, nil
+	}
+	return nil, fmt.Errorf("unsupported recipe import format: %s", format)
+}
+
+func isZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// readZipFiles reads the files in a zip archive with one of the given
+// extensions, in archive order
+func readZipFiles(data []byte, extensions ...string) (map[string][]byte, []string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	var names []string
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !hasExtension(file.Name, extensions) {
+			continue
+		}
+		if strings.HasPrefix(filepath.Base(file.Name), "._") {
+			// macOS resource forks
+			continue
+		}
+
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		files[file.Name] = content
+		names = append(names, file.Name)
+	}
+	return files, names, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return readLimited(reader)
+}
+
+// readLimited reads r, refusing recipes larger than maxRecipeFileSize
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxRecipeFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRecipeFileSize {
+		return nil, fmt.Errorf("recipe is larger than %d bytes", maxRecipeFileSize)
+	}
+	return data, nil
+}
+
+func hasExtension(name string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, extension := range extensions {
+		if ext == extension {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines splits text into trimmed, non-empty lines. With stripNumbers
+// list markers like "1." are removed as well.
+func splitLines(text string, stripNumbers bool) []string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if stripNumbers {
+			line = stepNumberRegex.ReplaceAllString(line, "")
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// leadingNumber returns the first whole number in text, like the 4 in
+// "Serves 4"
+func leadingNumber(text string) (int, bool) {
+	start := strings.IndexFunc(text, func(r rune) bool { return r >= '0' && r <= '9' })
+	if start < 0 {
+		return 0, false
+	}
+	end := start
+	for end < len(text) && text[end] >= '0' && text[end] <= '9' {
+		end++
+	}
+	number, err := strconv.Atoi(text[start:end])
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+	return number, true
+}
+
+// stringPtr returns nil for empty strings
+func stringPtr(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -6,7 +6,9 @@ import (
 
 	"tofoss/sigil-go/pkg/config"
 	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/genai"
 	"tofoss/sigil-go/pkg/handlers"
+	"tofoss/sigil-go/pkg/importer"
 	"tofoss/sigil-go/pkg/middleware"
 	"tofoss/sigil-go/pkg/services"
 
@@ -18,8 +20,10 @@ import (
 )
 
 type Server struct {
-	Router   *chi.Mux
-	jobQueue *services.RecipeJobQueue
+	Router           *chi.Mux
+	jobQueue         *services.RecipeJobQueue
+	importJobQueue   *services.ImportJobQueue
+	refreshScheduler *services.RecipeRefreshScheduler
 }
 
 // NewServer creates a new server with all routes and background services
@@ -36,21 +40,45 @@ func NewServer(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) (*Se
 	sectionRepository := repositories.NewSectionRepository(pool)
 	tagRepository := repositories.NewTagRepository(pool)
 	recipeRepository := repositories.NewRecipeRepository(pool)
+	cookLogRepository := repositories.NewCookLogRepository(pool)
 	recipeJobRepository := repositories.NewRecipeJobRepository(pool)
 	recipeCacheRepository := repositories.NewRecipeURLCacheRepository(pool)
+	recipeUpdateRepository := repositories.NewRecipeUpdateRepository(pool)
+	recipeCollectionRepository := repositories.NewRecipeCollectionRepository(pool)
 	shoppingListRepository := repositories.NewShoppingListRepository(pool)
 	fileRepository := repositories.NewFileRepository(pool)
 	treeRepository := repositories.NewTreeRepository(pool)
 	inviteCodeRepository := repositories.NewInviteCodeRepository(pool)
+	importJobRepository := repositories.NewImportJobRepository(pool)
+	mealPlanRepository := repositories.NewMealPlanRepository(pool)
+	calendarFeedRepository := repositories.NewCalendarFeedRepository(pool)
+	pantryRepository := repositories.NewPantryRepository(pool)
 
 	// Initialize services
+	aiProvider, err := genai.NewProvider(cfg.AI)
+	if err != nil {
+		return nil, err
+	}
+
+	fileConfig := services.FileConfig{
+		StorageRoot:        cfg.UploadPath,
+		MaxFilesize:        int(cfg.MaxFileSize),
+		SupportedFiletypes: services.SupportedImageTypes,
+	}
+
+	fileService := services.NewFileService(fileRepository, fileConfig)
+
 	recipeProcessor, err := services.NewRecipeProcessor(
 		recipeRepository,
 		recipeJobRepository,
 		noteRepository,
 		recipeCacheRepository,
+		recipeUpdateRepository,
+		fileService,
+		aiProvider,
 		cfg.ContentFetchTimeout,
 		cfg.AIProcessingTimeout,
+		cfg.RecipeStepImages,
 	)
 	if err != nil {
 		return nil, err
@@ -64,22 +92,29 @@ func NewServer(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) (*Se
 		cfg.JobMaxRetries,
 		cfg.JobTimeout,
 	)
+	refreshScheduler := services.NewRecipeRefreshScheduler(
+		recipeRepository,
+		recipeJobRepository,
+		recipeCacheRepository,
+		cfg.RecipeRefreshInterval,
+	)
 
-	fileConfig := services.FileConfig{
-		StorageRoot: cfg.UploadPath,
-		MaxFilesize: int(cfg.MaxFileSize),
-		SupportedFiletypes: map[string]string{
-			"image/apng":    "apng",
-			"image/avif":    "avif",
-			"image/jpeg":    "jpeg",
-			"image/png":     "png",
-			"image/gif":     "gif",
-			"image/svg+xml": "svg",
-			"image/webp":    "webp",
-		},
-	}
+	exportService := services.NewExportService(treeRepository, noteRepository, recipeRepository, shoppingListRepository, fileService)
+	recipeService := services.NewRecipeService(recipeRepository, noteRepository, recipeUpdateRepository, fileService)
+	recipeCollectionService := services.NewRecipeCollectionService(recipeRepository, fileService)
+	mealPlanService := services.NewMealPlanService(mealPlanRepository, recipeRepository, shoppingListRepository)
+	calendarService := services.NewCalendarService(calendarFeedRepository, mealPlanRepository, cfg.AppURL)
+	pantryService := services.NewPantryService(pantryRepository, recipeRepository, mealPlanRepository)
+	clipService := services.NewClipService(noteRepository, sectionRepository, fileService, cfg.ContentFetchTimeout)
 
-	fileService := services.NewFileService(fileRepository, fileConfig)
+	importPipeline := importer.NewPipeline(noteRepository, notebookRepository, sectionRepository, tagRepository, fileService)
+	importJobQueue := services.NewImportJobQueue(
+		importJobRepository,
+		importPipeline,
+		cfg.UploadPath,
+		cfg.JobPollInterval,
+		cfg.ImportJobTimeout,
+	)
 
 	// Initialize handlers
 	authConfig := handlers.AuthConfig{
@@ -92,10 +127,17 @@ func NewServer(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) (*Se
 	notebookHandler := handlers.NewNotebookHandler(notebookRepository, noteRepository)
 	sectionHandler := handlers.NewSectionHandler(sectionRepository, notebookRepository)
 	tagHandler := handlers.NewTagHandler(tagRepository)
-	recipeHandler := handlers.NewRecipeHandler(recipeRepository, recipeJobRepository, noteRepository)
-	shoppingListHandler := handlers.NewShoppingListHandler(shoppingListRepository, noteRepository, recipeRepository)
+	recipeHandler := handlers.NewRecipeHandler(recipeRepository, recipeJobRepository, noteRepository, cookLogRepository, recipeUpdateRepository, recipeService)
+	recipeCollectionHandler := handlers.NewRecipeCollectionHandler(recipeCollectionRepository, recipeRepository, recipeCollectionService)
+	shoppingListHandler := handlers.NewShoppingListHandler(shoppingListRepository, noteRepository, recipeRepository, pantryService)
+	mealPlanHandler := handlers.NewMealPlanHandler(mealPlanRepository, mealPlanService)
+	calendarHandler := handlers.NewCalendarHandler(calendarFeedRepository, calendarService)
+	pantryHandler := handlers.NewPantryHandler(pantryRepository, pantryService)
 	fileHandler := handlers.NewFileHandler(fileService, fileConfig)
 	treeHandler := handlers.NewTreeHandler(treeRepository)
+	exportHandler := handlers.NewExportHandler(exportService)
+	importHandler := handlers.NewImportHandler(importJobRepository, cfg.UploadPath, cfg.MaxImportSize)
+	clipHandler := handlers.NewClipHandler(clipService, notebookRepository, sectionRepository)
 
 	// Setup rate limiter for auth endpoints
 	authLimiter := tollbooth.NewLimiter(cfg.AuthRateLimit, &limiter.ExpirableOptions{
@@ -124,6 +166,7 @@ func NewServer(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) (*Se
 		r.Get("/recent", noteHandler.FetchRecentNotes)
 		r.Delete("/recent/{id}", noteHandler.DeleteRecentNote)
 		r.Get("/search", noteHandler.SearchNotes)
+		r.Post("/clip", clipHandler.ClipURL)
 		r.Get("/{id}", noteHandler.FetchNote)
 		r.Post("/", noteHandler.PostNote)
 		r.Delete("/{id}", noteHandler.DeleteNote)
@@ -168,8 +211,44 @@ func NewServer(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) (*Se
 
 	router.Route("/recipes", func(r chi.Router) {
 		r.Use(middleware.JWTMiddleware(jwtKey), middleware.XSRFProtection(xsrfKey), chiMiddleware.Logger)
+		r.Get("/", recipeHandler.ListRecipes)
 		r.Post("/", recipeHandler.CreateRecipeFromURL)
+		r.Post("/manual", recipeHandler.CreateRecipe)
+		r.Post("/text", recipeHandler.CreateRecipeFromText)
+		r.Post("/upload", recipeHandler.UploadRecipeFile)
+		r.Post("/generate", recipeHandler.GenerateRecipe)
+		r.Get("/export", recipeHandler.ExportRecipes)
+		r.Post("/import", recipeHandler.ImportRecipes)
 		r.Get("/jobs/{id}", recipeHandler.GetRecipeJobStatus)
+		r.Get("/updates", recipeHandler.ListRecipeUpdates)
+		r.Post("/updates/{id}/apply", recipeHandler.ApplyRecipeUpdate)
+		r.Post("/updates/{id}/dismiss", recipeHandler.DismissRecipeUpdate)
+		r.Put("/cook-log/{id}", recipeHandler.UpdateCookLogEntry)
+		r.Delete("/cook-log/{id}", recipeHandler.DeleteCookLogEntry)
+		r.Get("/{id}", recipeHandler.FetchRecipe)
+		r.Get("/{id}/scaled", recipeHandler.ScaleRecipe)
+		r.Get("/{id}/cook", recipeHandler.CookRecipeStep)
+		r.Get("/{id}/export", recipeHandler.ExportRecipe)
+		r.Post("/{id}/refine", recipeHandler.RefineRecipe)
+		r.Post("/{id}/refresh", recipeHandler.RefreshRecipe)
+		r.Put("/{id}", recipeHandler.UpdateRecipe)
+		r.Patch("/{id}", recipeHandler.PatchRecipe)
+		r.Delete("/{id}", recipeHandler.DeleteRecipe)
+		r.Put("/{id}/image", recipeHandler.ReplaceRecipeImage)
+		r.Get("/{id}/cook-log", recipeHandler.ListCookLog)
+		r.Post("/{id}/cook-log", recipeHandler.CreateCookLogEntry)
+	})
+
+	router.Route("/recipe-collections", func(r chi.Router) {
+		r.Use(middleware.JWTMiddleware(jwtKey), middleware.XSRFProtection(xsrfKey), chiMiddleware.Logger)
+		r.Get("/", recipeCollectionHandler.ListRecipeCollections)
+		r.Post("/", recipeCollectionHandler.CreateRecipeCollection)
+		r.Get("/{id}", recipeCollectionHandler.FetchRecipeCollection)
+		r.Put("/{id}", recipeCollectionHandler.UpdateRecipeCollection)
+		r.Delete("/{id}", recipeCollectionHandler.DeleteRecipeCollection)
+		r.Put("/{id}/recipes/{recipeId}", recipeCollectionHandler.AddRecipeToCollection)
+		r.Delete("/{id}/recipes/{recipeId}", recipeCollectionHandler.RemoveRecipeFromCollection)
+		r.Get("/{id}/cookbook", recipeCollectionHandler.ExportCookbook)
 	})
 
 	router.Route("/shopping-lists", func(r chi.Router) {
@@ -192,6 +271,38 @@ func NewServer(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) (*Se
 		r.Post("/{id}/merge-recipe", shoppingListHandler.MergeRecipeIngredients)
 	})
 
+	router.Route("/meal-plans", func(r chi.Router) {
+		r.Use(middleware.JWTMiddleware(jwtKey), middleware.XSRFProtection(xsrfKey), chiMiddleware.Logger)
+		r.Get("/", mealPlanHandler.ListMealPlans)
+		r.Post("/", mealPlanHandler.CreateMealPlan)
+
+		// Items left out of generated shopping lists
+		r.Get("/stocked-items", mealPlanHandler.FetchStockedItems)
+		r.Put("/stocked-items", mealPlanHandler.UpdateStockedItems)
+
+		// Secret URL for subscribing to planned meals from calendar apps
+		r.Get("/calendar-feed", calendarHandler.FetchCalendarFeed)
+		r.Post("/calendar-feed", calendarHandler.CreateCalendarFeed)
+		r.Delete("/calendar-feed", calendarHandler.DeleteCalendarFeed)
+
+		r.Get("/{id}", mealPlanHandler.FetchMealPlan)
+		r.Put("/{id}", mealPlanHandler.UpdateMealPlan)
+		r.Delete("/{id}", mealPlanHandler.DeleteMealPlan)
+		r.Post("/{id}/shopping-list", mealPlanHandler.GenerateShoppingList)
+	})
+
+	router.Route("/pantry", func(r chi.Router) {
+		r.Use(middleware.JWTMiddleware(jwtKey), middleware.XSRFProtection(xsrfKey), chiMiddleware.Logger)
+		r.Get("/", pantryHandler.ListPantryItems)
+		r.Post("/", pantryHandler.CreatePantryItem)
+		r.Get("/recipe-suggestions", pantryHandler.SuggestRecipes)
+		r.Put("/{id}", pantryHandler.UpdatePantryItem)
+		r.Delete("/{id}", pantryHandler.DeletePantryItem)
+	})
+
+	// Calendar apps authenticate with the token in the feed URL
+	router.Get("/calendar/{token}.ics", calendarHandler.ServeCalendarFeed)
+
 	router.Route("/files", func(r chi.Router) {
 		r.Use(middleware.JWTMiddleware(jwtKey), middleware.XSRFProtection(xsrfKey), chiMiddleware.Logger)
 		r.Post("/", fileHandler.UploadFile)
@@ -203,9 +314,22 @@ func NewServer(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) (*Se
 		r.Get("/", treeHandler.GetTree)
 	})
 
+	router.Route("/export", func(r chi.Router) {
+		r.Use(middleware.JWTMiddleware(jwtKey), middleware.XSRFProtection(xsrfKey), chiMiddleware.Logger)
+		r.Get("/", exportHandler.ExportAccount)
+	})
+
+	router.Route("/imports", func(r chi.Router) {
+		r.Use(middleware.JWTMiddleware(jwtKey), middleware.XSRFProtection(xsrfKey), chiMiddleware.Logger)
+		r.Post("/", importHandler.CreateImport)
+		r.Get("/{id}", importHandler.GetImportJob)
+	})
+
 	return &Server{
-		Router:   router,
-		jobQueue: jobQueue,
+		Router:           router,
+		jobQueue:         jobQueue,
+		importJobQueue:   importJobQueue,
+		refreshScheduler: refreshScheduler,
 	}, nil
 }
 
@@ -213,10 +337,14 @@ func NewServer(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) (*Se
 func (s *Server) Start(ctx context.Context) {
 	log.Printf("Starting server background services")
 	s.jobQueue.Start(ctx)
+	s.importJobQueue.Start(ctx)
+	s.refreshScheduler.Start(ctx)
 }
 
 // Stop gracefully stops the server's background services
 func (s *Server) Stop() {
 	log.Printf("Stopping server background services")
 	s.jobQueue.Stop()
+	s.importJobQueue.Stop()
+	s.refreshScheduler.Stop()
 }
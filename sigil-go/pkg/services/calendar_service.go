@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// calendarHistoryDays is how far back feeds show planned meals
+	calendarHistoryDays  = 60
+	calendarMealDuration = time.Hour
+)
+
+// mealSlotHours are the start times of meals shown as timed events
+var mealSlotHours = map[string]int{
+	models.MealSlotBreakfast: 8,
+	models.MealSlotLunch:     12,
+	models.MealSlotDinner:    18,
+}
+
+// CalendarService serves planned meals as iCalendar feeds calendar apps can
+// subscribe to. Feeds are authenticated by a secret token in their URL.
+type CalendarService struct {
+	feedRepo     *repositories.CalendarFeedRepository
+	mealPlanRepo *repositories.MealPlanRepository
+	appURL       string
+}
+
+func NewCalendarService(
+	feedRepo *repositories.CalendarFeedRepository,
+	mealPlanRepo *repositories.MealPlanRepository,
+	appURL string,
+) *CalendarService {
+	return &CalendarService{
+		feedRepo:     feedRepo,
+		mealPlanRepo: mealPlanRepo,
+		appURL:       strings.TrimSuffix(appURL, "/"),
+	}
+}
+
+// CreateFeed creates the user's feed and returns its token. An earlier feed
+// URL of the user stops working.
+func (s *CalendarService) CreateFeed(ctx context.Context, userID uuid.UUID) (string, models.CalendarFeed, error) {
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", models.CalendarFeed{}, err
+	}
+
+	feed, err := s.feedRepo.Upsert(ctx, models.CalendarFeed{
+		UserID:    userID,
+		TokenHash: utils.HashToken(token),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return "", models.CalendarFeed{}, fmt.Errorf("failed to store calendar feed: %w", err)
+	}
+
+	return token, feed, nil
+}
+
+// Feed returns the iCalendar feed of a token, pgx.ErrNoRows if the token
+// isn't valid. Meals are all-day events unless timed is set.
+func (s *CalendarService) Feed(ctx context.Context, token string, timed bool) (string, error) {
+	feed, err := s.feedRepo.FetchByTokenHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return "", err
+	}
+
+	since := time.Now().AddDate(0, 0, -calendarHistoryDays).Format("2006-01-02")
+	meals, err := s.mealPlanRepo.FetchCalendarMeals(ctx, feed.UserID, since)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch planned meals: %w", err)
+	}
+
+	return mealCalendar(meals, s.appURL, timed), nil
+}
+
+// mealCalendar writes planned meals as an iCalendar (RFC 5545). Events are
+// identified by the id of the planned meal, so calendar apps update them in
+// place when the plan changes.
+func mealCalendar(meals []models.CalendarMeal, appURL string, timed bool) string {
+	var b strings.Builder
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//Sigil//Meal plan//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:Meal plan")
+	writeICalLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	writeICalLine(&b, "X-PUBLISHED-TTL:PT1H")
+
+	for _, meal := range meals {
+		date, err := time.Parse("2006-01-02", meal.Date)
+		if err != nil {
+			continue
+		}
+
+		modified := meal.UpdatedAt
+		if meal.RecipeUpdatedAt.After(modified) {
+			modified = meal.RecipeUpdatedAt
+		}
+		stamp := modified.UTC().Format("20060102T150405Z")
+
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+meal.ID.String()+"@sigil")
+		writeICalLine(&b, "DTSTAMP:"+stamp)
+		writeICalLine(&b, "LAST-MODIFIED:"+stamp)
+		writeICalLine(&b, fmt.Sprintf("SEQUENCE:%d", meal.Sequence))
+		if hour, ok := mealSlotHours[meal.Slot]; ok && timed {
+			// Floating times, shown in the time zone of the calendar app
+			start := date.Add(time.Duration(hour) * time.Hour)
+			writeICalLine(&b, "DTSTART:"+start.Format("20060102T150405"))
+			writeICalLine(&b, "DTEND:"+start.Add(calendarMealDuration).Format("20060102T150405"))
+		} else {
+			writeICalLine(&b, "DTSTART;VALUE=DATE:"+date.Format("20060102"))
+			writeICalLine(&b, "DTEND;VALUE=DATE:"+date.AddDate(0, 0, 1).Format("20060102"))
+			writeICalLine(&b, "TRANSP:TRANSPARENT")
+		}
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(mealSlotName(meal.Slot)+": "+meal.RecipeName))
+
+		link := ""
+		if meal.NoteID != nil && appURL != "" {
+			link = appURL + "/notes/" + meal.NoteID.String()
+		}
+		if description := mealDescription(meal, link); description != "" {
+			writeICalLine(&b, "DESCRIPTION:"+escapeICalText(description))
+		}
+		if link != "" {
+			writeICalLine(&b, "URL:"+link)
+		}
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// mealDescription describes the recipe of a meal in its calendar event
+func mealDescription(meal models.CalendarMeal, link string) string {
+	var paragraphs []string
+	if meal.RecipeSummary != nil && strings.TrimSpace(*meal.RecipeSummary) != "" {
+		paragraphs = append(paragraphs, strings.TrimSpace(*meal.RecipeSummary))
+	}
+
+	var details []string
+	if meal.RecipePrepTime != nil && *meal.RecipePrepTime != "" {
+		details = append(details, "Prep time: "+*meal.RecipePrepTime)
+	}
+	if meal.Servings != nil {
+		details = append(details, fmt.Sprintf("Servings: %d", *meal.Servings))
+	}
+	if len(details) > 0 {
+		paragraphs = append(paragraphs, strings.Join(details, "\n"))
+	}
+
+	if link != "" {
+		paragraphs = append(paragraphs, link)
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func mealSlotName(slot string) string {
+	if slot == "" {
+		return slot
+	}
+	return strings.ToUpper(slot[:1]) + slot[1:]
+}
+
+var icalTextEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// escapeICalText escapes a TEXT property value
+func escapeICalText(text string) string {
+	return icalTextEscaper.Replace(text)
+}
+
+// writeICalLine writes a content line, folded so no line is longer than 75
+// octets
+func writeICalLine(b *strings.Builder, line string) {
+	const maxLength = 75
+	length := maxLength
+	for len(line) > length {
+		cut := length
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of continuation lines counts towards their length
+		length = maxLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
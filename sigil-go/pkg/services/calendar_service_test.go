@@ -0,0 +1,75 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMealCalendar(t *testing.T) {
+	mealID := uuid.MustParse("6f1c2f7e-4a51-4c3e-9a55-0d6a3b1c2d3e")
+	noteID := uuid.MustParse("0b7e2a61-96c4-4f5e-8a0c-54b1d9e7f001")
+	summary := "Light and fluffy, with lemon; serve warm."
+	prepTime := "30 minutes"
+	servings := 6
+
+	meal := models.CalendarMeal{
+		PlannedMeal: models.PlannedMeal{
+			ID:         mealID,
+			Date:       "2025-03-17",
+			Slot:       models.MealSlotDinner,
+			RecipeName: "Pancakes",
+			Servings:   &servings,
+			Sequence:   2,
+			UpdatedAt:  time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC),
+		},
+		RecipeSummary:   &summary,
+		RecipePrepTime:  &prepTime,
+		RecipeUpdatedAt: time.Date(2025, 3, 12, 18, 0, 0, 0, time.UTC),
+		NoteID:          &noteID,
+	}
+
+	t.Run("All-day events", func(t *testing.T) {
+		calendar := mealCalendar([]models.CalendarMeal{meal}, "https://sigil.example.com", false)
+		unfolded := strings.ReplaceAll(calendar, "\r\n ", "")
+
+		assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+		assert.True(t, strings.HasSuffix(calendar, "END:VCALENDAR\r\n"))
+		assert.Contains(t, unfolded, "UID:"+mealID.String()+"@sigil\r\n")
+		assert.Contains(t, unfolded, "SEQUENCE:2\r\n")
+		// The recipe changed after the meal
+		assert.Contains(t, unfolded, "DTSTAMP:20250312T180000Z\r\n")
+		assert.Contains(t, unfolded, "DTSTART;VALUE=DATE:20250317\r\nDTEND;VALUE=DATE:20250318\r\n")
+		assert.Contains(t, unfolded, "SUMMARY:Dinner: Pancakes\r\n")
+		assert.Contains(t, unfolded, `DESCRIPTION:Light and fluffy\, with lemon\; serve warm.\n\nPrep time: 30 minutes\nServings: 6\n\nhttps://sigil.example.com/notes/`+noteID.String()+"\r\n")
+		assert.Contains(t, unfolded, "URL:https://sigil.example.com/notes/"+noteID.String()+"\r\n")
+
+		for _, line := range strings.Split(calendar, "\r\n") {
+			assert.LessOrEqual(t, len(line), 75, line)
+		}
+	})
+
+	t.Run("Timed events", func(t *testing.T) {
+		calendar := mealCalendar([]models.CalendarMeal{meal}, "", true)
+
+		assert.Contains(t, calendar, "DTSTART:20250317T180000\r\nDTEND:20250317T190000\r\n")
+		assert.NotContains(t, calendar, "URL:")
+	})
+}
+
+func TestWriteICalLine(t *testing.T) {
+	var b strings.Builder
+	line := "DESCRIPTION:" + strings.Repeat("æ", 50)
+	writeICalLine(&b, line)
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	assert.Len(t, lines, 2)
+	assert.LessOrEqual(t, len(lines[0]), 75)
+	assert.True(t, strings.HasPrefix(lines[1], " "))
+	assert.Equal(t, line, lines[0]+strings.TrimPrefix(lines[1], " "))
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/parser"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxClipImages limits how many images are downloaded for a single page
+	maxClipImages    = 50
+	maxClipImageSize = 20 << 20
+)
+
+// Remote images in converted markdown: ![alt](https://...)
+var remoteImageRegex = regexp.MustCompile(`!\[([^\]]*)\]\((https?://[^)\s]+)\)`)
+
+// ClipService saves web pages as notes
+type ClipService struct {
+	noteRepo    repositories.NoteRepositoryInterface
+	sectionRepo repositories.SectionRepositoryInterface
+	fileService *FileService
+	extractor   *parser.MainContentExtractor
+	httpClient  *http.Client
+}
+
+func NewClipService(
+	noteRepo repositories.NoteRepositoryInterface,
+	sectionRepo repositories.SectionRepositoryInterface,
+	fileService *FileService,
+	fetchTimeout time.Duration,
+) *ClipService {
+	extractor := parser.NewMainContentExtractor()
+	extractor.Timeout = fetchTimeout
+
+	return &ClipService{
+		noteRepo:    noteRepo,
+		sectionRepo: sectionRepo,
+		fileService: fileService,
+		extractor:   extractor,
+		httpClient:  newExternalHTTPClient(fetchTimeout),
+	}
+}
+
+// Clip is a web page saved as a note
+type Clip struct {
+	Note models.Note
+	// Warning tells what went wrong after the note was saved, the note is
+	// kept either way
+	Warning string
+}
+
+// ClipURL fetches a page and saves its main content as a markdown note with
+// the page's images stored as files. The note is added to the notebook and
+// section when given, their ownership has to be checked by the caller. A note
+// that can't be added to the notebook is still returned, with a warning, so a
+// retry doesn't clip the page twice.
+func (s *ClipService) ClipURL(
+	ctx context.Context,
+	userID uuid.UUID,
+	pageURL string,
+	notebookID *uuid.UUID,
+	sectionID *uuid.UUID,
+) (Clip, error) {
+	if err := utils.ValidateExternalURL(pageURL); err != nil {
+		return Clip{}, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	html, finalURL, err := s.extractor.RenderURL(pageURL)
+	if err != nil {
+		return Clip{}, fmt.Errorf("failed to fetch page: %w", err)
+	}
+
+	// The page may have redirected somewhere that isn't allowed
+	if err := utils.ValidateExternalURL(finalURL); err != nil {
+		return Clip{}, fmt.Errorf("page redirected to invalid URL: %w", err)
+	}
+
+	base, err := url.Parse(finalURL)
+	if err != nil {
+		return Clip{}, fmt.Errorf("invalid page URL: %w", err)
+	}
+
+	article, err := parser.ExtractArticle(html)
+	if err != nil {
+		return Clip{}, fmt.Errorf("failed to extract content: %w", err)
+	}
+
+	converter := &parser.MarkdownConverter{
+		ResolveURL: func(target string) string {
+			resolved, err := base.Parse(target)
+			if err != nil {
+				return target
+			}
+			return resolved.String()
+		},
+	}
+	body := converter.ConvertNode(article.Content)
+
+	now := time.Now()
+	title := article.Title
+	if title == "" {
+		title = base.Hostname()
+	}
+
+	note := models.Note{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     title,
+		Content:   clipContent(pageURL, article, body, now),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	note, err = s.noteRepo.Upsert(ctx, note)
+	if err != nil {
+		return Clip{}, fmt.Errorf("failed to save note: %w", err)
+	}
+
+	// Files reference their note, so images are stored once the note exists
+	if content := s.storeImages(ctx, userID, note.ID, note.Content); content != note.Content {
+		note.Content = content
+		note, err = s.noteRepo.Upsert(ctx, note)
+		if err != nil {
+			return Clip{}, fmt.Errorf("failed to save note: %w", err)
+		}
+	}
+
+	if notebookID != nil {
+		if err := s.sectionRepo.AssignNoteToSection(ctx, note.ID, *notebookID, sectionID); err != nil {
+			log.Printf("failed to add clipped note %s to notebook %s: %v", note.ID, *notebookID, err)
+			return Clip{Note: note, Warning: "The page was clipped but couldn't be added to the notebook"}, nil
+		}
+	}
+
+	return Clip{Note: note}, nil
+}
+
+// clipContent puts a line with the source and clip date above the content
+func clipContent(pageURL string, article parser.Article, body string, clippedAt time.Time) string {
+	source := article.SiteName
+	if source == "" {
+		if parsed, err := url.Parse(pageURL); err == nil {
+			source = parsed.Hostname()
+		}
+	}
+
+	header := fmt.Sprintf("> Clipped from [%s](%s) on %s", source, pageURL, clippedAt.Format("January 2, 2006"))
+	return strings.TrimSpace(header + "\n\n" + body)
+}
+
+// storeImages downloads remote images into the file store and points the
+// content at them. Images that can't be downloaded keep their remote URL.
+func (s *ClipService) storeImages(ctx context.Context, userID, noteID uuid.UUID, content string) string {
+	stored := make(map[string]string)
+	attempted := 0
+
+	return remoteImageRegex.ReplaceAllStringFunc(content, func(match string) string {
+		parts := remoteImageRegex.FindStringSubmatch(match)
+		alt, imageURL := parts[1], parts[2]
+
+		target, ok := stored[imageURL]
+		if !ok {
+			if attempted >= maxClipImages {
+				return match
+			}
+			attempted++
+
+			fileID, err := s.storeImage(ctx, userID, noteID, imageURL)
+			if err != nil {
+				log.Printf("failed to store clipped image %s: %v", imageURL, err)
+				target = imageURL
+			} else {
+				target = "/files/" + fileID.String()
+			}
+			stored[imageURL] = target
+		}
+
+		return "![" + alt + "](" + target + ")"
+	})
+}
+
+func (s *ClipService) storeImage(ctx context.Context, userID, noteID uuid.UUID, imageURL string) (uuid.UUID, error) {
+	data, err := downloadImage(ctx, s.httpClient, imageURL, maxClipImageSize)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	file, err := s.fileService.CreateFileFromBytes(ctx, data, &noteID, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return file.ID, nil
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	exportAttachmentsDir   = "attachments"
+	exportRecipesDir       = "recipes"
+	exportShoppingListsDir = "shopping-lists"
+
+	// exportShoppingListLimit caps the number of shopping lists included in an export
+	exportShoppingListLimit = 10000
+)
+
+// ExportService builds a portable archive of everything a user owns
+type ExportService struct {
+	treeRepo         repositories.TreeRepositoryInterface
+	noteRepo         repositories.NoteRepositoryInterface
+	recipeRepo       *repositories.RecipeRepository
+	shoppingListRepo repositories.ShoppingListRepositoryInterface
+	fileService      *FileService
+}
+
+func NewExportService(
+	treeRepo repositories.TreeRepositoryInterface,
+	noteRepo repositories.NoteRepositoryInterface,
+	recipeRepo *repositories.RecipeRepository,
+	shoppingListRepo repositories.ShoppingListRepositoryInterface,
+	fileService *FileService,
+) *ExportService {
+	return &ExportService{
+		treeRepo:         treeRepo,
+		noteRepo:         noteRepo,
+		recipeRepo:       recipeRepo,
+		shoppingListRepo: shoppingListRepo,
+		fileService:      fileService,
+	}
+}
+
+// exportArchive tracks state while a single export is being written
+type exportArchive struct {
+	zw          *zip.Writer
+	userID      uuid.UUID
+	usedPaths   map[string]bool
+	notes       map[uuid.UUID]bool
+	attachments map[uuid.UUID]string
+}
+
+// ExportAccount streams a zip archive of the user's notes, attachments, recipes
+// and shopping lists to w. Notes are written as markdown with YAML front matter
+// in folders mirroring their notebook and section.
+func (s *ExportService) ExportAccount(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	archive := &exportArchive{
+		zw:     zip.NewWriter(w),
+		userID: userID,
+		// Reserve the top-level folders so notebooks can't collide with them
+		usedPaths: map[string]bool{
+			exportAttachmentsDir:   true,
+			exportRecipesDir:       true,
+			exportShoppingListsDir: true,
+		},
+		notes:       make(map[uuid.UUID]bool),
+		attachments: make(map[uuid.UUID]string),
+	}
+
+	tree, err := s.treeRepo.GetTree(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tree: %w", err)
+	}
+
+	for _, notebook := range tree.Notebooks {
+		notebookDir := archive.uniquePath("", utils.SanitizeFilename(notebook.Title), "")
+
+		for _, section := range notebook.Sections {
+			sectionDir := archive.uniquePath(notebookDir, utils.SanitizeFilename(section.Title), "")
+			for _, note := range section.Notes {
+				if err := s.exportNote(ctx, archive, sectionDir, note.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, note := range notebook.Unsectioned {
+			if err := s.exportNote(ctx, archive, notebookDir, note.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, note := range tree.Unassigned {
+		if err := s.exportNote(ctx, archive, "", note.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.exportRecipes(ctx, archive); err != nil {
+		return err
+	}
+
+	if err := s.exportShoppingLists(ctx, archive); err != nil {
+		return err
+	}
+
+	return archive.zw.Close()
+}
+
+func (s *ExportService) exportNote(ctx context.Context, archive *exportArchive, dir string, noteID uuid.UUID) error {
+	// A note can live in several notebooks, only the first placement is exported
+	if archive.notes[noteID] {
+		return nil
+	}
+	archive.notes[noteID] = true
+
+	note, err := s.noteRepo.FetchUsersNoteWithTags(ctx, noteID, archive.userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch note %s: %w", noteID, err)
+	}
+
+	frontMatter := utils.NoteFrontMatter{
+		ID:        note.ID.String(),
+		Title:     note.Title,
+		Created:   &note.CreatedAt,
+		Updated:   &note.UpdatedAt,
+		Published: note.Published,
+	}
+	for _, tag := range note.Tags {
+		frontMatter.Tags = append(frontMatter.Tags, tag.Name)
+	}
+
+	header, err := utils.RenderFrontMatter(frontMatter)
+	if err != nil {
+		return fmt.Errorf("failed to render front matter for note %s: %w", noteID, err)
+	}
+
+	// Links are rewritten relative to the note's folder
+	depth := 0
+	if dir != "" {
+		depth = strings.Count(dir, "/") + 1
+	}
+	prefix := strings.Repeat("../", depth)
+
+	var attachmentErr error
+	content := utils.ReplaceFileLinks(note.Content, func(fileID uuid.UUID) (string, bool) {
+		attachmentPath, ok, err := s.exportAttachment(ctx, archive, fileID)
+		if err != nil && attachmentErr == nil {
+			attachmentErr = err
+		}
+		if !ok {
+			return "", false
+		}
+		return prefix + attachmentPath, true
+	})
+	if attachmentErr != nil {
+		return attachmentErr
+	}
+
+	title := note.Title
+	if title == "" {
+		title = utils.GenerateTitleFromContent(note.Content)
+	}
+	filename := archive.uniquePath(dir, utils.SanitizeFilename(title), ".md")
+
+	return archive.writeFile(filename, note.UpdatedAt, []byte(header+"\n"+content))
+}
+
+// exportAttachment copies a referenced file into the archive once and returns its
+// path. Files that don't belong to the user or are missing on disk are skipped.
+func (s *ExportService) exportAttachment(ctx context.Context, archive *exportArchive, fileID uuid.UUID) (string, bool, error) {
+	if attachmentPath, ok := archive.attachments[fileID]; ok {
+		return attachmentPath, attachmentPath != "", nil
+	}
+	archive.attachments[fileID] = ""
+
+	metadata, err := s.fileService.GetMetadata(ctx, fileID, archive.userID)
+	if err != nil {
+		log.Printf("skipping attachment %s in export: %v", fileID, err)
+		return "", false, nil
+	}
+
+	file, err := s.fileService.OpenFile(*metadata)
+	if err != nil {
+		log.Printf("skipping attachment %s in export: %v", fileID, err)
+		return "", false, nil
+	}
+	defer file.Close()
+
+	attachmentPath := path.Join(exportAttachmentsDir, metadata.Filename())
+	dst, err := archive.zw.Create(attachmentPath)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to add attachment %s: %w", fileID, err)
+	}
+
+	if _, err := io.Copy(dst, file); err != nil {
+		return "", false, fmt.Errorf("failed to write attachment %s: %w", fileID, err)
+	}
+
+	archive.attachments[fileID] = attachmentPath
+	return attachmentPath, true, nil
+}
+
+func (s *ExportService) exportRecipes(ctx context.Context, archive *exportArchive) error {
+	recipes, err := s.recipeRepo.FetchByUserID(ctx, archive.userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch recipes: %w", err)
+	}
+
+	for _, recipe := range recipes {
+		data, err := json.MarshalIndent(recipe, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal recipe %s: %w", recipe.ID, err)
+		}
+
+		filename := archive.uniquePath(exportRecipesDir, utils.SanitizeFilename(recipe.Name), ".json")
+		if err := archive.writeFile(filename, recipe.UpdatedAt, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ExportService) exportShoppingLists(ctx context.Context, archive *exportArchive) error {
+	lists, err := s.shoppingListRepo.GetByUserID(ctx, archive.userID, exportShoppingListLimit)
+	if err != nil {
+		return fmt.Errorf("failed to fetch shopping lists: %w", err)
+	}
+
+	for _, list := range lists {
+		filename := archive.uniquePath(exportShoppingListsDir, utils.SanitizeFilename(list.Title), ".md")
+		if err := archive.writeFile(filename, list.UpdatedAt, []byte(list.Content)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uniquePath joins dir and name, appending a counter when the path is already taken.
+// Paths are compared case-insensitively so archives extract cleanly on any filesystem.
+func (a *exportArchive) uniquePath(dir, name, ext string) string {
+	candidate := path.Join(dir, name+ext)
+	for i := 2; a.usedPaths[strings.ToLower(candidate)]; i++ {
+		candidate = path.Join(dir, fmt.Sprintf("%s (%d)%s", name, i, ext))
+	}
+	a.usedPaths[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func (a *exportArchive) writeFile(name string, modified time.Time, data []byte) error {
+	dst, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to export: %w", name, err)
+	}
+
+	if _, err := dst.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to export: %w", name, err)
+	}
+	return nil
+}
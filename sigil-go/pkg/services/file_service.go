@@ -40,6 +40,17 @@ func NewFileService(
 	}
 }
 
+// SupportedImageTypes maps the MIME types accepted for uploads to their file extension
+var SupportedImageTypes = map[string]string{
+	"image/apng":    "apng",
+	"image/avif":    "avif",
+	"image/jpeg":    "jpeg",
+	"image/png":     "png",
+	"image/gif":     "gif",
+	"image/svg+xml": "svg",
+	"image/webp":    "webp",
+}
+
 type FileConfig struct {
 	StorageRoot        string
 	MaxFilesize        int
@@ -62,7 +73,22 @@ func (s *FileService) CreateFile(
 		return nil, fmt.Errorf("could not read file content %w", err)
 	}
 
-	mimeType, err := s.checkSupportedFiletype(buffer)
+	return s.CreateFileFromBytes(ctx, buffer, noteID, userID)
+}
+
+// CreateFileFromBytes validates and stores file content that is already in memory,
+// e.g. attachments extracted from an import archive
+func (s *FileService) CreateFileFromBytes(
+	ctx context.Context,
+	data []byte,
+	noteID *uuid.UUID,
+	userID uuid.UUID,
+) (*models.FileMetadata, error) {
+	if s.config.MaxFilesize > 0 && len(data) > s.config.MaxFilesize {
+		return nil, fmt.Errorf("file exceeds maximum size of %d bytes", s.config.MaxFilesize)
+	}
+
+	mimeType, err := s.checkSupportedFiletype(data)
 	if err != nil {
 		return nil, err
 	}
@@ -79,11 +105,11 @@ func (s *FileService) CreateFile(
 		UserID:    userID,
 		NoteID:    noteID,
 		Filetype:  mimeType,
-		Filesize:  int(header.Size),
+		Filesize:  len(data),
 		Extension: s.config.SupportedFiletypes[mimeType],
 	}
 
-	if err = s.storeFileToDisk(buffer, metadata); err != nil {
+	if err = s.storeFileToDisk(data, metadata); err != nil {
 		return nil, err
 	}
 
@@ -138,6 +164,11 @@ func (s *FileService) checkSupportedFiletype(data []byte) (string, error) {
 	return mimeType, nil
 }
 
+// OpenFile opens the stored content of a file for reading
+func (s *FileService) OpenFile(file models.FileMetadata) (*os.File, error) {
+	return os.Open(path.Join(file.Filepath(s.config.StorageRoot), file.Filename()))
+}
+
 func (s *FileService) DeleteFileFromDisk(file models.FileMetadata) error {
 	filepath := file.Filepath(s.config.StorageRoot)
 	filename := path.Join(filepath, file.Filename())
@@ -175,3 +206,79 @@ func (s *FileService) DeleteFilesForNote(ctx context.Context, noteID uuid.UUID)
 
 	return nil
 }
+
+// DeleteUnusedRecipeImages deletes the given recipe images when no recipe
+// uses them anymore. Recipe copies share their images with the original.
+func (s *FileService) DeleteUnusedRecipeImages(ctx context.Context, fileIDs []uuid.UUID) error {
+	if len(fileIDs) == 0 {
+		return nil
+	}
+
+	files, err := s.repo.FetchUnusedRecipeImages(ctx, fileIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch unused recipe images: %w", err)
+	}
+
+	for _, file := range files {
+		if err := s.DeleteFileFromDisk(file); err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, file.ID); err != nil {
+			return fmt.Errorf("failed to delete file %s: %w", file.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// StoredFile is the content of a file read from storage
+type StoredFile struct {
+	Metadata models.FileMetadata
+	Data     []byte
+}
+
+// ReadFiles reads the user's files into memory, in order, until maxBytes have
+// been read. Files that can't be read, or no longer fit, are skipped.
+func (s *FileService) ReadFiles(
+	ctx context.Context,
+	userID uuid.UUID,
+	fileIDs []uuid.UUID,
+	maxBytes int,
+) map[uuid.UUID]StoredFile {
+	files := make(map[uuid.UUID]StoredFile)
+	total := 0
+
+	for _, fileID := range fileIDs {
+		if _, ok := files[fileID]; ok || total >= maxBytes {
+			continue
+		}
+
+		metadata, err := s.GetMetadata(ctx, fileID, userID)
+		if err != nil {
+			log.Printf("skipping file %s: %v", fileID, err)
+			continue
+		}
+		if total+metadata.Filesize > maxBytes {
+			continue
+		}
+
+		data, err := s.readFile(*metadata)
+		if err != nil {
+			log.Printf("skipping file %s: %v", fileID, err)
+			continue
+		}
+
+		files[fileID] = StoredFile{Metadata: *metadata, Data: data}
+		total += len(data)
+	}
+	return files
+}
+
+func (s *FileService) readFile(metadata models.FileMetadata) ([]byte, error) {
+	file, err := s.OpenFile(metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"tofoss/sigil-go/pkg/utils"
+)
+
+// newExternalHTTPClient returns a client for URLs found on user supplied
+// pages. Redirects get the same SSRF checks as the original URL.
+func newExternalHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return utils.ValidateExternalURL(req.URL.String())
+		},
+	}
+}
+
+// downloadImage fetches an image from an external URL. The content type is
+// checked by the file service when the image is stored.
+func downloadImage(ctx context.Context, client *http.Client, imageURL string, maxSize int) ([]byte, error) {
+	if err := utils.ValidateExternalURL(imageURL); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/importer"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// ImportDir returns the directory the upload for an import job is kept in until
+// the job has been processed
+func ImportDir(uploadRoot string, jobID uuid.UUID) string {
+	return filepath.Join(uploadRoot, "imports", jobID.String())
+}
+
+// ImportArchivePath returns where the upload for an import job is stored. The
+// original filename is kept since sources like ENEX name notebooks after it.
+func ImportArchivePath(uploadRoot string, job models.ImportJob) string {
+	return filepath.Join(ImportDir(uploadRoot, job.ID), job.Filename)
+}
+
+// ImportJobQueue processes uploaded import archives in the background, one at a time
+type ImportJobQueue struct {
+	jobRepo    *repositories.ImportJobRepository
+	pipeline   *importer.Pipeline
+	uploadRoot string
+	running    bool
+	stopCh     chan struct{}
+	wg         sync.WaitGroup
+
+	// Configuration
+	pollInterval time.Duration
+	jobTimeout   time.Duration
+}
+
+func NewImportJobQueue(
+	jobRepo *repositories.ImportJobRepository,
+	pipeline *importer.Pipeline,
+	uploadRoot string,
+	pollInterval time.Duration,
+	jobTimeout time.Duration,
+) *ImportJobQueue {
+	return &ImportJobQueue{
+		jobRepo:      jobRepo,
+		pipeline:     pipeline,
+		uploadRoot:   uploadRoot,
+		running:      false,
+		stopCh:       make(chan struct{}),
+		pollInterval: pollInterval,
+		jobTimeout:   jobTimeout,
+	}
+}
+
+// Start begins the background job processing
+func (q *ImportJobQueue) Start(ctx context.Context) {
+	if q.running {
+		log.Printf("Import job queue is already running")
+		return
+	}
+
+	q.running = true
+	log.Printf("Starting import job queue with poll interval: %v", q.pollInterval)
+
+	q.wg.Add(1)
+	go q.worker(ctx)
+}
+
+// Stop gracefully stops the background job processing
+func (q *ImportJobQueue) Stop() {
+	if !q.running {
+		return
+	}
+
+	log.Printf("Stopping import job queue...")
+	q.running = false
+	close(q.stopCh)
+	q.wg.Wait()
+	log.Printf("Import job queue stopped")
+}
+
+// worker is the background goroutine that processes jobs
+func (q *ImportJobQueue) worker(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Import job queue worker stopping due to context cancellation")
+			return
+		case <-q.stopCh:
+			log.Printf("Import job queue worker stopping due to stop signal")
+			return
+		case <-ticker.C:
+			q.processPending(ctx)
+		}
+	}
+}
+
+// processPending handles the next pending import. Imports are large, so they
+// run one after another instead of concurrently.
+func (q *ImportJobQueue) processPending(ctx context.Context) {
+	jobs, err := q.jobRepo.FetchPendingJobs(ctx, 1)
+	if err != nil {
+		log.Printf("Error fetching pending import jobs: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		q.processJob(ctx, job)
+	}
+}
+
+func (q *ImportJobQueue) processJob(ctx context.Context, job models.ImportJob) {
+	claimed, err := q.jobRepo.MarkProcessing(ctx, job.ID)
+	if err != nil {
+		log.Printf("Error claiming import job %s: %v", job.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	log.Printf("Processing import job %s (%s)", job.ID, job.Format)
+
+	archivePath := ImportArchivePath(q.uploadRoot, job)
+	defer func() {
+		if err := os.RemoveAll(ImportDir(q.uploadRoot, job.ID)); err != nil {
+			log.Printf("Failed to remove archive for import job %s: %v", job.ID, err)
+		}
+	}()
+
+	jobCtx, cancel := context.WithTimeout(ctx, q.jobTimeout)
+	defer cancel()
+
+	progress := func(processed, total int) {
+		if err := q.jobRepo.UpdateProgress(jobCtx, job.ID, processed, total); err != nil {
+			log.Printf("Failed to update progress for import job %s: %v", job.ID, err)
+		}
+	}
+
+	report, err := q.pipeline.Import(jobCtx, job.UserID, job.Format, archivePath, progress)
+	if err != nil {
+		log.Printf("Import job %s failed: %v", job.ID, err)
+		// The job context may have expired, the failure still needs to be recorded
+		if err := q.jobRepo.Fail(context.Background(), job.ID, err.Error()); err != nil {
+			log.Printf("Failed to mark import job %s as failed: %v", job.ID, err)
+		}
+		return
+	}
+
+	if err := q.jobRepo.Complete(jobCtx, job.ID, report.Imported, report.Errors); err != nil {
+		log.Printf("Failed to complete import job %s: %v", job.ID, err)
+		return
+	}
+
+	log.Printf("Import job %s completed: %d notes imported, %d errors", job.ID, report.Imported, len(report.Errors))
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// MealPlanService turns meal plans into shopping lists
+type MealPlanService struct {
+	mealPlanRepo     *repositories.MealPlanRepository
+	recipeRepo       *repositories.RecipeRepository
+	shoppingListRepo *repositories.ShoppingListRepository
+}
+
+func NewMealPlanService(
+	mealPlanRepo *repositories.MealPlanRepository,
+	recipeRepo *repositories.RecipeRepository,
+	shoppingListRepo *repositories.ShoppingListRepository,
+) *MealPlanService {
+	return &MealPlanService{
+		mealPlanRepo:     mealPlanRepo,
+		recipeRepo:       recipeRepo,
+		shoppingListRepo: shoppingListRepo,
+	}
+}
+
+// CanPlanRecipe reports whether the user has access to a recipe through
+// their notes
+func (s *MealPlanService) CanPlanRecipe(ctx context.Context, userID, recipeID uuid.UUID) (bool, error) {
+	noteIDs, err := s.recipeRepo.FetchUserNoteIDs(ctx, recipeID, userID)
+	if err != nil {
+		return false, err
+	}
+	return len(noteIDs) > 0, nil
+}
+
+// GenerateShoppingList creates a shopping list with the ingredients of every
+// meal in the plan, scaled to the planned servings. Ingredients used by
+// several meals are merged, and items the user always has in stock are left
+// out. It returns the list and the names of the items left out.
+func (s *MealPlanService) GenerateShoppingList(
+	ctx context.Context,
+	userID uuid.UUID,
+	plan models.MealPlan,
+) (*models.ShoppingList, []string, error) {
+	stocked, err := s.mealPlanRepo.FetchStockedItems(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch stocked items: %w", err)
+	}
+
+	recipes := make([]models.Recipe, 0, len(plan.Meals))
+	for _, meal := range plan.Meals {
+		recipe, err := s.recipeRepo.FetchByID(ctx, meal.RecipeID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch recipe %s: %w", meal.RecipeID, err)
+		}
+		recipes = append(recipes, scaleToServings(recipe, meal.Servings))
+	}
+
+	content, skipped := mealPlanShoppingList(plan.Title, recipes, stocked)
+
+	items, err := utils.ParseShoppingList(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse shopping list: %w", err)
+	}
+
+	now := time.Now()
+	list := models.ShoppingList{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Title:       plan.Title,
+		Content:     content,
+		ContentHash: s.shoppingListRepo.HashContent(content),
+		Items:       items,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for i := range list.Items {
+		list.Items[i].ShoppingListID = list.ID
+	}
+
+	created, err := s.shoppingListRepo.Create(ctx, list)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create shopping list: %w", err)
+	}
+
+	for _, item := range items {
+		if err := s.shoppingListRepo.AddToVocabulary(ctx, userID, item.ItemName); err != nil {
+			log.Printf("warning: failed to add item to vocabulary: %v", err)
+		}
+	}
+
+	return created, skipped, nil
+}
+
+// scaleToServings scales a recipe to the servings planned for a meal
+func scaleToServings(recipe models.Recipe, servings *int) models.Recipe {
+	if servings == nil || recipe.Servings == nil || *recipe.Servings <= 0 {
+		return recipe
+	}
+	factor := float64(*servings) / float64(*recipe.Servings)
+	return utils.ScaleRecipe(recipe, factor, utils.UnitSystemOriginal)
+}
+
+// mealPlanShoppingList writes the ingredients of the recipes as shopping list
+// markdown with a section per recipe. An ingredient needed by several recipes
+// is listed once, in the section of the first recipe using it.
+func mealPlanShoppingList(title string, recipes []models.Recipe, stocked []string) (string, []string) {
+	content := "# " + title + "\n"
+	skipped := []string{}
+	skippedNames := make(map[string]bool)
+	for _, recipe := range recipes {
+		var ingredients []models.Ingredient
+		for _, ingredient := range recipe.Ingredients {
+			name := utils.NormalizeItemName(ingredient.Name)
+			if slices.Contains(stocked, name) {
+				if !skippedNames[name] {
+					skippedNames[name] = true
+					skipped = append(skipped, ingredient.Name)
+				}
+				continue
+			}
+			ingredients = append(ingredients, ingredient)
+		}
+		content = utils.MergeIntoShoppingList(content, ingredients, recipe.Name).Content
+	}
+	return content, skipped
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMealPlanShoppingList(t *testing.T) {
+	pancakes := models.Recipe{
+		Name: "Pancakes",
+		Ingredients: []models.Ingredient{
+			utils.ParseIngredient("3 dl flour"),
+			utils.ParseIngredient("2 eggs"),
+			utils.ParseIngredient("5 dl milk"),
+			utils.ParseIngredient("1 pinch salt"),
+		},
+	}
+	omelette := models.Recipe{
+		Name: "Omelette",
+		Ingredients: []models.Ingredient{
+			utils.ParseIngredient("3 eggs"),
+			utils.ParseIngredient("1 dl milk"),
+			utils.ParseIngredient("50 g cheese"),
+			utils.ParseIngredient("Salt to taste"),
+		},
+	}
+
+	content, skipped := mealPlanShoppingList("Week 12", []models.Recipe{pancakes, omelette}, []string{"salt"})
+
+	expected := "# Week 12\n" +
+		"\n## Pancakes\n" +
+		"- [ ] 3 dl flour\n" +
+		"- [ ] 5 eggs\n" +
+		"- [ ] 6 dl milk\n" +
+		"\n## Omelette\n" +
+		"- [ ] 50 g cheese\n"
+	assert.Equal(t, expected, content)
+	assert.Equal(t, []string{"salt"}, skipped)
+
+	items, err := utils.ParseShoppingList(content)
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	assert.Equal(t, "Pancakes", items[1].SectionHeader)
+	assert.Equal(t, "Omelette", items[3].SectionHeader)
+}
+
+func TestMealPlanShoppingListSameRecipeTwice(t *testing.T) {
+	soup := models.Recipe{
+		Name:        "Soup",
+		Ingredients: []models.Ingredient{utils.ParseIngredient("500 g carrots")},
+	}
+
+	content, skipped := mealPlanShoppingList("Soup week", []models.Recipe{soup, soup}, nil)
+
+	assert.Equal(t, "# Soup week\n\n## Soup\n- [ ] 1000 g carrots\n", content)
+	assert.Empty(t, skipped)
+}
+
+func TestScaleToServings(t *testing.T) {
+	four, two := 4, 2
+	recipe := models.Recipe{
+		Name:        "Pancakes",
+		Servings:    &four,
+		Ingredients: []models.Ingredient{utils.ParseIngredient("4 eggs")},
+	}
+
+	scaled := scaleToServings(recipe, &two)
+	require.NotNil(t, scaled.Servings)
+	assert.Equal(t, 2, *scaled.Servings)
+	assert.Equal(t, 2.0, *scaled.Ingredients[0].Quantity.Min)
+
+	// Without a servings override, or servings on the recipe, nothing changes
+	assert.Equal(t, recipe, scaleToServings(recipe, nil))
+	noServings := models.Recipe{Ingredients: recipe.Ingredients}
+	assert.Equal(t, noServings, scaleToServings(noServings, &two))
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// PantryService keeps track of the food users have at home
+type PantryService struct {
+	pantryRepo   *repositories.PantryRepository
+	recipeRepo   *repositories.RecipeRepository
+	mealPlanRepo *repositories.MealPlanRepository
+}
+
+func NewPantryService(
+	pantryRepo *repositories.PantryRepository,
+	recipeRepo *repositories.RecipeRepository,
+	mealPlanRepo *repositories.MealPlanRepository,
+) *PantryService {
+	return &PantryService{
+		pantryRepo:   pantryRepo,
+		recipeRepo:   recipeRepo,
+		mealPlanRepo: mealPlanRepo,
+	}
+}
+
+// AddShoppingListItem adds a bought shopping list item to the pantry. When
+// the item is already in the pantry with a compatible unit, the quantities
+// are added up instead.
+func (s *PantryService) AddShoppingListItem(
+	ctx context.Context,
+	userID uuid.UUID,
+	entry models.ShoppingListEntry,
+) (models.PantryItem, error) {
+	added := pantryItemFromEntry(entry)
+
+	existing, err := s.pantryRepo.FetchByItemName(ctx, userID, added.ItemName)
+	if err != nil {
+		return models.PantryItem{}, fmt.Errorf("failed to fetch pantry items: %w", err)
+	}
+
+	now := time.Now()
+	for _, item := range existing {
+		if merged, ok := addToPantryItem(item, added); ok {
+			merged.UpdatedAt = now
+			return s.pantryRepo.Update(ctx, merged)
+		}
+	}
+
+	added.ID = uuid.New()
+	added.UserID = userID
+	added.CreatedAt = now
+	added.UpdatedAt = now
+	return s.pantryRepo.Create(ctx, added)
+}
+
+// SuggestRecipes ranks the user's recipes by how many of their ingredients
+// are in the pantry or always in stock
+func (s *PantryService) SuggestRecipes(
+	ctx context.Context,
+	userID uuid.UUID,
+	limit int,
+) ([]models.RecipeSuggestion, error) {
+	pantry, err := s.pantryRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pantry: %w", err)
+	}
+	stocked, err := s.mealPlanRepo.FetchStockedItems(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stocked items: %w", err)
+	}
+	recipes, err := s.recipeRepo.FetchByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recipes: %w", err)
+	}
+
+	have := stocked
+	for _, item := range pantry {
+		have = append(have, item.ItemName)
+	}
+
+	suggestions := rankRecipes(recipes, have)
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// pantryItemFromEntry converts a shopping list item to a pantry item. Ranges
+// count as their upper bound.
+func pantryItemFromEntry(entry models.ShoppingListEntry) models.PantryItem {
+	item := models.PantryItem{
+		ItemName:    entry.ItemName,
+		DisplayName: entry.ItemName,
+	}
+	// The display name of the entry includes its quantity and notes
+	if name := utils.ParseIngredient(entry.DisplayName).Name; utils.NormalizeItemName(name) == entry.ItemName {
+		item.DisplayName = name
+	}
+
+	if entry.Quantity != nil {
+		item.Unit = entry.Quantity.Unit
+		switch {
+		case entry.Quantity.Max != nil:
+			item.Quantity = entry.Quantity.Max
+		case entry.Quantity.Min != nil:
+			item.Quantity = entry.Quantity.Min
+		}
+	}
+	return item
+}
+
+// addToPantryItem adds a quantity to an item already in the pantry
+func addToPantryItem(item, added models.PantryItem) (models.PantryItem, bool) {
+	switch {
+	case added.Quantity == nil:
+		return item, item.Quantity == nil
+	case item.Quantity == nil:
+		return item, false
+	}
+
+	sum, ok := utils.AddQuantities(
+		models.Quantity{Min: item.Quantity, Max: item.Quantity, Unit: item.Unit},
+		models.Quantity{Min: added.Quantity, Max: added.Quantity, Unit: added.Unit},
+	)
+	if !ok {
+		return item, false
+	}
+	item.Quantity = sum.Max
+	item.Unit = sum.Unit
+	return item, true
+}
+
+// rankRecipes suggests the recipes using any of the items, those with the
+// most ingredients at hand and the fewest to buy first
+func rankRecipes(recipes []models.Recipe, items []string) []models.RecipeSuggestion {
+	have := make([][]string, 0, len(items))
+	for _, item := range items {
+		if words := itemNameWords(item); len(words) > 0 {
+			have = append(have, words)
+		}
+	}
+
+	suggestions := []models.RecipeSuggestion{}
+	for _, recipe := range recipes {
+		suggestion := models.RecipeSuggestion{
+			Recipe:    recipe,
+			Available: []string{},
+			Missing:   []string{},
+		}
+		for _, ingredient := range recipe.Ingredients {
+			words := itemNameWords(ingredient.Name)
+			available := slices.ContainsFunc(have, func(item []string) bool {
+				return containsWords(words, item) || containsWords(item, words)
+			})
+			switch {
+			case available:
+				suggestion.Available = append(suggestion.Available, ingredient.Name)
+			case !ingredient.IsOptional:
+				suggestion.Missing = append(suggestion.Missing, ingredient.Name)
+			}
+		}
+		if len(suggestion.Available) > 0 {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	slices.SortStableFunc(suggestions, func(a, b models.RecipeSuggestion) int {
+		return cmp.Or(
+			cmp.Compare(len(b.Available), len(a.Available)),
+			cmp.Compare(len(a.Missing), len(b.Missing)),
+			strings.Compare(strings.ToLower(a.Recipe.Name), strings.ToLower(b.Recipe.Name)),
+		)
+	})
+	return suggestions
+}
+
+// itemNameWords splits an item name into singular, lowercase words, so
+// "Red onions" and "onion" can be compared
+func itemNameWords(name string) []string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for i, word := range words {
+		words[i] = singularWord(word)
+	}
+	return words
+}
+
+// singularWord strips common English plural endings
+func singularWord(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case len(word) > 3 && strings.HasSuffix(word, "s") &&
+		!strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// containsWords reports whether words contains part as a sequence
+func containsWords(words, part []string) bool {
+	if len(part) == 0 || len(part) > len(words) {
+		return false
+	}
+	for i := 0; i+len(part) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(part)], part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ingredients(lines ...string) []models.Ingredient {
+	result := make([]models.Ingredient, len(lines))
+	for i, line := range lines {
+		result[i] = utils.ParseIngredient(line)
+	}
+	return result
+}
+
+func TestRankRecipes(t *testing.T) {
+	recipes := []models.Recipe{
+		{Name: "Tomato soup", Ingredients: ingredients("4 tomatoes", "1 red onion", "2 cloves garlic", "5 dl stock")},
+		{Name: "Omelette", Ingredients: ingredients("3 eggs", "1 dl milk", "Chives (optional)")},
+		{Name: "Pancakes", Ingredients: ingredients("3 dl flour", "2 eggs", "5 dl milk")},
+		{Name: "Sushi", Ingredients: ingredients("300 g sushi rice", "Nori")},
+	}
+
+	suggestions := rankRecipes(recipes, []string{"egg", "whole milk", "onions", "tomato", "chives"})
+
+	require.Len(t, suggestions, 3)
+
+	assert.Equal(t, "Omelette", suggestions[0].Recipe.Name)
+	assert.Equal(t, []string{"eggs", "milk", "Chives"}, suggestions[0].Available)
+	assert.Empty(t, suggestions[0].Missing)
+
+	// Pancakes and the soup both have two ingredients at hand, pancakes need less shopping
+	assert.Equal(t, "Pancakes", suggestions[1].Recipe.Name)
+	assert.Equal(t, []string{"flour"}, suggestions[1].Missing)
+	assert.Equal(t, "Tomato soup", suggestions[2].Recipe.Name)
+	assert.Equal(t, []string{"tomatoes", "red onion"}, suggestions[2].Available)
+	assert.Equal(t, []string{"garlic", "stock"}, suggestions[2].Missing)
+}
+
+func TestPantryItemFromEntry(t *testing.T) {
+	entries, err := utils.ParseShoppingList("- [ ] 2L Milk (organic)\n- [ ] 2-3 lemons\n- [ ] Bread\n")
+	require.NoError(t, err)
+
+	milk := pantryItemFromEntry(entries[0])
+	assert.Equal(t, "milk", milk.ItemName)
+	assert.Equal(t, "Milk", milk.DisplayName)
+	require.NotNil(t, milk.Quantity)
+	assert.Equal(t, 2.0, *milk.Quantity)
+	assert.Equal(t, "L", milk.Unit)
+
+	lemons := pantryItemFromEntry(entries[1])
+	require.NotNil(t, lemons.Quantity)
+	assert.Equal(t, 3.0, *lemons.Quantity)
+
+	bread := pantryItemFromEntry(entries[2])
+	assert.Equal(t, "Bread", bread.DisplayName)
+	assert.Nil(t, bread.Quantity)
+}
+
+func TestAddToPantryItem(t *testing.T) {
+	quantity := func(v float64) *float64 { return &v }
+
+	merged, ok := addToPantryItem(
+		models.PantryItem{ItemName: "milk", Quantity: quantity(500), Unit: "ml"},
+		models.PantryItem{ItemName: "milk", Quantity: quantity(1), Unit: "l"},
+	)
+	require.True(t, ok)
+	assert.Equal(t, 1.5, *merged.Quantity)
+	assert.Equal(t, "l", merged.Unit)
+
+	_, ok = addToPantryItem(
+		models.PantryItem{ItemName: "milk", Quantity: quantity(1), Unit: "l"},
+		models.PantryItem{ItemName: "milk", Quantity: quantity(2), Unit: "cartons"},
+	)
+	assert.False(t, ok)
+
+	_, ok = addToPantryItem(models.PantryItem{ItemName: "bread"}, models.PantryItem{ItemName: "bread"})
+	assert.True(t, ok)
+}
+
+func TestItemNameWords(t *testing.T) {
+	assert.Equal(t, []string{"red", "onion"}, itemNameWords("Red onions"))
+	assert.Equal(t, []string{"tomato"}, itemNameWords("tomatoes"))
+	assert.Equal(t, []string{"berry"}, itemNameWords("berries"))
+	assert.Equal(t, []string{"peach"}, itemNameWords("peaches"))
+	assert.Equal(t, []string{"egg"}, itemNameWords("eggs"))
+	assert.Equal(t, []string{"swiss", "cheese"}, itemNameWords("Swiss cheese"))
+	assert.Equal(t, []string{"hummus"}, itemNameWords("hummus"))
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/recipeformat"
+
+	"github.com/google/uuid"
+)
+
+// maxCookbookImageBytes limits the images embedded in a single cookbook.
+// Images past the limit are left out, the recipes are still written.
+const maxCookbookImageBytes = 50 << 20
+
+// RecipeCollectionService writes recipe collections as cookbooks
+type RecipeCollectionService struct {
+	recipeRepo  *repositories.RecipeRepository
+	fileService *FileService
+}
+
+func NewRecipeCollectionService(
+	recipeRepo *repositories.RecipeRepository,
+	fileService *FileService,
+) *RecipeCollectionService {
+	return &RecipeCollectionService{
+		recipeRepo:  recipeRepo,
+		fileService: fileService,
+	}
+}
+
+// WriteCookbook writes the recipes of a collection as a single HTML document
+// with a table of contents. Images are embedded, so the document can be
+// opened and printed without signing in.
+func (s *RecipeCollectionService) WriteCookbook(
+	ctx context.Context,
+	w io.Writer,
+	userID uuid.UUID,
+	collection models.RecipeCollection,
+) error {
+	recipes, err := s.recipeRepo.FetchByCollection(ctx, collection.ID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch recipes of collection %s: %w", collection.ID, err)
+	}
+
+	cookbook := recipeformat.Cookbook{
+		Title:   collection.Name,
+		Recipes: recipes,
+		Images:  s.cookbookImages(ctx, userID, recipes),
+	}
+	if collection.Description != nil {
+		cookbook.Description = *collection.Description
+	}
+
+	return recipeformat.WriteCookbook(w, cookbook)
+}
+
+// cookbookImages reads the recipe and step images of recipes as data URLs.
+// Images that can't be read are skipped.
+func (s *RecipeCollectionService) cookbookImages(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipes []models.Recipe,
+) map[uuid.UUID]string {
+	var fileIDs []uuid.UUID
+	for _, recipe := range recipes {
+		if recipe.ImageID != nil {
+			fileIDs = append(fileIDs, *recipe.ImageID)
+		}
+		for _, image := range recipe.StepImages {
+			fileIDs = append(fileIDs, image.FileID)
+		}
+	}
+
+	images := make(map[uuid.UUID]string)
+	for fileID, file := range s.fileService.ReadFiles(ctx, userID, fileIDs, maxCookbookImageBytes) {
+		images[fileID] = "data:" + file.Metadata.Filetype + ";base64," + base64.StdEncoding.EncodeToString(file.Data)
+	}
+	return images
+}
@@ -1,13 +1,17 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 	"tofoss/sigil-go/pkg/db/repositories"
@@ -17,18 +21,39 @@ import (
 	"tofoss/sigil-go/pkg/utils"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+const (
+	maxRecipeImageSize = 20 << 20
+	// maxRecipeStepImages limits how many step images are downloaded for a
+	// single recipe
+	maxRecipeStepImages = 30
+)
+
+// recipeImages are the image URLs found on a recipe page
+type recipeImages struct {
+	main  string
+	steps map[int]string // by index into the recipe's steps
+}
+
 type RecipeProcessor struct {
 	recipeRepo          *repositories.RecipeRepository
 	jobRepo             *repositories.RecipeJobRepository
 	noteRepo            *repositories.NoteRepository
 	cacheRepo           *repositories.RecipeURLCacheRepository
+	updateRepo          *repositories.RecipeUpdateRepository
+	fileService         *FileService
 	extractor           *parser.MainContentExtractor
-	aiClient            genai.DeepseekClient
+	httpClient          *http.Client
+	aiClient            genai.Provider
 	systemPrompt        string
+	ingredientPrompt    string
+	generationPrompt    string
+	refinementPrompt    string
 	contentFetchTimeout time.Duration
 	aiProcessingTimeout time.Duration
+	stepImages          bool
 }
 
 func NewRecipeProcessor(
@@ -36,8 +61,12 @@ func NewRecipeProcessor(
 	jobRepo *repositories.RecipeJobRepository,
 	noteRepo *repositories.NoteRepository,
 	cacheRepo *repositories.RecipeURLCacheRepository,
+	updateRepo *repositories.RecipeUpdateRepository,
+	fileService *FileService,
+	aiClient genai.Provider,
 	contentFetchTimeout time.Duration,
 	aiProcessingTimeout time.Duration,
+	stepImages bool,
 ) (*RecipeProcessor, error) {
 	// Load AI prompt from file
 	promptPath := filepath.Join("prompts", "recipe_extraction.txt")
@@ -46,21 +75,51 @@ func NewRecipeProcessor(
 		return nil, fmt.Errorf("failed to load recipe extraction prompt: %w", err)
 	}
 
+	ingredientPromptBytes, err := os.ReadFile(filepath.Join("prompts", "ingredient_structuring.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ingredient structuring prompt: %w", err)
+	}
+
+	generationPromptBytes, err := os.ReadFile(filepath.Join("prompts", "recipe_generation.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recipe generation prompt: %w", err)
+	}
+
+	refinementPromptBytes, err := os.ReadFile(filepath.Join("prompts", "recipe_refinement.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recipe refinement prompt: %w", err)
+	}
+
 	return &RecipeProcessor{
 		recipeRepo:          recipeRepo,
 		jobRepo:             jobRepo,
 		noteRepo:            noteRepo,
 		cacheRepo:           cacheRepo,
+		updateRepo:          updateRepo,
+		fileService:         fileService,
 		extractor:           parser.NewMainContentExtractor(),
-		aiClient:            genai.NewDeepseekClient(),
+		httpClient:          newExternalHTTPClient(contentFetchTimeout),
+		aiClient:            aiClient,
 		systemPrompt:        string(systemPromptBytes),
+		ingredientPrompt:    string(ingredientPromptBytes),
+		generationPrompt:    string(generationPromptBytes),
+		refinementPrompt:    string(refinementPromptBytes),
 		contentFetchTimeout: contentFetchTimeout,
 		aiProcessingTimeout: aiProcessingTimeout,
+		stepImages:          stepImages,
 	}, nil
 }
 
-// ProcessJob processes a recipe job from URL to completed recipe and note
+// ProcessJob processes a recipe job from URL, pasted text or uploaded file to
+// completed recipe and note
 func (p *RecipeProcessor) ProcessJob(ctx context.Context, job models.RecipeJob) error {
+	switch job.SourceType {
+	case models.RecipeSourceText, models.RecipeSourceHTML, models.RecipeSourceGenerate, models.RecipeSourceRefine:
+		return p.processSourceContent(ctx, job)
+	case models.RecipeSourceRefresh:
+		return p.processRefresh(ctx, job)
+	}
+
 	log.Printf("Processing recipe job %s for URL: %s", job.ID, job.URL)
 
 	// Update job status to processing
@@ -77,49 +136,455 @@ func (p *RecipeProcessor) ProcessJob(ctx context.Context, job models.RecipeJob)
 		return p.createNoteFromCachedRecipe(ctx, job, cached.RecipeID)
 	}
 
-	// Extract content from URL
-	content, err := p.extractContent(ctx, job.URL)
+	// Fetch the page from URL
+	page, err := p.fetchPage(ctx, job.URL)
 	if err != nil {
 		return p.failJob(ctx, job.ID, fmt.Sprintf("Failed to extract content: %v", err))
 	}
 
-	// Process with AI
-	recipe, err := p.processWithAI(ctx, content)
+	// The page may name its preferred URL, e.g. an AMP page or one with
+	// another path. Only URLs on the same site are trusted, so a page can't
+	// take over the cache entry of another site's recipe.
+	cacheURLs := []string{job.URL}
+	if canonical := canonicalLink(job.URL, page); canonical != "" {
+		canonicalHash := p.hashURL(canonical)
+		if canonicalHash != urlHash {
+			cached, err := p.cacheRepo.GetByURLHash(ctx, canonicalHash)
+			if err == nil {
+				log.Printf("Found cached recipe for canonical URL %s of %s", canonical, job.URL)
+				p.cacheURL(ctx, urlHash, job.URL, cached.RecipeID)
+				return p.createNoteFromCachedRecipe(ctx, job, cached.RecipeID)
+			}
+			cacheURLs = append(cacheURLs, canonical)
+		}
+	}
+
+	recipe, images, err := p.extractRecipe(ctx, page)
 	if err != nil {
-		return p.failJob(ctx, job.ID, fmt.Sprintf("AI processing failed: %v", err))
+		return p.failJob(ctx, job.ID, err.Error())
 	}
 
-	// Set source URL
+	// Set source URL, and keep the recipe as read to tell later changes at
+	// the source from the user's edits
 	recipe.SourceURL = &job.URL
+	recipe = utils.NormalizeRecipeMetadata(recipe)
+	source := utils.SnapshotRecipe(recipe)
+	recipe.Source = &source
+
+	recipe = p.storeImages(ctx, job.UserID, recipe, images, job.URL)
+	createdRecipe, err := p.createRecipe(ctx, recipe)
+	if err != nil {
+		return p.failJob(ctx, job.ID, fmt.Sprintf("Failed to create recipe: %v", err))
+	}
+
+	// Cache the URL -> recipe mapping, under the page's canonical URL too
+	for _, cacheURL := range cacheURLs {
+		p.cacheURL(ctx, p.hashURL(cacheURL), cacheURL, createdRecipe.ID)
+	}
+
+	// Create note for user
+	return p.createNoteFromRecipe(ctx, job, createdRecipe)
+}
+
+// processSourceContent processes pasted text, uploaded files and AI requests.
+// They bypass the URL cache, every job creates a recipe of its own.
+func (p *RecipeProcessor) processSourceContent(ctx context.Context, job models.RecipeJob) error {
+	log.Printf("Processing recipe job %s from %s", job.ID, job.SourceType)
+
+	err := p.jobRepo.UpdateStatus(ctx, job.ID, "processing", nil)
+	if err != nil {
+		return fmt.Errorf("failed to update job status: %w", err)
+	}
+
+	if job.SourceContent == nil || strings.TrimSpace(*job.SourceContent) == "" {
+		return p.failJob(ctx, job.ID, "Nothing to import")
+	}
+
+	var recipe models.Recipe
+	switch job.SourceType {
+	case models.RecipeSourceHTML:
+		// Saved pages don't have a URL, only their absolute image URLs can be
+		// downloaded
+		var images recipeImages
+		recipe, images, err = p.extractRecipe(ctx, *job.SourceContent)
+		if err == nil {
+			recipe = p.storeImages(ctx, job.UserID, recipe, images, "")
+		}
+	case models.RecipeSourceGenerate:
+		recipe, err = p.generateRecipe(ctx, *job.SourceContent)
+	case models.RecipeSourceRefine:
+		recipe, err = p.refineRecipe(ctx, job.SourceRecipeID, *job.SourceContent)
+	default:
+		recipe, err = p.extractTextRecipe(ctx, *job.SourceContent)
+	}
+	if err != nil {
+		return p.failJob(ctx, job.ID, err.Error())
+	}
+
+	createdRecipe, err := p.createRecipe(ctx, recipe)
+	if err != nil {
+		return p.failJob(ctx, job.ID, fmt.Sprintf("Failed to create recipe: %v", err))
+	}
+
+	return p.createNoteFromRecipe(ctx, job, createdRecipe)
+}
+
+// processRefresh reads a recipe from its source URL again and stores the
+// changes found as an update for each user of the recipe to review. The
+// recipe itself is left as it is.
+func (p *RecipeProcessor) processRefresh(ctx context.Context, job models.RecipeJob) error {
+	log.Printf("Refreshing recipe for job %s from URL: %s", job.ID, job.URL)
+
+	err := p.jobRepo.UpdateStatus(ctx, job.ID, "processing", nil)
+	if err != nil {
+		return fmt.Errorf("failed to update job status: %w", err)
+	}
+
+	if job.SourceRecipeID == nil {
+		return p.failJob(ctx, job.ID, "The recipe to refresh no longer exists")
+	}
+
+	recipe, err := p.recipeRepo.FetchByID(ctx, *job.SourceRecipeID)
+	if err != nil {
+		return p.failJob(ctx, job.ID, fmt.Sprintf("Failed to fetch recipe to refresh: %v", err))
+	}
+	if recipe.SourceURL == nil {
+		return p.failJob(ctx, job.ID, "The recipe has no source URL")
+	}
 
-	// Create recipe in database
+	noteIDs, err := p.recipeRepo.FetchUserNoteIDs(ctx, recipe.ID, job.UserID)
+	if err != nil || len(noteIDs) == 0 {
+		return p.failJob(ctx, job.ID, "The recipe to refresh is no longer in your notes")
+	}
+
+	// The page is read once for everybody sharing the recipe
+	userIDs, err := p.recipeRepo.FetchUserIDs(ctx, recipe.ID)
+	if err != nil {
+		return p.failJob(ctx, job.ID, fmt.Sprintf("Failed to fetch users of recipe: %v", err))
+	}
+
+	if err := p.checkSource(ctx, userIDs, recipe); err != nil {
+		return p.failJob(ctx, job.ID, err.Error())
+	}
+
+	err = p.jobRepo.Complete(ctx, job.ID, recipe.ID, noteIDs[0])
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+
+	log.Printf("Successfully processed job %s: refreshed recipe %s", job.ID, recipe.ID)
+	return nil
+}
+
+// checkSource reads a recipe from its source URL and stores what changed
+// since it was last read as an update for each of the users, replacing their
+// pending updates of the recipe
+func (p *RecipeProcessor) checkSource(ctx context.Context, userIDs []uuid.UUID, recipe models.Recipe) error {
+	page, err := p.fetchPage(ctx, *recipe.SourceURL)
+	if err != nil {
+		return fmt.Errorf("Failed to extract content: %v", err)
+	}
+
+	// Images aren't compared, the recipe keeps its own
+	extracted, _, err := p.extractRecipe(ctx, page)
+	if err != nil {
+		return err
+	}
+
+	if err := p.recipeRepo.MarkSourceChecked(ctx, recipe.ID, time.Now()); err != nil {
+		log.Printf("Warning: failed to record source check of recipe %s: %v", recipe.ID, err)
+	}
+
+	source := utils.SnapshotRecipe(utils.NormalizeRecipeMetadata(extracted))
+	diff := utils.DiffRecipeSource(recipe, source)
+	if diff.Empty() {
+		log.Printf("Recipe %s is unchanged at its source", recipe.ID)
+	}
+
+	for _, userID := range userIDs {
+		if err := p.storeSourceUpdate(ctx, userID, recipe, source, diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeSourceUpdate keeps the changes at a recipe's source as an update for
+// the user. Nothing is stored when nothing changed, or when the user's latest
+// update already has the same changes.
+func (p *RecipeProcessor) storeSourceUpdate(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipe models.Recipe,
+	source models.RecipeSnapshot,
+	diff models.RecipeDiff,
+) error {
+	if diff.Empty() {
+		return p.updateRepo.DeletePending(ctx, userID, recipe.ID)
+	}
+
+	latest, err := p.updateRepo.FetchLatest(ctx, userID, recipe.ID)
+	if err == nil && sameSnapshot(latest.Source, source) {
+		log.Printf("Recipe %s has no changes at its source since update %s", recipe.ID, latest.ID)
+		return nil
+	}
+	if err != nil && err != pgx.ErrNoRows {
+		return fmt.Errorf("Failed to fetch recipe updates: %v", err)
+	}
+
+	if err := p.updateRepo.DeletePending(ctx, userID, recipe.ID); err != nil {
+		return fmt.Errorf("Failed to replace recipe updates: %v", err)
+	}
+	_, err = p.updateRepo.Create(ctx, models.RecipeUpdate{
+		ID:        uuid.New(),
+		UserID:    userID,
+		RecipeID:  recipe.ID,
+		Source:    source,
+		Diff:      diff,
+		Status:    models.RecipeUpdatePending,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to store recipe update: %v", err)
+	}
+	return nil
+}
+
+// sameSnapshot compares snapshots the way they are stored
+func sameSnapshot(a, b models.RecipeSnapshot) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
+
+func (p *RecipeProcessor) createRecipe(ctx context.Context, recipe models.Recipe) (models.Recipe, error) {
+	recipe = utils.NormalizeRecipeMetadata(recipe)
 	now := time.Now()
 	recipe.ID = uuid.New()
 	recipe.CreatedAt = now
 	recipe.UpdatedAt = now
 
-	createdRecipe, err := p.recipeRepo.Create(ctx, recipe)
+	created, err := p.recipeRepo.Create(ctx, recipe)
 	if err != nil {
-		return p.failJob(ctx, job.ID, fmt.Sprintf("Failed to create recipe: %v", err))
+		if cleanupErr := p.fileService.DeleteUnusedRecipeImages(ctx, recipeImageIDs(recipe)); cleanupErr != nil {
+			log.Printf("Warning: failed to delete images of recipe that wasn't created: %v", cleanupErr)
+		}
+		return models.Recipe{}, err
 	}
+	return created, nil
+}
 
-	// Cache the URL -> recipe mapping
-	err = p.cacheRepo.Create(ctx, models.RecipeURLCache{
-		URLHash:      urlHash,
-		OriginalURL:  job.URL,
-		RecipeID:     createdRecipe.ID,
-		CreatedAt:    now,
-		LastAccessed: now,
-	})
+// storeImages downloads a recipe's images into the file store, along with the
+// step images when enabled. Images are optional, the recipe is imported
+// without those that fail to download.
+func (p *RecipeProcessor) storeImages(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipe models.Recipe,
+	images recipeImages,
+	pageURL string,
+) models.Recipe {
+	stored := make(map[string]uuid.UUID)
+	store := func(imageURL string) (uuid.UUID, bool) {
+		imageURL = resolveURL(pageURL, imageURL)
+		if fileID, ok := stored[imageURL]; ok {
+			return fileID, true
+		}
+
+		data, err := downloadImage(ctx, p.httpClient, imageURL, maxRecipeImageSize)
+		if err != nil {
+			log.Printf("Failed to download recipe image %s: %v", imageURL, err)
+			return uuid.Nil, false
+		}
+		file, err := p.fileService.CreateFileFromBytes(ctx, data, nil, userID)
+		if err != nil {
+			log.Printf("Failed to store recipe image %s: %v", imageURL, err)
+			return uuid.Nil, false
+		}
+
+		stored[imageURL] = file.ID
+		return file.ID, true
+	}
+
+	if images.main != "" {
+		if fileID, ok := store(images.main); ok {
+			recipe.ImageID = &fileID
+		}
+	}
+
+	if !p.stepImages {
+		return recipe
+	}
+
+	steps := make([]int, 0, len(images.steps))
+	for step := range images.steps {
+		if step < len(recipe.Steps) {
+			steps = append(steps, step)
+		}
+	}
+	sort.Ints(steps)
+	for _, step := range steps {
+		if len(recipe.StepImages) >= maxRecipeStepImages {
+			break
+		}
+		if fileID, ok := store(images.steps[step]); ok {
+			recipe.StepImages = append(recipe.StepImages, models.StepImage{Step: step, FileID: fileID})
+		}
+	}
+	return recipe
+}
+
+// resolveURL resolves a URL found on a page against the page's URL
+func resolveURL(pageURL, ref string) string {
+	base, err := url.Parse(pageURL)
 	if err != nil {
-		log.Printf("Warning: failed to cache recipe URL mapping: %v", err)
+		return ref
+	}
+	resolved, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
 	}
+	return resolved.String()
+}
 
-	// Create note for user
-	return p.createNoteFromRecipe(ctx, job, createdRecipe)
+// canonicalLink returns the URL a page names as its canonical one, resolved
+// against the page's URL. Links to other sites are ignored.
+func canonicalLink(pageURL, page string) string {
+	href := parser.CanonicalLink(page)
+	if href == "" {
+		return ""
+	}
+	canonical := resolveURL(pageURL, href)
+	if !utils.SameSite(pageURL, canonical) {
+		return ""
+	}
+	return canonical
+}
+
+// extractTextRecipe parses text with ingredients and instructions headings
+// without the AI. Other text is sent to the AI as it is.
+func (p *RecipeProcessor) extractTextRecipe(ctx context.Context, text string) (models.Recipe, error) {
+	if textRecipe, ok := parser.ParseTextRecipe(text); ok {
+		log.Printf("Parsed recipe %q from text", textRecipe.Name)
+		return p.recipeFromSchema(ctx, textRecipe), nil
+	}
+
+	recipe, err := p.processWithAI(ctx, text)
+	if err != nil {
+		return models.Recipe{}, fmt.Errorf("AI processing failed: %v", err)
+	}
+	return recipe, nil
+}
+
+// extractRecipe reads the recipe from the page's structured data when it has
+// any, which is how most recipe sites publish them. Other pages are sent to
+// the AI as text. The page's share image stands in for a missing recipe image.
+func (p *RecipeProcessor) extractRecipe(ctx context.Context, page string) (models.Recipe, recipeImages, error) {
+	if schemaRecipe, ok := parser.ExtractSchemaRecipe(page); ok {
+		log.Printf("Found structured recipe data for %q", schemaRecipe.Name)
+		images := recipeImages{main: schemaRecipe.Image, steps: schemaRecipe.StepImages}
+		if images.main == "" {
+			images.main = parser.PageImage(page)
+		}
+		return p.recipeFromSchema(ctx, schemaRecipe), images, nil
+	}
+
+	content, err := p.extractor.ExtractFromHTML(page)
+	if err != nil {
+		return models.Recipe{}, recipeImages{}, fmt.Errorf("Failed to extract content: %v", err)
+	}
+
+	recipe, err := p.processWithAI(ctx, content)
+	if err != nil {
+		return models.Recipe{}, recipeImages{}, fmt.Errorf("AI processing failed: %v", err)
+	}
+	return recipe, recipeImages{main: parser.PageImage(page)}, nil
+}
+
+// generateRecipe has the AI create a recipe from a RecipeGeneration stored as
+// JSON
+func (p *RecipeProcessor) generateRecipe(ctx context.Context, content string) (models.Recipe, error) {
+	var generation models.RecipeGeneration
+	if err := json.Unmarshal([]byte(content), &generation); err != nil {
+		return models.Recipe{}, fmt.Errorf("Invalid recipe description: %v", err)
+	}
+
+	recipe, err := p.chatRecipe(ctx, p.generationPrompt, generationRequest(generation))
+	if err != nil {
+		return models.Recipe{}, fmt.Errorf("AI processing failed: %v", err)
+	}
+	return recipe, nil
+}
+
+// refineRecipe has the AI apply an instruction like "make it vegetarian" to a
+// recipe. The result is a new revision, the recipe itself is left as it is.
+func (p *RecipeProcessor) refineRecipe(ctx context.Context, recipeID *uuid.UUID, instruction string) (models.Recipe, error) {
+	if recipeID == nil {
+		return models.Recipe{}, fmt.Errorf("The recipe to refine no longer exists")
+	}
+
+	current, err := p.recipeRepo.FetchByID(ctx, *recipeID)
+	if err != nil {
+		return models.Recipe{}, fmt.Errorf("Failed to fetch recipe to refine: %v", err)
+	}
+
+	content, err := refinementRequest(current, instruction)
+	if err != nil {
+		return models.Recipe{}, err
+	}
+
+	recipe, err := p.chatRecipe(ctx, p.refinementPrompt, content)
+	if err != nil {
+		return models.Recipe{}, fmt.Errorf("AI processing failed: %v", err)
+	}
+	return recipe, nil
+}
+
+// generationRequest writes a recipe description and its constraints the way
+// the generation prompt expects them
+func generationRequest(generation models.RecipeGeneration) string {
+	var request strings.Builder
+	request.WriteString("Dish: " + strings.TrimSpace(generation.Description) + "\n")
+	if generation.Servings != nil {
+		request.WriteString(fmt.Sprintf("Servings: %d\n", *generation.Servings))
+	}
+	if len(generation.Dietary) > 0 {
+		request.WriteString("Dietary restrictions: " + strings.Join(generation.Dietary, ", ") + "\n")
+	}
+	if len(generation.Ingredients) > 0 {
+		request.WriteString("Available ingredients: " + strings.Join(generation.Ingredients, ", ") + "\n")
+	}
+	return request.String()
+}
+
+// refinementRequest writes a recipe and the change to make to it the way the
+// refinement prompt expects them
+func refinementRequest(recipe models.Recipe, instruction string) (string, error) {
+	current, err := json.MarshalIndent(struct {
+		Name         string               `json:"name"`
+		Summary      *string              `json:"summary"`
+		Servings     *int                 `json:"servings"`
+		PrepTime     *string              `json:"prepTime"`
+		CookTime     *string              `json:"cookTime"`
+		TotalTime    *string              `json:"totalTime"`
+		Cuisine      *string              `json:"cuisine"`
+		Course       *string              `json:"course"`
+		Equipment    []string             `json:"equipment"`
+		Ingredients  []models.Ingredient  `json:"ingredients"`
+		Steps        []string             `json:"steps"`
+		StepSections []models.StepSection `json:"stepSections"`
+	}{
+		recipe.Name, recipe.Summary, recipe.Servings, recipe.PrepTime, recipe.CookTime, recipe.TotalTime,
+		recipe.Cuisine, recipe.Course, recipe.Equipment, recipe.Ingredients, recipe.Steps, recipe.StepSections,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("Failed to encode recipe to refine: %v", err)
+	}
+
+	return "CURRENT RECIPE:\n" + string(current) + "\n\nREQUESTED CHANGE:\n" + strings.TrimSpace(instruction), nil
 }
 
-func (p *RecipeProcessor) extractContent(ctx context.Context, url string) (string, error) {
+func (p *RecipeProcessor) fetchPage(ctx context.Context, url string) (string, error) {
 	// Set timeout for content extraction
 	extractCtx, cancel := context.WithTimeout(ctx, p.contentFetchTimeout)
 	defer cancel()
@@ -129,7 +594,7 @@ func (p *RecipeProcessor) extractContent(ctx context.Context, url string) (strin
 	errorChan := make(chan error, 1)
 
 	go func() {
-		content, err := p.extractor.ExtractFromURL(url)
+		content, _, err := p.extractor.RenderURL(url)
 		if err != nil {
 			errorChan <- err
 			return
@@ -150,12 +615,21 @@ func (p *RecipeProcessor) extractContent(ctx context.Context, url string) (strin
 func (p *RecipeProcessor) processWithAI(
 	ctx context.Context,
 	content string,
+) (models.Recipe, error) {
+	return p.chatRecipe(ctx, p.systemPrompt, content)
+}
+
+// chatRecipe sends content to the AI and reads the recipe it responds with
+func (p *RecipeProcessor) chatRecipe(
+	ctx context.Context,
+	systemPrompt string,
+	content string,
 ) (models.Recipe, error) {
 	// Set timeout for AI processing
 	aiCtx, cancel := context.WithTimeout(ctx, p.aiProcessingTimeout)
 	defer cancel()
 
-	response, err := p.aiClient.Chat(p.systemPrompt, content, true, aiCtx)
+	response, err := p.aiClient.Chat(aiCtx, systemPrompt, content, true)
 	if err != nil {
 		return models.Recipe{}, fmt.Errorf("AI chat failed: %w", err)
 	}
@@ -172,6 +646,100 @@ func (p *RecipeProcessor) processWithAI(
 	return recipe, nil
 }
 
+// recipeFromSchema converts structured recipe data. Only ingredients the
+// parser can't structure need the AI.
+func (p *RecipeProcessor) recipeFromSchema(ctx context.Context, schemaRecipe parser.SchemaRecipe) models.Recipe {
+	recipe := models.Recipe{
+		Name:        schemaRecipe.Name,
+		Ingredients: p.structureIngredients(ctx, schemaRecipe.Ingredients),
+		Steps:       schemaRecipe.Instructions,
+	}
+
+	if recipe.Name == "" {
+		recipe.Name = "Untitled recipe"
+	}
+	if schemaRecipe.Description != "" {
+		recipe.Summary = &schemaRecipe.Description
+	}
+	if servings, ok := schemaRecipe.Servings(); ok {
+		recipe.Servings = &servings
+	}
+
+	recipe.PrepTime = durationText(schemaRecipe.PrepTime)
+	recipe.CookTime = durationText(schemaRecipe.CookTime)
+	recipe.TotalTime = durationText(schemaRecipe.TotalTime)
+	if schemaRecipe.Cuisine != "" {
+		recipe.Cuisine = &schemaRecipe.Cuisine
+	}
+	if schemaRecipe.Category != "" {
+		recipe.Course = &schemaRecipe.Category
+	}
+	recipe.Equipment = schemaRecipe.Tools
+
+	if recipe.Steps == nil {
+		recipe.Steps = []string{}
+	}
+	for step, name := range schemaRecipe.Sections {
+		recipe.StepSections = append(recipe.StepSections, models.StepSection{Step: step, Name: name})
+	}
+	recipe.StepSections = utils.NormalizeStepSections(recipe.StepSections, len(recipe.Steps))
+
+	return recipe
+}
+
+// durationText formats a duration as recipe text, or nil when it's zero
+func durationText(d time.Duration) *string {
+	if d <= 0 {
+		return nil
+	}
+	text := utils.FormatDuration(d)
+	return &text
+}
+
+// structureIngredients parses ingredient lines into quantities and names.
+// Lines the parser can't make sense of, like "juice of 1 lemon", are sent to
+// the AI. Without it they are kept as parsed.
+func (p *RecipeProcessor) structureIngredients(ctx context.Context, lines []string) []models.Ingredient {
+	ingredients := make([]models.Ingredient, len(lines))
+	var unparsed []int
+	for i, line := range lines {
+		ingredients[i] = utils.ParseIngredient(line)
+		if ingredients[i].Quantity == nil && strings.ContainsAny(ingredients[i].Name, "0123456789") {
+			unparsed = append(unparsed, i)
+		}
+	}
+	if len(unparsed) == 0 {
+		return ingredients
+	}
+
+	unparsedLines := make([]string, len(unparsed))
+	for i, index := range unparsed {
+		unparsedLines[i] = lines[index]
+	}
+
+	aiCtx, cancel := context.WithTimeout(ctx, p.aiProcessingTimeout)
+	defer cancel()
+
+	response, err := p.aiClient.Chat(aiCtx, p.ingredientPrompt, strings.Join(unparsedLines, "\n"), true)
+	if err != nil {
+		log.Printf("Keeping ingredients as parsed, AI chat failed: %v", err)
+		return ingredients
+	}
+
+	var structured struct {
+		Ingredients []models.Ingredient `json:"ingredients"`
+	}
+	if err := json.Unmarshal([]byte(p.cleanAIResponse(response)), &structured); err != nil || len(structured.Ingredients) != len(unparsed) {
+		log.Printf("Keeping ingredients as parsed, invalid AI response: %v", err)
+		return ingredients
+	}
+
+	for i, index := range unparsed {
+		ingredients[index] = structured.Ingredients[i]
+	}
+	return ingredients
+}
+
 func (p *RecipeProcessor) createNoteFromRecipe(
 	ctx context.Context,
 	job models.RecipeJob,
@@ -244,11 +812,28 @@ func (p *RecipeProcessor) failJob(ctx context.Context, jobID uuid.UUID, errorMes
 	return fmt.Errorf("%s", errorMessage)
 }
 
+// hashURL hashes the canonical form of a URL, so variants of the same page
+// share a cache entry
 func (p *RecipeProcessor) hashURL(url string) string {
-	hash := sha256.Sum256([]byte(url))
+	hash := sha256.Sum256([]byte(utils.CanonicalURL(url)))
 	return fmt.Sprintf("%x", hash)
 }
 
+// cacheURL maps a URL hash to a recipe
+func (p *RecipeProcessor) cacheURL(ctx context.Context, urlHash, originalURL string, recipeID uuid.UUID) {
+	now := time.Now()
+	err := p.cacheRepo.Create(ctx, models.RecipeURLCache{
+		URLHash:      urlHash,
+		OriginalURL:  originalURL,
+		RecipeID:     recipeID,
+		CreatedAt:    now,
+		LastAccessed: now,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to cache recipe URL mapping: %v", err)
+	}
+}
+
 // cleanAIResponse removes markdown code block markers from AI response
 func (p *RecipeProcessor) cleanAIResponse(response string) string {
 	// Remove leading and trailing whitespace
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"tofoss/sigil-go/pkg/genai"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecipeFromSchema(t *testing.T) {
+	schemaRecipe := parser.SchemaRecipe{
+		Name:         "Pancakes",
+		Description:  "Light and fluffy.",
+		Yield:        []string{"4 servings"},
+		CookTime:     20 * time.Minute,
+		TotalTime:    90 * time.Minute,
+		Cuisine:      "American",
+		Category:     "Breakfast",
+		Tools:        []string{"Frying pan"},
+		Ingredients:  []string{"200 g flour", "Juice of 1 lemon", "2 eggs"},
+		Instructions: []string{"Mix.", "Fry."},
+		Sections:     map[int]string{1: "To serve", 0: "Batter"},
+	}
+
+	ai := genai.NewFakeProvider(`{"ingredients": [{"name": "Lemon juice", "quantity": {"min": 1, "max": 1, "unit": "lemon"}}]}`)
+	processor := &RecipeProcessor{aiClient: ai, ingredientPrompt: "structure", aiProcessingTimeout: time.Second}
+
+	recipe := processor.recipeFromSchema(context.Background(), schemaRecipe)
+
+	assert.Equal(t, "Pancakes", recipe.Name)
+	require.NotNil(t, recipe.Summary)
+	assert.Equal(t, "Light and fluffy.", *recipe.Summary)
+	require.NotNil(t, recipe.Servings)
+	assert.Equal(t, 4, *recipe.Servings)
+	assert.Nil(t, recipe.PrepTime)
+	require.NotNil(t, recipe.CookTime)
+	assert.Equal(t, "20 minutes", *recipe.CookTime)
+	require.NotNil(t, recipe.TotalTime)
+	assert.Equal(t, "1 hour 30 minutes", *recipe.TotalTime)
+	require.NotNil(t, recipe.Cuisine)
+	assert.Equal(t, "American", *recipe.Cuisine)
+	require.NotNil(t, recipe.Course)
+	assert.Equal(t, "Breakfast", *recipe.Course)
+	assert.Equal(t, []string{"Frying pan"}, recipe.Equipment)
+	assert.Equal(t, []string{"Mix.", "Fry."}, recipe.Steps)
+	assert.Equal(t, []models.StepSection{{Step: 0, Name: "Batter"}, {Step: 1, Name: "To serve"}}, recipe.StepSections)
+
+	require.Len(t, recipe.Ingredients, 3)
+	assert.Equal(t, "flour", recipe.Ingredients[0].Name)
+	assert.Equal(t, "g", recipe.Ingredients[0].Quantity.Unit)
+	assert.Equal(t, "Lemon juice", recipe.Ingredients[1].Name)
+	assert.Equal(t, "eggs", recipe.Ingredients[2].Name)
+
+	// Only the line the parser couldn't structure is sent to the AI
+	require.Len(t, ai.Requests(), 1)
+	assert.Equal(t, genai.FakeRequest{SystemContent: "structure", UserContent: "Juice of 1 lemon", JSONMode: true}, ai.Requests()[0])
+}
+
+func TestRecipeFromSchemaWithoutAI(t *testing.T) {
+	ai := genai.NewFakeProvider()
+	ai.Err = errors.New("deepseek API key is not configured")
+	processor := &RecipeProcessor{aiClient: ai, aiProcessingTimeout: time.Second}
+
+	recipe := processor.recipeFromSchema(context.Background(), parser.SchemaRecipe{
+		Ingredients: []string{"4 lemons", "Juice of 1 lime"},
+	})
+
+	assert.Equal(t, "Untitled recipe", recipe.Name)
+	assert.Nil(t, recipe.Servings)
+	assert.Nil(t, recipe.PrepTime)
+	assert.Equal(t, []string{}, recipe.Steps)
+	require.Len(t, recipe.Ingredients, 2)
+	assert.Equal(t, "lemons", recipe.Ingredients[0].Name)
+	assert.Equal(t, models.Ingredient{Name: "Juice of 1 lime"}, recipe.Ingredients[1])
+	assert.Len(t, ai.Requests(), 1)
+}
+
+func TestExtractTextRecipe(t *testing.T) {
+	ai := genai.NewFakeProvider(`{"name": "Omelette", "ingredients": [{"name": "eggs"}], "steps": ["Whisk and fry."]}`)
+	processor := &RecipeProcessor{aiClient: ai, systemPrompt: "extract", aiProcessingTimeout: time.Second}
+
+	// Text with ingredients and instructions headings doesn't need the AI
+	recipe, err := processor.extractTextRecipe(context.Background(), "Pancakes\nServes 4\n\nIngredients:\n3 dl flour\n2 eggs\n\nMethod:\n1. Mix.\n2. Fry.")
+	require.NoError(t, err)
+	assert.Equal(t, "Pancakes", recipe.Name)
+	require.NotNil(t, recipe.Servings)
+	assert.Equal(t, 4, *recipe.Servings)
+	require.Len(t, recipe.Ingredients, 2)
+	assert.Equal(t, "flour", recipe.Ingredients[0].Name)
+	assert.Equal(t, []string{"Mix.", "Fry."}, recipe.Steps)
+	assert.Empty(t, ai.Requests())
+
+	text := "Whisk two eggs with a pinch of salt and fry them in butter."
+	recipe, err = processor.extractTextRecipe(context.Background(), text)
+	require.NoError(t, err)
+	assert.Equal(t, "Omelette", recipe.Name)
+	require.Len(t, ai.Requests(), 1)
+	assert.Equal(t, genai.FakeRequest{SystemContent: "extract", UserContent: text, JSONMode: true}, ai.Requests()[0])
+}
+
+func TestGenerateRecipe(t *testing.T) {
+	ai := genai.NewFakeProvider(`{"name": "Vegan Curry", "servings": 2, "ingredients": [{"name": "Chickpeas"}], "steps": ["Simmer."]}`)
+	processor := &RecipeProcessor{aiClient: ai, generationPrompt: "generate", aiProcessingTimeout: time.Second}
+
+	servings := 2
+	content, err := json.Marshal(models.RecipeGeneration{
+		Description: "Curry",
+		Servings:    &servings,
+		Dietary:     []string{"vegan", "nut-free"},
+		Ingredients: []string{"chickpeas"},
+	})
+	require.NoError(t, err)
+
+	recipe, err := processor.generateRecipe(context.Background(), string(content))
+	require.NoError(t, err)
+	assert.Equal(t, "Vegan Curry", recipe.Name)
+	assert.Equal(t, []string{"Simmer."}, recipe.Steps)
+
+	require.Len(t, ai.Requests(), 1)
+	assert.Equal(t, "generate", ai.Requests()[0].SystemContent)
+	assert.Equal(t, "Dish: Curry\nServings: 2\nDietary restrictions: vegan, nut-free\nAvailable ingredients: chickpeas\n", ai.Requests()[0].UserContent)
+}
+
+func TestRefinementRequest(t *testing.T) {
+	servings := 4
+	content, err := refinementRequest(models.Recipe{
+		Name:        "Chicken Curry",
+		Servings:    &servings,
+		Ingredients: []models.Ingredient{{Name: "Chicken"}},
+		Steps:       []string{"Simmer."},
+	}, " make it vegetarian ")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(content, "CURRENT RECIPE:\n{\n  \"name\": \"Chicken Curry\""))
+	assert.True(t, strings.HasSuffix(content, "\n\nREQUESTED CHANGE:\nmake it vegetarian"))
+	assert.NotContains(t, content, "createdAt")
+}
+
+func TestResolveURL(t *testing.T) {
+	pageURL := "https://example.com/recipes/pancakes?ref=home"
+	assert.Equal(t, "https://cdn.example.com/pancakes.jpg", resolveURL(pageURL, "https://cdn.example.com/pancakes.jpg"))
+	assert.Equal(t, "https://example.com/images/pancakes.jpg", resolveURL(pageURL, "/images/pancakes.jpg"))
+	assert.Equal(t, "https://example.com/recipes/step-1.jpg", resolveURL(pageURL, " step-1.jpg "))
+	assert.Equal(t, "https://static.example.com/pancakes.jpg", resolveURL(pageURL, "//static.example.com/pancakes.jpg"))
+	assert.Equal(t, "/images/pancakes.jpg", resolveURL("", "/images/pancakes.jpg"))
+}
+
+func TestCanonicalLink(t *testing.T) {
+	page := func(href string) string {
+		return `<html><head><link rel="canonical" href="` + href + `"></head><body></body></html>`
+	}
+	pageURL := "https://amp.example.com/recipes/pancakes/amp"
+	assert.Equal(t, "https://example.com/recipes/pancakes", canonicalLink(pageURL, page("https://example.com/recipes/pancakes")))
+	assert.Equal(t, "https://amp.example.com/recipes/pancakes", canonicalLink(pageURL, page("/recipes/pancakes")))
+	assert.Equal(t, "", canonicalLink(pageURL, page("https://other.com/recipes/pancakes")))
+	assert.Equal(t, "", canonicalLink(pageURL, "<html><head></head></html>"))
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// recipeRefreshTick is how often the scheduler looks for recipes due for a
+	// check
+	recipeRefreshTick = time.Hour
+	// recipeRefreshBatchSize limits the recipes queued per tick, so checks are
+	// spread out instead of all fetched at once
+	recipeRefreshBatchSize = 20
+)
+
+// RecipeRefreshScheduler re-checks recipes imported from URLs for changes at
+// their source. Recipes not read within the interval get a refresh job, the
+// changes found are kept as updates for each of the recipe's users to review.
+// URL cache entries older than the interval are expired as well, so the next
+// import of the URL reads the page again.
+type RecipeRefreshScheduler struct {
+	recipeRepo *repositories.RecipeRepository
+	jobRepo    *repositories.RecipeJobRepository
+	cacheRepo  *repositories.RecipeURLCacheRepository
+	interval   time.Duration
+	running    bool
+	stopCh     chan struct{}
+	wg         sync.WaitGroup
+}
+
+func NewRecipeRefreshScheduler(
+	recipeRepo *repositories.RecipeRepository,
+	jobRepo *repositories.RecipeJobRepository,
+	cacheRepo *repositories.RecipeURLCacheRepository,
+	interval time.Duration,
+) *RecipeRefreshScheduler {
+	return &RecipeRefreshScheduler{
+		recipeRepo: recipeRepo,
+		jobRepo:    jobRepo,
+		cacheRepo:  cacheRepo,
+		interval:   interval,
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Start begins the scheduled checks, unless they are turned off with a zero
+// interval
+func (s *RecipeRefreshScheduler) Start(ctx context.Context) {
+	if s.running || s.interval <= 0 {
+		return
+	}
+
+	s.running = true
+	log.Printf("Starting recipe refresh scheduler, checking recipes every %v", s.interval)
+
+	s.wg.Add(1)
+	go s.worker(ctx)
+}
+
+// Stop gracefully stops the scheduled checks
+func (s *RecipeRefreshScheduler) Stop() {
+	if !s.running {
+		return
+	}
+
+	log.Printf("Stopping recipe refresh scheduler...")
+	s.running = false
+	close(s.stopCh)
+	s.wg.Wait()
+	log.Printf("Recipe refresh scheduler stopped")
+}
+
+func (s *RecipeRefreshScheduler) worker(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(min(s.interval, recipeRefreshTick))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.scheduleChecks(ctx)
+		}
+	}
+}
+
+// scheduleChecks expires old URL cache entries and queues refresh jobs for
+// the recipes due for a check
+func (s *RecipeRefreshScheduler) scheduleChecks(ctx context.Context) {
+	now := time.Now()
+	checkedBefore := now.Add(-s.interval)
+
+	expired, err := s.cacheRepo.DeleteOldEntries(ctx, checkedBefore)
+	if err != nil {
+		log.Printf("Error expiring recipe URL cache: %v", err)
+	} else if expired > 0 {
+		log.Printf("Expired %d recipe URL cache entries", expired)
+	}
+
+	checks, err := s.recipeRepo.FetchDueForSourceCheck(ctx, checkedBefore, recipeRefreshBatchSize)
+	if err != nil {
+		log.Printf("Error fetching recipes due for a source check: %v", err)
+		return
+	}
+
+	for _, check := range checks {
+		// Marked up front so a failing source isn't queued again every tick
+		if err := s.recipeRepo.MarkSourceChecked(ctx, check.RecipeID, now); err != nil {
+			log.Printf("Error recording source check of recipe %s: %v", check.RecipeID, err)
+			continue
+		}
+
+		// One job reads the page for all users of the recipe, it runs as the
+		// first of them
+		recipeID := check.RecipeID
+		_, err := s.jobRepo.Create(ctx, models.RecipeJob{
+			ID:             uuid.New(),
+			UserID:         check.UserIDs[0],
+			URL:            check.SourceURL,
+			SourceType:     models.RecipeSourceRefresh,
+			SourceRecipeID: &recipeID,
+			Status:         "pending",
+			CreatedAt:      now,
+		})
+		if err != nil {
+			log.Printf("Error queueing refresh of recipe %s: %v", check.RecipeID, err)
+		}
+	}
+
+	if len(checks) > 0 {
+		log.Printf("Queued source checks of %d recipes", len(checks))
+	}
+}
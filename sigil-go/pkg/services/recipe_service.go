@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/recipeformat"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// maxExportPhotoBytes limits the photos embedded in a single recipe export.
+// Photos past the limit are left out, the recipes are still written.
+const maxExportPhotoBytes = 100 << 20
+
+// RecipeService manages recipes edited by users. Recipes are shared between
+// users who imported the same URL, so changes to a shared recipe are made to
+// a private copy instead.
+type RecipeService struct {
+	recipeRepo  *repositories.RecipeRepository
+	noteRepo    *repositories.NoteRepository
+	updateRepo  *repositories.RecipeUpdateRepository
+	fileService *FileService
+}
+
+func NewRecipeService(
+	recipeRepo *repositories.RecipeRepository,
+	noteRepo *repositories.NoteRepository,
+	updateRepo *repositories.RecipeUpdateRepository,
+	fileService *FileService,
+) *RecipeService {
+	return &RecipeService{recipeRepo: recipeRepo, noteRepo: noteRepo, updateRepo: updateRepo, fileService: fileService}
+}
+
+// CreateRecipe stores a recipe along with a note showing it
+func (s *RecipeService) CreateRecipe(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipe models.Recipe,
+) (models.Recipe, models.Note, error) {
+	now := time.Now()
+	recipe = utils.NormalizeRecipeMetadata(recipe)
+	recipe.ID = uuid.New()
+	recipe.CreatedAt = now
+	recipe.UpdatedAt = now
+
+	return s.recipeRepo.CreateWithNote(ctx, recipe, models.Note{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     recipe.Name,
+		Content:   utils.RecipeToMarkdown(recipe),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+// ImportRecipe stores a recipe read from another app's file. Its photo is kept
+// in the file store as the recipe's image, a photo that can't be stored is
+// left out rather than failing the recipe.
+func (s *RecipeService) ImportRecipe(
+	ctx context.Context,
+	userID uuid.UUID,
+	imported recipeformat.ImportedRecipe,
+) (models.Recipe, models.Note, error) {
+	recipe := imported.Recipe
+	if len(imported.Photo) > 0 {
+		file, err := s.fileService.CreateFileFromBytes(ctx, imported.Photo, nil, userID)
+		if err != nil {
+			log.Printf("skipping photo of imported recipe %q: %v", recipe.Name, err)
+		} else {
+			recipe.ImageID = &file.ID
+		}
+	}
+
+	created, note, err := s.CreateRecipe(ctx, userID, recipe)
+	if err != nil && recipe.ImageID != nil {
+		if cleanupErr := s.fileService.DeleteUnusedRecipeImages(ctx, []uuid.UUID{*recipe.ImageID}); cleanupErr != nil {
+			log.Printf("failed to delete image %s: %v", *recipe.ImageID, cleanupErr)
+		}
+	}
+	return created, note, err
+}
+
+// ExportPhotos reads the images of recipes for formats that embed them, see
+// recipeformat.Export
+func (s *RecipeService) ExportPhotos(
+	ctx context.Context,
+	userID uuid.UUID,
+	format string,
+	recipes []models.Recipe,
+) map[uuid.UUID][]byte {
+	if format != recipeformat.FormatPaprika {
+		return nil
+	}
+
+	var fileIDs []uuid.UUID
+	for _, recipe := range recipes {
+		if recipe.ImageID != nil {
+			fileIDs = append(fileIDs, *recipe.ImageID)
+		}
+	}
+
+	photos := make(map[uuid.UUID][]byte)
+	for fileID, file := range s.fileService.ReadFiles(ctx, userID, fileIDs, maxExportPhotoBytes) {
+		photos[fileID] = file.Data
+	}
+	return photos
+}
+
+// UpdateRecipe saves the user's changes to a recipe linked to the given notes.
+// The returned recipe has a new ID when a private copy had to be made. With
+// regenerateNote the notes' content is replaced with the updated recipe.
+func (s *RecipeService) UpdateRecipe(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipe models.Recipe,
+	noteIDs []uuid.UUID,
+	regenerateNote bool,
+) (models.Recipe, error) {
+	shared, err := s.recipeRepo.IsShared(ctx, recipe.ID, userID)
+	if err != nil {
+		return models.Recipe{}, fmt.Errorf("failed to check recipe sharing: %w", err)
+	}
+
+	recipe = utils.NormalizeRecipeMetadata(recipe)
+	recipe.UpdatedAt = time.Now()
+
+	var updated models.Recipe
+	if shared {
+		updated, err = s.copyRecipe(ctx, userID, recipe, noteIDs)
+	} else {
+		updated, err = s.recipeRepo.Update(ctx, recipe)
+	}
+	if err != nil {
+		return models.Recipe{}, fmt.Errorf("failed to update recipe: %w", err)
+	}
+
+	if regenerateNote {
+		for _, noteID := range noteIDs {
+			if err := s.regenerateNote(ctx, userID, noteID, updated); err != nil {
+				return updated, err
+			}
+		}
+	}
+
+	return updated, nil
+}
+
+// DeleteRecipe removes a recipe from the user's notes, meal plans, cook log,
+// collections and source updates. The recipe itself is only deleted when
+// nobody else has access to it.
+func (s *RecipeService) DeleteRecipe(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipeID uuid.UUID,
+	noteIDs []uuid.UUID,
+) error {
+	shared, err := s.recipeRepo.IsShared(ctx, recipeID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check recipe sharing: %w", err)
+	}
+
+	if !shared {
+		recipe, err := s.recipeRepo.FetchByID(ctx, recipeID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch recipe: %w", err)
+		}
+		if err := s.recipeRepo.Delete(ctx, recipeID); err != nil {
+			return err
+		}
+		return s.fileService.DeleteUnusedRecipeImages(ctx, recipeImageIDs(recipe))
+	}
+
+	if err := s.recipeRepo.RemoveFromUser(ctx, recipeID, userID, noteIDs); err != nil {
+		return fmt.Errorf("failed to remove recipe from user's notes: %w", err)
+	}
+	return nil
+}
+
+// ReplaceImage stores an uploaded image as the recipe's image. With
+// regenerateNote the notes linked to the recipe are rewritten, otherwise only
+// their links to the previous image are. The previous image is deleted unless
+// a copy of the recipe still uses it.
+func (s *RecipeService) ReplaceImage(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipe models.Recipe,
+	noteIDs []uuid.UUID,
+	image []byte,
+	regenerateNote bool,
+) (models.Recipe, error) {
+	file, err := s.fileService.CreateFileFromBytes(ctx, image, nil, userID)
+	if err != nil {
+		return models.Recipe{}, fmt.Errorf("failed to store image: %w", err)
+	}
+
+	previous := recipe.ImageID
+	recipe.ImageID = &file.ID
+
+	updated, err := s.UpdateRecipe(ctx, userID, recipe, noteIDs, regenerateNote)
+	if err != nil {
+		if cleanupErr := s.fileService.DeleteUnusedRecipeImages(ctx, []uuid.UUID{file.ID}); cleanupErr != nil {
+			log.Printf("failed to delete image %s: %v", file.ID, cleanupErr)
+		}
+		return updated, err
+	}
+
+	if !regenerateNote && previous != nil {
+		for _, noteID := range noteIDs {
+			if err := s.replaceNoteImage(ctx, userID, noteID, *previous, file.ID); err != nil {
+				return updated, err
+			}
+		}
+	}
+
+	if previous != nil {
+		if err := s.fileService.DeleteUnusedRecipeImages(ctx, []uuid.UUID{*previous}); err != nil {
+			log.Printf("failed to delete previous image of recipe %s: %v", updated.ID, err)
+		}
+	}
+	return updated, nil
+}
+
+// MergeSourceUpdate applies the changes found at a recipe's source to the
+// recipe, keeping the user's own edits where they conflict. Like any other
+// change, a shared recipe is copied first.
+func (s *RecipeService) MergeSourceUpdate(
+	ctx context.Context,
+	userID uuid.UUID,
+	update models.RecipeUpdate,
+	recipe models.Recipe,
+	noteIDs []uuid.UUID,
+	regenerateNote bool,
+) (models.Recipe, error) {
+	merged := utils.MergeRecipeSource(recipe, update.Source)
+	updated, err := s.UpdateRecipe(ctx, userID, merged, noteIDs, regenerateNote)
+	if err != nil {
+		return models.Recipe{}, err
+	}
+
+	if err := s.updateRepo.Resolve(ctx, update.ID, models.RecipeUpdateApplied, time.Now()); err != nil {
+		return updated, fmt.Errorf("failed to resolve recipe update: %w", err)
+	}
+	return updated, nil
+}
+
+// CreateSourceVersion creates the recipe as it is at its source as a new
+// recipe with a note of its own, leaving the user's recipe as it is. The new
+// version shares the recipe's images.
+func (s *RecipeService) CreateSourceVersion(
+	ctx context.Context,
+	userID uuid.UUID,
+	update models.RecipeUpdate,
+	recipe models.Recipe,
+) (models.Recipe, models.Note, error) {
+	source := update.Source
+	version := models.Recipe{
+		Name:         source.Name,
+		Summary:      source.Summary,
+		Servings:     source.Servings,
+		PrepTime:     source.PrepTime,
+		CookTime:     source.CookTime,
+		TotalTime:    source.TotalTime,
+		Cuisine:      source.Cuisine,
+		Course:       source.Course,
+		Equipment:    source.Equipment,
+		SourceURL:    recipe.SourceURL,
+		ImageID:      recipe.ImageID,
+		Ingredients:  source.Ingredients,
+		Steps:        source.Steps,
+		StepSections: source.StepSections,
+		Source:       &source,
+	}
+	if version.Steps == nil {
+		version.Steps = []string{}
+	}
+	for _, image := range recipe.StepImages {
+		if image.Step < len(version.Steps) {
+			version.StepImages = append(version.StepImages, image)
+		}
+	}
+
+	created, note, err := s.CreateRecipe(ctx, userID, version)
+	if err != nil {
+		return models.Recipe{}, models.Note{}, err
+	}
+
+	if err := s.updateRepo.Resolve(ctx, update.ID, models.RecipeUpdateApplied, time.Now()); err != nil {
+		return created, note, fmt.Errorf("failed to resolve recipe update: %w", err)
+	}
+	return created, note, nil
+}
+
+// recipeImageIDs returns the files of a recipe's image and step images
+func recipeImageIDs(recipe models.Recipe) []uuid.UUID {
+	var fileIDs []uuid.UUID
+	if recipe.ImageID != nil {
+		fileIDs = append(fileIDs, *recipe.ImageID)
+	}
+	for _, image := range recipe.StepImages {
+		fileIDs = append(fileIDs, image.FileID)
+	}
+	return fileIDs
+}
+
+// copyRecipe stores the recipe under a new ID and moves the user's notes over
+// to it
+func (s *RecipeService) copyRecipe(
+	ctx context.Context,
+	userID uuid.UUID,
+	recipe models.Recipe,
+	noteIDs []uuid.UUID,
+) (models.Recipe, error) {
+	originalID := recipe.ID
+	recipe.ID = uuid.New()
+	recipe.CreatedAt = recipe.UpdatedAt
+
+	return s.recipeRepo.Copy(ctx, recipe, originalID, userID, noteIDs)
+}
+
+// replaceNoteImage points the note's links to one image at another, leaving
+// the rest of the note as the user wrote it
+func (s *RecipeService) replaceNoteImage(
+	ctx context.Context,
+	userID uuid.UUID,
+	noteID uuid.UUID,
+	previous uuid.UUID,
+	image uuid.UUID,
+) error {
+	note, err := s.noteRepo.FetchUsersNote(ctx, noteID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch note %s: %w", noteID, err)
+	}
+
+	note.Content = utils.ReplaceFileLinks(note.Content, func(fileID uuid.UUID) (string, bool) {
+		return "/files/" + image.String(), fileID == previous
+	})
+	note.UpdatedAt = time.Now()
+
+	if _, err := s.noteRepo.Upsert(ctx, note); err != nil {
+		return fmt.Errorf("failed to update note %s: %w", noteID, err)
+	}
+	return nil
+}
+
+func (s *RecipeService) regenerateNote(
+	ctx context.Context,
+	userID uuid.UUID,
+	noteID uuid.UUID,
+	recipe models.Recipe,
+) error {
+	note, err := s.noteRepo.FetchUsersNote(ctx, noteID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch note %s: %w", noteID, err)
+	}
+
+	note.Title = recipe.Name
+	note.Content = utils.RecipeToMarkdown(recipe)
+	note.UpdatedAt = time.Now()
+
+	if _, err := s.noteRepo.Upsert(ctx, note); err != nil {
+		return fmt.Errorf("failed to update note %s: %w", noteID, err)
+	}
+	return nil
+}
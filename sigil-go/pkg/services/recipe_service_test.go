@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tofoss/sigil-go/pkg/db/dbtest"
+	"tofoss/sigil-go/pkg/db/repositories"
+	"tofoss/sigil-go/pkg/models"
+	"tofoss/sigil-go/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharedRecipeTest is a recipe in the notes of two users, so editing it makes
+// a private copy
+type sharedRecipeTest struct {
+	pool    *pgxpool.Pool
+	service *RecipeService
+	recipes *repositories.RecipeRepository
+	recipe  models.Recipe
+	owner   uuid.UUID
+	other   uuid.UUID
+}
+
+func newSharedRecipeTest(t *testing.T) sharedRecipeTest {
+	pool := dbtest.NewPool(t)
+	ctx := context.Background()
+
+	recipes := repositories.NewRecipeRepository(pool)
+	notes := repositories.NewNoteRepository(pool)
+	test := sharedRecipeTest{
+		pool:    pool,
+		service: NewRecipeService(recipes, notes, repositories.NewRecipeUpdateRepository(pool), nil),
+		recipes: recipes,
+		owner:   createTestUser(t, pool, "owner"),
+		other:   createTestUser(t, pool, "other"),
+	}
+
+	recipe, _, err := test.service.CreateRecipe(ctx, test.owner, models.Recipe{
+		Name:        "Pancakes",
+		Ingredients: []models.Ingredient{utils.ParseIngredient("3 dl flour")},
+		Steps:       []string{"Whisk everything together."},
+	})
+	require.NoError(t, err)
+	test.recipe = recipe
+
+	now := time.Now()
+	note, err := notes.Upsert(ctx, models.Note{
+		ID:        uuid.New(),
+		UserID:    test.other,
+		Title:     recipe.Name,
+		Content:   utils.RecipeToMarkdown(recipe),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	require.NoError(t, err)
+	require.NoError(t, recipes.LinkRecipeToNote(ctx, recipe.ID, note.ID))
+
+	return test
+}
+
+// edit renames the recipe for the owner, returning the private copy
+func (test sharedRecipeTest) edit(t *testing.T) models.Recipe {
+	ctx := context.Background()
+
+	noteIDs, err := test.recipes.FetchUserNoteIDs(ctx, test.recipe.ID, test.owner)
+	require.NoError(t, err)
+
+	recipe := test.recipe
+	recipe.Name = "Fluffy pancakes"
+	updated, err := test.service.UpdateRecipe(ctx, test.owner, recipe, noteIDs, true)
+	require.NoError(t, err)
+	require.NotEqual(t, test.recipe.ID, updated.ID, "a shared recipe is copied")
+
+	return updated
+}
+
+func (test sharedRecipeTest) logCooked(t *testing.T, userID uuid.UUID, cookedOn string, rating int) {
+	now := time.Now()
+	_, err := repositories.NewCookLogRepository(test.pool).Create(context.Background(), models.CookLogEntry{
+		ID:        uuid.New(),
+		UserID:    userID,
+		RecipeID:  test.recipe.ID,
+		CookedOn:  cookedOn,
+		Rating:    &rating,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	require.NoError(t, err)
+}
+
+func (test sharedRecipeTest) addToCollection(t *testing.T, userID uuid.UUID, name string) models.RecipeCollection {
+	ctx := context.Background()
+	collections := repositories.NewRecipeCollectionRepository(test.pool)
+
+	now := time.Now()
+	collection, err := collections.Create(ctx, models.RecipeCollection{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	require.NoError(t, err)
+	require.NoError(t, collections.AddRecipe(ctx, collection.ID, test.recipe.ID, now))
+	return collection
+}
+
+func (test sharedRecipeTest) planMeal(t *testing.T, userID uuid.UUID) models.MealPlan {
+	now := time.Now()
+	planID := uuid.New()
+	plan, err := repositories.NewMealPlanRepository(test.pool).Create(context.Background(), models.MealPlan{
+		ID:        planID,
+		UserID:    userID,
+		Title:     "This week",
+		StartDate: "2026-03-02",
+		EndDate:   "2026-03-08",
+		Meals: []models.PlannedMeal{{
+			ID:         uuid.New(),
+			MealPlanID: planID,
+			Date:       "2026-03-03",
+			Slot:       "dinner",
+			RecipeID:   test.recipe.ID,
+			UpdatedAt:  now,
+		}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	require.NoError(t, err)
+	return plan
+}
+
+func (test sharedRecipeTest) storeUpdate(t *testing.T, userID uuid.UUID, status string) models.RecipeUpdate {
+	update, err := repositories.NewRecipeUpdateRepository(test.pool).Create(context.Background(), models.RecipeUpdate{
+		ID:        uuid.New(),
+		UserID:    userID,
+		RecipeID:  test.recipe.ID,
+		Source:    models.RecipeSnapshot{Name: "Pancakes"},
+		Diff:      models.RecipeDiff{},
+		Status:    status,
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	return update
+}
+
+func createTestUser(t *testing.T, pool *pgxpool.Pool, username string) uuid.UUID {
+	users := repositories.NewUserRepository(pool)
+	require.NoError(t, users.Insert(context.Background(), username, "password"))
+
+	user, err := users.FetchUser(context.Background(), username)
+	require.NoError(t, err)
+	return user.ID
+}
+
+func TestUpdateSharedRecipeKeepsCookLog(t *testing.T) {
+	test := newSharedRecipeTest(t)
+	ctx := context.Background()
+	cookLog := repositories.NewCookLogRepository(test.pool)
+
+	test.logCooked(t, test.owner, "2026-01-10", 4)
+	test.logCooked(t, test.owner, "2026-02-14", 5)
+	test.logCooked(t, test.other, "2026-03-01", 2)
+
+	updated := test.edit(t)
+
+	stats, err := cookLog.FetchStats(ctx, test.owner, updated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.TimesCooked)
+	require.NotNil(t, stats.AverageRating)
+	assert.Equal(t, 4.5, *stats.AverageRating)
+	require.NotNil(t, stats.LastCooked)
+	assert.Equal(t, "2026-02-14", *stats.LastCooked)
+
+	listed, err := test.recipes.ListByUserID(ctx, test.owner, repositories.RecipeFilter{}, repositories.RecipeOrder{}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, updated.ID, listed[0].ID)
+	require.NotNil(t, listed[0].Stats)
+	assert.Equal(t, 2, listed[0].Stats.TimesCooked)
+
+	// The other user's history stays with the original
+	stats, err = cookLog.FetchStats(ctx, test.other, test.recipe.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.TimesCooked)
+}
+
+func TestUpdateSharedRecipeKeepsCollections(t *testing.T) {
+	test := newSharedRecipeTest(t)
+	ctx := context.Background()
+
+	weeknight := test.addToCollection(t, test.owner, "Weeknight")
+	brunch := test.addToCollection(t, test.other, "Brunch")
+
+	updated := test.edit(t)
+
+	recipes, err := test.recipes.FetchByCollection(ctx, weeknight.ID, test.owner)
+	require.NoError(t, err)
+	require.Len(t, recipes, 1)
+	assert.Equal(t, updated.ID, recipes[0].ID)
+
+	collectionID := weeknight.ID
+	listed, err := test.recipes.ListByUserID(ctx, test.owner, repositories.RecipeFilter{Collection: &collectionID}, repositories.RecipeOrder{}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, updated.ID, listed[0].ID)
+
+	// The other user's collection keeps the original
+	recipes, err = test.recipes.FetchByCollection(ctx, brunch.ID, test.other)
+	require.NoError(t, err)
+	require.Len(t, recipes, 1)
+	assert.Equal(t, test.recipe.ID, recipes[0].ID)
+}
+
+func TestUpdateSharedRecipeKeepsSourceUpdates(t *testing.T) {
+	test := newSharedRecipeTest(t)
+	ctx := context.Background()
+	updates := repositories.NewRecipeUpdateRepository(test.pool)
+
+	pending := test.storeUpdate(t, test.owner, models.RecipeUpdatePending)
+	dismissed := test.storeUpdate(t, test.owner, models.RecipeUpdateDismissed)
+	otherPending := test.storeUpdate(t, test.other, models.RecipeUpdatePending)
+
+	updated := test.edit(t)
+
+	listed, err := updates.ListPending(ctx, test.owner, &updated.ID)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, pending.ID, listed[0].ID)
+
+	// Resolved updates are history of the original
+	update, err := updates.FetchByID(ctx, dismissed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, test.recipe.ID, update.RecipeID)
+
+	// The other user's updates stay with the original
+	update, err = updates.FetchByID(ctx, otherPending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, test.recipe.ID, update.RecipeID)
+}
+
+func TestDeleteSharedRecipeRemovesUsersEntries(t *testing.T) {
+	test := newSharedRecipeTest(t)
+	ctx := context.Background()
+	cookLog := repositories.NewCookLogRepository(test.pool)
+	mealPlans := repositories.NewMealPlanRepository(test.pool)
+	collections := repositories.NewRecipeCollectionRepository(test.pool)
+
+	test.logCooked(t, test.owner, "2026-01-10", 4)
+	test.logCooked(t, test.other, "2026-01-12", 3)
+	ownerPlan := test.planMeal(t, test.owner)
+	otherPlan := test.planMeal(t, test.other)
+	weeknight := test.addToCollection(t, test.owner, "Weeknight")
+	brunch := test.addToCollection(t, test.other, "Brunch")
+	test.storeUpdate(t, test.owner, models.RecipeUpdatePending)
+	otherUpdate := test.storeUpdate(t, test.other, models.RecipeUpdatePending)
+
+	noteIDs, err := test.recipes.FetchUserNoteIDs(ctx, test.recipe.ID, test.owner)
+	require.NoError(t, err)
+	require.NoError(t, test.service.DeleteRecipe(ctx, test.owner, test.recipe.ID, noteIDs))
+
+	_, err = test.recipes.FetchByID(ctx, test.recipe.ID)
+	require.NoError(t, err, "the other user still has the recipe")
+
+	stats, err := cookLog.FetchStats(ctx, test.owner, test.recipe.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.TimesCooked)
+
+	plan, err := mealPlans.FetchByID(ctx, ownerPlan.ID)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Meals)
+
+	// Collections only count recipes in the user's notes, the entry itself
+	// has to be gone
+	var entries int
+	err = test.pool.QueryRow(ctx, "SELECT COUNT(*) FROM recipe_collection_recipes WHERE collection_id = $1", weeknight.ID).Scan(&entries)
+	require.NoError(t, err)
+	assert.Equal(t, 0, entries)
+
+	pending, err := repositories.NewRecipeUpdateRepository(test.pool).ListPending(ctx, test.owner, nil)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// The other user's entries are left alone
+	stats, err = cookLog.FetchStats(ctx, test.other, test.recipe.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.TimesCooked)
+
+	plan, err = mealPlans.FetchByID(ctx, otherPlan.ID)
+	require.NoError(t, err)
+	assert.Len(t, plan.Meals, 1)
+
+	collection, err := collections.FetchByID(ctx, brunch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, collection.RecipeCount)
+
+	_, err = repositories.NewRecipeUpdateRepository(test.pool).FetchByID(ctx, otherUpdate.ID)
+	require.NoError(t, err)
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// trackingParams are query parameters that identify a visit rather than a
+// page. Parameters starting with utm_ are tracking parameters as well.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+	"_gl":     true,
+	"ref":     true,
+	"ref_src": true,
+	"amp":     true,
+}
+
+var (
+	// AMP versions of a page at /amp/... or .../amp
+	ampPrefixRegex = regexp.MustCompile(`^/amp(/|$)`)
+	ampSuffixRegex = regexp.MustCompile(`/amp/?$`)
+)
+
+// CanonicalURL returns the form of a URL used to tell whether two URLs are
+// the same page, e.g. https://site.com/r for http://www.site.com/r/?utm_source=x.
+// The scheme becomes https, the host is lowercased without www, amp or a
+// default port, AMP paths and trailing slashes are removed, and tracking
+// parameters and the fragment are dropped. The remaining query parameters are
+// sorted. The path is unescaped, so /kjøttkaker and /kj%C3%B8ttkaker are the
+// same page. URLs that aren't http or https, or whose path isn't UTF-8, are
+// returned trimmed.
+//
+// The cache re-keying in db/V29__canonical_recipe_urls.sql reimplements these
+// rules in SQL, TestCanonicalURLMatchesMigration checks the two agree. URLs
+// url.Parse rejects, like hosts with spaces, may still be rewritten there.
+func CanonicalURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return rawURL
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	// Only while a dotted host remains, amp.dev is a site of its own
+	for _, prefix := range []string{"www.", "amp."} {
+		if rest, ok := strings.CutPrefix(host, prefix); ok && strings.Contains(rest, ".") {
+			host = rest
+		}
+	}
+	if port := parsed.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	path := parsed.Path
+	if !utf8.ValidString(path) {
+		return rawURL
+	}
+	path = ampPrefixRegex.ReplaceAllString(path, "/")
+	path = ampSuffixRegex.ReplaceAllString(path, "")
+	path = strings.TrimRight(path, "/")
+	if path == "" {
+		path = "/"
+	}
+
+	canonical := "https://" + host + path
+	if query := canonicalQuery(parsed.RawQuery); query != "" {
+		canonical += "?" + query
+	}
+	return canonical
+}
+
+// canonicalQuery drops the tracking parameters of a raw query and sorts the
+// rest, leaving their encoding as it is
+func canonicalQuery(rawQuery string) string {
+	var params []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(param, "=")
+		key = strings.ToLower(key)
+		if strings.HasPrefix(key, "utm_") || trackingParams[key] {
+			continue
+		}
+		params = append(params, param)
+	}
+	slices.Sort(params)
+	return strings.Join(params, "&")
+}
+
+// SameSite reports whether two URLs are on the same host once canonical, so
+// www and AMP hosts count as the site itself
+func SameSite(a, b string) bool {
+	parsedA, errA := url.Parse(CanonicalURL(a))
+	parsedB, errB := url.Parse(CanonicalURL(b))
+	if errA != nil || errB != nil || parsedA.Host == "" {
+		return false
+	}
+	return parsedA.Scheme == "https" && parsedB.Scheme == "https" && parsedA.Host == parsedB.Host
+}
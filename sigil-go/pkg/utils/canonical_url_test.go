@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+
+	"tofoss/sigil-go/pkg/db/dbtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// canonicalURLTests are checked against CanonicalURL and the SQL version of
+// it in db/V29__canonical_recipe_urls.sql
+var canonicalURLTests = []struct {
+	name     string
+	url      string
+	expected string
+}{
+	{"already canonical", "https://site.com/r", "https://site.com/r"},
+	{"tracking parameters", "https://site.com/r?utm_source=x&utm_medium=email&fbclid=abc", "https://site.com/r"},
+	{"www, http and trailing slash", "http://www.site.com/r/", "https://site.com/r"},
+	{"uppercase host and default port", "https://WWW.Site.COM:443/Recipes/Pancakes", "https://site.com/Recipes/Pancakes"},
+	{"other port kept", "http://site.com:8080/r", "https://site.com:8080/r"},
+	{"amp suffix", "https://site.com/r/amp/", "https://site.com/r"},
+	{"amp prefix", "https://site.com/amp/r", "https://site.com/r"},
+	{"amp host and parameter", "https://amp.site.com/r?amp=1", "https://site.com/r"},
+	{"amp inside a path segment kept", "https://site.com/ramp/r", "https://site.com/ramp/r"},
+	{"fragment dropped", "https://site.com/r#comments", "https://site.com/r"},
+	{"root", "https://www.site.com", "https://site.com/"},
+	{"parameters sorted", "https://site.com/r?page=2&id=7&utm_campaign=spring", "https://site.com/r?id=7&page=2"},
+	{"surrounding space", "  https://site.com/r  ", "https://site.com/r"},
+	{"not http", "ftp://site.com/r/", "ftp://site.com/r/"},
+	{"not a URL", "pancakes", "pancakes"},
+	{"unicode path", "https://www.matprat.no/oppskrifter/kos/kjøttkaker/", "https://matprat.no/oppskrifter/kos/kjøttkaker"},
+	{"escaped unicode path", "https://matprat.no/oppskrifter/kos/kj%C3%B8ttkaker", "https://matprat.no/oppskrifter/kos/kjøttkaker"},
+	{"escaped space", "https://site.com/r/pancakes%20with%20jam", "https://site.com/r/pancakes with jam"},
+	{"path not UTF-8", "https://site.com/r/%FF", "https://site.com/r/%FF"},
+	{"amp is the site", "https://amp.dev/documentation/", "https://amp.dev/documentation"},
+	{"www is the site", "http://www.com/r", "https://www.com/r"},
+	{"www before amp", "https://www.amp.site.com/r", "https://site.com/r"},
+}
+
+func TestCanonicalURL(t *testing.T) {
+	for _, tt := range canonicalURLTests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CanonicalURL(tt.url))
+		})
+	}
+}
+
+// canonicalURLFunctionRegex matches the SQL version of CanonicalURL in the
+// migration that re-keyed the recipe URL cache
+var canonicalURLFunctionRegex = regexp.MustCompile(`(?s)CREATE FUNCTION pg_temp\.canonical_recipe_url.*?LANGUAGE plpgsql IMMUTABLE;`)
+
+func TestCanonicalURLMatchesMigration(t *testing.T) {
+	pool := dbtest.NewPool(t)
+	ctx := context.Background()
+
+	migration, err := os.ReadFile("../../../db/V29__canonical_recipe_urls.sql")
+	require.NoError(t, err)
+	function := canonicalURLFunctionRegex.Find(migration)
+	require.NotNil(t, function)
+
+	// Temporary functions only exist on the connection that created them
+	conn, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	defer conn.Release()
+	_, err = conn.Exec(ctx, string(function))
+	require.NoError(t, err)
+
+	for _, tt := range canonicalURLTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var canonical string
+			err := conn.QueryRow(ctx, "SELECT pg_temp.canonical_recipe_url($1)", tt.url).Scan(&canonical)
+			require.NoError(t, err)
+			assert.Equal(t, CanonicalURL(tt.url), canonical)
+		})
+	}
+}
+
+func TestSameSite(t *testing.T) {
+	assert.True(t, SameSite("http://www.site.com/r", "https://site.com/other"))
+	assert.True(t, SameSite("https://amp.site.com/r", "https://site.com/r"))
+	assert.False(t, SameSite("https://amp.dev/r", "https://dev/r"))
+	assert.False(t, SameSite("https://site.com/r", "https://other.com/r"))
+	assert.False(t, SameSite("https://site.com/r", "/r"))
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"strings"
+	"tofoss/sigil-go/pkg/models"
+	"unicode"
+)
+
+// dietaryKeywords are the words that rule out a dietary label, in English and
+// Norwegian. English keywords match the start of a word, so "egg" matches
+// "eggs". Norwegian keywords match the start or the end of a word, since
+// Norwegian writes compounds like "svinekjøtt" and "kyllingfilet" as one word.
+type dietaryKeywords struct {
+	english   []string
+	norwegian []string
+}
+
+var (
+	meatKeywords = dietaryKeywords{
+		english: []string{
+			"meat", "beef", "steak", "veal", "pork", "bacon", "ham", "sausage", "salami", "pepperoni", "chorizo",
+			"prosciutto", "pancetta", "chicken", "turkey", "duck", "goose", "lamb", "mutton", "venison", "lard",
+			"suet", "gelatin", "fish", "salmon", "tuna", "cod", "haddock", "trout", "mackerel", "sardine",
+			"anchov", "halibut", "shrimp", "prawn", "crab", "lobster", "mussel", "clam", "oyster", "scallop",
+			"squid", "octopus", "worcestershire",
+		},
+		norwegian: []string{
+			"kjøtt", "storfe", "okse", "biff", "svin", "skinke", "pølse", "kylling", "kalkun", "høns", "lamme",
+			"vilt", "fisk", "laks", "torsk", "ørret", "makrell", "sild", "ansjos", "kveite", "reke", "krabbe",
+			"hummer", "skjell",
+		},
+	}
+	// Dairy, eggs and honey, which vegetarians eat and vegans don't
+	dairyKeywords = dietaryKeywords{
+		english: []string{
+			"milk", "cream", "butter", "cheese", "parmesan", "mozzarella", "cheddar", "feta", "ricotta",
+			"mascarpone", "gruyere", "halloumi", "paneer", "yogurt", "yoghurt", "ghee", "whey", "kefir", "crème",
+			"creme",
+		},
+		norwegian: []string{"melk", "fløte", "smør", "ost", "rømme", "kesam", "kvarg"},
+	}
+	animalKeywords = dietaryKeywords{
+		english:   []string{"egg", "honey", "mayo"},
+		norwegian: []string{"honning", "majones"},
+	}
+	glutenKeywords = dietaryKeywords{
+		english: []string{
+			"flour", "wheat", "bread", "bun", "baguette", "brioche", "pita", "tortilla", "crouton", "panko",
+			"pasta", "spaghetti", "macaroni", "penne", "fusilli", "lasagn", "tagliatelle", "linguine", "orzo",
+			"gnocchi", "noodle", "udon", "ramen", "couscous", "bulgur", "semolina", "barley", "rye", "spelt",
+			"farro", "seitan", "pastry", "dough", "biscuit", "cracker", "beer", "malt", "soy sauce",
+		},
+		norwegian: []string{"hvete", "rugmel", "spelt", "brød", "nudler", "byggryn", "soyasaus", "panering"},
+	}
+
+	// Ingredients that sound like they rule out a label but don't. They are
+	// removed before the keywords are matched.
+	dietaryExceptions = []string{
+		"coconut milk", "coconut cream", "almond milk", "oat milk", "soy milk", "rice milk", "cashew milk",
+		"peanut butter", "almond butter", "nut butter", "cocoa butter", "butternut", "butter bean",
+		"cream of tartar", "eggplant", "gooseberr", "rice flour", "almond flour", "coconut flour", "chickpea flour",
+		"corn flour", "rice noodle", "glass noodle", "corn tortilla",
+		"kokosmelk", "havremelk", "mandelmelk", "soyamelk", "rismelk", "peanøttsmør", "kakaosmør",
+		"smørbønne", "rismel", "maismel", "mandelmel", "risnudler", "glassnudler",
+	}
+	// Words marking a substitute, like "vegan cheese" or "gluten-free pasta"
+	animalFreeWords = []string{"vegan", "vegansk", "plant-based", "dairy-free", "non-dairy", "meatless"}
+	glutenFreeWords = []string{"gluten-free", "glutenfri"}
+)
+
+// InferDietary guesses the dietary labels of a recipe from its ingredients.
+// Optional ingredients are left out, since they can be skipped. A recipe
+// without ingredients gets no labels.
+func InferDietary(ingredients []models.Ingredient) []string {
+	labels := []string{}
+	var meat, dairy, animal, gluten, counted bool
+	for _, ingredient := range ingredients {
+		if ingredient.IsOptional {
+			continue
+		}
+		counted = true
+
+		words := dietaryWords(ingredient.Name)
+		if !containsAnyWord(words, animalFreeWords) {
+			meat = meat || meatKeywords.match(words)
+			dairy = dairy || dairyKeywords.match(words)
+			animal = animal || animalKeywords.match(words)
+		}
+		if !containsAnyWord(words, glutenFreeWords) {
+			gluten = gluten || glutenKeywords.match(words)
+		}
+	}
+	if !counted {
+		return labels
+	}
+
+	if !meat {
+		labels = append(labels, models.DietaryVegetarian)
+	}
+	if !meat && !dairy && !animal {
+		labels = append(labels, models.DietaryVegan)
+	}
+	if !gluten {
+		labels = append(labels, models.DietaryGlutenFree)
+	}
+	if !dairy {
+		labels = append(labels, models.DietaryDairyFree)
+	}
+	return labels
+}
+
+// dietaryWords splits an ingredient name into lowercase words, with the
+// exceptions removed
+func dietaryWords(name string) []string {
+	name = strings.ToLower(name)
+	for _, exception := range dietaryExceptions {
+		name = strings.ReplaceAll(name, exception, " ")
+	}
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+}
+
+func (k dietaryKeywords) match(words []string) bool {
+	// Joined so keywords of several words, like "soy sauce", match too
+	text := " " + strings.Join(words, " ")
+	for _, keyword := range k.english {
+		if strings.Contains(text, " "+keyword) {
+			return true
+		}
+	}
+	for _, word := range words {
+		for _, keyword := range k.norwegian {
+			if strings.HasPrefix(word, keyword) || strings.HasSuffix(word, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsAnyWord(words []string, candidates []string) bool {
+	for _, word := range words {
+		for _, candidate := range candidates {
+			if word == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
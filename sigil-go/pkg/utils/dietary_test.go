@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferDietary(t *testing.T) {
+	const (
+		vegetarian = models.DietaryVegetarian
+		vegan      = models.DietaryVegan
+		glutenFree = models.DietaryGlutenFree
+		dairyFree  = models.DietaryDairyFree
+	)
+
+	tests := []struct {
+		name        string
+		ingredients []string
+		expected    []string
+	}{
+		{"meat", []string{"Chicken thighs", "Rice"}, []string{glutenFree, dairyFree}},
+		{"fish", []string{"Salmon fillet", "Lemon"}, []string{glutenFree, dairyFree}},
+		{"dairy", []string{"Potatoes", "Butter"}, []string{vegetarian, glutenFree}},
+		{"eggs", []string{"Eggs", "Spinach"}, []string{vegetarian, glutenFree, dairyFree}},
+		{"gluten", []string{"All-purpose flour", "Water", "Yeast"}, []string{vegetarian, vegan, dairyFree}},
+		{"soy sauce", []string{"Tofu", "Soy sauce"}, []string{vegetarian, vegan, dairyFree}},
+		{"exceptions", []string{"Coconut milk", "Eggplant", "Peanut butter", "Rice flour", "Butternut squash"}, []string{vegetarian, vegan, glutenFree, dairyFree}},
+		{"substitutes", []string{"Vegan cheese", "Gluten-free pasta"}, []string{vegetarian, vegan, glutenFree, dairyFree}},
+		{"norwegian", []string{"Svinekjøtt", "Lettmelk", "Hvetemel"}, []string{}},
+		{"norwegian vegan", []string{"Kikerter", "Kokosmelk", "Risnudler"}, []string{vegetarian, vegan, glutenFree, dairyFree}},
+		{"no ingredients", nil, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ingredients := make([]models.Ingredient, len(test.ingredients))
+			for i, name := range test.ingredients {
+				ingredients[i] = models.Ingredient{Name: name}
+			}
+			assert.Equal(t, test.expected, InferDietary(ingredients))
+		})
+	}
+
+	// Optional ingredients can be left out
+	optional := []models.Ingredient{{Name: "Lentils"}, {Name: "Bacon", IsOptional: true}}
+	assert.Equal(t, []string{vegetarian, vegan, glutenFree, dairyFree}, InferDietary(optional))
+}
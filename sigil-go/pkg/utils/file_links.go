@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// fileLinkRegex matches references to uploaded files as written by the editor: (/files/<uuid>)
+var fileLinkRegex = regexp.MustCompile(`\(/files/([0-9a-fA-F-]{36})\)`)
+
+// ReplaceFileLinks rewrites every (/files/<id>) reference in markdown content.
+// The replace function receives the file ID and returns the new link target;
+// returning false leaves the reference untouched.
+func ReplaceFileLinks(content string, replace func(fileID uuid.UUID) (string, bool)) string {
+	return fileLinkRegex.ReplaceAllStringFunc(content, func(match string) string {
+		rawID := fileLinkRegex.FindStringSubmatch(match)[1]
+		fileID, err := uuid.Parse(rawID)
+		if err != nil {
+			return match
+		}
+
+		target, ok := replace(fileID)
+		if !ok {
+			return match
+		}
+		return "(" + target + ")"
+	})
+}
+
+// FileLinkIDs returns the IDs of all uploaded files referenced in markdown content
+func FileLinkIDs(content string) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, match := range fileLinkRegex.FindAllStringSubmatch(content, -1) {
+		if id, err := uuid.Parse(match[1]); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// SanitizeFilename turns a title into a name that is safe to use as a file or
+// folder name on common filesystems
+func SanitizeFilename(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			b.WriteRune('-')
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		case unicode.IsControl(r):
+			continue
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	sanitized := strings.Join(strings.Fields(b.String()), " ")
+	sanitized = strings.Trim(sanitized, ". ")
+
+	// Keep names comfortably below filesystem limits
+	if runes := []rune(sanitized); len(runes) > 100 {
+		sanitized = strings.TrimSpace(string(runes[:100]))
+	}
+
+	if sanitized == "" {
+		return "Untitled"
+	}
+	return sanitized
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceFileLinks(t *testing.T) {
+	known := uuid.MustParse("0b7e3f52-8a3c-4d8e-9c1a-2f4b5d6e7f80")
+	unknown := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+
+	replace := func(fileID uuid.UUID) (string, bool) {
+		if fileID == known {
+			return "attachments/" + fileID.String() + ".png", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Image link is rewritten",
+			content:  "![uploaded image](/files/" + known.String() + ")",
+			expected: "![uploaded image](attachments/" + known.String() + ".png)",
+		},
+		{
+			name:     "Unknown file is left untouched",
+			content:  "![uploaded image](/files/" + unknown.String() + ")",
+			expected: "![uploaded image](/files/" + unknown.String() + ")",
+		},
+		{
+			name:     "Multiple links",
+			content:  "[a](/files/" + known.String() + ") and [b](/files/" + known.String() + ")",
+			expected: "[a](attachments/" + known.String() + ".png) and [b](attachments/" + known.String() + ".png)",
+		},
+		{
+			name:     "Other links are ignored",
+			content:  "[site](https://example.com/files/" + known.String() + ")",
+			expected: "[site](https://example.com/files/" + known.String() + ")",
+		},
+		{
+			name:     "No links",
+			content:  "Just some text",
+			expected: "Just some text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ReplaceFileLinks(tt.content, replace))
+		})
+	}
+}
+
+func TestFileLinkIDs(t *testing.T) {
+	first := uuid.New()
+	second := uuid.New()
+
+	content := "![a](/files/" + first.String() + ")\ntext\n![b](/files/" + second.String() + ")"
+	assert.Equal(t, []uuid.UUID{first, second}, FileLinkIDs(content))
+	assert.Empty(t, FileLinkIDs("no files here"))
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Plain title", input: "Weekly plan", expected: "Weekly plan"},
+		{name: "Path separators", input: "Pros/Cons\\Notes", expected: "Pros-Cons-Notes"},
+		{name: "Reserved characters", input: `What? "Why": <now>`, expected: "What- -Why-- -now-"},
+		{name: "Collapses whitespace", input: "  too   many\tspaces ", expected: "too many spaces"},
+		{name: "Trailing dots", input: "Ends with dots...", expected: "Ends with dots"},
+		{name: "Empty", input: "", expected: "Untitled"},
+		{name: "Only dots", input: "..", expected: "Untitled"},
+		{name: "Unicode is kept", input: "Kjøttkaker med brunsaus", expected: "Kjøttkaker med brunsaus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SanitizeFilename(tt.input))
+		})
+	}
+}
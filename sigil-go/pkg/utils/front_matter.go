@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---"
+
+// NoteFrontMatter is the YAML header written at the top of exported notes
+type NoteFrontMatter struct {
+	ID        string     `yaml:"id,omitempty"`
+	Title     string     `yaml:"title,omitempty"`
+	Tags      []string   `yaml:"tags,omitempty"`
+	Created   *time.Time `yaml:"created,omitempty"`
+	Updated   *time.Time `yaml:"updated,omitempty"`
+	Published bool       `yaml:"published"`
+}
+
+// RenderFrontMatter serializes the given value as a YAML front matter block,
+// including the surrounding --- delimiters and a trailing newline
+func RenderFrontMatter(frontMatter any) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter + "\n")
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(frontMatter); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	buf.WriteString(frontMatterDelimiter + "\n")
+	return buf.String(), nil
+}
+
+// SplitFrontMatter separates a leading YAML front matter block from markdown
+// content. Content without front matter is returned unchanged with a nil map.
+func SplitFrontMatter(content string) (map[string]any, string, error) {
+	normalized := strings.TrimPrefix(content, "\ufeff")
+	normalized = strings.ReplaceAll(normalized, "\r\n", "\n")
+
+	lines := strings.Split(normalized, "\n")
+	if len(lines) < 2 || strings.TrimRight(lines[0], " ") != frontMatterDelimiter {
+		return nil, content, nil
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], " ") != frontMatterDelimiter {
+			continue
+		}
+
+		raw := strings.Join(lines[1:i], "\n")
+		body := strings.Join(lines[i+1:], "\n")
+
+		frontMatter := map[string]any{}
+		if err := yaml.Unmarshal([]byte(raw), &frontMatter); err != nil {
+			return nil, body, err
+		}
+		return frontMatter, body, nil
+	}
+
+	// No closing delimiter, so this is just a horizontal rule
+	return nil, content, nil
+}
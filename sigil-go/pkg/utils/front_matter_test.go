@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderFrontMatter(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	updated := time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)
+
+	result, err := RenderFrontMatter(NoteFrontMatter{
+		ID:        "7f1c2d3e-0000-0000-0000-000000000001",
+		Title:     "Shopping: weekend",
+		Tags:      []string{"food", "weekend"},
+		Created:   &created,
+		Updated:   &updated,
+		Published: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `---
+id: 7f1c2d3e-0000-0000-0000-000000000001
+title: 'Shopping: weekend'
+tags:
+  - food
+  - weekend
+created: 2024-03-01T12:30:00Z
+updated: 2024-03-02T08:00:00Z
+published: true
+---
+`, result)
+}
+
+func TestRenderFrontMatterOmitsEmptyFields(t *testing.T) {
+	result, err := RenderFrontMatter(NoteFrontMatter{ID: "abc"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "---\nid: abc\npublished: false\n---\n", result)
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		expectedMeta map[string]any
+		expectedBody string
+		expectError  bool
+	}{
+		{
+			name:         "No front matter",
+			content:      "# Title\nBody",
+			expectedMeta: nil,
+			expectedBody: "# Title\nBody",
+		},
+		{
+			name:         "Front matter with tags",
+			content:      "---\ntitle: Hello\ntags: [a, b]\n---\n# Hello\n",
+			expectedMeta: map[string]any{"title": "Hello", "tags": []any{"a", "b"}},
+			expectedBody: "# Hello\n",
+		},
+		{
+			name:         "Windows line endings",
+			content:      "---\r\ntitle: Hello\r\n---\r\nBody",
+			expectedMeta: map[string]any{"title": "Hello"},
+			expectedBody: "Body",
+		},
+		{
+			name:         "Empty front matter",
+			content:      "---\n---\nBody",
+			expectedMeta: map[string]any{},
+			expectedBody: "Body",
+		},
+		{
+			name:         "Unclosed delimiter is a horizontal rule",
+			content:      "---\nJust text",
+			expectedMeta: nil,
+			expectedBody: "---\nJust text",
+		},
+		{
+			name:        "Invalid yaml",
+			content:     "---\ntitle: [unclosed\n---\nBody",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := SplitFrontMatter(tt.content)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedMeta, meta)
+			assert.Equal(t, tt.expectedBody, body)
+		})
+	}
+}
+
+func TestFrontMatterRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	header, err := RenderFrontMatter(NoteFrontMatter{ID: "abc", Tags: []string{"x"}, Created: &created})
+	assert.NoError(t, err)
+
+	meta, body, err := SplitFrontMatter(header + "\nContent")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", meta["id"])
+	assert.Equal(t, []any{"x"}, meta["tags"])
+	assert.Equal(t, created, meta["created"])
+	assert.Equal(t, "\nContent", body)
+}
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"tofoss/sigil-go/pkg/models"
+)
+
+// Numbers as written in ingredient lines: "2", "1.5", "1,5", "1/2" or "1 1/2"
+const ingredientNumberPattern = `\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?`
+
+var (
+	// Quantity at the start of a line, e.g. "1-2", "ca. 500" or "at least 1 1/2"
+	leadingIngredientQuantityRegex = regexp.MustCompile(`^(?i)(?:(?:ca\.?|about|approx\.?|approximately|around|circa|cirka|omtrent)\s+)?(?:(at\s+least|minst|up\s+to|opptil|inntil)\s+)?(` +
+		ingredientNumberPattern + `)(?:(?:\s*-\s*|\s+(?:to|til|or|eller)\s+)(` + ingredientNumberPattern + `))?`)
+
+	// Quantity at the end of a line, e.g. "Carrots, 5kg", "Flour - 2 kg" or "Sugar 3kg"
+	trailingQuantityWithCommaRegex = regexp.MustCompile(`^(.+?)\s*,\s*(` + ingredientNumberPattern + `)\s*(\pL+\.?)?$`)
+	trailingQuantityWithDashRegex  = regexp.MustCompile(`^(.+?)\s+-\s+(` + ingredientNumberPattern + `)\s*(\pL+\.?)?$`)
+	trailingQuantityNoSepRegex     = regexp.MustCompile(`^(.+?)\s+(` + ingredientNumberPattern + `)\s*(\pL+\.?)$`)
+
+	optionalMarkerRegex = regexp.MustCompile(`(?i)[,\s]*\*?\(?\b(?:optional|valgfritt|valgfri)\b\)?\*?`)
+	toTasteRegex        = regexp.MustCompile(`(?i)\b(?:(?:or|eller)\s+)?(to\s+taste|etter\s+smak|as\s+needed|as\s+required|if\s+needed|etter\s+behov|ved\s+behov)\b\.?`)
+	listMarkerRegex     = regexp.MustCompile(`^[-*•]\s+`)
+	ofRegex             = regexp.MustCompile(`(?i)^(?:of|av)\s+`)
+	whitespaceRegex     = regexp.MustCompile(`\s+`)
+
+	unicodeFractions = strings.NewReplacer(
+		"½", " 1/2 ", "⅓", " 1/3 ", "⅔", " 2/3 ", "¼", " 1/4 ", "¾", " 3/4 ",
+		"⅕", " 1/5 ", "⅖", " 2/5 ", "⅗", " 3/5 ", "⅘", " 4/5 ", "⅙", " 1/6 ", "⅚", " 5/6 ",
+		"⅛", " 1/8 ", "⅜", " 3/8 ", "⅝", " 5/8 ", "⅞", " 7/8 ",
+		"⁄", "/", "–", "-", "—", "-",
+	)
+)
+
+// ParseIngredient parses an ingredient line like "1 1/2 dl milk, lukewarm" or
+// "2 ss smør (optional)" into an Ingredient. Units are kept as written, use
+// NormalizeUnit to compare them. Text after the first comma and text in
+// parentheses become notes.
+func ParseIngredient(line string) models.Ingredient {
+	text := normalizeIngredientText(line)
+	text = listMarkerRegex.ReplaceAllString(text, "")
+
+	ingredient := models.Ingredient{}
+	if optionalMarkerRegex.MatchString(text) {
+		ingredient.IsOptional = true
+		text = optionalMarkerRegex.ReplaceAllString(text, "")
+	}
+
+	var notes, parentheticals []string
+	for _, match := range parentheticalRegex.FindAllStringSubmatch(text, -1) {
+		if content := strings.TrimSpace(match[1]); content != "" {
+			parentheticals = append(parentheticals, content)
+		}
+	}
+	text = cleanIngredientText(parentheticalRegex.ReplaceAllString(text, " "))
+
+	var toTaste string
+	if match := toTasteRegex.FindStringSubmatch(text); match != nil {
+		toTaste = match[1]
+		text = cleanIngredientText(toTasteRegex.ReplaceAllString(text, " "))
+	}
+
+	quantity, name := parseIngredientQuantity(text)
+	if quantity == nil {
+		// Quantities can also be given in parentheses, e.g. "Milk (2L)"
+		for i, content := range parentheticals {
+			if q, rest := parseLeadingQuantity(content); q != nil && rest == "" {
+				quantity = q
+				parentheticals = append(parentheticals[:i:i], parentheticals[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if before, after, found := strings.Cut(name, ","); found {
+		name = before
+		if after = cleanIngredientText(after); after != "" {
+			notes = append(notes, after)
+		}
+	}
+	notes = append(notes, parentheticals...)
+	if toTaste != "" {
+		notes = append(notes, toTaste)
+	}
+
+	ingredient.Name = cleanIngredientText(name)
+	if ingredient.Name == "" && quantity != nil && quantity.Unit != "" {
+		// "2 cans" names the ingredient by its unit
+		ingredient.Name = quantity.Unit
+		quantity.Unit = ""
+	}
+	if ingredient.Name == "" {
+		ingredient.Name = strings.TrimSpace(line)
+	}
+	ingredient.Quantity = quantity
+	ingredient.Notes = strings.Join(notes, ", ")
+
+	return ingredient
+}
+
+// ParseQuantity parses an amount like "2", "1/2" or "1-2" given separately
+// from its unit, as in recipe formats that keep them apart. Returns nil when
+// the amount isn't a number.
+func ParseQuantity(amount, unit string) *models.Quantity {
+	quantity, rest := parseLeadingQuantity(normalizeIngredientText(amount))
+	if quantity == nil || rest != "" {
+		return nil
+	}
+	if unit = strings.TrimSpace(unit); unit != "" {
+		quantity.Unit = unit
+	}
+	return quantity
+}
+
+// parseIngredientQuantity finds the quantity at the start or end of text and
+// returns the rest of it
+func parseIngredientQuantity(text string) (*models.Quantity, string) {
+	if quantity, rest := parseLeadingQuantity(text); quantity != nil {
+		return quantity, ofRegex.ReplaceAllString(rest, "")
+	}
+
+	for _, regex := range []*regexp.Regexp{trailingQuantityWithCommaRegex, trailingQuantityWithDashRegex, trailingQuantityNoSepRegex} {
+		match := regex.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		unit := strings.TrimSuffix(match[3], ".")
+		if unit != "" {
+			if _, known := NormalizeUnit(unit); !known {
+				continue
+			}
+		}
+		value, ok := parseIngredientNumber(match[2])
+		if !ok {
+			continue
+		}
+		return &models.Quantity{Min: &value, Max: &value, Unit: unit}, match[1]
+	}
+
+	return nil, text
+}
+
+// parseLeadingQuantity parses a quantity and unit at the start of text
+func parseLeadingQuantity(text string) (*models.Quantity, string) {
+	match := leadingIngredientQuantityRegex.FindStringSubmatchIndex(text)
+	if match == nil {
+		return nil, text
+	}
+
+	rest := text[match[1]:]
+	// The number must be followed by a unit or a word, not part of "7-Up" or "100%"
+	if rest != "" && !startsWithSpaceOrLetter(rest) {
+		return nil, text
+	}
+
+	min, ok := parseIngredientNumber(text[match[4]:match[5]])
+	if !ok {
+		return nil, text
+	}
+	max := min
+	if match[6] >= 0 {
+		if max, ok = parseIngredientNumber(text[match[6]:match[7]]); !ok {
+			return nil, text
+		}
+	}
+
+	quantity := &models.Quantity{Min: &min, Max: &max}
+	if match[2] >= 0 && match[6] < 0 {
+		switch bound := strings.ToLower(strings.Join(strings.Fields(text[match[2]:match[3]]), " ")); bound {
+		case "at least", "minst":
+			quantity.Max = nil
+		default:
+			quantity.Min = nil
+		}
+	}
+
+	quantity.Unit, rest = parseUnit(strings.TrimSpace(rest))
+	return quantity, rest
+}
+
+// parseUnit splits a known unit from the start of text
+func parseUnit(text string) (string, string) {
+	fields := strings.Fields(text)
+	// Units like "fl oz" and "fluid ounces" are two words
+	for _, count := range []int{2, 1} {
+		if len(fields) < count {
+			continue
+		}
+		unit := strings.TrimSuffix(strings.Join(fields[:count], " "), ".")
+		if _, known := NormalizeUnit(unit); known {
+			return unit, strings.Join(fields[count:], " ")
+		}
+	}
+	return "", text
+}
+
+// parseIngredientNumber parses "2", "1,5", "1/2" and "1 1/2"
+func parseIngredientNumber(s string) (float64, bool) {
+	var total float64
+	for _, part := range strings.Fields(strings.ReplaceAll(s, ",", ".")) {
+		if numerator, denominator, found := strings.Cut(part, "/"); found {
+			n, err := strconv.ParseFloat(numerator, 64)
+			if err != nil {
+				return 0, false
+			}
+			d, err := strconv.ParseFloat(denominator, 64)
+			if err != nil || d == 0 {
+				return 0, false
+			}
+			total += n / d
+			continue
+		}
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+		total += value
+	}
+	return total, true
+}
+
+// normalizeIngredientText writes unicode fractions and dashes as ASCII
+func normalizeIngredientText(text string) string {
+	text = unicodeFractions.Replace(text)
+	// "(½ cup)" becomes "( 1/2 cup)", keep it a parenthetical
+	text = strings.ReplaceAll(text, "( ", "(")
+	return strings.TrimSpace(whitespaceRegex.ReplaceAllString(text, " "))
+}
+
+// cleanIngredientText trims the whitespace and punctuation left behind after
+// removing parts of a line
+func cleanIngredientText(text string) string {
+	text = whitespaceRegex.ReplaceAllString(text, " ")
+	text = strings.ReplaceAll(text, " ,", ",")
+	return strings.Trim(text, " ,;:-")
+}
+
+func startsWithSpaceOrLetter(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r) || unicode.IsLetter(r)
+}
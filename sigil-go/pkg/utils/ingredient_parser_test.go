@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func qty(min, max float64, unit string) *models.Quantity {
+	return &models.Quantity{Min: &min, Max: &max, Unit: unit}
+}
+
+func TestParseIngredient(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected models.Ingredient
+	}{
+		// Whole numbers and decimals
+		{"2 eggs", models.Ingredient{Name: "eggs", Quantity: qty(2, 2, "")}},
+		{"10 potatoes", models.Ingredient{Name: "potatoes", Quantity: qty(10, 10, "")}},
+		{"200 g flour", models.Ingredient{Name: "flour", Quantity: qty(200, 200, "g")}},
+		{"200g flour", models.Ingredient{Name: "flour", Quantity: qty(200, 200, "g")}},
+		{"1.5 l water", models.Ingredient{Name: "water", Quantity: qty(1.5, 1.5, "l")}},
+		{"1,5 dl melk", models.Ingredient{Name: "melk", Quantity: qty(1.5, 1.5, "dl")}},
+		{"0.5L Milk", models.Ingredient{Name: "Milk", Quantity: qty(0.5, 0.5, "L")}},
+		{"1 kg kyllingfilet", models.Ingredient{Name: "kyllingfilet", Quantity: qty(1, 1, "kg")}},
+
+		// Fractions and mixed numbers
+		{"1/2 cup sugar", models.Ingredient{Name: "sugar", Quantity: qty(0.5, 0.5, "cup")}},
+		{"1 1/2 cups milk", models.Ingredient{Name: "milk", Quantity: qty(1.5, 1.5, "cups")}},
+		{"3/4 tsp salt", models.Ingredient{Name: "salt", Quantity: qty(0.75, 0.75, "tsp")}},
+		{"2 1/4 teaspoons dry yeast", models.Ingredient{Name: "dry yeast", Quantity: qty(2.25, 2.25, "teaspoons")}},
+
+		// Unicode fractions
+		{"½ cup butter", models.Ingredient{Name: "butter", Quantity: qty(0.5, 0.5, "cup")}},
+		{"1½ cups flour", models.Ingredient{Name: "flour", Quantity: qty(1.5, 1.5, "cups")}},
+		{"1 ½ dl fløte", models.Ingredient{Name: "fløte", Quantity: qty(1.5, 1.5, "dl")}},
+		{"¼ ts kanel", models.Ingredient{Name: "kanel", Quantity: qty(0.25, 0.25, "ts")}},
+		{"¾ cup brown sugar", models.Ingredient{Name: "brown sugar", Quantity: qty(0.75, 0.75, "cup")}},
+		{"1⁄2 tsp pepper", models.Ingredient{Name: "pepper", Quantity: qty(0.5, 0.5, "tsp")}},
+
+		// Ranges
+		{"1-2 tablespoons soy sauce", models.Ingredient{Name: "soy sauce", Quantity: qty(1, 2, "tablespoons")}},
+		{"2 - 3 cloves garlic", models.Ingredient{Name: "garlic", Quantity: qty(2, 3, "cloves")}},
+		{"2–3 cups stock", models.Ingredient{Name: "stock", Quantity: qty(2, 3, "cups")}},
+		{"2 to 3 carrots", models.Ingredient{Name: "carrots", Quantity: qty(2, 3, "")}},
+		{"3 til 4 poteter", models.Ingredient{Name: "poteter", Quantity: qty(3, 4, "")}},
+		{"2 or 3 apples", models.Ingredient{Name: "apples", Quantity: qty(2, 3, "")}},
+		{"½-1 ts chili", models.Ingredient{Name: "chili", Quantity: qty(0.5, 1, "ts")}},
+
+		// Bounds and approximations
+		{"at least 1L water", models.Ingredient{Name: "water", Quantity: &models.Quantity{Min: floatPtr(1), Unit: "L"}}},
+		{"up to 2 cups milk", models.Ingredient{Name: "milk", Quantity: &models.Quantity{Max: floatPtr(2), Unit: "cups"}}},
+		{"minst 3 dl vann", models.Ingredient{Name: "vann", Quantity: &models.Quantity{Min: floatPtr(3), Unit: "dl"}}},
+		{"opptil 2 ss sukker", models.Ingredient{Name: "sukker", Quantity: &models.Quantity{Max: floatPtr(2), Unit: "ss"}}},
+		{"ca. 500 g kjøttdeig", models.Ingredient{Name: "kjøttdeig", Quantity: qty(500, 500, "g")}},
+		{"about 1 cup rice", models.Ingredient{Name: "rice", Quantity: qty(1, 1, "cup")}},
+
+		// English units
+		{"2 tbsp olive oil", models.Ingredient{Name: "olive oil", Quantity: qty(2, 2, "tbsp")}},
+		{"1 Tbsp. honey", models.Ingredient{Name: "honey", Quantity: qty(1, 1, "Tbsp")}},
+		{"1 teaspoon vanilla extract", models.Ingredient{Name: "vanilla extract", Quantity: qty(1, 1, "teaspoon")}},
+		{"8 oz cream cheese", models.Ingredient{Name: "cream cheese", Quantity: qty(8, 8, "oz")}},
+		{"2 lbs ground beef", models.Ingredient{Name: "ground beef", Quantity: qty(2, 2, "lbs")}},
+		{"4 fl oz cream", models.Ingredient{Name: "cream", Quantity: qty(4, 4, "fl oz")}},
+		{"1 pinch nutmeg", models.Ingredient{Name: "nutmeg", Quantity: qty(1, 1, "pinch")}},
+		{"2 cups of flour", models.Ingredient{Name: "flour", Quantity: qty(2, 2, "cups")}},
+		{"1 can chickpeas", models.Ingredient{Name: "chickpeas", Quantity: qty(1, 1, "can")}},
+		{"3 sprigs thyme", models.Ingredient{Name: "thyme", Quantity: qty(3, 3, "sprigs")}},
+
+		// Norwegian units
+		{"2 ss smør", models.Ingredient{Name: "smør", Quantity: qty(2, 2, "ss")}},
+		{"1 ts salt", models.Ingredient{Name: "salt", Quantity: qty(1, 1, "ts")}},
+		{"3 dl hvetemel", models.Ingredient{Name: "hvetemel", Quantity: qty(3, 3, "dl")}},
+		{"2 fedd hvitløk", models.Ingredient{Name: "hvitløk", Quantity: qty(2, 2, "fedd")}},
+		{"1 boks hermetiske tomater", models.Ingredient{Name: "hermetiske tomater", Quantity: qty(1, 1, "boks")}},
+		{"1 klype salt", models.Ingredient{Name: "salt", Quantity: qty(1, 1, "klype")}},
+		{"4 stk egg", models.Ingredient{Name: "egg", Quantity: qty(4, 4, "stk")}},
+		{"1 pk gjær", models.Ingredient{Name: "gjær", Quantity: qty(1, 1, "pk")}},
+		{"2 ss. sitronsaft", models.Ingredient{Name: "sitronsaft", Quantity: qty(2, 2, "ss")}},
+
+		// Preparation notes
+		{"1 clove garlic, minced", models.Ingredient{Name: "garlic", Quantity: qty(1, 1, "clove"), Notes: "minced"}},
+		{"1 onion, finely chopped, divided", models.Ingredient{Name: "onion", Quantity: qty(1, 1, ""), Notes: "finely chopped, divided"}},
+		{"2 eggs (at room temperature)", models.Ingredient{Name: "eggs", Quantity: qty(2, 2, ""), Notes: "at room temperature"}},
+		{"1 løk, finhakket", models.Ingredient{Name: "løk", Quantity: qty(1, 1, ""), Notes: "finhakket"}},
+		{"1 (14 oz) can tomatoes", models.Ingredient{Name: "tomatoes", Quantity: qty(1, 1, "can"), Notes: "14 oz"}},
+		{"1-2 tablespoons soy sauce (adjust to taste)", models.Ingredient{Name: "soy sauce", Quantity: qty(1, 2, "tablespoons"), Notes: "adjust to taste"}},
+
+		// To taste
+		{"Salt to taste", models.Ingredient{Name: "Salt", Notes: "to taste"}},
+		{"Salt and pepper, to taste", models.Ingredient{Name: "Salt and pepper", Notes: "to taste"}},
+		{"salt og pepper etter smak", models.Ingredient{Name: "salt og pepper", Notes: "etter smak"}},
+		{"1 tsp salt, or to taste", models.Ingredient{Name: "salt", Quantity: qty(1, 1, "tsp"), Notes: "to taste"}},
+		{"Water as needed", models.Ingredient{Name: "Water", Notes: "as needed"}},
+		{"Salt (to taste)", models.Ingredient{Name: "Salt", Notes: "to taste"}},
+
+		// Optional markers
+		{"1/2 teaspoon chili flakes (optional)", models.Ingredient{Name: "chili flakes", Quantity: qty(0.5, 0.5, "teaspoon"), IsOptional: true}},
+		{"Fresh parsley, optional", models.Ingredient{Name: "Fresh parsley", IsOptional: true}},
+		{"1 ts chili *(optional)*", models.Ingredient{Name: "chili", Quantity: qty(1, 1, "ts"), IsOptional: true}},
+		{"2 ss persille (valgfritt)", models.Ingredient{Name: "persille", Quantity: qty(2, 2, "ss"), IsOptional: true}},
+		{"Optional: 50 g walnuts", models.Ingredient{Name: "walnuts", Quantity: qty(50, 50, "g"), IsOptional: true}},
+
+		// Quantities after the name
+		{"Carrots, 5kg", models.Ingredient{Name: "Carrots", Quantity: qty(5, 5, "kg")}},
+		{"Flour - 2 kg", models.Ingredient{Name: "Flour", Quantity: qty(2, 2, "kg")}},
+		{"Sugar 3kg", models.Ingredient{Name: "Sugar", Quantity: qty(3, 3, "kg")}},
+		{"Milk (2L)", models.Ingredient{Name: "Milk", Quantity: qty(2, 2, "L")}},
+		{"Eggs, 12", models.Ingredient{Name: "Eggs", Quantity: qty(12, 12, "")}},
+		{"Butter (½ cup)", models.Ingredient{Name: "Butter", Quantity: qty(0.5, 0.5, "cup")}},
+
+		// No quantity
+		{"Fresh basil leaves", models.Ingredient{Name: "Fresh basil leaves"}},
+		{"Coffee & Tea", models.Ingredient{Name: "Coffee & Tea"}},
+		{"7-Up", models.Ingredient{Name: "7-Up"}},
+		{"100% whole wheat flour", models.Ingredient{Name: "100% whole wheat flour"}},
+		{"Ice cubes 2 bags", models.Ingredient{Name: "Ice cubes 2 bags"}},
+
+		// List markers and spacing
+		{"- 2 eggs", models.Ingredient{Name: "eggs", Quantity: qty(2, 2, "")}},
+		{"•  250 g   smør ", models.Ingredient{Name: "smør", Quantity: qty(250, 250, "g")}},
+		{"2 cans", models.Ingredient{Name: "cans", Quantity: qty(2, 2, "")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseIngredient(tt.line))
+		})
+	}
+}
+
+func TestNormalizeUnit(t *testing.T) {
+	tests := []struct {
+		unit      string
+		canonical string
+		known     bool
+	}{
+		{"tablespoons", "tbsp", true},
+		{"Tbsp.", "tbsp", true},
+		{"ss", "tbsp", true},
+		{"ts", "tsp", true},
+		{"Teaspoon", "tsp", true},
+		{"L", "l", true},
+		{"liter", "l", true},
+		{"dl", "dl", true},
+		{"grams", "g", true},
+		{"fedd", "clove", true},
+		{"fl oz", "fl oz", true},
+		{"handful", "handful", true},
+		{"large", "large", false},
+	}
+
+	for _, tt := range tests {
+		canonical, known := NormalizeUnit(tt.unit)
+		assert.Equal(t, tt.canonical, canonical, tt.unit)
+		assert.Equal(t, tt.known, known, tt.unit)
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	assert.Equal(t, qty(2, 2, "dl"), ParseQuantity("2", "dl"))
+	assert.Equal(t, qty(0.5, 0.5, "cup"), ParseQuantity("1/2", "cup"))
+	assert.Equal(t, qty(1, 2, ""), ParseQuantity(" 1-2 ", ""))
+	assert.Equal(t, qty(1.5, 1.5, "g"), ParseQuantity("1,5 g", ""))
+	assert.Equal(t, &models.Quantity{Min: floatPtr(1), Unit: "tsp"}, ParseQuantity("at least 1", "tsp"))
+	assert.Nil(t, ParseQuantity("some", "pinch"))
+	assert.Nil(t, ParseQuantity("", ""))
+}
+
+func TestFormatIngredient(t *testing.T) {
+	ingredients := []models.Ingredient{
+		{Name: "milk", Quantity: qty(2, 2, "dl"), Notes: "lukewarm"},
+		{Name: "chili flakes", Quantity: qty(0.5, 1, "tsp"), IsOptional: true},
+		{Name: "water", Quantity: &models.Quantity{Min: floatPtr(1), Unit: "l"}},
+		{Name: "Salt", Notes: "to taste"},
+		{Name: "eggs", Quantity: qty(3, 3, "")},
+	}
+
+	expected := []string{
+		"2 dl milk, lukewarm",
+		"1/2-1 tsp chili flakes (optional)",
+		"at least 1 l water",
+		"Salt, to taste",
+		"3 eggs",
+	}
+
+	for i, ingredient := range ingredients {
+		line := FormatIngredient(ingredient)
+		assert.Equal(t, expected[i], line)
+		// Lines are read back as the same ingredient
+		assert.Equal(t, ingredient, ParseIngredient(line), line)
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,371 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"tofoss/sigil-go/pkg/models"
+)
+
+// recipeField is a single value of a recipe, compared as text
+type recipeField struct {
+	name string
+	text func(recipe models.RecipeSnapshot) string
+	set  func(recipe *models.Recipe, source models.RecipeSnapshot)
+}
+
+var recipeFields = []recipeField{
+	{
+		"name",
+		func(r models.RecipeSnapshot) string { return r.Name },
+		func(r *models.Recipe, s models.RecipeSnapshot) { r.Name = s.Name },
+	},
+	{
+		"summary",
+		func(r models.RecipeSnapshot) string { return optionalText(r.Summary) },
+		func(r *models.Recipe, s models.RecipeSnapshot) { r.Summary = s.Summary },
+	},
+	{
+		"servings",
+		func(r models.RecipeSnapshot) string {
+			if r.Servings == nil {
+				return ""
+			}
+			return strconv.Itoa(*r.Servings)
+		},
+		func(r *models.Recipe, s models.RecipeSnapshot) { r.Servings = s.Servings },
+	},
+	{
+		"prepTime",
+		func(r models.RecipeSnapshot) string { return optionalText(r.PrepTime) },
+		func(r *models.Recipe, s models.RecipeSnapshot) { r.PrepTime = s.PrepTime },
+	},
+	{
+		"cookTime",
+		func(r models.RecipeSnapshot) string { return optionalText(r.CookTime) },
+		func(r *models.Recipe, s models.RecipeSnapshot) { r.CookTime = s.CookTime },
+	},
+	{
+		"totalTime",
+		func(r models.RecipeSnapshot) string { return optionalText(r.TotalTime) },
+		func(r *models.Recipe, s models.RecipeSnapshot) { r.TotalTime = s.TotalTime },
+	},
+	{
+		"cuisine",
+		func(r models.RecipeSnapshot) string { return optionalText(r.Cuisine) },
+		func(r *models.Recipe, s models.RecipeSnapshot) { r.Cuisine = s.Cuisine },
+	},
+	{
+		"course",
+		func(r models.RecipeSnapshot) string { return optionalText(r.Course) },
+		func(r *models.Recipe, s models.RecipeSnapshot) { r.Course = s.Course },
+	},
+	{
+		"equipment",
+		func(r models.RecipeSnapshot) string { return strings.Join(r.Equipment, ", ") },
+		func(r *models.Recipe, s models.RecipeSnapshot) { r.Equipment = s.Equipment },
+	},
+}
+
+// SnapshotRecipe returns the content of a recipe compared with its source.
+// The snapshot has its own copy of the lists.
+func SnapshotRecipe(recipe models.Recipe) models.RecipeSnapshot {
+	return models.RecipeSnapshot{
+		Name:         recipe.Name,
+		Summary:      recipe.Summary,
+		Servings:     recipe.Servings,
+		PrepTime:     recipe.PrepTime,
+		CookTime:     recipe.CookTime,
+		TotalTime:    recipe.TotalTime,
+		Cuisine:      recipe.Cuisine,
+		Course:       recipe.Course,
+		Equipment:    slices.Clone(recipe.Equipment),
+		Ingredients:  slices.Clone(recipe.Ingredients),
+		Steps:        slices.Clone(recipe.Steps),
+		StepSections: slices.Clone(recipe.StepSections),
+	}
+}
+
+// DiffRecipeSource lists the changes at a recipe's source since it was last
+// read, by comparing the recipe's snapshot with the source as it is now.
+// Changes to anything the user edited since are conflicts, unless the user
+// made the same change. The steps count as edited when any step or section
+// was. Without a snapshot there's no telling the user's edits from changes at
+// the source, everything that differs from the source is a conflict.
+func DiffRecipeSource(recipe models.Recipe, source models.RecipeSnapshot) models.RecipeDiff {
+	current := SnapshotRecipe(recipe)
+	known := recipe.Source != nil
+	base := current
+	if known {
+		base = *recipe.Source
+	}
+
+	diff := models.RecipeDiff{
+		Fields:      []models.FieldChange{},
+		Ingredients: []models.IngredientChange{},
+		Steps:       []models.StepChange{},
+	}
+
+	for _, field := range recipeFields {
+		old, updated, now := field.text(base), field.text(source), field.text(current)
+		if old != updated {
+			diff.Fields = append(diff.Fields, models.FieldChange{
+				Field:    field.name,
+				Old:      old,
+				New:      updated,
+				Conflict: (!known || now != old) && now != updated,
+			})
+		}
+	}
+
+	diff.Ingredients = diffIngredients(base.Ingredients, current.Ingredients, source.Ingredients, known)
+
+	stepsConflict := (!known || !sameSteps(current, base)) && !sameSteps(current, source)
+	if old, updated := sectionsText(base), sectionsText(source); old != updated {
+		diff.Fields = append(diff.Fields, models.FieldChange{
+			Field:    "stepSections",
+			Old:      old,
+			New:      updated,
+			Conflict: stepsConflict,
+		})
+	}
+	for _, change := range diffSteps(base.Steps, source.Steps) {
+		change.Conflict = stepsConflict
+		diff.Steps = append(diff.Steps, change)
+	}
+
+	return diff
+}
+
+// MergeRecipeSource applies the changes at a recipe's source to the recipe,
+// keeping the user's edits where they conflict, and makes the source the
+// recipe's new snapshot. Recipes without a snapshot are left as they are.
+func MergeRecipeSource(recipe models.Recipe, source models.RecipeSnapshot) models.Recipe {
+	merged := recipe
+	merged.Source = &source
+	if recipe.Source == nil {
+		return merged
+	}
+
+	base := *recipe.Source
+	current := SnapshotRecipe(recipe)
+	for _, field := range recipeFields {
+		if field.text(current) == field.text(base) {
+			field.set(&merged, source)
+		}
+	}
+
+	merged.Ingredients = mergeIngredients(base.Ingredients, current.Ingredients, source.Ingredients)
+
+	// Steps are merged as a whole, step images follow their step number
+	if sameSteps(current, base) {
+		merged.Steps = source.Steps
+		merged.StepSections = source.StepSections
+		merged.StepImages = slices.DeleteFunc(slices.Clone(recipe.StepImages), func(image models.StepImage) bool {
+			return image.Step >= len(source.Steps)
+		})
+	}
+
+	return merged
+}
+
+// diffIngredients lists the ingredients added, changed and removed between
+// old and updated. Ingredients are matched by name.
+func diffIngredients(old, current, updated []models.Ingredient, known bool) []models.IngredientChange {
+	oldKeys, currentKeys, newKeys := ingredientKeys(old), ingredientKeys(current), ingredientKeys(updated)
+	oldByKey := keyedIngredients(old, oldKeys)
+	currentByKey := keyedIngredients(current, currentKeys)
+	newByKey := keyedIngredients(updated, newKeys)
+
+	// conflict reports whether the user edited an ingredient to something
+	// else than the source did
+	conflict := func(key string) bool {
+		edited := !known || !sameKeyedIngredient(oldByKey, currentByKey, key)
+		return edited && !sameKeyedIngredient(currentByKey, newByKey, key)
+	}
+
+	changes := []models.IngredientChange{}
+	for i, key := range newKeys {
+		ingredient := updated[i]
+		previous, found := oldByKey[key]
+		switch {
+		case !found:
+			changes = append(changes, models.IngredientChange{
+				Change:   models.RecipeChangeAdded,
+				New:      &ingredient,
+				Conflict: conflict(key),
+			})
+		case !reflect.DeepEqual(previous, ingredient):
+			changes = append(changes, models.IngredientChange{
+				Change:   models.RecipeChangeChanged,
+				Old:      &previous,
+				New:      &ingredient,
+				Conflict: conflict(key),
+			})
+		}
+	}
+	for i, key := range oldKeys {
+		if _, found := newByKey[key]; !found {
+			ingredient := old[i]
+			changes = append(changes, models.IngredientChange{
+				Change:   models.RecipeChangeRemoved,
+				Old:      &ingredient,
+				Conflict: conflict(key),
+			})
+		}
+	}
+	return changes
+}
+
+// mergeIngredients applies the changes between old and updated to the user's
+// current ingredients. Ingredients the user edited are kept as they are, new
+// ones are added after the ingredient they follow in updated.
+func mergeIngredients(old, current, updated []models.Ingredient) []models.Ingredient {
+	oldByKey := keyedIngredients(old, ingredientKeys(old))
+	newKeys := ingredientKeys(updated)
+	newByKey := keyedIngredients(updated, newKeys)
+
+	merged := make([]models.Ingredient, 0, len(current)+len(updated))
+	var mergedKeys []string
+	for i, key := range ingredientKeys(current) {
+		previous, found := oldByKey[key]
+		if !found || !reflect.DeepEqual(previous, current[i]) {
+			merged = append(merged, current[i])
+			mergedKeys = append(mergedKeys, key)
+		} else if ingredient, found := newByKey[key]; found {
+			merged = append(merged, ingredient)
+			mergedKeys = append(mergedKeys, key)
+		}
+	}
+
+	for i, key := range newKeys {
+		if _, found := oldByKey[key]; found || slices.Contains(mergedKeys, key) {
+			continue
+		}
+		at := 0
+		for j := i - 1; j >= 0; j-- {
+			if index := slices.Index(mergedKeys, newKeys[j]); index >= 0 {
+				at = index + 1
+				break
+			}
+		}
+		merged = slices.Insert(merged, at, updated[i])
+		mergedKeys = slices.Insert(mergedKeys, at, key)
+	}
+	return merged
+}
+
+// ingredientKeys returns the keys ingredients are matched by, their name in
+// lower case. Repeated names are numbered in order.
+func ingredientKeys(ingredients []models.Ingredient) []string {
+	seen := make(map[string]int)
+	keys := make([]string, len(ingredients))
+	for i, ingredient := range ingredients {
+		key := strings.ToLower(strings.Join(strings.Fields(ingredient.Name), " "))
+		seen[key]++
+		if seen[key] > 1 {
+			key = fmt.Sprintf("%s#%d", key, seen[key])
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func keyedIngredients(ingredients []models.Ingredient, keys []string) map[string]models.Ingredient {
+	byKey := make(map[string]models.Ingredient, len(ingredients))
+	for i, key := range keys {
+		byKey[key] = ingredients[i]
+	}
+	return byKey
+}
+
+// sameKeyedIngredient reports whether both lists have the same ingredient, or
+// neither has it
+func sameKeyedIngredient(a, b map[string]models.Ingredient, key string) bool {
+	ingredientA, foundA := a[key]
+	ingredientB, foundB := b[key]
+	return foundA == foundB && reflect.DeepEqual(ingredientA, ingredientB)
+}
+
+// diffSteps lists the steps added, removed and reworded between old and
+// updated. Steps in both are found with the longest common subsequence, a
+// step removed where another is added counts as reworded.
+func diffSteps(old, updated []string) []models.StepChange {
+	// common[i][j] is the length of the longest common subsequence of old[i:]
+	// and updated[j:]
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(updated)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(updated) - 1; j >= 0; j-- {
+			if old[i] == updated[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	changes := []models.StepChange{}
+	var removed, added []int
+	flush := func() {
+		reworded := min(len(removed), len(added))
+		for k := 0; k < reworded; k++ {
+			changes = append(changes, models.StepChange{
+				Change: models.RecipeChangeChanged,
+				Step:   added[k],
+				Old:    old[removed[k]],
+				New:    updated[added[k]],
+			})
+		}
+		for _, i := range removed[reworded:] {
+			changes = append(changes, models.StepChange{Change: models.RecipeChangeRemoved, Step: i, Old: old[i]})
+		}
+		for _, j := range added[reworded:] {
+			changes = append(changes, models.StepChange{Change: models.RecipeChangeAdded, Step: j, New: updated[j]})
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(old) || j < len(updated) {
+		switch {
+		case i < len(old) && j < len(updated) && old[i] == updated[j]:
+			flush()
+			i++
+			j++
+		case j < len(updated) && (i == len(old) || common[i][j+1] >= common[i+1][j]):
+			added = append(added, j)
+			j++
+		default:
+			removed = append(removed, i)
+			i++
+		}
+	}
+	flush()
+
+	return changes
+}
+
+func sameSteps(a, b models.RecipeSnapshot) bool {
+	return slices.Equal(a.Steps, b.Steps) && sectionsText(a) == sectionsText(b)
+}
+
+// sectionsText writes step sections like "Sauce (step 1), Pasta (step 3)"
+func sectionsText(recipe models.RecipeSnapshot) string {
+	sections := make([]string, len(recipe.StepSections))
+	for i, section := range recipe.StepSections {
+		sections[i] = fmt.Sprintf("%s (step %d)", section.Name, section.Step+1)
+	}
+	return strings.Join(sections, ", ")
+}
+
+func optionalText(text *string) string {
+	if text == nil {
+		return ""
+	}
+	return *text
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func quantity(amount float64, unit string) *models.Quantity {
+	return &models.Quantity{Min: &amount, Max: &amount, Unit: unit}
+}
+
+func importedPancakes() models.Recipe {
+	servings := 4
+	recipe := models.Recipe{
+		Name:     "Pancakes",
+		Servings: &servings,
+		Ingredients: []models.Ingredient{
+			{Name: "flour", Quantity: quantity(3, "dl")},
+			{Name: "milk", Quantity: quantity(6, "dl")},
+			{Name: "eggs", Quantity: quantity(3, "")},
+			{Name: "salt"},
+		},
+		Steps: []string{"Whisk the flour and milk.", "Add the eggs.", "Fry in butter."},
+	}
+	source := SnapshotRecipe(recipe)
+	recipe.Source = &source
+	return recipe
+}
+
+func changedPancakes() models.RecipeSnapshot {
+	servings := 6
+	source := SnapshotRecipe(importedPancakes())
+	source.Name = "Thin pancakes"
+	source.Servings = &servings
+	source.Ingredients = []models.Ingredient{
+		{Name: "flour", Quantity: quantity(4, "dl")},
+		{Name: "sugar", Quantity: quantity(1, "tbsp")},
+		{Name: "milk", Quantity: quantity(6, "dl")},
+		{Name: "eggs", Quantity: quantity(3, "")},
+	}
+	source.Steps = []string{"Whisk the flour, sugar and milk.", "Add the eggs.", "Let the batter rest.", "Fry in butter."}
+	return source
+}
+
+func TestDiffRecipeSource(t *testing.T) {
+	diff := DiffRecipeSource(importedPancakes(), changedPancakes())
+
+	assert.Equal(t, []models.FieldChange{
+		{Field: "name", Old: "Pancakes", New: "Thin pancakes"},
+		{Field: "servings", Old: "4", New: "6"},
+	}, diff.Fields)
+
+	require.Len(t, diff.Ingredients, 3)
+	assert.Equal(t, models.RecipeChangeChanged, diff.Ingredients[0].Change)
+	assert.Equal(t, "flour", diff.Ingredients[0].New.Name)
+	assert.Equal(t, models.RecipeChangeAdded, diff.Ingredients[1].Change)
+	assert.Equal(t, "sugar", diff.Ingredients[1].New.Name)
+	assert.Equal(t, models.RecipeChangeRemoved, diff.Ingredients[2].Change)
+	assert.Equal(t, "salt", diff.Ingredients[2].Old.Name)
+	for _, change := range diff.Ingredients {
+		assert.False(t, change.Conflict)
+	}
+
+	assert.Equal(t, []models.StepChange{
+		{Change: models.RecipeChangeChanged, Step: 0, Old: "Whisk the flour and milk.", New: "Whisk the flour, sugar and milk."},
+		{Change: models.RecipeChangeAdded, Step: 2, New: "Let the batter rest."},
+	}, diff.Steps)
+}
+
+func TestDiffRecipeSourceUnchanged(t *testing.T) {
+	recipe := importedPancakes()
+	assert.True(t, DiffRecipeSource(recipe, *recipe.Source).Empty())
+}
+
+func TestDiffRecipeSourceWithEdits(t *testing.T) {
+	recipe := importedPancakes()
+	servings := 2
+	recipe.Servings = &servings
+	recipe.Ingredients[0].Quantity = quantity(1.5, "dl")
+	recipe.Steps[0] = "Whisk the flour and milk until smooth."
+
+	diff := DiffRecipeSource(recipe, changedPancakes())
+
+	assert.False(t, diff.Fields[0].Conflict, "name")
+	assert.True(t, diff.Fields[1].Conflict, "servings")
+	assert.True(t, diff.Ingredients[0].Conflict, "flour")
+	assert.False(t, diff.Ingredients[1].Conflict, "sugar")
+	for _, change := range diff.Steps {
+		assert.True(t, change.Conflict)
+	}
+}
+
+func TestDiffRecipeSourceWithoutSnapshot(t *testing.T) {
+	recipe := importedPancakes()
+	recipe.Source = nil
+
+	diff := DiffRecipeSource(recipe, changedPancakes())
+	assert.False(t, diff.Empty())
+	for _, change := range diff.Fields {
+		assert.True(t, change.Conflict)
+	}
+	for _, change := range diff.Ingredients {
+		assert.True(t, change.Conflict)
+	}
+}
+
+func TestMergeRecipeSource(t *testing.T) {
+	source := changedPancakes()
+	merged := MergeRecipeSource(importedPancakes(), source)
+
+	assert.Equal(t, "Thin pancakes", merged.Name)
+	assert.Equal(t, 6, *merged.Servings)
+	assert.Equal(t, source.Ingredients, merged.Ingredients)
+	assert.Equal(t, source.Steps, merged.Steps)
+	assert.Equal(t, &source, merged.Source)
+}
+
+func TestMergeRecipeSourceKeepsEdits(t *testing.T) {
+	recipe := importedPancakes()
+	servings := 2
+	recipe.Servings = &servings
+	recipe.Ingredients[0].Quantity = quantity(1.5, "dl")
+	recipe.Ingredients = append(recipe.Ingredients, models.Ingredient{Name: "butter"})
+	recipe.Steps[2] = "Fry in plenty of butter."
+
+	merged := MergeRecipeSource(recipe, changedPancakes())
+
+	assert.Equal(t, "Thin pancakes", merged.Name)
+	assert.Equal(t, 2, *merged.Servings)
+	assert.Equal(t, []models.Ingredient{
+		{Name: "flour", Quantity: quantity(1.5, "dl")},
+		{Name: "sugar", Quantity: quantity(1, "tbsp")},
+		{Name: "milk", Quantity: quantity(6, "dl")},
+		{Name: "eggs", Quantity: quantity(3, "")},
+		{Name: "butter"},
+	}, merged.Ingredients)
+	assert.Equal(t, recipe.Steps, merged.Steps)
+}
+
+func TestMergeRecipeSourceWithoutSnapshot(t *testing.T) {
+	recipe := importedPancakes()
+	recipe.Source = nil
+	source := changedPancakes()
+
+	merged := MergeRecipeSource(recipe, source)
+
+	assert.Equal(t, recipe.Name, merged.Name)
+	assert.Equal(t, recipe.Ingredients, merged.Ingredients)
+	assert.Equal(t, &source, merged.Source)
+}
+
+func TestDiffSteps(t *testing.T) {
+	changes := diffSteps([]string{"a", "b", "c", "d"}, []string{"a", "c", "x", "d", "e"})
+	assert.Equal(t, []models.StepChange{
+		{Change: models.RecipeChangeRemoved, Step: 1, Old: "b"},
+		{Change: models.RecipeChangeAdded, Step: 2, New: "x"},
+		{Change: models.RecipeChangeAdded, Step: 4, New: "e"},
+	}, changes)
+}
@@ -1,9 +1,14 @@
 package utils
 
 import (
+	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
 )
 
 // RecipeToMarkdown converts a Recipe struct to formatted markdown
@@ -15,6 +20,11 @@ func RecipeToMarkdown(recipe models.Recipe) string {
 	md.WriteString(recipe.Name)
 	md.WriteString("\n\n")
 
+	// Image, served from the file store
+	if recipe.ImageID != nil {
+		md.WriteString(fmt.Sprintf("![%s](/files/%s)\n\n", recipe.Name, recipe.ImageID))
+	}
+
 	// Summary
 	if recipe.Summary != nil && *recipe.Summary != "" {
 		md.WriteString(*recipe.Summary)
@@ -36,6 +46,47 @@ func RecipeToMarkdown(recipe models.Recipe) string {
 		md.WriteString("\n")
 	}
 
+	if recipe.CookTime != nil && *recipe.CookTime != "" {
+		md.WriteString("**Cook Time:** ")
+		md.WriteString(*recipe.CookTime)
+		md.WriteString("\n")
+	}
+
+	// The total is prep + cook time when the recipe doesn't state it
+	if recipe.TotalTime != nil && *recipe.TotalTime != "" {
+		md.WriteString("**Total Time:** ")
+		md.WriteString(*recipe.TotalTime)
+		md.WriteString("\n")
+	} else if recipe.TotalMinutes != nil && recipe.PrepMinutes != nil && recipe.CookMinutes != nil {
+		md.WriteString("**Total Time:** ")
+		md.WriteString(FormatDuration(time.Duration(*recipe.TotalMinutes) * time.Minute))
+		md.WriteString("\n")
+	}
+
+	if recipe.Cuisine != nil && *recipe.Cuisine != "" {
+		md.WriteString("**Cuisine:** ")
+		md.WriteString(*recipe.Cuisine)
+		md.WriteString("\n")
+	}
+
+	if recipe.Course != nil && *recipe.Course != "" {
+		md.WriteString("**Course:** ")
+		md.WriteString(*recipe.Course)
+		md.WriteString("\n")
+	}
+
+	if len(recipe.Dietary) > 0 {
+		md.WriteString("**Dietary:** ")
+		md.WriteString(strings.Join(recipe.Dietary, ", "))
+		md.WriteString("\n")
+	}
+
+	if len(recipe.Equipment) > 0 {
+		md.WriteString("**Equipment:** ")
+		md.WriteString(strings.Join(recipe.Equipment, ", "))
+		md.WriteString("\n")
+	}
+
 	if recipe.SourceURL != nil && *recipe.SourceURL != "" {
 		md.WriteString("**Source:** ")
 		md.WriteString(*recipe.SourceURL)
@@ -79,16 +130,99 @@ func RecipeToMarkdown(recipe models.Recipe) string {
 
 	// Instructions section
 	md.WriteString("## Instructions\n\n")
+	stepImages := make(map[int]uuid.UUID, len(recipe.StepImages))
+	for _, image := range recipe.StepImages {
+		stepImages[image.Step] = image.FileID
+	}
+	sections := make(map[int]string, len(recipe.StepSections))
+	for _, section := range recipe.StepSections {
+		sections[section.Step] = section.Name
+	}
 	for i, step := range recipe.Steps {
+		// Numbering continues across sections
+		if name, ok := sections[i]; ok {
+			if i > 0 {
+				md.WriteString("\n")
+			}
+			md.WriteString("### " + name + "\n\n")
+		}
 		md.WriteString(strconv.Itoa(i + 1))
 		md.WriteString(". ")
 		md.WriteString(step)
 		md.WriteString("\n")
+		// Indented to stay part of the list item
+		if fileID, ok := stepImages[i]; ok {
+			md.WriteString(fmt.Sprintf("   ![Step %d](/files/%s)\n", i+1, fileID))
+		}
+	}
+
+	// Nutrition section, only when an estimate was made
+	if recipe.Nutrition != nil {
+		md.WriteString("\n")
+		writeNutritionMarkdown(&md, *recipe.Nutrition)
 	}
 
 	return md.String()
 }
 
+// writeNutritionMarkdown writes a nutrition estimate as a table, followed by
+// the ingredients left out of it
+func writeNutritionMarkdown(md *strings.Builder, nutrition models.RecipeNutrition) {
+	md.WriteString("## Nutrition\n\n")
+	md.WriteString("| | Calories | Protein | Fat | Carbs |\n")
+	md.WriteString("| --- | --- | --- | --- | --- |\n")
+	if nutrition.PerServing != nil {
+		writeNutrientsRow(md, "Per serving", *nutrition.PerServing)
+	}
+	writeNutrientsRow(md, "Total", nutrition.Total)
+
+	var missing []string
+	for _, ingredient := range nutrition.Ingredients {
+		switch ingredient.Status {
+		case models.NutritionUnknownFood:
+			missing = append(missing, ingredient.Name+" (unknown)")
+		case models.NutritionUnknownAmount:
+			missing = append(missing, ingredient.Name+" (no amount)")
+		}
+	}
+
+	md.WriteString("\n*Estimated from ")
+	md.WriteString(strconv.Itoa(int(math.Round(nutrition.Confidence * 100))))
+	md.WriteString("% of the ingredients.")
+	if len(missing) > 0 {
+		md.WriteString(" Not included: ")
+		md.WriteString(strings.Join(missing, ", "))
+		md.WriteString(".")
+	}
+	md.WriteString("*\n")
+}
+
+func writeNutrientsRow(md *strings.Builder, label string, nutrients models.Nutrients) {
+	md.WriteString("| " + label)
+	md.WriteString(" | " + formatNumber(nutrients.Calories) + " kcal")
+	md.WriteString(" | " + formatNumber(nutrients.Protein) + " g")
+	md.WriteString(" | " + formatNumber(nutrients.Fat) + " g")
+	md.WriteString(" | " + formatNumber(nutrients.Carbs) + " g |\n")
+}
+
+// FormatIngredient writes an ingredient as a single line, like
+// "2 dl milk (optional), lukewarm", which ParseIngredient reads back
+func FormatIngredient(ingredient models.Ingredient) string {
+	text := ingredient.Name
+	if ingredient.Quantity != nil {
+		if quantity := FormatQuantity(*ingredient.Quantity); quantity != "" {
+			text = quantity + " " + text
+		}
+	}
+	if ingredient.IsOptional {
+		text += " (optional)"
+	}
+	if ingredient.Notes != "" {
+		text += ", " + ingredient.Notes
+	}
+	return text
+}
+
 // FormatQuantity formats a Quantity struct into a readable string
 func FormatQuantity(q models.Quantity) string {
 	if q.Min == nil && q.Max == nil {
@@ -101,17 +235,17 @@ func FormatQuantity(q models.Quantity) string {
 		// Range: "1-2 cups"
 		if *q.Min == *q.Max {
 			// Same min/max: "1 cup"
-			quantityStr = formatNumber(*q.Min)
+			quantityStr = formatAmount(*q.Min, q.Unit)
 		} else {
 			// Different min/max: "1-2 cups"
-			quantityStr = formatNumber(*q.Min) + "-" + formatNumber(*q.Max)
+			quantityStr = formatAmount(*q.Min, q.Unit) + "-" + formatAmount(*q.Max, q.Unit)
 		}
 	} else if q.Min != nil {
 		// Only minimum: "at least 1 cup"
-		quantityStr = "at least " + formatNumber(*q.Min)
+		quantityStr = "at least " + formatAmount(*q.Min, q.Unit)
 	} else if q.Max != nil {
 		// Only maximum: "up to 2 cups"
-		quantityStr = "up to " + formatNumber(*q.Max)
+		quantityStr = "up to " + formatAmount(*q.Max, q.Unit)
 	}
 
 	// Add unit
@@ -124,13 +258,38 @@ func FormatQuantity(q models.Quantity) string {
 	return quantityStr
 }
 
+// formatAmount formats US units as fractions, like "1 1/2 cups", and other
+// units as decimals
+func formatAmount(n float64, unit string) string {
+	if canonical, _ := NormalizeUnit(unit); usUnits[canonical] {
+		if fraction, ok := formatFraction(n); ok {
+			return fraction
+		}
+	}
+	return formatNumber(n)
+}
+
+// formatFraction formats amounts close to a measuring fraction, like 0.333
+func formatFraction(n float64) (string, bool) {
+	whole := math.Floor(n)
+	names := map[float64]string{1.0 / 8: "1/8", 1.0 / 4: "1/4", 1.0 / 3: "1/3", 1.0 / 2: "1/2", 2.0 / 3: "2/3", 3.0 / 4: "3/4"}
+	for fraction, name := range names {
+		if math.Abs(n-whole-fraction) < 0.01 {
+			if whole == 0 {
+				return name, true
+			}
+			return strconv.Itoa(int(whole)) + " " + name, true
+		}
+	}
+	return "", false
+}
+
 // formatNumber formats a float64 to remove unnecessary decimal places
 func formatNumber(n float64) string {
 	if n == float64(int(n)) {
 		// Whole number
 		return strconv.Itoa(int(n))
 	}
-	// Has decimal places
-	return strconv.FormatFloat(n, 'g', -1, 64)
+	// Has decimal places, amounts like 1/3 are shown as 0.33
+	return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
 }
-
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecipeToMarkdownNutrition(t *testing.T) {
+	recipe := models.Recipe{
+		Name:        "Omelette",
+		Ingredients: []models.Ingredient{{Name: "eggs", Quantity: qty(3, 3, "")}},
+		Steps:       []string{"Whisk and fry."},
+	}
+
+	assert.NotContains(t, RecipeToMarkdown(recipe), "## Nutrition")
+
+	recipe.Nutrition = &models.RecipeNutrition{
+		Total:      models.Nutrients{Calories: 430, Protein: 37.8, Fat: 28.5, Carbs: 2.1},
+		PerServing: &models.Nutrients{Calories: 215, Protein: 18.9, Fat: 14.3, Carbs: 1.1},
+		Confidence: 0.5,
+		Ingredients: []models.IngredientNutrition{
+			{Name: "eggs", Status: models.NutritionEstimated},
+			{Name: "truffle", Status: models.NutritionUnknownFood},
+			{Name: "chives", Status: models.NutritionOptional},
+		},
+	}
+
+	expected := "1. Whisk and fry.\n\n" +
+		"## Nutrition\n\n" +
+		"| | Calories | Protein | Fat | Carbs |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| Per serving | 215 kcal | 18.9 g | 14.3 g | 1.1 g |\n" +
+		"| Total | 430 kcal | 37.8 g | 28.5 g | 2.1 g |\n" +
+		"\n*Estimated from 50% of the ingredients. Not included: truffle (unknown).*\n"
+	assert.Contains(t, RecipeToMarkdown(recipe), expected)
+}
+
+func TestRecipeToMarkdownMetadata(t *testing.T) {
+	prepTime, cookTime, cuisine, course := "15 minutes", "1 hour", "Indian", "dinner"
+	recipe := NormalizeRecipeMetadata(models.Recipe{
+		Name:        "Dal",
+		PrepTime:    &prepTime,
+		CookTime:    &cookTime,
+		Cuisine:     &cuisine,
+		Course:      &course,
+		Equipment:   []string{"Pressure cooker"},
+		Ingredients: []models.Ingredient{{Name: "Red lentils"}, {Name: "Ghee"}},
+	})
+
+	expected := "## Recipe Info\n\n" +
+		"**Prep Time:** 15 minutes\n" +
+		"**Cook Time:** 1 hour\n" +
+		"**Total Time:** 1 hour 15 minutes\n" +
+		"**Cuisine:** Indian\n" +
+		"**Course:** dinner\n" +
+		"**Dietary:** vegetarian, gluten-free\n" +
+		"**Equipment:** Pressure cooker\n\n"
+	assert.Contains(t, RecipeToMarkdown(recipe), expected)
+}
+
+func TestRecipeToMarkdownImages(t *testing.T) {
+	imageID := uuid.MustParse("6f1c2d3e-4a5b-4c6d-8e7f-9a0b1c2d3e4f")
+	stepImageID := uuid.MustParse("0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d")
+	recipe := models.Recipe{
+		Name:       "Omelette",
+		ImageID:    &imageID,
+		Steps:      []string{"Whisk the eggs.", "Fry."},
+		StepImages: []models.StepImage{{Step: 1, FileID: stepImageID}},
+	}
+
+	markdown := RecipeToMarkdown(recipe)
+	assert.Contains(t, markdown, "# Omelette\n\n![Omelette](/files/6f1c2d3e-4a5b-4c6d-8e7f-9a0b1c2d3e4f)\n\n## Recipe Info")
+	assert.Contains(t, markdown, "1. Whisk the eggs.\n2. Fry.\n   ![Step 2](/files/0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d)\n")
+}
+
+func TestRecipeToMarkdownStepSections(t *testing.T) {
+	recipe := models.Recipe{
+		Name:         "Lasagna",
+		Steps:        []string{"Brown the mince.", "Add the tomatoes.", "Melt the butter.", "Whisk in the flour."},
+		StepSections: []models.StepSection{{Step: 0, Name: "Meat sauce"}, {Step: 2, Name: "Béchamel"}},
+	}
+
+	expected := "## Instructions\n\n" +
+		"### Meat sauce\n\n" +
+		"1. Brown the mince.\n" +
+		"2. Add the tomatoes.\n\n" +
+		"### Béchamel\n\n" +
+		"3. Melt the butter.\n" +
+		"4. Whisk in the flour.\n"
+	assert.Contains(t, RecipeToMarkdown(recipe), expected)
+}
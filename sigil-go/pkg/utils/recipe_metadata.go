@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"tofoss/sigil-go/pkg/models"
+)
+
+// durationPartRegex matches the parts of durations like "1 hour 30 minutes",
+// "45 min" or "1,5 timer"
+var durationPartRegex = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(hours?|hrs?|h|timer?|t|minutes?|mins?|minutter?|m)\b`)
+
+// ParseDuration reads free text durations like "1 hour 30 minutes", returning
+// zero when there is no duration in the text
+func ParseDuration(text string) time.Duration {
+	var duration time.Duration
+	for _, match := range durationPartRegex.FindAllStringSubmatch(text, -1) {
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", "."), 64)
+		if err != nil {
+			continue
+		}
+		unit := time.Minute
+		if strings.HasPrefix(strings.ToLower(match[2]), "h") || strings.HasPrefix(strings.ToLower(match[2]), "t") {
+			unit = time.Hour
+		}
+		duration += time.Duration(amount * float64(unit))
+	}
+	return duration.Round(time.Minute)
+}
+
+// FormatDuration formats a duration the way recipes state times, e.g.
+// "1 hour 30 minutes"
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d / time.Hour)
+	minutes := int((d % time.Hour) / time.Minute)
+
+	var parts []string
+	if hours > 0 {
+		parts = append(parts, pluralize(hours, "hour"))
+	}
+	if minutes > 0 || hours == 0 {
+		parts = append(parts, pluralize(minutes, "minute"))
+	}
+	return strings.Join(parts, " ")
+}
+
+func pluralize(count int, unit string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, unit)
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// NormalizeRecipeMetadata fills in the structured metadata of a recipe before
+// it's saved. The minutes are parsed from the time texts, with the total
+// falling back to prep + cook time. Dietary labels are inferred from the
+// ingredients unless the user set them. Step sections are kept in step order.
+func NormalizeRecipeMetadata(recipe models.Recipe) models.Recipe {
+	recipe.PrepTime = trimmedText(recipe.PrepTime)
+	recipe.CookTime = trimmedText(recipe.CookTime)
+	recipe.TotalTime = trimmedText(recipe.TotalTime)
+	recipe.PrepMinutes = durationMinutes(recipe.PrepTime)
+	recipe.CookMinutes = durationMinutes(recipe.CookTime)
+	recipe.TotalMinutes = durationMinutes(recipe.TotalTime)
+	if recipe.TotalMinutes == nil && (recipe.PrepMinutes != nil || recipe.CookMinutes != nil) {
+		total := 0
+		for _, minutes := range []*int{recipe.PrepMinutes, recipe.CookMinutes} {
+			if minutes != nil {
+				total += *minutes
+			}
+		}
+		recipe.TotalMinutes = &total
+	}
+
+	recipe.Cuisine = trimmedText(recipe.Cuisine)
+	if course := trimmedText(recipe.Course); course != nil {
+		lower := strings.ToLower(*course)
+		recipe.Course = &lower
+	} else {
+		recipe.Course = nil
+	}
+
+	if recipe.DietaryManual {
+		recipe.Dietary = NormalizeDietary(recipe.Dietary)
+	} else {
+		recipe.Dietary = InferDietary(recipe.Ingredients)
+	}
+
+	equipment := []string{}
+	for _, item := range recipe.Equipment {
+		item = strings.TrimSpace(item)
+		duplicate := slices.ContainsFunc(equipment, func(existing string) bool {
+			return strings.EqualFold(existing, item)
+		})
+		if item != "" && !duplicate {
+			equipment = append(equipment, item)
+		}
+	}
+	recipe.Equipment = equipment
+
+	recipe.StepSections = NormalizeStepSections(recipe.StepSections, len(recipe.Steps))
+
+	return recipe
+}
+
+// NormalizeDietary returns the known labels among labels in the order of
+// models.DietaryLabels. Vegan recipes are vegetarian and dairy-free too.
+func NormalizeDietary(labels []string) []string {
+	set := map[string]bool{}
+	for _, label := range labels {
+		set[strings.ToLower(strings.TrimSpace(label))] = true
+	}
+	if set[models.DietaryVegan] {
+		set[models.DietaryVegetarian] = true
+		set[models.DietaryDairyFree] = true
+	}
+
+	normalized := []string{}
+	for _, label := range models.DietaryLabels {
+		if set[label] {
+			normalized = append(normalized, label)
+		}
+	}
+	return normalized
+}
+
+// trimmedText trims text, returning nil when nothing is left
+func trimmedText(text *string) *string {
+	if text == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*text)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// durationMinutes parses a time text into whole minutes, or nil when it
+// doesn't state a duration
+func durationMinutes(text *string) *int {
+	if text == nil {
+		return nil
+	}
+	duration := ParseDuration(*text)
+	if duration <= 0 {
+		return nil
+	}
+	minutes := int(duration / time.Minute)
+	return &minutes
+}
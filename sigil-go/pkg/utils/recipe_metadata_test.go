@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"1 hour 30 minutes": 90 * time.Minute,
+		"45 min":            45 * time.Minute,
+		"2 hrs":             2 * time.Hour,
+		"1,5 timer":         90 * time.Minute,
+		"1 t 15 min":        75 * time.Minute,
+		"overnight":         0,
+	}
+
+	for text, expected := range tests {
+		assert.Equal(t, expected, ParseDuration(text), text)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Minute:               "1 minute",
+		45 * time.Minute:          "45 minutes",
+		time.Hour:                 "1 hour",
+		2*time.Hour + time.Minute: "2 hours 1 minute",
+		20 * time.Second:          "0 minutes",
+	}
+
+	for duration, expected := range tests {
+		assert.Equal(t, expected, FormatDuration(duration))
+	}
+}
+
+func TestNormalizeRecipeMetadata(t *testing.T) {
+	prepTime, cookTime, overnight := " 15 min ", "1 hour", "overnight"
+	cuisine, course := " Italian ", "Dinner"
+	stale := 999
+
+	recipe := NormalizeRecipeMetadata(models.Recipe{
+		PrepTime:     &prepTime,
+		CookTime:     &cookTime,
+		TotalTime:    &overnight,
+		TotalMinutes: &stale,
+		Cuisine:      &cuisine,
+		Course:       &course,
+		Equipment:    []string{"Oven", " oven", "", "Baking sheet"},
+		Ingredients:  []models.Ingredient{{Name: "Spaghetti"}, {Name: "Tomatoes"}, {Name: "Parmesan", IsOptional: true}},
+	})
+
+	require.NotNil(t, recipe.PrepTime)
+	assert.Equal(t, "15 min", *recipe.PrepTime)
+	assert.Equal(t, 15, *recipe.PrepMinutes)
+	assert.Equal(t, 60, *recipe.CookMinutes)
+	// The total time text doesn't state a duration, so prep + cook is used
+	assert.Equal(t, "overnight", *recipe.TotalTime)
+	assert.Equal(t, 75, *recipe.TotalMinutes)
+	assert.Equal(t, "Italian", *recipe.Cuisine)
+	assert.Equal(t, "dinner", *recipe.Course)
+	assert.Equal(t, []string{"Oven", "Baking sheet"}, recipe.Equipment)
+	assert.Equal(t, []string{models.DietaryVegetarian, models.DietaryVegan, models.DietaryDairyFree}, recipe.Dietary)
+
+	empty := NormalizeRecipeMetadata(models.Recipe{Cuisine: new(string)})
+	assert.Nil(t, empty.TotalMinutes)
+	assert.Nil(t, empty.Cuisine)
+	assert.Equal(t, []string{}, empty.Equipment)
+	assert.Equal(t, []string{}, empty.Dietary)
+}
+
+func TestNormalizeRecipeMetadataManualDietary(t *testing.T) {
+	recipe := NormalizeRecipeMetadata(models.Recipe{
+		DietaryManual: true,
+		Dietary:       []string{"Vegan", "gluten-free", "keto"},
+		Ingredients:   []models.Ingredient{{Name: "Bread"}},
+	})
+
+	assert.Equal(t, []string{models.DietaryVegetarian, models.DietaryVegan, models.DietaryGlutenFree, models.DietaryDairyFree}, recipe.Dietary)
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"tofoss/sigil-go/pkg/models"
+)
+
+// UnitSystem is the system recipe quantities are converted to
+type UnitSystem string
+
+const (
+	UnitSystemOriginal UnitSystem = "original"
+	UnitSystemMetric   UnitSystem = "metric"
+	UnitSystemUS       UnitSystem = "us"
+)
+
+// ParseUnitSystem parses a unit system name, defaulting to the recipe's own
+// units
+func ParseUnitSystem(name string) (UnitSystem, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "original", "as-is":
+		return UnitSystemOriginal, nil
+	case "metric":
+		return UnitSystemMetric, nil
+	case "us", "imperial":
+		return UnitSystemUS, nil
+	}
+	return "", fmt.Errorf("unknown unit system %q", name)
+}
+
+// ScaleRecipe returns a copy of the recipe with ingredient quantities
+// multiplied by factor and converted to the unit system
+func ScaleRecipe(recipe models.Recipe, factor float64, system UnitSystem) models.Recipe {
+	scaled := recipe
+	if recipe.Servings != nil {
+		servings := int(math.Max(1, math.Round(float64(*recipe.Servings)*factor)))
+		scaled.Servings = &servings
+	}
+
+	scaled.Ingredients = make([]models.Ingredient, len(recipe.Ingredients))
+	for i, ingredient := range recipe.Ingredients {
+		if ingredient.Quantity != nil {
+			quantity := ScaleQuantity(*ingredient.Quantity, ingredient.Name, factor, system)
+			ingredient.Quantity = &quantity
+		}
+		scaled.Ingredients[i] = ingredient
+	}
+
+	return scaled
+}
+
+// ScaleQuantity multiplies a quantity by factor and converts it to the unit
+// system. Changed quantities are rounded to amounts that can be measured.
+func ScaleQuantity(q models.Quantity, ingredientName string, factor float64, system UnitSystem) models.Quantity {
+	scaled := models.Quantity{Unit: q.Unit}
+	if q.Min != nil {
+		min := *q.Min * factor
+		scaled.Min = &min
+	}
+	if q.Max != nil {
+		max := *q.Max * factor
+		scaled.Max = &max
+	}
+
+	converted := convertQuantity(scaled, ingredientName, system)
+	if factor == 1 && converted.Unit == q.Unit {
+		return converted
+	}
+
+	unit, _ := NormalizeUnit(converted.Unit)
+	if converted.Min != nil {
+		min := roundAmount(*converted.Min, unit)
+		converted.Min = &min
+	}
+	if converted.Max != nil {
+		max := roundAmount(*converted.Max, unit)
+		converted.Max = &max
+	}
+	return converted
+}
+
+// convertQuantity converts mass and volume units to the unit system, using
+// the density of staples to convert between mass and volume
+func convertQuantity(q models.Quantity, ingredientName string, system UnitSystem) models.Quantity {
+	unit, known := NormalizeUnit(q.Unit)
+	if system == UnitSystemOriginal || !known {
+		return q
+	}
+
+	grams, isMass := massUnitGrams[unit]
+	milliliters, isVolume := volumeUnitMilliliters[unit]
+	density, hasDensity := ingredientDensity(ingredientName)
+
+	var toUnit func(float64) (string, float64)
+	var size float64
+	switch {
+	case system == UnitSystemMetric && isMass && usUnits[unit]:
+		size, toUnit = grams, metricMassUnit
+	case system == UnitSystemMetric && isVolume && usUnits[unit] && unit != "tsp" && unit != "tbsp":
+		if hasDensity {
+			size, toUnit = milliliters*density, metricMassUnit
+		} else {
+			size, toUnit = milliliters, metricVolumeUnit
+		}
+	case system == UnitSystemUS && isMass && !usUnits[unit]:
+		if hasDensity {
+			size, toUnit = grams/density, usVolumeUnit
+		} else {
+			size, toUnit = grams, usMassUnit
+		}
+	case system == UnitSystemUS && isVolume && !usUnits[unit]:
+		size, toUnit = milliliters, usVolumeUnit
+	default:
+		return q
+	}
+
+	// Pick the unit from the largest amount so ranges keep a single unit
+	reference := q.Max
+	if reference == nil {
+		reference = q.Min
+	}
+	if reference == nil {
+		return q
+	}
+	newUnit, newSize := toUnit(*reference * size)
+
+	converted := models.Quantity{Unit: newUnit}
+	if q.Min != nil {
+		min := *q.Min * size / newSize
+		converted.Min = &min
+	}
+	if q.Max != nil {
+		max := *q.Max * size / newSize
+		converted.Max = &max
+	}
+	return converted
+}
+
+func metricMassUnit(grams float64) (string, float64) {
+	if grams >= 1000 {
+		return "kg", massUnitGrams["kg"]
+	}
+	return "g", 1
+}
+
+func metricVolumeUnit(milliliters float64) (string, float64) {
+	switch {
+	case milliliters >= 1000:
+		return "l", volumeUnitMilliliters["l"]
+	case milliliters >= 100:
+		return "dl", volumeUnitMilliliters["dl"]
+	}
+	return "ml", 1
+}
+
+func usMassUnit(grams float64) (string, float64) {
+	if grams >= massUnitGrams["lb"] {
+		return "lb", massUnitGrams["lb"]
+	}
+	return "oz", massUnitGrams["oz"]
+}
+
+func usVolumeUnit(milliliters float64) (string, float64) {
+	switch {
+	case milliliters >= volumeUnitMilliliters["cup"]/4:
+		return "cup", volumeUnitMilliliters["cup"]
+	case milliliters >= volumeUnitMilliliters["tbsp"]:
+		return "tbsp", volumeUnitMilliliters["tbsp"]
+	}
+	return "tsp", volumeUnitMilliliters["tsp"]
+}
+
+// Fractions US measuring cups and spoons come in
+var measuringFractions = []float64{0, 1.0 / 8, 1.0 / 4, 1.0 / 3, 1.0 / 2, 2.0 / 3, 3.0 / 4, 1}
+
+// roundAmount rounds to fractions for US units, to halves for counts and to
+// steps that fit the size for metric units, e.g. 473 ml to 475 ml
+func roundAmount(amount float64, unit string) float64 {
+	switch {
+	case amount <= 0:
+		return amount
+	case usUnits[unit]:
+		return roundToFraction(amount)
+	case unit == "g" || unit == "ml":
+		return roundToStep(amount)
+	case massUnitGrams[unit] > 0 || volumeUnitMilliliters[unit] > 0:
+		return roundSignificant(amount, 2)
+	case amount >= 1:
+		// Counts, like eggs or cloves of garlic
+		return math.Round(amount*2) / 2
+	}
+	return roundToFraction(amount)
+}
+
+func roundToStep(amount float64) float64 {
+	var step float64
+	switch {
+	case amount < 1:
+		return roundSignificant(amount, 2)
+	case amount < 10:
+		step = 0.5
+	case amount < 100:
+		step = 1
+	case amount < 1000:
+		step = 5
+	default:
+		step = 10
+	}
+	return math.Round(amount/step) * step
+}
+
+func roundToFraction(amount float64) float64 {
+	whole := math.Floor(amount)
+	fraction := amount - whole
+
+	closest := measuringFractions[0]
+	for _, f := range measuringFractions {
+		if math.Abs(fraction-f) < math.Abs(fraction-closest) {
+			closest = f
+		}
+	}
+	if whole == 0 && closest == 0 {
+		// Don't round a pinch away
+		closest = measuringFractions[1]
+	}
+	return whole + closest
+}
+
+func roundSignificant(amount float64, digits int) float64 {
+	// Divide by a whole number to avoid results like 1.3000000000000003
+	scale := math.Pow(10, float64(digits-1)-math.Floor(math.Log10(amount)))
+	if scale >= 1 {
+		return math.Round(amount*scale) / scale
+	}
+	return math.Round(amount*scale) * (1 / scale)
+}
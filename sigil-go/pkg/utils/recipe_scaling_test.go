@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaleQuantity(t *testing.T) {
+	tests := []struct {
+		name       string
+		quantity   *models.Quantity
+		ingredient string
+		factor     float64
+		system     UnitSystem
+		expected   *models.Quantity
+	}{
+		{"unchanged", qty(237, 237, "ml"), "milk", 1, UnitSystemOriginal, qty(237, 237, "ml")},
+		{"doubled", qty(200, 200, "g"), "flour", 2, UnitSystemOriginal, qty(400, 400, "g")},
+		{"range", qty(1, 2, "tbsp"), "soy sauce", 1.5, UnitSystemOriginal, qty(1.5, 3, "tbsp")},
+		{"rounded grams", qty(125, 125, "g"), "cheese", 1.5, UnitSystemOriginal, qty(190, 190, "g")},
+		{"counts in halves", qty(3, 3, ""), "eggs", 1.5, UnitSystemOriginal, qty(4.5, 4.5, "")},
+		{"cups as fractions", qty(1, 1, "cup"), "milk", 1.0 / 3, UnitSystemOriginal, qty(1.0/3, 1.0/3, "cup")},
+		{"at least", &models.Quantity{Min: floatPtr(1), Unit: "l"}, "water", 2, UnitSystemOriginal, &models.Quantity{Min: floatPtr(2), Unit: "l"}},
+
+		{"cups of milk to metric", qty(2, 2, "cups"), "milk", 1, UnitSystemMetric, qty(4.7, 4.7, "dl")},
+		{"cups of flour to grams", qty(1, 1, "cup"), "all-purpose flour", 1, UnitSystemMetric, qty(125, 125, "g")},
+		{"pounds to grams", qty(2, 2, "lb"), "ground beef", 1, UnitSystemMetric, qty(905, 905, "g")},
+		{"pounds to kilograms", qty(3, 3, "lb"), "potatoes", 1, UnitSystemMetric, qty(1.4, 1.4, "kg")},
+		{"ounces to grams", qty(8, 8, "oz"), "cream cheese", 1, UnitSystemMetric, qty(225, 225, "g")},
+		{"spoons stay", qty(2, 2, "tbsp"), "sugar", 1, UnitSystemMetric, qty(2, 2, "tbsp")},
+		{"metric stays metric", qty(3, 3, "dl"), "melk", 1, UnitSystemMetric, qty(3, 3, "dl")},
+
+		{"grams of butter to cups", qty(227, 227, "g"), "butter", 1, UnitSystemUS, qty(1, 1, "cup")},
+		{"grams of sugar to cups", qty(100, 100, "g"), "sukker", 1, UnitSystemUS, qty(0.5, 0.5, "cup")},
+		{"grams to ounces", qty(200, 200, "g"), "cheese", 1, UnitSystemUS, qty(7, 7, "oz")},
+		{"kilograms to pounds", qty(1, 1, "kg"), "potatoes", 1, UnitSystemUS, qty(2.25, 2.25, "lb")},
+		{"deciliters to cups", qty(1, 2, "dl"), "fløte", 1, UnitSystemUS, qty(0.5, 0.75, "cup")},
+		{"milliliters to teaspoons", qty(5, 5, "ml"), "vanilla", 1, UnitSystemUS, qty(1, 1, "tsp")},
+		{"unknown units stay", qty(2, 2, "fedd"), "hvitløk", 2, UnitSystemUS, qty(4, 4, "fedd")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaled := ScaleQuantity(*tt.quantity, tt.ingredient, tt.factor, tt.system)
+			assert.Equal(t, tt.expected.Unit, scaled.Unit)
+			if tt.expected.Min != nil {
+				require.NotNil(t, scaled.Min)
+				assert.InDelta(t, *tt.expected.Min, *scaled.Min, 0.001)
+			} else {
+				assert.Nil(t, scaled.Min)
+			}
+			if tt.expected.Max != nil {
+				require.NotNil(t, scaled.Max)
+				assert.InDelta(t, *tt.expected.Max, *scaled.Max, 0.001)
+			} else {
+				assert.Nil(t, scaled.Max)
+			}
+		})
+	}
+}
+
+func TestScaleRecipe(t *testing.T) {
+	servings := 4
+	recipe := models.Recipe{
+		Name:     "Pancakes",
+		Servings: &servings,
+		Ingredients: []models.Ingredient{
+			{Name: "flour", Quantity: qty(1.5, 1.5, "cups")},
+			{Name: "eggs", Quantity: qty(2, 2, "")},
+			{Name: "salt", Notes: "to taste"},
+		},
+	}
+
+	scaled := ScaleRecipe(recipe, 1.5, UnitSystemOriginal)
+
+	require.NotNil(t, scaled.Servings)
+	assert.Equal(t, 6, *scaled.Servings)
+	assert.Equal(t, 2.25, *scaled.Ingredients[0].Quantity.Min)
+	assert.Equal(t, 3.0, *scaled.Ingredients[1].Quantity.Max)
+	assert.Nil(t, scaled.Ingredients[2].Quantity)
+
+	// The original recipe is left as it was
+	assert.Equal(t, 4, *recipe.Servings)
+	assert.Equal(t, 1.5, *recipe.Ingredients[0].Quantity.Min)
+
+	assert.Contains(t, RecipeToMarkdown(scaled), "- 2 1/4 cups flour\n")
+}
+
+func TestParseUnitSystem(t *testing.T) {
+	for name, expected := range map[string]UnitSystem{"": UnitSystemOriginal, "as-is": UnitSystemOriginal, "Metric": UnitSystemMetric, "us": UnitSystemUS} {
+		system, err := ParseUnitSystem(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, system)
+	}
+
+	_, err := ParseUnitSystem("cubits")
+	assert.Error(t, err)
+}
+
+func TestFormatQuantity(t *testing.T) {
+	tests := map[string]models.Quantity{
+		"1/2 cup":        *qty(0.5, 0.5, "cup"),
+		"1 1/3 cups":     *qty(4.0/3, 4.0/3, "cups"),
+		"1-1 1/2 tbsp":   *qty(1, 1.5, "tbsp"),
+		"0.33 l":         *qty(1.0/3, 1.0/3, "l"),
+		"2.5 dl":         *qty(2.5, 2.5, "dl"),
+		"at least 200 g": {Min: floatPtr(200), Unit: "g"},
+		"up to 3":        {Max: floatPtr(3)},
+	}
+
+	for expected, quantity := range tests {
+		assert.Equal(t, expected, FormatQuantity(quantity))
+	}
+}
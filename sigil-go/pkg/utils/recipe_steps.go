@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"tofoss/sigil-go/pkg/models"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// stepTimerRegex matches durations in step text like "20 minutes",
+	// "10-15 min", "1½ hours" or "2 timer", in English and Norwegian
+	stepTimerRegex = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?|\d*½)(?:\s*(?:-|–|to|til|or|eller)\s*(\d+(?:[.,]\d+)?|\d*½))?\s*(seconds?|secs?|sekunder|sekund|minutes?|mins?|minutter|minutt|min|hours?|hrs?|timer|time)\b`)
+	// wordTimerRegex matches durations written as words
+	wordTimerRegex = regexp.MustCompile(`(?i)\b(half an hour|an hour|en halvtime|en time)\b`)
+	// timerJoinRegex matches what can stand between the hours and minutes of
+	// one duration, like "1 hour and 30 minutes"
+	timerJoinRegex = regexp.MustCompile(`^(?i)\s*(?:,|and|og)?\s*$`)
+)
+
+var wordTimerSeconds = map[string]int{
+	"half an hour": 1800, "an hour": 3600,
+	"en halvtime": 1800, "en time": 3600,
+}
+
+// ingredientDescriptors are words in ingredient names that say how an
+// ingredient is prepared rather than what it is, so they don't tie a step to
+// the ingredient
+var ingredientDescriptors = map[string]bool{
+	"fresh": true, "dried": true, "frozen": true, "ground": true, "whole": true, "large": true, "small": true,
+	"medium": true, "chopped": true, "minced": true, "sliced": true, "diced": true, "grated": true, "melted": true,
+	"softened": true, "unsalted": true, "salted": true, "plain": true, "extra": true, "virgin": true, "light": true,
+	"dark": true, "sweet": true, "red": true, "green": true, "yellow": true, "white": true, "black": true,
+	"brown": true, "finely": true, "roughly": true, "about": true, "and": true, "with": true, "for": true,
+	"fersk": true, "ferske": true, "tørket": true, "frossen": true, "hakket": true, "finhakket": true,
+	"revet": true, "smeltet": true, "stor": true, "store": true, "liten": true, "små": true, "rød": true,
+	"røde": true, "grønn": true, "hvit": true, "hvite": true, "sort": true, "og": true, "til": true,
+}
+
+// ingredientWordEndings are the inflections a step can use for an ingredient
+// word, like "eggs" for "egg" or "løken" for "løk"
+var ingredientWordEndings = []string{"", "s", "es", "e", "en", "er", "ene", "et", "a"}
+
+// StructureSteps returns a recipe's steps with their sections and images,
+// and the timers and ingredients found in their text
+func StructureSteps(recipe models.Recipe) []models.RecipeStep {
+	sections := make(map[int]string, len(recipe.StepSections))
+	for _, section := range recipe.StepSections {
+		sections[section.Step] = section.Name
+	}
+	images := make(map[int]uuid.UUID, len(recipe.StepImages))
+	for _, image := range recipe.StepImages {
+		images[image.Step] = image.FileID
+	}
+
+	steps := make([]models.RecipeStep, len(recipe.Steps))
+	section := ""
+	for i, text := range recipe.Steps {
+		if name, ok := sections[i]; ok {
+			section = name
+		}
+		steps[i] = models.RecipeStep{
+			Number:      i + 1,
+			Section:     section,
+			Text:        text,
+			Timers:      ExtractTimers(text),
+			Ingredients: StepIngredients(text, recipe.Ingredients),
+		}
+		if fileID, ok := images[i]; ok {
+			steps[i].ImageID = &fileID
+		}
+	}
+	return steps
+}
+
+// ExtractTimers finds the durations mentioned in a step, like "simmer for
+// 20 minutes" or "bake 1 hour and 15 minutes", in the order they're written
+func ExtractTimers(text string) []models.StepTimer {
+	type match struct {
+		start, end int
+		timer      models.StepTimer
+		hours      bool
+	}
+
+	var matches []match
+	for _, m := range stepTimerRegex.FindAllStringSubmatchIndex(text, -1) {
+		unit := strings.ToLower(text[m[6]:m[7]])
+		seconds := timerUnitSeconds(unit)
+		min, ok := timerAmount(text[m[2]:m[3]])
+		if !ok {
+			continue
+		}
+		max := min
+		if m[4] >= 0 {
+			if max, ok = timerAmount(text[m[4]:m[5]]); !ok || max < min {
+				continue
+			}
+		}
+		matches = append(matches, match{
+			start: m[0],
+			end:   m[1],
+			timer: models.StepTimer{
+				Text:       text[m[0]:m[1]],
+				Seconds:    int(min * float64(seconds)),
+				MaxSeconds: int(max * float64(seconds)),
+			},
+			hours: seconds == 3600 && m[4] < 0,
+		})
+	}
+	for _, m := range wordTimerRegex.FindAllStringIndex(text, -1) {
+		seconds := wordTimerSeconds[strings.ToLower(text[m[0]:m[1]])]
+		matches = append(matches, match{
+			start: m[0],
+			end:   m[1],
+			timer: models.StepTimer{Text: text[m[0]:m[1]], Seconds: seconds, MaxSeconds: seconds},
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	timers := []models.StepTimer{}
+	for i := 0; i < len(matches); i++ {
+		current := matches[i]
+		// The minutes of "1 hour 30 minutes" belong to the hours
+		if current.hours && i+1 < len(matches) {
+			next := matches[i+1]
+			isMinutes := next.timer.Seconds < 3600 && next.timer.Seconds == next.timer.MaxSeconds
+			if isMinutes && timerJoinRegex.MatchString(text[current.end:next.start]) {
+				current.timer.Text = text[current.start:next.end]
+				current.timer.Seconds += next.timer.Seconds
+				current.timer.MaxSeconds += next.timer.MaxSeconds
+				i++
+			}
+		}
+		timers = append(timers, current.timer)
+	}
+	return timers
+}
+
+func timerUnitSeconds(unit string) int {
+	switch {
+	case strings.HasPrefix(unit, "s"):
+		return 1
+	case strings.HasPrefix(unit, "m"):
+		return 60
+	default:
+		return 3600
+	}
+}
+
+// timerAmount parses amounts like "20", "1,5" or "1½"
+func timerAmount(text string) (float64, bool) {
+	half := 0.0
+	if number, found := strings.CutSuffix(text, "½"); found {
+		half, text = 0.5, number
+		if text == "" {
+			return half, true
+		}
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", "."), 64)
+	if err != nil || amount+half <= 0 {
+		return 0, false
+	}
+	return amount + half, true
+}
+
+// StepIngredients returns the indexes of the ingredients a step mentions.
+// An ingredient is mentioned when the step has one of the words of its name,
+// leaving out words like "chopped" or "large", so "Whisk the eggs" uses
+// "2 large eggs".
+func StepIngredients(step string, ingredients []models.Ingredient) []int {
+	stepWords := lowercaseWords(step)
+
+	used := []int{}
+	for i, ingredient := range ingredients {
+		name, _, _ := strings.Cut(ingredient.Name, ",")
+		name, _, _ = strings.Cut(name, "(")
+		for _, word := range lowercaseWords(name) {
+			if len([]rune(word)) < 3 || ingredientDescriptors[word] {
+				continue
+			}
+			if mentionsWord(stepWords, word) {
+				used = append(used, i)
+				break
+			}
+		}
+	}
+	return used
+}
+
+// mentionsWord reports whether one of the words is an inflection of word
+func mentionsWord(words []string, word string) bool {
+	stem := word
+	for _, suffix := range []string{"es", "s"} {
+		if trimmed, found := strings.CutSuffix(word, suffix); found && len([]rune(trimmed)) >= 3 {
+			stem = trimmed
+			break
+		}
+	}
+
+	for _, candidate := range words {
+		if candidate == word {
+			return true
+		}
+		if ending, found := strings.CutPrefix(candidate, stem); found {
+			for _, allowed := range ingredientWordEndings {
+				if ending == allowed {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func lowercaseWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// NormalizeStepSections trims section names and orders the sections by step.
+// Sections without a name or a step are dropped, of two sections starting at
+// the same step the last one is kept.
+func NormalizeStepSections(sections []models.StepSection, stepCount int) []models.StepSection {
+	byStep := make(map[int]string)
+	for _, section := range sections {
+		name := strings.TrimSpace(section.Name)
+		if name != "" && section.Step >= 0 && section.Step < stepCount {
+			byStep[section.Step] = name
+		}
+	}
+
+	normalized := make([]models.StepSection, 0, len(byStep))
+	for step, name := range byStep {
+		normalized = append(normalized, models.StepSection{Step: step, Name: name})
+	}
+	sort.Slice(normalized, func(i, j int) bool { return normalized[i].Step < normalized[j].Step })
+	return normalized
+}
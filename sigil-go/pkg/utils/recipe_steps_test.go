@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractTimers(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []models.StepTimer
+	}{
+		{"Simmer for 20 minutes.", []models.StepTimer{{Text: "20 minutes", Seconds: 1200, MaxSeconds: 1200}}},
+		{"Bake 10-15 min until golden", []models.StepTimer{{Text: "10-15 min", Seconds: 600, MaxSeconds: 900}}},
+		{"Roast for 1 hour and 30 minutes", []models.StepTimer{{Text: "1 hour and 30 minutes", Seconds: 5400, MaxSeconds: 5400}}},
+		{"Stek i 1½ time, og la hvile i 10 minutter", []models.StepTimer{
+			{Text: "1½ time", Seconds: 5400, MaxSeconds: 5400},
+			{Text: "10 minutter", Seconds: 600, MaxSeconds: 600},
+		}},
+		{"Microwave 30 secs, stir and let rest for half an hour", []models.StepTimer{
+			{Text: "30 secs", Seconds: 30, MaxSeconds: 30},
+			{Text: "half an hour", Seconds: 1800, MaxSeconds: 1800},
+		}},
+		{"Kok i 2 til 3 timer", []models.StepTimer{{Text: "2 til 3 timer", Seconds: 7200, MaxSeconds: 10800}}},
+		{"Preheat the oven to 200°C. Add 2 eggs, one at a time.", []models.StepTimer{}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ExtractTimers(tt.text), tt.text)
+	}
+}
+
+func TestStepIngredients(t *testing.T) {
+	ingredients := []models.Ingredient{
+		{Name: "all-purpose flour"},
+		{Name: "large eggs"},
+		{Name: "milk, lukewarm"},
+		{Name: "red onion (finely chopped)"},
+		{Name: "løk"},
+		{Name: "eggplant"},
+		{Name: "salt and pepper"},
+	}
+
+	assert.Equal(t, []int{0, 1, 2}, StepIngredients("Whisk the flour, the egg and the milk.", ingredients))
+	assert.Equal(t, []int{3, 4}, StepIngredients("Fry the onions and løken.", ingredients))
+	assert.Equal(t, []int{6}, StepIngredients("Season with pepper.", ingredients))
+	assert.Equal(t, []int{}, StepIngredients("Serve warm.", ingredients))
+}
+
+func TestStructureSteps(t *testing.T) {
+	imageID := uuid.New()
+	recipe := models.Recipe{
+		Ingredients:  []models.Ingredient{{Name: "tomatoes"}, {Name: "pasta"}},
+		Steps:        []string{"Simmer the tomatoes for 20 minutes.", "Boil the pasta.", "Serve."},
+		StepSections: []models.StepSection{{Step: 0, Name: "Sauce"}, {Step: 1, Name: "Pasta"}},
+		StepImages:   []models.StepImage{{Step: 2, FileID: imageID}},
+	}
+
+	steps := StructureSteps(recipe)
+	require.Len(t, steps, 3)
+	assert.Equal(t, models.RecipeStep{
+		Number:      1,
+		Section:     "Sauce",
+		Text:        "Simmer the tomatoes for 20 minutes.",
+		Timers:      []models.StepTimer{{Text: "20 minutes", Seconds: 1200, MaxSeconds: 1200}},
+		Ingredients: []int{0},
+	}, steps[0])
+	assert.Equal(t, "Pasta", steps[1].Section)
+	assert.Equal(t, []int{1}, steps[1].Ingredients)
+	assert.Equal(t, "Pasta", steps[2].Section)
+	assert.Equal(t, &imageID, steps[2].ImageID)
+}
+
+func TestNormalizeStepSections(t *testing.T) {
+	sections := NormalizeStepSections([]models.StepSection{
+		{Step: 2, Name: " Sauce "},
+		{Step: 0, Name: "Dough"},
+		{Step: 0, Name: "Pastry"},
+		{Step: 1, Name: "  "},
+		{Step: 5, Name: "Serving"},
+	}, 3)
+
+	assert.Equal(t, []models.StepSection{{Step: 0, Name: "Pastry"}, {Step: 2, Name: "Sauce"}}, sections)
+	assert.Equal(t, []models.StepSection{}, NormalizeStepSections(nil, 3))
+}
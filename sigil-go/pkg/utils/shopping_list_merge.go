@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"tofoss/sigil-go/pkg/models"
+)
+
+// MergeResult is shopping list markdown with ingredients merged into it
+type MergeResult struct {
+	Content string
+	// Merged holds the names of items already on the list that were updated
+	Merged []string
+	// Added holds the names of items appended to the list
+	Added []string
+}
+
+// mergeLine is an unchecked shopping list item, or one about to be added
+type mergeLine struct {
+	index      int
+	prefix     string
+	ingredient models.Ingredient
+}
+
+// MergeIntoShoppingList merges ingredients into shopping list markdown.
+// Ingredients already on the list, and not checked off, have their quantities
+// added to the existing line when the units are compatible. Other ingredients
+// are appended under the heading.
+func MergeIntoShoppingList(content string, ingredients []models.Ingredient, heading string) MergeResult {
+	return mergeIntoShoppingList(content, ingredients, make([]bool, len(ingredients)), heading)
+}
+
+// MergeEntriesIntoShoppingList merges the items of another shopping list into
+// shopping list markdown like MergeIntoShoppingList. Checked items are
+// appended as they are, still checked off.
+func MergeEntriesIntoShoppingList(content string, entries []models.ShoppingListEntry, heading string) MergeResult {
+	ingredients := make([]models.Ingredient, len(entries))
+	checked := make([]bool, len(entries))
+	for i, entry := range entries {
+		ingredients[i] = EntryToIngredient(entry)
+		checked[i] = entry.Checked
+	}
+	return mergeIntoShoppingList(content, ingredients, checked, heading)
+}
+
+func mergeIntoShoppingList(content string, ingredients []models.Ingredient, checked []bool, heading string) MergeResult {
+	lines := strings.Split(content, "\n")
+	existing := make(map[string]*mergeLine)
+	for i, line := range lines {
+		match := checkboxRegex.FindStringSubmatchIndex(line)
+		if match == nil || line[match[2]:match[3]] != " " {
+			continue
+		}
+		itemText := strings.TrimSpace(line[match[4]:match[5]])
+		if linkRegex.MatchString(itemText) {
+			// Links are kept as they are written
+			continue
+		}
+		ingredient := ParseIngredient(itemText)
+		name := NormalizeItemName(ingredient.Name)
+		if _, found := existing[name]; !found {
+			existing[name] = &mergeLine{index: i, prefix: line[:match[4]], ingredient: ingredient}
+		}
+	}
+
+	result := MergeResult{Merged: []string{}, Added: []string{}}
+	var added []*mergeLine
+	changed := make(map[*mergeLine]bool)
+	for i, ingredient := range ingredients {
+		name := NormalizeItemName(ingredient.Name)
+		if name == "" {
+			continue
+		}
+
+		if checked[i] {
+			added = append(added, &mergeLine{index: -1, prefix: "- [x] ", ingredient: ingredient})
+			result.Added = append(result.Added, ingredient.Name)
+			continue
+		}
+
+		if target, found := existing[name]; found {
+			if quantity, ok := mergeQuantities(target.ingredient.Quantity, ingredient.Quantity); ok {
+				if quantity != target.ingredient.Quantity {
+					target.ingredient.Quantity = quantity
+					changed[target] = true
+				}
+				if target.index >= 0 && !slices.Contains(result.Merged, target.ingredient.Name) {
+					result.Merged = append(result.Merged, target.ingredient.Name)
+				}
+				continue
+			}
+		}
+
+		line := &mergeLine{index: -1, prefix: "- [ ] ", ingredient: ingredient}
+		added = append(added, line)
+		if _, found := existing[name]; !found {
+			existing[name] = line
+		}
+		result.Added = append(result.Added, ingredient.Name)
+	}
+
+	for line := range changed {
+		if line.index >= 0 {
+			lines[line.index] = line.prefix + shoppingListItemText(line.ingredient)
+		}
+	}
+	content = strings.Join(lines, "\n")
+
+	if len(added) > 0 {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += "\n## " + heading + "\n"
+		for _, line := range added {
+			content += line.prefix + shoppingListItemText(line.ingredient) + "\n"
+		}
+	}
+
+	result.Content = content
+	return result
+}
+
+// EntryToIngredient converts a shopping list item so it can be merged into
+// another list. Links are kept as they are written.
+func EntryToIngredient(entry models.ShoppingListEntry) models.Ingredient {
+	if linkRegex.MatchString(entry.DisplayName) {
+		return models.Ingredient{Name: entry.DisplayName}
+	}
+	return ParseIngredient(entry.DisplayName)
+}
+
+// mergeQuantities combines the quantities of an item listed twice. Items
+// without a quantity take the other one, items with incompatible units can't
+// be merged.
+func mergeQuantities(a, b *models.Quantity) (*models.Quantity, bool) {
+	switch {
+	case b == nil:
+		return a, true
+	case a == nil:
+		return b, true
+	}
+	sum, ok := AddQuantities(*a, *b)
+	return &sum, ok
+}
+
+// AddQuantities adds two quantities. Units that measure the same thing, like
+// g and kg or tsp and tbsp, are converted to the larger of the two. Ranges are
+// kept, "at least" and "up to" quantities stay open ended.
+func AddQuantities(a, b models.Quantity) (models.Quantity, bool) {
+	unitA, _ := NormalizeUnit(a.Unit)
+	unitB, _ := NormalizeUnit(b.Unit)
+
+	unit := a.Unit
+	scaleA, scaleB := 1.0, 1.0
+	if !strings.EqualFold(unitA, unitB) {
+		sizeA, sizeB, ok := unitSizes(unitA, unitB)
+		if !ok {
+			return models.Quantity{}, false
+		}
+		if sizeB > sizeA {
+			unit = b.Unit
+			scaleA = sizeA / sizeB
+		} else {
+			scaleB = sizeB / sizeA
+		}
+	}
+
+	sum := models.Quantity{Unit: unit}
+	if a.Min != nil || b.Min != nil {
+		min := roundMerged(valueOrZero(a.Min)*scaleA + valueOrZero(b.Min)*scaleB)
+		sum.Min = &min
+	}
+	if a.Max != nil && b.Max != nil {
+		max := roundMerged(*a.Max*scaleA + *b.Max*scaleB)
+		sum.Max = &max
+	}
+	return sum, true
+}
+
+// unitSizes returns the sizes of two mass or two volume units
+func unitSizes(unitA, unitB string) (float64, float64, bool) {
+	if sizeA, ok := massUnitGrams[unitA]; ok {
+		sizeB, ok := massUnitGrams[unitB]
+		return sizeA, sizeB, ok
+	}
+	if sizeA, ok := volumeUnitMilliliters[unitA]; ok {
+		sizeB, ok := volumeUnitMilliliters[unitB]
+		return sizeA, sizeB, ok
+	}
+	return 0, 0, false
+}
+
+// roundMerged drops floating point noise from unit conversions
+func roundMerged(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+func valueOrZero(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// shoppingListItemText formats an ingredient as a shopping list item
+func shoppingListItemText(ingredient models.Ingredient) string {
+	text := ingredient.Name
+	if ingredient.Quantity != nil {
+		if quantity := FormatQuantity(*ingredient.Quantity); quantity != "" {
+			text = quantity + " " + text
+		}
+	}
+	if ingredient.Notes != "" {
+		text += " (" + ingredient.Notes + ")"
+	}
+	return text
+}
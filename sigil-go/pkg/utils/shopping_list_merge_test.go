@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"testing"
+
+	"tofoss/sigil-go/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeIntoShoppingList(t *testing.T) {
+	content := `## Groceries
+- [ ] 500g Flour (organic)
+- [x] 2 dl Milk
+- [ ] Salt
+- [ ] 1 tsp cumin
+- [ ] Eggs, 6
+- [ ] [Good bread](https://example.com/)`
+
+	result := MergeIntoShoppingList(content, []models.Ingredient{
+		{Name: "flour", Quantity: qty(1, 1, "kg")},
+		{Name: "Milk", Quantity: qty(3, 3, "dl")},
+		{Name: "salt", Quantity: qty(1, 1, "tsp")},
+		{Name: "Cumin", Quantity: qty(1, 2, "tbsp")},
+		{Name: "eggs", Quantity: qty(2, 2, "")},
+		{Name: "Butter", Quantity: qty(50, 50, "g"), Notes: "softened"},
+		{Name: "butter", Quantity: qty(1, 1, "dl")},
+		{Name: "Butter", Quantity: qty(25, 25, "g")},
+		{Name: "Good bread"},
+	}, "Pancakes")
+
+	expected := `## Groceries
+- [ ] 1.5 kg Flour (organic)
+- [x] 2 dl Milk
+- [ ] 1 tsp Salt
+- [ ] 1 1/3-2 1/3 tbsp cumin
+- [ ] 8 Eggs
+- [ ] [Good bread](https://example.com/)
+
+## Pancakes
+- [ ] 3 dl Milk
+- [ ] 75 g Butter (softened)
+- [ ] 1 dl butter
+- [ ] Good bread
+`
+	assert.Equal(t, expected, result.Content)
+	assert.Equal(t, []string{"Flour", "Salt", "cumin", "Eggs"}, result.Merged)
+	assert.Equal(t, []string{"Milk", "Butter", "butter", "Good bread"}, result.Added)
+}
+
+func TestMergeIntoEmptyShoppingList(t *testing.T) {
+	result := MergeIntoShoppingList("", []models.Ingredient{{Name: "Eggs", Quantity: qty(2, 2, "")}}, "Omelette")
+
+	assert.Equal(t, "\n## Omelette\n- [ ] 2 Eggs\n", result.Content)
+	assert.Empty(t, result.Merged)
+	assert.Equal(t, []string{"Eggs"}, result.Added)
+}
+
+func TestMergeEntriesIntoShoppingList(t *testing.T) {
+	entries, err := ParseShoppingList("- [ ] 2 dl Milk\n- [x] 6 Eggs\n- [ ] Salt\n")
+	require.NoError(t, err)
+
+	result := MergeEntriesIntoShoppingList("- [ ] 1 dl milk\n- [ ] 6 eggs", entries, "Omelette")
+
+	assert.Equal(t, "- [ ] 3 dl milk\n- [ ] 6 eggs\n\n## Omelette\n- [x] 6 Eggs\n- [ ] Salt\n", result.Content)
+	assert.Equal(t, []string{"milk"}, result.Merged)
+	assert.Equal(t, []string{"Eggs", "Salt"}, result.Added)
+}
+
+func TestAddQuantities(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     models.Quantity
+		expected *models.Quantity
+	}{
+		{"same unit", *qty(1, 1, "dl"), *qty(2, 2, "dl"), qty(3, 3, "dl")},
+		{"unit aliases", *qty(1, 1, "ss"), *qty(2, 2, "tablespoons"), qty(3, 3, "ss")},
+		{"grams to kilograms", *qty(500, 500, "g"), *qty(1, 1, "kg"), qty(1.5, 1.5, "kg")},
+		{"milliliters to deciliters", *qty(2, 2, "dl"), *qty(50, 50, "ml"), qty(2.5, 2.5, "dl")},
+		{"deciliters to liters", *qty(5, 5, "dl"), *qty(1, 1, "l"), qty(1.5, 1.5, "l")},
+		{"teaspoons to tablespoons", *qty(3, 3, "tsp"), *qty(1, 1, "tbsp"), qty(2, 2, "tbsp")},
+		{"ranges", *qty(1, 2, ""), *qty(2, 3, ""), qty(3, 5, "")},
+		{"range and amount", *qty(100, 200, "g"), *qty(50, 50, "g"), qty(150, 250, "g")},
+		{"at least", models.Quantity{Min: floatPtr(1), Unit: "l"}, *qty(1, 1, "l"), &models.Quantity{Min: floatPtr(2), Unit: "l"}},
+		{"up to", models.Quantity{Max: floatPtr(2), Unit: "cups"}, *qty(1, 1, "cup"), &models.Quantity{Min: floatPtr(1), Max: floatPtr(3), Unit: "cups"}},
+		{"mass and volume", *qty(100, 100, "g"), *qty(1, 1, "dl"), nil},
+		{"count and mass", *qty(2, 2, ""), *qty(100, 100, "g"), nil},
+		{"unknown units", *qty(1, 1, "bags"), *qty(1, 1, "boxes"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum, ok := AddQuantities(tt.a, tt.b)
+			if tt.expected == nil {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, *tt.expected, sum)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// unitAliases maps the ways units are written in English and Norwegian
+// recipes to one canonical name per unit
+var unitAliases = map[string]string{
+	// Mass
+	"mg": "mg", "milligram": "mg", "milligrams": "mg", "milligramme": "mg", "milligrammes": "mg",
+	"g": "g", "gr": "g", "gram": "g", "grams": "g", "gramme": "g", "grammes": "g",
+	"hg": "hg", "hekto": "hg", "hectogram": "hg",
+	"kg": "kg", "kilo": "kg", "kilos": "kg", "kilogram": "kg", "kilograms": "kg",
+	"oz": "oz", "ounce": "oz", "ounces": "oz",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb",
+
+	// Volume
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"cl": "cl", "centiliter": "cl", "centiliters": "cl", "centilitre": "cl", "centilitres": "cl",
+	"dl": "dl", "deciliter": "dl", "deciliters": "dl", "decilitre": "dl", "decilitres": "dl",
+	"l": "l", "liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"tsp": "tsp", "tsps": "tsp", "teaspoon": "tsp", "teaspoons": "tsp",
+	"ts": "tsp", "tsk": "tsp", "teskje": "tsp", "teskjeer": "tsp",
+	"tbsp": "tbsp", "tbsps": "tbsp", "tbs": "tbsp", "tbl": "tbsp", "tablespoon": "tbsp", "tablespoons": "tbsp",
+	"ss": "tbsp", "ssk": "tbsp", "spiseskje": "tbsp", "spiseskjeer": "tbsp",
+	"cup": "cup", "cups": "cup", "kopp": "cup", "kopper": "cup",
+	"fl oz": "fl oz", "fluid ounce": "fl oz", "fluid ounces": "fl oz",
+	"pt": "pint", "pint": "pint", "pints": "pint",
+	"qt": "quart", "quart": "quart", "quarts": "quart",
+	"gal": "gallon", "gallon": "gallon", "gallons": "gallon",
+	"pinch": "pinch", "pinches": "pinch", "klype": "pinch", "klyper": "pinch",
+	"dash": "dash", "dashes": "dash", "skvett": "dash",
+
+	// Length, for shopping list items like "Cable 2m"
+	"mm": "mm", "cm": "cm", "m": "m",
+
+	// Counts
+	"clove": "clove", "cloves": "clove", "fedd": "clove",
+	"can": "can", "cans": "can", "tin": "can", "tins": "can", "boks": "can", "bokser": "can",
+	"package": "package", "packages": "package", "pack": "package", "packs": "package", "pkg": "package",
+	"pk": "package", "pakke": "package", "pakker": "package",
+	"slice": "slice", "slices": "slice", "skive": "slice", "skiver": "slice",
+	"bunch": "bunch", "bunches": "bunch", "bunt": "bunch", "bunter": "bunch",
+	"handful": "handful", "handfuls": "handful", "neve": "handful", "never": "handful",
+	"sprig": "sprig", "sprigs": "sprig", "kvist": "sprig", "kvister": "sprig",
+	"stick": "stick", "sticks": "stick",
+	"piece": "piece", "pieces": "piece", "pc": "piece", "pcs": "piece", "stk": "piece",
+}
+
+// NormalizeUnit returns the canonical name of a unit, e.g. "tbsp" for
+// "tablespoons" or "ss". Unknown units are returned unchanged.
+func NormalizeUnit(unit string) (string, bool) {
+	key := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(unit), "."))
+	if canonical, ok := unitAliases[key]; ok {
+		return canonical, true
+	}
+	return unit, false
+}
+
+// UnitGrams returns the size of a mass unit in grams
+func UnitGrams(unit string) (float64, bool) {
+	canonical, _ := NormalizeUnit(unit)
+	grams, ok := massUnitGrams[canonical]
+	return grams, ok
+}
+
+// UnitMilliliters returns the size of a volume unit in milliliters
+func UnitMilliliters(unit string) (float64, bool) {
+	canonical, _ := NormalizeUnit(unit)
+	milliliters, ok := volumeUnitMilliliters[canonical]
+	return milliliters, ok
+}
+
+// Sizes of mass units in grams and volume units in milliliters, by canonical
+// name
+var (
+	massUnitGrams = map[string]float64{
+		"mg": 0.001, "g": 1, "hg": 100, "kg": 1000,
+		"oz": 28.349523125, "lb": 453.59237,
+	}
+	volumeUnitMilliliters = map[string]float64{
+		"ml": 1, "cl": 10, "dl": 100, "l": 1000,
+		"tsp": 4.92892159375, "tbsp": 14.78676478125, "fl oz": 29.5735295625,
+		"cup": 236.5882365, "pint": 473.176473, "quart": 946.352946, "gallon": 3785.411784,
+	}
+
+	// US customary units, tsp and tbsp are used with both systems
+	usUnits = map[string]bool{
+		"oz": true, "lb": true, "fl oz": true, "cup": true, "pint": true, "quart": true, "gallon": true,
+		"tsp": true, "tbsp": true,
+	}
+)
+
+// ingredientDensities holds grams per milliliter of common staples, to convert
+// cups of flour to grams and back. Names are matched as whole words.
+var ingredientDensities = []struct {
+	names   []string
+	density float64
+}{
+	// Longer names first, so brown sugar isn't matched as sugar
+	{[]string{"brown sugar", "brunt sukker", "brunsukker"}, 0.93},
+	{[]string{"powdered sugar", "icing sugar", "confectioners sugar", "melis"}, 0.56},
+	{[]string{"cocoa", "kakao", "kakaopulver"}, 0.42},
+	{[]string{"flour", "mel", "hvetemel", "sammalt hvetemel", "rugmel", "speltmel"}, 0.53},
+	{[]string{"sugar", "sukker", "farin"}, 0.85},
+	{[]string{"butter", "smør"}, 0.96},
+	{[]string{"rice", "ris"}, 0.78},
+	{[]string{"oats", "rolled oats", "havregryn"}, 0.38},
+	{[]string{"honey", "honning"}, 1.42},
+	{[]string{"salt"}, 1.2},
+}
+
+// ingredientDensity returns the density of a staple in grams per milliliter
+func ingredientDensity(name string) (float64, bool) {
+	words := " " + strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ") + " "
+
+	for _, staple := range ingredientDensities {
+		for _, stapleName := range staple.names {
+			if strings.Contains(words, " "+stapleName+" ") {
+				return staple.density, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ValidateExternalURL performs comprehensive URL validation and SSRF protection.
+// It should be called before the server fetches any user supplied URL.
+func ValidateExternalURL(urlStr string) error {
+	// Parse URL
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	// Require http/https scheme
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return fmt.Errorf("URL must use http or https scheme")
+	}
+
+	hostname := strings.ToLower(parsedURL.Hostname())
+
+	// Block localhost aliases
+	if hostname == "localhost" || hostname == "0.0.0.0" {
+		return fmt.Errorf("private/localhost URLs are not allowed")
+	}
+
+	// Try to parse as IP address
+	ip := net.ParseIP(hostname)
+	if ip != nil {
+		// Direct IP address - check if it's private
+		if IsPrivateIP(ip) {
+			return fmt.Errorf("private IP addresses are not allowed")
+		}
+	} else {
+		// Hostname - resolve it and check all IPs
+		ips, err := net.LookupIP(hostname)
+		if err != nil {
+			return fmt.Errorf("failed to resolve hostname: %w", err)
+		}
+
+		for _, resolvedIP := range ips {
+			if IsPrivateIP(resolvedIP) {
+				return fmt.Errorf("hostname resolves to private IP address")
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsPrivateIP checks if an IP address is private/internal
+func IsPrivateIP(ip net.IP) bool {
+	// Loopback addresses
+	if ip.IsLoopback() {
+		return true
+	}
+
+	// Link-local addresses (169.254.0.0/16 for IPv4, fe80::/10 for IPv6)
+	if ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return true
+	}
+
+	// Check for private IPv4 ranges
+	if ip4 := ip.To4(); ip4 != nil {
+		// 10.0.0.0/8
+		if ip4[0] == 10 {
+			return true
+		}
+		// 172.16.0.0/12
+		if ip4[0] == 172 && ip4[1] >= 16 && ip4[1] <= 31 {
+			return true
+		}
+		// 192.168.0.0/16
+		if ip4[0] == 192 && ip4[1] == 168 {
+			return true
+		}
+		// 127.0.0.0/8 (loopback, but double-check)
+		if ip4[0] == 127 {
+			return true
+		}
+	}
+
+	// Check for private IPv6 ranges
+	if ip.To4() == nil {
+		// Unique local addresses (fc00::/7)
+		if len(ip) >= 2 && (ip[0]&0xfe) == 0xfc {
+			return true
+		}
+	}
+
+	return false
+}
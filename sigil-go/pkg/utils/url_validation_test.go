@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		private bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"172.31.255.255", true},
+		{"172.32.0.1", false},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"8.8.8.8", false},
+		{"2001:4860:4860::8888", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.private, IsPrivateIP(net.ParseIP(tt.ip)))
+		})
+	}
+}
+
+func TestValidateExternalURL(t *testing.T) {
+	assert.NoError(t, ValidateExternalURL("https://8.8.8.8/page"))
+
+	assert.Error(t, ValidateExternalURL("ftp://8.8.8.8/file"))
+	assert.Error(t, ValidateExternalURL("file:///etc/passwd"))
+	assert.Error(t, ValidateExternalURL("http://localhost:8080"))
+	assert.Error(t, ValidateExternalURL("http://0.0.0.0"))
+	assert.Error(t, ValidateExternalURL("http://127.0.0.1/admin"))
+	assert.Error(t, ValidateExternalURL("http://[::1]/"))
+	assert.Error(t, ValidateExternalURL("http://169.254.169.254/latest/meta-data"))
+}